@@ -27,19 +27,56 @@ import (
 	"net/http"
 )
 
-func (f *Fosite) WriteAccessError(rw http.ResponseWriter, _ AccessRequester, err error) {
-	f.writeJsonError(rw, err)
+// accessErrorStatusCodes contains the status codes mandated by https://tools.ietf.org/html/rfc6749#section-5.2
+//
+// The authorization server responds with an HTTP 400 (Bad Request)
+// status code (unless specified otherwise) [...]
+var accessErrorStatusCodes = map[string]int{
+	errInvalidRequestName:         http.StatusBadRequest,
+	errInvalidClientName:          http.StatusUnauthorized,
+	errInvalidGrantName:           http.StatusBadRequest,
+	errUnauthorizedClientName:     http.StatusBadRequest,
+	errUnsupportedGrantTypeName:   http.StatusBadRequest,
+	errInvalidScopeName:           http.StatusBadRequest,
+	errServerErrorName:            http.StatusInternalServerError,
+	errTemporarilyUnavailableName: http.StatusServiceUnavailable,
 }
 
-func (f *Fosite) writeJsonError(rw http.ResponseWriter, err error) {
-	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+func (f *Fosite) WriteAccessError(rw http.ResponseWriter, req AccessRequester, err error) {
+	rfcerr := *ErrorToRFC6749Error(err)
+	rfcerr.Code = f.accessErrorStatusCode(&rfcerr)
+	f.localizeError(&rfcerr, req)
+	if req != nil {
+		rfcerr.ID = req.GetID()
+	}
 
-	rfcerr := ErrorToRFC6749Error(err)
-	if !f.SendDebugMessagesToClients {
-		rfcerr.Debug = ""
+	// https://tools.ietf.org/html/rfc6749#section-5.2
+	// The authorization server MAY return an HTTP 401 (Unauthorized) status code to indicate
+	// which HTTP authentication schemes are supported. If the client attempted to authenticate
+	// via the "Authorization" request header field, the authorization server MUST respond with
+	// an HTTP 401 (Unauthorized) status code and include the "WWW-Authenticate" response header
+	// field matching the authentication scheme used by the client.
+	if rfcerr.Code == http.StatusUnauthorized {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
 	}
 
-	js, err := json.Marshal(rfcerr)
+	f.writeJsonError(rw, &rfcerr)
+}
+
+func (f *Fosite) accessErrorStatusCode(rfcerr *RFC6749Error) int {
+	if code, ok := f.AccessErrorStatusCodes[rfcerr.Name]; ok {
+		return code
+	} else if code, ok := accessErrorStatusCodes[rfcerr.Name]; ok {
+		return code
+	}
+	return rfcerr.Code
+}
+
+func (f *Fosite) writeJsonError(rw http.ResponseWriter, err error) {
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+
+	rfcerr := f.clientError(err)
+	js, err := json.Marshal(&rfcerr)
 	if err != nil {
 		http.Error(rw, fmt.Sprintf(`{"error": "%s"}`, err.Error()), http.StatusInternalServerError)
 		return
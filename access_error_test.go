@@ -99,3 +99,53 @@ func TestWriteAccessError_RFC6749(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAccessError_StatusCodes(t *testing.T) {
+	for k, c := range []struct {
+		err                  error
+		mapping              map[string]int
+		expectStatus         int
+		expectAuthentication bool
+	}{
+		{err: ErrInvalidRequest, expectStatus: http.StatusBadRequest},
+		{err: ErrInvalidGrant, expectStatus: http.StatusBadRequest},
+		{err: ErrInvalidScope, expectStatus: http.StatusBadRequest},
+		{err: ErrInvalidClient, expectStatus: http.StatusUnauthorized, expectAuthentication: true},
+		{err: ErrTemporarilyUnavailable, expectStatus: http.StatusServiceUnavailable},
+		{err: ErrServerError, expectStatus: http.StatusInternalServerError},
+		{err: ErrAccessDenied, expectStatus: http.StatusForbidden},
+		{err: fmt.Errorf("some error"), expectStatus: http.StatusInternalServerError},
+		{err: ErrInvalidClient, mapping: map[string]int{"invalid_client": http.StatusBadRequest}, expectStatus: http.StatusBadRequest},
+		{err: ErrInvalidGrant, mapping: map[string]int{"invalid_grant": http.StatusForbidden}, expectStatus: http.StatusForbidden},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			f := &Fosite{AccessErrorStatusCodes: c.mapping}
+			rw := httptest.NewRecorder()
+			f.WriteAccessError(rw, nil, c.err)
+
+			assert.Equal(t, c.expectStatus, rw.Code)
+			if c.expectAuthentication {
+				assert.NotEmpty(t, rw.Header().Get("WWW-Authenticate"))
+			} else {
+				assert.Empty(t, rw.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestWriteAccessError_RequestID(t *testing.T) {
+	f := &Fosite{}
+	ar := NewAccessRequest(nil)
+	ar.SetID("request-id")
+
+	rw := httptest.NewRecorder()
+	f.WriteAccessError(rw, ar, ErrInvalidGrant)
+
+	var params struct {
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+	assert.Equal(t, "request-id", params.RequestID)
+	assert.Empty(t, ErrInvalidGrant.RequestID())
+	assert.Equal(t, "request-id", ErrInvalidGrant.WithRequestID("request-id").RequestID())
+}
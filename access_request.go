@@ -26,6 +26,9 @@ type AccessRequest struct {
 	HandledGrantType Arguments `json:"handledGrantType" gorethink:"handledGrantType"`
 
 	Request
+
+	idempotencyKey     string
+	idempotentResponse *AccessResponse
 }
 
 func NewAccessRequest(session Session) *AccessRequest {
@@ -41,3 +44,13 @@ func NewAccessRequest(session Session) *AccessRequest {
 func (a *AccessRequest) GetGrantTypes() Arguments {
 	return a.GrantTypes
 }
+
+// Sanitize returns a copy of the request which only keeps the form values of allowedParameters, see Request.Sanitize.
+// The grant types are kept.
+func (a *AccessRequest) Sanitize(allowedParameters []string) Requester {
+	return &AccessRequest{
+		GrantTypes:       copyArguments(a.GrantTypes),
+		HandledGrantType: copyArguments(a.HandledGrantType),
+		Request:          *a.Request.sanitize(allowedParameters),
+	}
+}
@@ -24,8 +24,9 @@ package fosite
 import (
 	"context"
 	"net/http"
-	"strings"
+	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -54,25 +55,44 @@ import (
 //   credentials (or assigned other authentication requirements), the
 //   client MUST authenticate with the authorization server as described
 //   in Section 3.2.1.
-func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session Session) (AccessRequester, error) {
-	var err error
+func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session Session) (_ AccessRequester, err error) {
 	accessRequest := NewAccessRequest(session)
+	accessRequest.RequestedAt = Now(f.Clock)
+	accessRequest.ID = uuid.New()
+	start := time.Now()
+	ctx, span := f.startSpan(ctx, "fosite.NewAccessRequest")
+	span.SetAttribute("fosite.request_id", accessRequest.ID)
+	defer func() {
+		span.End(err)
+		f.observeRequest("token", accessRequest.GrantTypes, start, err)
+	}()
 
 	if r.Method != "POST" {
 		return accessRequest, errors.WithStack(ErrInvalidRequest.WithHintf("HTTP method is \"%s\", expected \"POST\".", r.Method))
-	} else if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
-		return accessRequest, errors.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithDebug(err.Error()))
+	} else if err := f.parsePostForm(r); err != nil {
+		return accessRequest, err
 	} else if len(r.PostForm) == 0 {
 		return accessRequest, errors.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
 	}
 
 	accessRequest.Form = r.PostForm
-	if session == nil {
+	if err := f.validateRequestLimits(r.PostForm); err != nil {
+		return accessRequest, err
+	}
+	if name, ok := duplicateParameter(r.PostForm, f.RepeatableParameters); ok {
+		return accessRequest, errors.WithStack(ErrInvalidRequest.WithHintf("The request parameter \"%s\" must not be included more than once.", name))
+	}
+	accessRequest.Languages = RequestLanguages(r, r.PostForm)
+	ctx = ContextWithRemoteIP(ctx, RemoteIP(r))
+	if session == nil && len(f.SessionFactories) == 0 {
 		return accessRequest, errors.New("Session must not be nil")
 	}
 
-	accessRequest.SetRequestedScopes(removeEmpty(strings.Split(r.PostForm.Get("scope"), " ")))
-	accessRequest.GrantTypes = removeEmpty(strings.Split(r.PostForm.Get("grant_type"), " "))
+	accessRequest.SetRequestedScopes(SplitArguments(r.PostForm.Get("scope")))
+	if malformed, ok := malformedScope(accessRequest.GetRequestedScopes()); ok {
+		return accessRequest, errors.WithStack(ErrInvalidScope.WithHintf(`The requested scope %q contains characters which are not allowed in scope values.`, malformed))
+	}
+	accessRequest.GrantTypes = SplitArguments(r.PostForm.Get("grant_type"))
 	if len(accessRequest.GrantTypes) < 1 {
 		return accessRequest, errors.WithStack(ErrInvalidRequest.WithHint(`Request parameter "grant_type"" is missing`))
 	}
@@ -81,11 +101,34 @@ func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session
 	if err != nil {
 		return accessRequest, err
 	}
+	if f.FAPIProfile != nil {
+		if err := f.FAPIProfile.validateClientAuthentication(client, r.PostForm); err != nil {
+			return accessRequest, err
+		}
+	}
 	accessRequest.Client = client
 
+	if session == nil {
+		if accessRequest.Session = f.newSession(ctx, accessRequest); accessRequest.Session == nil {
+			return accessRequest, errors.New("Session must not be nil")
+		}
+	}
+
+	if f.IdempotencyStorage != nil {
+		if err := f.loadIdempotentResponse(ctx, r, accessRequest); err != nil {
+			return accessRequest, err
+		} else if accessRequest.idempotentResponse != nil {
+			// The request was already handled, its response is returned by NewAccessResponse.
+			return accessRequest, nil
+		}
+	}
+
 	var found bool = false
 	for _, loader := range f.TokenEndpointHandlers {
-		if err := loader.HandleTokenEndpointRequest(ctx, accessRequest); err == nil {
+		err := f.runHandler(ctx, "token", loader, accessRequest, func(ctx context.Context) error {
+			return loader.HandleTokenEndpointRequest(ctx, accessRequest)
+		})
+		if err == nil {
 			found = true
 		} else if errors.Cause(err).Error() == ErrUnknownRequest.Error() {
 			// do nothing
@@ -97,5 +140,9 @@ func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session
 	if !found {
 		return nil, errors.WithStack(ErrInvalidRequest)
 	}
+
+	if err := f.evaluatePolicy(ctx, PolicyStageToken, accessRequest); err != nil {
+		return accessRequest, err
+	}
 	return accessRequest, nil
 }
@@ -22,15 +22,18 @@
 package fosite_test
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -93,6 +96,18 @@ func TestNewAccessRequest(t *testing.T) {
 				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(nil, errors.New(""))
 			},
 		},
+		{
+			header: http.Header{
+				"Authorization": {basicAuth("foo", "bar")},
+			},
+			method: "POST",
+			form: url.Values{
+				"grant_type": {"foo"},
+				"scope":      {"foo", "bar"},
+			},
+			expectErr: ErrInvalidRequest,
+			mock:      func() {},
+		},
 		{
 			header: http.Header{
 				"Authorization": {basicAuth("foo", "bar")},
@@ -222,3 +237,31 @@ func TestNewAccessRequest(t *testing.T) {
 func basicAuth(username, password string) string {
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
 }
+
+type acceptingTokenEndpointHandler struct{}
+
+func (acceptingTokenEndpointHandler) HandleTokenEndpointRequest(_ context.Context, _ AccessRequester) error {
+	return nil
+}
+
+func (acceptingTokenEndpointHandler) PopulateTokenEndpointResponse(_ context.Context, _ AccessRequester, _ AccessResponder) error {
+	return nil
+}
+
+func BenchmarkNewAccessRequest(b *testing.B) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true, GrantTypes: []string{"client_credentials"}}
+	f := &Fosite{Store: store, TokenEndpointHandlers: TokenEndpointHandlers{acceptingTokenEndpointHandler{}}}
+	body := url.Values{"grant_type": {"client_credentials"}, "client_id": {"foo"}, "scope": {"foo bar baz"}}.Encode()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if _, err := f.NewAccessRequest(ctx, r, new(DefaultSession)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
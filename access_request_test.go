@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAccessRequest(t *testing.T) {
@@ -37,3 +38,20 @@ func TestAccessRequest(t *testing.T) {
 	assert.Equal(t, ar.GrantTypes, ar.GetGrantTypes())
 	assert.Equal(t, ar.Client, ar.GetClient())
 }
+
+func TestAccessRequestSanitize(t *testing.T) {
+	ar := NewAccessRequest(new(DefaultSession))
+	ar.GrantTypes = Arguments{"refresh_token"}
+	ar.GrantScope("foo")
+	ar.Form.Set("refresh_token", "some-token")
+
+	sanitized, ok := ar.Sanitize([]string{}).(*AccessRequest)
+	require.True(t, ok)
+	assert.Equal(t, ar.GetID(), sanitized.GetID())
+	assert.Equal(t, Arguments{"refresh_token"}, sanitized.GetGrantTypes())
+	assert.Equal(t, Arguments{"foo"}, sanitized.GetGrantedScopes())
+	assert.Empty(t, sanitized.GetRequestForm())
+
+	sanitized.GrantedScopes[0] = "bar"
+	assert.Equal(t, Arguments{"foo"}, ar.GetGrantedScopes())
+}
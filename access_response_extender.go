@@ -0,0 +1,69 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// AccessResponseExtender adds custom members, for example the tenant of the subject or an issued_token_type, to
+// successful token responses.
+type AccessResponseExtender interface {
+	// ExtendAccessResponse returns the members to add to the token response of requester. The members defined in
+	// https://tools.ietf.org/html/rfc6749#section-5.1, see AccessResponseMembers, must not be returned. It is called
+	// before the tokens are issued, and the request fails without issuing them if it returns an error.
+	ExtendAccessResponse(ctx context.Context, requester AccessRequester) (map[string]interface{}, error)
+}
+
+// AccessResponseExtenderFunc adapts a function to the AccessResponseExtender interface.
+type AccessResponseExtenderFunc func(ctx context.Context, requester AccessRequester) (map[string]interface{}, error)
+
+func (f AccessResponseExtenderFunc) ExtendAccessResponse(ctx context.Context, requester AccessRequester) (map[string]interface{}, error) {
+	return f(ctx, requester)
+}
+
+// AccessResponseMembers are the members of token responses which are set by the token endpoint handlers and must not
+// be set by an AccessResponseExtender.
+var AccessResponseMembers = []string{"access_token", "token_type", "expires_in", "refresh_token", "scope", "id_token"}
+
+// accessResponseExtension returns the members the AccessResponseExtender adds to the token response of requester. It
+// is called before the token endpoint handlers issue and persist tokens, so that a failing extender does not consume
+// the grant, for example the authorize code.
+func (f *Fosite) accessResponseExtension(ctx context.Context, requester AccessRequester) (map[string]interface{}, error) {
+	if f.AccessResponseExtender == nil {
+		return nil, nil
+	}
+
+	members, err := f.AccessResponseExtender.ExtendAccessResponse(ctx, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range members {
+		if StringInSlice(k, AccessResponseMembers) {
+			return nil, errors.WithStack(ErrServerError.WithDebugf("The access response extender must not set the member \"%s\".", k))
+		}
+	}
+	return members, nil
+}
@@ -27,13 +27,26 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequester) (AccessResponder, error) {
-	var err error
+func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequester) (_ AccessResponder, err error) {
 	var tk TokenEndpointHandler
+	ctx, span := f.startSpan(ctx, "fosite.NewAccessResponse")
+	defer func() { span.End(err) }()
+
+	if ar, ok := requester.(*AccessRequest); ok && ar.idempotentResponse != nil {
+		return ar.idempotentResponse, nil
+	}
+
+	extension, err := f.accessResponseExtension(ctx, requester)
+	if err != nil {
+		return nil, err
+	}
 
 	response := NewAccessResponse()
 	for _, tk = range f.TokenEndpointHandlers {
-		if err = tk.PopulateTokenEndpointResponse(ctx, requester, response); err == nil {
+		err = f.runHandler(ctx, "token", tk, requester, func(ctx context.Context) error {
+			return tk.PopulateTokenEndpointResponse(ctx, requester, response)
+		})
+		if err == nil {
 		} else if errors.Cause(err).Error() == ErrUnknownRequest.Error() {
 		} else if err != nil {
 			return nil, err
@@ -44,5 +57,22 @@ func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequeste
 		return nil, errors.WithStack(ErrServerError.WithHint("An internal server occurred while trying to complete the request.").WithDebug("Access token or token type not set by TokenEndpointHandlers."))
 	}
 
+	for k, v := range extension {
+		response.SetExtra(k, v)
+	}
+
+	if f.IdempotencyStorage != nil {
+		if err := f.storeIdempotentResponse(ctx, requester, response); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.AuditLogger != nil {
+		if requester.GetGrantTypes().Exact("refresh_token") {
+			f.audit(ctx, AuditTokenRefreshed, requester, nil)
+		} else {
+			f.audit(ctx, AuditTokenIssued, requester, nil)
+		}
+	}
 	return response, nil
 }
@@ -105,3 +105,32 @@ func TestNewAccessResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAccessResponse_Extender(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := internal.NewMockTokenEndpointHandler(ctrl)
+	defer ctrl.Finish()
+
+	handler.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ AccessRequester, resp AccessResponder) {
+		resp.SetAccessToken("foo")
+		resp.SetTokenType("bearer")
+	}).Return(nil).Times(1)
+
+	var members map[string]interface{}
+	f := &Fosite{
+		TokenEndpointHandlers: TokenEndpointHandlers{handler},
+		AccessResponseExtender: AccessResponseExtenderFunc(func(_ context.Context, _ AccessRequester) (map[string]interface{}, error) {
+			return members, nil
+		}),
+	}
+
+	members = map[string]interface{}{"tenant": "acme"}
+	resp, err := f.NewAccessResponse(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"access_token": "foo", "token_type": "bearer", "tenant": "acme"}, resp.ToMap())
+
+	// A failing extender fails the request before the handlers issue tokens.
+	members = map[string]interface{}{"access_token": "bar"}
+	_, err = f.NewAccessResponse(context.Background(), nil)
+	assert.EqualError(t, errors.Cause(err), ErrServerError.Error())
+}
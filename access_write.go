@@ -26,7 +26,13 @@ import (
 	"net/http"
 )
 
+// WriteAccessResponse writes the token response as defined in https://tools.ietf.org/html/rfc6749#section-5.1. The
+// response contains tokens and must not be cached, which is why Cache-Control and Pragma are set even if the
+// response can not be encoded.
 func (f *Fosite) WriteAccessResponse(rw http.ResponseWriter, requester AccessRequester, responder AccessResponder) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
 	js, err := json.Marshal(responder.ToMap())
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
@@ -34,8 +40,6 @@ func (f *Fosite) WriteAccessResponse(rw http.ResponseWriter, requester AccessReq
 	}
 
 	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
-	rw.Header().Set("Cache-Control", "no-store")
-	rw.Header().Set("Pragma", "no-cache")
 
 	rw.WriteHeader(http.StatusOK)
 	rw.Write(js)
@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"net/http"
+	"net/http/httptest"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/ory/fosite"
@@ -51,3 +52,18 @@ func TestWriteAccessResponse(t *testing.T) {
 	assert.Equal(t, "no-store", header.Get("Cache-Control"))
 	assert.Equal(t, "no-cache", header.Get("Pragma"))
 }
+
+func BenchmarkWriteAccessResponse(b *testing.B) {
+	f := &Fosite{}
+	resp := NewAccessResponse()
+	resp.SetAccessToken("some-access-token.some-signature")
+	resp.SetTokenType("bearer")
+	resp.SetExtra("expires_in", 3600)
+	resp.SetExtra("scope", "foo bar baz")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.WriteAccessResponse(httptest.NewRecorder(), nil, resp)
+	}
+}
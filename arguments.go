@@ -23,9 +23,26 @@ package fosite
 
 import "strings"
 
+// Arguments is a set of space-delimited values, such as scopes, response types or grant types. The order of the
+// values does not matter, see https://tools.ietf.org/html/rfc6749#section-3.1.1 and
+// https://tools.ietf.org/html/rfc6749#section-3.3
 type Arguments []string
 
+// SplitArguments returns the values of a space-delimited list, for example the "scope" or "response_type" parameter.
+// Empty values, which are the result of leading, trailing or repeated spaces, are removed.
+func SplitArguments(value string) Arguments {
+	return Arguments(removeEmpty(strings.Split(value, " ")))
+}
+
+// String returns the values as space-delimited list.
+func (r Arguments) String() string {
+	return strings.Join(r, " ")
+}
+
+// Matches returns true if r and items contain the same values, regardless of their order. Items may be
+// space-delimited lists themselves, so that Matches("code id_token") and Matches("id_token", "code") are the same.
 func (r Arguments) Matches(items ...string) bool {
+	items = splitItems(items)
 	found := make(map[string]bool)
 	for _, item := range items {
 		if !StringInSlice(item, r) {
@@ -37,8 +54,9 @@ func (r Arguments) Matches(items ...string) bool {
 	return len(found) == len(r) && len(r) == len(items)
 }
 
+// Has returns true if r contains all of the items. Items may be space-delimited lists themselves.
 func (r Arguments) Has(items ...string) bool {
-	for _, item := range items {
+	for _, item := range splitItems(items) {
 		if !StringInSlice(item, r) {
 			return false
 		}
@@ -47,6 +65,7 @@ func (r Arguments) Has(items ...string) bool {
 	return true
 }
 
+// HasOneOf returns true if r contains at least one of the items.
 func (r Arguments) HasOneOf(items ...string) bool {
 	for _, item := range items {
 		if StringInSlice(item, r) {
@@ -57,6 +76,36 @@ func (r Arguments) HasOneOf(items ...string) bool {
 	return false
 }
 
+// Exact returns true if r contains exactly the values of the space-delimited list name, regardless of their order.
+// Unlike Matches, the values are compared case-sensitively.
 func (r Arguments) Exact(name string) bool {
-	return name == strings.Join(r, " ")
+	values := SplitArguments(name)
+	if len(values) != len(r) {
+		return false
+	}
+
+	remaining := make(map[string]int, len(r))
+	for _, v := range r {
+		remaining[v]++
+	}
+	for _, v := range values {
+		if remaining[v] == 0 {
+			return false
+		}
+		remaining[v]--
+	}
+	return true
+}
+
+// splitItems splits items which are space-delimited lists into their values. Other items are kept as they are.
+func splitItems(items []string) []string {
+	split := make([]string, 0, len(items))
+	for _, item := range items {
+		if strings.Contains(item, " ") {
+			split = append(split, SplitArguments(item)...)
+		} else {
+			split = append(split, item)
+		}
+	}
+	return split
 }
@@ -58,6 +58,26 @@ func TestArgumentsExact(t *testing.T) {
 			exact:  "baz",
 			expect: false,
 		},
+		{
+			args:   Arguments{"code", "id_token"},
+			exact:  "id_token code",
+			expect: true,
+		},
+		{
+			args:   Arguments{"code", "id_token"},
+			exact:  " code  id_token ",
+			expect: true,
+		},
+		{
+			args:   Arguments{"code", "id_token"},
+			exact:  "code code",
+			expect: false,
+		},
+		{
+			args:   Arguments{"code"},
+			exact:  "Code",
+			expect: false,
+		},
 	} {
 		assert.Equal(t, c.expect, c.args.Exact(c.exact), "%d", k)
 		t.Logf("Passed test case %d", k)
@@ -162,6 +182,16 @@ func TestArgumentsMatches(t *testing.T) {
 			is:     []string{"baz"},
 			expect: false,
 		},
+		{
+			args:   Arguments{"code", "id_token"},
+			is:     []string{"id_token code"},
+			expect: true,
+		},
+		{
+			args:   Arguments{"code", "id_token", "token"},
+			is:     []string{"token", "id_token  code"},
+			expect: true,
+		},
 	} {
 		assert.Equal(t, c.expect, c.args.Matches(c.is...), "%d", k)
 		t.Logf("Passed test case %d", k)
@@ -194,3 +224,11 @@ func TestArgumentsOneOf(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestSplitArguments(t *testing.T) {
+	assert.Equal(t, Arguments{"code", "id_token"}, SplitArguments(" code  id_token "))
+	assert.Empty(t, SplitArguments(""))
+	assert.Equal(t, "code id_token", SplitArguments("code   id_token").String())
+	assert.True(t, SplitArguments("openid offline").Has("offline openid"))
+	assert.False(t, Arguments{"foo"}.Has(""))
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AuditEventType identifies an event reported to the AuditLogger.
+type AuditEventType string
+
+const (
+	// AuditAuthorizeRequestReceived is reported once an authorize request has been processed by NewAuthorizeRequest.
+	// The event carries the error if the request was rejected.
+	AuditAuthorizeRequestReceived AuditEventType = "authorize_request_received"
+
+	// AuditConsentGranted is reported when NewAuthorizeResponse answers an authorize request the end-user consented to.
+	AuditConsentGranted AuditEventType = "consent_granted"
+
+	// AuditTokenIssued is reported when NewAccessResponse issues tokens for any grant but the refresh token grant.
+	AuditTokenIssued AuditEventType = "token_issued"
+
+	// AuditTokenRefreshed is reported when NewAccessResponse issues tokens for the refresh token grant.
+	AuditTokenRefreshed AuditEventType = "token_refreshed"
+
+	// AuditTokenRevoked is reported when a token was revoked using RevokeToken or the revocation endpoint.
+	AuditTokenRevoked AuditEventType = "token_revoked"
+
+	// AuditAuthenticationFailed is reported when AuthenticateClient rejects the client credentials.
+	AuditAuthenticationFailed AuditEventType = "authentication_failed"
+
+	// AuditHandlerPanicked is reported when an endpoint handler panicked, see PanicHook. The event carries the
+	// resulting server error.
+	AuditHandlerPanicked AuditEventType = "handler_panicked"
+)
+
+// AuditLogger receives structured audit events from the core flows, for example to meet compliance logging
+// requirements. LogAuditEvent is called synchronously and should not block.
+type AuditLogger interface {
+	LogAuditEvent(ctx context.Context, event *AuditEvent)
+}
+
+// AuditEvent describes an event reported to the AuditLogger. Tokens and client secrets are never part of an event.
+type AuditEvent struct {
+	Type       AuditEventType `json:"type"`
+	Time       time.Time      `json:"time"`
+	ClientID   string         `json:"client_id,omitempty"`
+	Subject    string         `json:"subject,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+	GrantTypes Arguments      `json:"grant_types,omitempty"`
+	Scopes     Arguments      `json:"scopes,omitempty"`
+
+	// Reason is set for AuditTokenRevoked events.
+	Reason RevocationReason `json:"reason,omitempty"`
+
+	// Error is set if the request was rejected.
+	Error error `json:"-"`
+
+	// Form contains the parameters of the request the event relates to, with credentials such as client secrets,
+	// codes and tokens redacted, see RedactForm.
+	Form url.Values `json:"form,omitempty"`
+}
+
+// audit reports an event to the AuditLogger, if one is set. The requester may be nil.
+func (f *Fosite) audit(ctx context.Context, eventType AuditEventType, requester Requester, err error) {
+	if f.AuditLogger == nil {
+		return
+	}
+
+	event := &AuditEvent{
+		Type:  eventType,
+		Time:  Now(f.Clock),
+		Error: err,
+	}
+	if requester != nil {
+		event.Form = RedactForm(requester.GetRequestForm())
+		event.RequestID = requester.GetID()
+		event.Scopes = requester.GetGrantedScopes()
+		if len(event.Scopes) == 0 {
+			event.Scopes = requester.GetRequestedScopes()
+		}
+		if c := requester.GetClient(); c != nil && c.GetID() != "" {
+			event.ClientID = c.GetID()
+		} else if form := requester.GetRequestForm(); form != nil {
+			// The client is unknown if the request was rejected early, identify it as presented.
+			event.ClientID = form.Get("client_id")
+		}
+		if s := requester.GetSession(); s != nil {
+			event.Subject = s.GetSubject()
+		}
+		if ar, ok := requester.(AccessRequester); ok {
+			event.GrantTypes = ar.GetGrantTypes()
+		}
+	}
+
+	f.AuditLogger.LogAuditEvent(ctx, event)
+}
+
+// auditAuthenticationFailure reports a failed client authentication, identifying the client by the credentials it
+// presented.
+func (f *Fosite) auditAuthenticationFailure(ctx context.Context, r *http.Request, form url.Values, err error) {
+	if f.AuditLogger == nil {
+		return
+	}
+
+	f.AuditLogger.LogAuditEvent(ctx, &AuditEvent{
+		Type:     AuditAuthenticationFailed,
+		Time:     Now(f.Clock),
+		ClientID: presentedClientID(r, form),
+		Form:     RedactForm(form),
+		Error:    err,
+	})
+}
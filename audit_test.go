@@ -0,0 +1,120 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditLogger []*AuditEvent
+
+func (l *recordingAuditLogger) LogAuditEvent(_ context.Context, event *AuditEvent) {
+	*l = append(*l, event)
+}
+
+type issuingTokenEndpointHandler struct{ acceptingTokenEndpointHandler }
+
+func (issuingTokenEndpointHandler) PopulateTokenEndpointResponse(_ context.Context, _ AccessRequester, responder AccessResponder) error {
+	responder.SetAccessToken("token")
+	responder.SetTokenType("bearer")
+	return nil
+}
+
+type acceptingRevocationHandler struct{}
+
+func (acceptingRevocationHandler) RevokeToken(_ context.Context, _ string, _ TokenType, _ Client) error {
+	return nil
+}
+
+func TestAuditLogger(t *testing.T) {
+	var events recordingAuditLogger
+	hasher := &BCrypt{WorkFactor: 4}
+	secret, err := hasher.Hash([]byte("secret"))
+	require.NoError(t, err)
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Secret: secret}
+	f := &Fosite{
+		Store:                 store,
+		Hasher:                hasher,
+		TokenEndpointHandlers: TokenEndpointHandlers{issuingTokenEndpointHandler{}},
+		RevocationHandlers:    RevocationHandlers{acceptingRevocationHandler{}},
+		AuditLogger:           &events,
+	}
+	ctx := context.Background()
+
+	r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}, "client_id": {"foo"}, "client_secret": {"bar"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = f.NewAccessRequest(ctx, r, new(DefaultSession))
+	require.Error(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, AuditAuthenticationFailed, events[0].Type)
+	assert.Equal(t, "foo", events[0].ClientID)
+	assert.Error(t, events[0].Error)
+
+	// Events never carry credentials.
+	assert.Equal(t, "client_credentials", events[0].Form.Get("grant_type"))
+	assert.NotEqual(t, "bar", events[0].Form.Get("client_secret"))
+
+	r, _ = http.NewRequest("GET", "/auth?client_id=unknown", nil)
+	_, err = f.NewAuthorizeRequest(ctx, r)
+	require.Error(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, AuditAuthorizeRequestReceived, events[1].Type)
+	assert.Equal(t, "unknown", events[1].ClientID)
+	assert.Error(t, events[1].Error)
+
+	ar := NewAccessRequest(&DefaultSession{Subject: "peter"})
+	ar.Client = store.Clients["foo"]
+	ar.GrantTypes = Arguments{"authorization_code"}
+	ar.GrantScope("photos")
+	ar.Form = url.Values{"code": {"secret-code"}, "code_verifier": {"secret-verifier"}}
+	_, err = f.NewAccessResponse(ctx, ar)
+	require.NoError(t, err)
+	ar.GrantTypes = Arguments{"refresh_token"}
+	_, err = f.NewAccessResponse(ctx, ar)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, AuditTokenIssued, events[2].Type)
+	assert.Equal(t, AuditTokenRefreshed, events[3].Type)
+	for _, event := range events[2:] {
+		assert.Equal(t, "foo", event.ClientID)
+		assert.Equal(t, "peter", event.Subject)
+		assert.Equal(t, Arguments{"photos"}, event.Scopes)
+		assert.NoError(t, event.Error)
+		assert.NotContains(t, event.Form.Encode(), "secret-")
+	}
+
+	require.NoError(t, f.RevokeToken(ctx, "token", AccessToken, store.Clients["foo"], RevocationReasonCompromise))
+	require.Len(t, events, 5)
+	assert.Equal(t, AuditTokenRevoked, events[4].Type)
+	assert.Equal(t, "foo", events[4].ClientID)
+	assert.Equal(t, RevocationReasonCompromise, events[4].Reason)
+}
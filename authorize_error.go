@@ -22,38 +22,57 @@
 package fosite
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"net/url"
 
 	"github.com/pkg/errors"
 )
 
+// WriteAuthorizeError writes the error like WriteAuthorizeErrorWithContext. It does not know the tenant of the request,
+// so that the "iss" parameter is left out if TenantResolver is set.
 func (f *Fosite) WriteAuthorizeError(rw http.ResponseWriter, ar AuthorizeRequester, err error) {
-	rfcerr := ErrorToRFC6749Error(err)
-	if !ar.IsRedirectURIValid() {
-		if !f.SendDebugMessagesToClients {
-			rfcerr.Debug = ""
-		}
-
-		js, err := json.MarshalIndent(rfcerr, "", "\t")
-		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	f.WriteAuthorizeErrorWithContext(f.unknownTenantContext(), rw, ar, err)
+}
 
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(rfcerr.Code)
-		rw.Write(js)
+// WriteAuthorizeErrorWithContext writes the redirect returned by NewAuthorizeErrorRedirect for ctx, which is the
+// context of the request, or the error page if there is none.
+func (f *Fosite) WriteAuthorizeErrorWithContext(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	redirect, rfcerr := f.NewAuthorizeErrorRedirect(ctx, ar, err)
+	if redirect == nil {
+		f.authorizeResponseWriter().WriteAuthorizeErrorPage(rw, rfcerr)
 		return
 	}
+	f.authorizeResponseWriter().WriteAuthorizeRedirect(rw, redirect)
+}
+
+// NewAuthorizeErrorRedirect returns the redirect which returns err to the client. If the client or the redirect URI of
+// the request could not be validated, the redirect is nil and the error must be shown to the user agent instead, so
+// that the authorize endpoint can not be abused as an open redirector, see
+// https://tools.ietf.org/html/rfc6749#section-4.1.2.1
+func (f *Fosite) NewAuthorizeErrorRedirect(ctx context.Context, ar AuthorizeRequester, err error) (*AuthorizeRedirect, *RFC6749Error) {
+	rfcerr := f.clientError(err)
+	f.localizeError(&rfcerr, ar)
+
+	if !f.canRedirectAuthorizeError(ar, &rfcerr) {
+		// The request ID is only returned when the error is shown to the user agent directly and is left out of
+		// redirects to the client.
+		rfcerr.ID = ar.GetID()
+		return nil, &rfcerr
+	}
 
-	redirectURI := ar.GetRedirectURI()
 	query := url.Values{}
 	query.Add("error", rfcerr.Name)
 	query.Add("error_description", rfcerr.Description)
 	query.Add("state", ar.GetState())
-	if f.SendDebugMessagesToClients && rfcerr.Debug != "" {
+	if rfcerr.URI != "" {
+		query.Add("error_uri", rfcerr.URI)
+	}
+	if iss := f.authorizationResponseIssuer(ctx); iss != "" {
+		query.Add("iss", iss)
+	}
+
+	if rfcerr.Debug != "" {
 		query.Add("error_debug", rfcerr.Debug)
 	}
 
@@ -61,17 +80,33 @@ func (f *Fosite) WriteAuthorizeError(rw http.ResponseWriter, ar AuthorizeRequest
 		query.Add("error_hint", rfcerr.Hint)
 	}
 
-	if !(len(ar.GetResponseTypes()) == 0 || ar.GetResponseTypes().Exact("code")) && errors.Cause(err) != ErrUnsupportedResponseType {
-		redirectURI.Fragment = query.Encode()
-	} else {
-		for key, values := range redirectURI.Query() {
-			for _, value := range values {
-				query.Add(key, value)
-			}
+	// https://tools.ietf.org/html/rfc6749#section-4.2.2.1
+	// Errors of the implicit grant are returned in the fragment component of the redirection URI, unless a different
+	// response mode was requested.
+	responseMode := ar.GetResponseMode()
+	if responseMode == ResponseModeDefault {
+		responseMode = ResponseModeQuery
+		if errors.Cause(err) != ErrUnsupportedResponseType {
+			responseMode = defaultResponseMode(ar.GetResponseTypes())
 		}
-		redirectURI.RawQuery = query.Encode()
 	}
 
-	rw.Header().Add("Location", redirectURI.String())
-	rw.WriteHeader(http.StatusFound)
+	redirect := &AuthorizeRedirect{RedirectURI: ar.GetRedirectURI(), ResponseMode: responseMode, Query: query, Fragment: url.Values{}}
+	if responseMode == ResponseModeFragment {
+		redirect.Query, redirect.Fragment = url.Values{}, query
+	}
+	return redirect, &rfcerr
+}
+
+// canRedirectAuthorizeError returns true if the client and the redirect URI of ar have both been validated. Errors
+// about the client itself are never redirected, even if the request carries a registered redirect URI.
+func (f *Fosite) canRedirectAuthorizeError(ar AuthorizeRequester, rfcerr *RFC6749Error) bool {
+	if rfcerr.Name == errInvalidClientName {
+		return false
+	} else if !ar.IsRedirectURIValid() {
+		return false
+	} else if f.RedirectURIPolicy != nil {
+		return f.RedirectURIPolicy.Validate(ar.GetRedirectURI(), ar.GetClient()) == nil
+	}
+	return true
 }
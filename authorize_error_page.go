@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+// DefaultAuthorizeErrorPageTemplate is the error page of HTMLAuthorizeResponseWriter if no Template is set.
+var DefaultAuthorizeErrorPageTemplate = template.Must(template.New("authorize_error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{ .Error.Name }}</title></head>
+<body>
+<h1>{{ .Error.Description }}</h1>
+{{ if .Error.Hint }}<p>{{ .Error.Hint }}</p>
+{{ end }}{{ if .Error.ID }}<p>Request ID: {{ .Error.ID }}</p>
+{{ end }}{{ if .JSON }}<pre>{{ .JSON }}</pre>
+{{ end }}</body>
+</html>`))
+
+// AuthorizeErrorPage is the data the error page template of HTMLAuthorizeResponseWriter is executed with.
+type AuthorizeErrorPage struct {
+	// Error is the error which could not be returned to the client.
+	Error *RFC6749Error
+
+	// JSON is the error as JSON. It is only set if the error carries debug details, which is the case if
+	// Fosite.SendDebugMessagesToClients is enabled, so that developers can inspect the machine-readable error.
+	JSON string
+}
+
+// HTMLAuthorizeResponseWriter shows authorize errors which can not be redirected to the client, for example because
+// the client or the redirect URI is invalid, as an HTML page rendered by Template. Everything else is written like
+// DefaultAuthorizeResponseWriter does.
+type HTMLAuthorizeResponseWriter struct {
+	DefaultAuthorizeResponseWriter
+
+	// Template renders the error page with an AuthorizeErrorPage. Defaults to DefaultAuthorizeErrorPageTemplate.
+	Template *template.Template
+}
+
+// WriteAuthorizeErrorPage implements AuthorizeResponseWriter.
+func (w *HTMLAuthorizeResponseWriter) WriteAuthorizeErrorPage(rw http.ResponseWriter, rfcerr *RFC6749Error) {
+	t := w.Template
+	if t == nil {
+		t = DefaultAuthorizeErrorPageTemplate
+	}
+
+	page := AuthorizeErrorPage{Error: rfcerr}
+	if rfcerr.Debug != "" {
+		js, err := json.MarshalIndent(rfcerr, "", "\t")
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.JSON = string(js)
+	}
+
+	// The page is rendered before anything is written, so that a broken template does not leave a half written page.
+	var b bytes.Buffer
+	if err := t.Execute(&b, page); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(rfcerr.Code)
+	rw.Write(b.Bytes())
+}
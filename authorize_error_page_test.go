@@ -0,0 +1,62 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+func TestHTMLAuthorizeResponseWriter(t *testing.T) {
+	f := &Fosite{AuthorizeResponseWriter: &HTMLAuthorizeResponseWriter{}}
+
+	rec := httptest.NewRecorder()
+	f.WriteAuthorizeError(rec, NewAuthorizeRequest(), ErrInvalidClient.WithHint("<script>alert(1)</script>").WithDebug("some-debug"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "<h1>"+template.HTMLEscapeString(ErrInvalidClient.Description)+"</h1>")
+	assert.Contains(t, rec.Body.String(), "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, rec.Body.String(), "<pre>", "debug details are only shown in debug mode")
+	assert.NotContains(t, rec.Body.String(), "some-debug")
+
+	f.SendDebugMessagesToClients = true
+	rec = httptest.NewRecorder()
+	f.WriteAuthorizeError(rec, NewAuthorizeRequest(), ErrInvalidClient.WithDebug("some-debug"))
+	assert.Contains(t, rec.Body.String(), "<pre>")
+	assert.Contains(t, rec.Body.String(), "some-debug")
+
+	f.AuthorizeResponseWriter = &HTMLAuthorizeResponseWriter{Template: template.Must(template.New("").Parse(`Oops: {{ .Error.Name }}`))}
+	rec = httptest.NewRecorder()
+	f.WriteAuthorizeError(rec, NewAuthorizeRequest(), ErrInvalidClient)
+	assert.Equal(t, "Oops: invalid_client", rec.Body.String())
+
+	f.AuthorizeResponseWriter = &HTMLAuthorizeResponseWriter{Template: template.Must(template.New("").Parse(`{{ .Missing }}`))}
+	rec = httptest.NewRecorder()
+	f.WriteAuthorizeError(rec, NewAuthorizeRequest(), ErrInvalidClient)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -23,8 +23,10 @@ package fosite_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"fmt"
 
@@ -32,6 +34,7 @@ import (
 	. "github.com/ory/fosite"
 	. "github.com/ory/fosite/internal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test for
@@ -60,6 +63,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 	}
 
 	header := http.Header{}
+	var body []byte
 	for k, c := range []struct {
 		err         error
 		debug       bool
@@ -70,6 +74,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidGrant,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(false)
+				req.EXPECT().GetID().Return("request-id")
 				rw.EXPECT().Header().Return(header)
 				rw.EXPECT().WriteHeader(http.StatusBadRequest)
 				rw.EXPECT().Write(gomock.Any())
@@ -83,6 +88,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err:   ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code"}))
@@ -99,6 +105,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code"}))
@@ -115,6 +122,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code"}))
@@ -127,10 +135,68 @@ func TestWriteAuthorizeError(t *testing.T) {
 				assert.Equal(t, a, b)
 			},
 		},
+		{
+			err: ErrInvalidRequest.WithHint("").WithURI("https://foobar.com/errors/invalid_request"),
+			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
+				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
+				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
+				req.EXPECT().GetState().Return("foostate")
+				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code"}))
+				rw.EXPECT().Header().Return(header)
+				rw.EXPECT().WriteHeader(http.StatusFound)
+			},
+			checkHeader: func(t *testing.T, k int) {
+				a, _ := url.Parse("https://foobar.com/?error=invalid_request&error_description=The+request+is+missing+a+required+parameter%2C+includes+an+invalid+parameter+value%2C+includes+a+parameter+more+than+once%2C+or+is+otherwise+malformed&error_uri=https%3A%2F%2Ffoobar.com%2Ferrors%2Finvalid_request&state=foostate")
+				b, _ := url.Parse(header.Get("Location"))
+				assert.Equal(t, a, b)
+			},
+		},
+		{
+			err: ErrInvalidRequest.WithHint(""),
+			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
+				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return(ResponseModeFragment)
+				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
+				req.EXPECT().GetState().Return("foostate")
+				rw.EXPECT().Header().Return(header)
+				rw.EXPECT().WriteHeader(http.StatusFound)
+			},
+			checkHeader: func(t *testing.T, k int) {
+				b, _ := url.Parse(header.Get("Location"))
+				assert.Empty(t, b.RawQuery)
+				fragment, err := url.ParseQuery(b.Fragment)
+				require.NoError(t, err)
+				assert.Equal(t, "invalid_request", fragment.Get("error"))
+				assert.Equal(t, "foostate", fragment.Get("state"))
+			},
+		},
+		{
+			err: ErrInvalidRequest.WithHint(""),
+			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
+				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return(ResponseModeFormPost)
+				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
+				req.EXPECT().GetState().Return("foostate")
+				rw.EXPECT().Header().AnyTimes().Return(header)
+				rw.EXPECT().WriteHeader(http.StatusOK)
+				rw.EXPECT().Write(gomock.Any()).AnyTimes().Do(func(b []byte) {
+					body = append(body, b...)
+				})
+			},
+			checkHeader: func(t *testing.T, k int) {
+				assert.Empty(t, header.Get("Location"))
+				assert.Equal(t, "text/html;charset=UTF-8", header.Get("Content-Type"))
+				assert.Contains(t, string(body), `<form method="post" action="https://foobar.com/">`)
+				assert.Contains(t, string(body), `<input type="hidden" name="error" value="invalid_request"/>`)
+				assert.Contains(t, string(body), `<input type="hidden" name="state" value="foostate"/>`)
+			},
+		},
 		{
 			err: ErrUnsupportedGrantType,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"foobar"}))
@@ -147,6 +213,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"token"}))
@@ -164,6 +231,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"token"}))
@@ -181,6 +249,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[0]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code", "token"}))
@@ -198,6 +267,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code", "token"}))
@@ -216,6 +286,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err:   ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"code", "token"}))
@@ -234,6 +305,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err:   ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"id_token"}))
@@ -252,6 +324,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err:   ErrInvalidRequest.WithDebug("with-debug"),
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(true)
+				req.EXPECT().GetResponseMode().Return("")
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().MaxTimes(2).Return(Arguments([]string{"token"}))
@@ -288,3 +361,38 @@ func copyUrl(u *url.URL) *url.URL {
 	u2, _ := url.Parse(u.String())
 	return u2
 }
+
+func TestAuthorizeErrorOpenRedirect(t *testing.T) {
+	client := &DefaultClient{RedirectURIs: []string{"https://foobar.com/cb"}}
+	newRequest := func(c Client) *AuthorizeRequest {
+		ar := NewAuthorizeRequest()
+		ar.RedirectURI, _ = url.Parse("https://foobar.com/cb")
+		ar.Client = c
+		ar.ResponseTypes = Arguments{"code"}
+		return ar
+	}
+
+	for k, c := range []struct {
+		f        *Fosite
+		ar       *AuthorizeRequest
+		err      error
+		redirect bool
+	}{
+		{f: &Fosite{}, ar: newRequest(client), err: ErrAccessDenied, redirect: true},
+		{f: &Fosite{}, ar: newRequest(client), err: ErrInvalidClient},
+		{f: &Fosite{}, ar: newRequest(nil), err: ErrInvalidRequest},
+		{f: &Fosite{}, ar: newRequest(&DeactivatedClient{Client: client, DeactivatedAt: time.Now()}), err: ErrAccessDenied},
+		{f: &Fosite{RedirectURIPolicy: &RedirectURIPolicy{RequireHTTPSForConfidentialClients: true}}, ar: newRequest(client), err: ErrAccessDenied, redirect: true},
+		{f: &Fosite{RedirectURIPolicy: &RedirectURIPolicy{AllowedSchemes: []string{"com.example.app"}}}, ar: newRequest(client), err: ErrAccessDenied},
+	} {
+		rec := httptest.NewRecorder()
+		c.f.WriteAuthorizeError(rec, c.ar, c.err)
+		if c.redirect {
+			assert.Equal(t, http.StatusFound, rec.Code, "%d", k)
+			assert.Contains(t, rec.Header().Get("Location"), "https://foobar.com/cb?", "%d", k)
+		} else {
+			assert.NotEqual(t, http.StatusFound, rec.Code, "%d", k)
+			assert.Empty(t, rec.Header().Get("Location"), "%d", k)
+		}
+	}
+}
@@ -23,6 +23,7 @@ package fosite
 
 import (
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/asaskevich/govalidator"
@@ -83,18 +84,92 @@ func MatchRedirectURIWithClientRedirectURIs(rawurl string, client Client) (*url.
 			// If no redirect_uri was given and the client has exactly one valid redirect_uri registered, use that instead
 			return redirectURIFromClient, nil
 		}
-	} else if rawurl != "" && StringInSlice(rawurl, client.GetRedirectURIs()) {
-		// If a redirect_uri was given and the clients knows it (simple string comparison!)
-		// return it.
+	} else if rawurl != "" && isRegisteredRedirectURI(rawurl, client.GetRedirectURIs()) {
+		// If a redirect_uri was given and the clients knows it (simple string comparison, ignoring the order of query
+		// parameters!) return it.
 		if parsed, err := url.Parse(rawurl); err == nil && IsValidRedirectURI(parsed) {
 			// If no redirect_uri was given and the client has exactly one valid redirect_uri registered, use that instead
 			return parsed, nil
 		}
+	} else if wc, ok := client.(ClientWithWildcardRedirectURIs); ok && rawurl != "" {
+		// Clients may opt in to wildcard redirect URIs, see ClientWithWildcardRedirectURIs.
+		for _, pattern := range wc.GetWildcardRedirectURIs() {
+			if !MatchWildcardRedirectURI(pattern, rawurl) {
+				continue
+			}
+			if parsed, err := url.Parse(rawurl); err == nil && IsValidRedirectURI(parsed) {
+				return parsed, nil
+			}
+		}
 	}
 
 	return nil, errors.WithStack(ErrInvalidRequest.WithHint(`The "redirect_uri" parameter does not match any of the OAuth 2.0 Client's pre-registered redirect urls.`))
 }
 
+// isRegisteredRedirectURI returns true if rawurl is one of the registered redirect URIs. The URIs are compared as
+// strings, except that the order of query parameters is ignored.
+//
+// * https://tools.ietf.org/html/rfc6749#section-3.1.2
+//   The endpoint URI MAY include an "application/x-www-form-urlencoded" formatted (per Appendix B) query
+//   component ([RFC3986] Section 3.4), which MUST be retained when adding additional query parameters.
+func isRegisteredRedirectURI(rawurl string, registered []string) bool {
+	if StringInSlice(rawurl, registered) {
+		return true
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil || u.RawQuery == "" {
+		return false
+	}
+	query := u.Query()
+	u.RawQuery = ""
+
+	for _, r := range registered {
+		ru, err := url.Parse(r)
+		if err != nil || ru.RawQuery == "" || !equalQueries(query, ru.Query()) {
+			continue
+		}
+		ru.RawQuery = ""
+		if u.String() == ru.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// equalQueries returns true if both queries have the same parameters with the same values, in any order.
+func equalQueries(a, b url.Values) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, values := range a {
+		other, ok := b[key]
+		if !ok || len(other) != len(values) {
+			return false
+		}
+
+		sortedValues, sortedOther := append([]string{}, values...), append([]string{}, other...)
+		sort.Strings(sortedValues)
+		sort.Strings(sortedOther)
+		for i := range sortedValues {
+			if sortedValues[i] != sortedOther[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EqualRedirectURIs returns true if the redirect_uri parameter presented at the token endpoint is the one used at
+// authorization time, as required by https://tools.ietf.org/html/rfc6749#section-4.1.3
+//
+// Both values are expected as decoded from the request form, so they are compared byte for byte. No normalization
+// takes place: the scheme and host are case sensitive, default ports, trailing slashes, the encoding and order of
+// query parameters must match, and dot segments are not removed.
+func EqualRedirectURIs(authorized, presented string) bool {
+	return authorized == presented
+}
+
 // IsValidRedirectURI validates a redirect_uri as specified in:
 //
 // * https://tools.ietf.org/html/rfc6749#section-3.1.2
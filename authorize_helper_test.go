@@ -154,6 +154,27 @@ func TestDoesClientWhiteListRedirect(t *testing.T) {
 			url:     "https://bar.com/cb123",
 			isError: true,
 		},
+		{
+			client:   &DefaultClient{RedirectURIs: []string{"https://bar.com/cb?a=1&b=2&b=3"}},
+			url:      "https://bar.com/cb?b=3&a=1&b=2",
+			isError:  false,
+			expected: "https://bar.com/cb?b=3&a=1&b=2",
+		},
+		{
+			client:  &DefaultClient{RedirectURIs: []string{"https://bar.com/cb?a=1&b=2"}},
+			url:     "https://bar.com/cb?b=2",
+			isError: true,
+		},
+		{
+			client:  &DefaultClient{RedirectURIs: []string{"https://bar.com/cb?a=1&b=2"}},
+			url:     "https://bar.com/cb?b=2&a=1&c=3",
+			isError: true,
+		},
+		{
+			client:  &DefaultClient{RedirectURIs: []string{"https://bar.com/cb?a=1&b=2"}},
+			url:     "https://bar.com/cb2?b=2&a=1",
+			isError: true,
+		},
 	} {
 		redir, err := MatchRedirectURIWithClientRedirectURIs(c.url, c.client)
 		assert.Equal(t, c.isError, err != nil, "%d: %s", k, err)
@@ -181,3 +202,25 @@ func TestIsRedirectURISecure(t *testing.T) {
 		assert.Equal(t, !c.err, IsRedirectURISecure(uu), "case %d", d)
 	}
 }
+
+func TestEqualRedirectURIs(t *testing.T) {
+	for k, c := range []struct {
+		authorized string
+		presented  string
+		expect     bool
+	}{
+		{authorized: "https://foo.com/cb", presented: "https://foo.com/cb", expect: true},
+		{authorized: "https://foo.com/cb?foo=bar%20baz", presented: "https://foo.com/cb?foo=bar%20baz", expect: true},
+		{authorized: "https://foo.com/cb?foo=bar%20baz", presented: "https://foo.com/cb?foo=bar+baz", expect: false},
+		{authorized: "https://foo.com/cb?foo=bar%20baz", presented: "https://foo.com/cb?foo=bar baz", expect: false},
+		{authorized: "https://foo.com/cb", presented: "", expect: false},
+		{authorized: "https://foo.com/cb", presented: "https://foo.com/cb/", expect: false},
+		{authorized: "https://foo.com/cb", presented: "https://FOO.com/cb", expect: false},
+		{authorized: "https://foo.com/cb", presented: "https://foo.com:443/cb", expect: false},
+		{authorized: "https://foo.com/cb?a=1&b=2", presented: "https://foo.com/cb?b=2&a=1", expect: false},
+		{authorized: "https://foo.com/cb", presented: "https://foo.com/bar/../cb", expect: false},
+		{authorized: "https://foo.com/cb%zz", presented: "https://foo.com/cb%zz", expect: true},
+	} {
+		assert.Equal(t, c.expect, EqualRedirectURIs(c.authorized, c.presented), "case %d", k)
+	}
+}
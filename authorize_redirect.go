@@ -0,0 +1,147 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// AuthorizeRedirect is an authorize response or error which is ready to be returned to the client through its
+// redirect URI. It does not depend on the transport, so that transports other than net/http can render it without
+// reimplementing how the parameters are placed. See NewAuthorizeRedirect and NewAuthorizeErrorRedirect.
+type AuthorizeRedirect struct {
+	// RedirectURI is the redirect URI of the client, without the parameters of the response.
+	RedirectURI *url.URL
+
+	// ResponseMode is the requested response mode. With ResponseModeFormPost, all parameters are posted to the
+	// redirect URI. Otherwise Query and Fragment are added to the query and fragment of the redirect URI.
+	ResponseMode string
+
+	// Query are the parameters returned in the query of the redirect URI.
+	Query url.Values
+
+	// Fragment are the parameters returned in the fragment of the redirect URI.
+	Fragment url.Values
+
+	// Header are the HTTP headers set by the handlers.
+	Header http.Header
+
+	// escapeSpaces encodes spaces in the parameters as "%20" instead of "+", which is done for responses but not for
+	// errors.
+	escapeSpaces bool
+}
+
+// Parameters returns all parameters of the redirect.
+func (r *AuthorizeRedirect) Parameters() url.Values {
+	return mergeValues(r.Query, r.Fragment)
+}
+
+// Location returns the redirect URI with the parameters added to its query and fragment. The query parameters of the
+// registered redirect URI are retained, see https://tools.ietf.org/html/rfc6749#section-3.1.2
+func (r *AuthorizeRedirect) Location() string {
+	u := *r.RedirectURI
+	q := u.Query()
+	for k, values := range r.Query {
+		for _, value := range values {
+			q.Add(k, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	u.Fragment = r.Fragment.Encode()
+	if !r.escapeSpaces {
+		return u.String()
+	}
+	return plusMatch.ReplaceAllString(u.String(), "%20")
+}
+
+// AuthorizeResponseWriter renders authorize responses and errors. Fosite.WriteAuthorizeResponse and
+// Fosite.WriteAuthorizeError use it after the parameters have been placed according to the response mode.
+type AuthorizeResponseWriter interface {
+	// WriteAuthorizeRedirect returns the response or error to the client.
+	WriteAuthorizeRedirect(rw http.ResponseWriter, redirect *AuthorizeRedirect)
+
+	// WriteAuthorizeErrorPage shows an error to the user agent, because it can not be returned to the client as the
+	// redirect URI is invalid.
+	WriteAuthorizeErrorPage(rw http.ResponseWriter, rfcerr *RFC6749Error)
+}
+
+// DefaultAuthorizeResponseWriter redirects the user agent with HTTP 302 Found, renders an auto-submitting form for
+// the form_post response mode and shows errors as JSON.
+type DefaultAuthorizeResponseWriter struct{}
+
+// WriteAuthorizeRedirect implements AuthorizeResponseWriter.
+func (DefaultAuthorizeResponseWriter) WriteAuthorizeRedirect(rw http.ResponseWriter, redirect *AuthorizeRedirect) {
+	// Set custom headers, e.g. "X-MySuperCoolCustomHeader" or "X-DONT-CACHE-ME"...
+	wh := rw.Header()
+	for k, values := range redirect.Header {
+		wh.Del(k)
+		for _, value := range values {
+			wh.Add(k, value)
+		}
+	}
+
+	if redirect.ResponseMode == ResponseModeFormPost {
+		writeFormPostResponse(rw, redirect.RedirectURI, redirect.Parameters())
+		return
+	}
+
+	// https://tools.ietf.org/html/rfc6749#section-4.1.1
+	// When a decision is established, the authorization server directs the
+	// user-agent to the provided client redirection URI using an HTTP
+	// redirection response, or by other means available to it via the
+	// user-agent.
+	wh.Set("Location", redirect.Location())
+	rw.WriteHeader(http.StatusFound)
+}
+
+// WriteAuthorizeErrorPage implements AuthorizeResponseWriter.
+func (DefaultAuthorizeResponseWriter) WriteAuthorizeErrorPage(rw http.ResponseWriter, rfcerr *RFC6749Error) {
+	js, err := json.MarshalIndent(rfcerr, "", "\t")
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(rfcerr.Code)
+	rw.Write(js)
+}
+
+func (f *Fosite) authorizeResponseWriter() AuthorizeResponseWriter {
+	if f.AuthorizeResponseWriter == nil {
+		return DefaultAuthorizeResponseWriter{}
+	}
+	return f.AuthorizeResponseWriter
+}
+
+// mergeValues returns the values of all of sets combined.
+func mergeValues(sets ...url.Values) url.Values {
+	merged := url.Values{}
+	for _, set := range sets {
+		for k, values := range set {
+			merged[k] = append(merged[k], values...)
+		}
+	}
+	return merged
+}
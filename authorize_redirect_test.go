@@ -0,0 +1,84 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuthorizeResponseWriter struct {
+	redirect *AuthorizeRedirect
+	rfcerr   *RFC6749Error
+}
+
+func (w *recordingAuthorizeResponseWriter) WriteAuthorizeRedirect(_ http.ResponseWriter, redirect *AuthorizeRedirect) {
+	w.redirect = redirect
+}
+
+func (w *recordingAuthorizeResponseWriter) WriteAuthorizeErrorPage(_ http.ResponseWriter, rfcerr *RFC6749Error) {
+	w.rfcerr = rfcerr
+}
+
+func TestAuthorizeResponseWriter(t *testing.T) {
+	w := new(recordingAuthorizeResponseWriter)
+	f := &Fosite{AuthorizeResponseWriter: w}
+
+	ar := NewAuthorizeRequest()
+	ar.RedirectURI, _ = url.Parse("https://foobar.com/cb?foo=bar")
+	ar.Client = &DefaultClient{RedirectURIs: []string{"https://foobar.com/cb?foo=bar"}}
+	ar.ResponseTypes = Arguments{"code", "id_token"}
+	ar.ResponseMode = ResponseModeFormPost
+	resp := NewAuthorizeResponse()
+	resp.AddFragment("code", "abc")
+	resp.AddFragment("id_token", "xyz")
+
+	rec := httptest.NewRecorder()
+	f.WriteAuthorizeResponse(rec, ar, resp)
+	require.NotNil(t, w.redirect)
+	assert.Equal(t, ResponseModeFormPost, w.redirect.ResponseMode)
+	assert.Equal(t, url.Values{"code": {"abc"}, "id_token": {"xyz"}}, w.redirect.Parameters())
+	assert.Equal(t, "https://foobar.com/cb?foo=bar#code=abc&id_token=xyz", w.redirect.Location())
+	assert.Empty(t, rec.Header(), "the default writer must not be used")
+
+	// Errors which can be redirected to the client are written as redirects.
+	ar.ResponseMode = ResponseModeDefault
+	f.WriteAuthorizeError(rec, ar, ErrAccessDenied)
+	assert.Equal(t, ResponseModeFragment, w.redirect.ResponseMode)
+	assert.Equal(t, "access_denied", w.redirect.Fragment.Get("error"))
+	assert.Nil(t, w.rfcerr)
+
+	// Errors are shown to the user agent if the redirect URI is invalid.
+	redirect, rfcerr := f.NewAuthorizeErrorRedirect(context.Background(), NewAuthorizeRequest(), ErrInvalidClient)
+	assert.Nil(t, redirect)
+	assert.Equal(t, ErrInvalidClient.Name, rfcerr.Name)
+	f.WriteAuthorizeError(rec, NewAuthorizeRequest(), ErrInvalidClient)
+	require.NotNil(t, w.rfcerr)
+	assert.Equal(t, ErrInvalidClient.Name, w.rfcerr.Name)
+}
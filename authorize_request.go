@@ -23,6 +23,7 @@ package fosite
 
 import (
 	"net/url"
+	"time"
 )
 
 // AuthorizeRequest is an implementation of AuthorizeRequester
@@ -31,15 +32,42 @@ type AuthorizeRequest struct {
 	RedirectURI          *url.URL  `json:"redirectUri" gorethink:"redirectUri"`
 	State                string    `json:"state" gorethink:"state"`
 	HandledResponseTypes Arguments `json:"handledResponseTypes" gorethink:"handledResponseTypes"`
+	ResponseMode         string    `json:"responseMode" gorethink:"responseMode"`
+
+	Prompt      Arguments `json:"prompt" gorethink:"prompt"`
+	LoginHint   string    `json:"loginHint" gorethink:"loginHint"`
+	IDTokenHint string    `json:"idTokenHint" gorethink:"idTokenHint"`
+	ACRValues   Arguments `json:"acrValues" gorethink:"acrValues"`
+	Display     string    `json:"display" gorethink:"display"`
+	DeviceHint  string    `json:"deviceHint" gorethink:"deviceHint"`
+	Nonce       string    `json:"nonce" gorethink:"nonce"`
+
+	// MaxAge is the maximum authentication age in seconds. A negative value means that max_age was not requested.
+	MaxAge int64 `json:"maxAge" gorethink:"maxAge"`
+
+	fullyHandled bool
 
 	Request
 }
 
+// FullyHandledAuthorizeRequester is implemented by authorize requests which an AuthorizeEndpointHandler can claim as a
+// whole, see AuthorizeRequest.MarkFullyHandled.
+type FullyHandledAuthorizeRequester interface {
+	// MarkFullyHandled marks every requested response type as handled and stops NewAuthorizeResponse from invoking
+	// the remaining handlers.
+	MarkFullyHandled()
+
+	// IsFullyHandled returns true if a handler called MarkFullyHandled.
+	IsFullyHandled() bool
+}
+
 func NewAuthorizeRequest() *AuthorizeRequest {
 	return &AuthorizeRequest{
 		ResponseTypes:        Arguments{},
 		RedirectURI:          &url.URL{},
 		HandledResponseTypes: Arguments{},
+		Prompt:               Arguments{},
+		MaxAge:               -1,
 		Request:              *NewRequest(),
 	}
 }
@@ -50,7 +78,7 @@ func (d *AuthorizeRequest) IsRedirectURIValid() bool {
 	}
 
 	raw := d.GetRedirectURI().String()
-	if d.GetClient() == nil {
+	if d.GetClient() == nil || !IsClientActive(d.GetClient()) {
 		return false
 	}
 
@@ -69,20 +97,127 @@ func (d *AuthorizeRequest) GetState() string {
 	return d.State
 }
 
+func (d *AuthorizeRequest) GetResponseMode() string {
+	return d.ResponseMode
+}
+
 func (d *AuthorizeRequest) GetRedirectURI() *url.URL {
 	return d.RedirectURI
 }
 
+func (d *AuthorizeRequest) GetPrompt() Arguments {
+	return d.Prompt
+}
+
+func (d *AuthorizeRequest) GetMaxAge() (time.Duration, bool) {
+	if d.MaxAge < 0 {
+		return 0, false
+	}
+	return time.Duration(d.MaxAge) * time.Second, true
+}
+
+func (d *AuthorizeRequest) GetLoginHint() string {
+	return d.LoginHint
+}
+
+func (d *AuthorizeRequest) GetIDTokenHint() string {
+	return d.IDTokenHint
+}
+
+func (d *AuthorizeRequest) GetACRValues() Arguments {
+	return d.ACRValues
+}
+
+func (d *AuthorizeRequest) GetDisplay() string {
+	return d.Display
+}
+
+func (d *AuthorizeRequest) GetDeviceHint() string {
+	return d.DeviceHint
+}
+
+// GetNonce returns the value of the OpenID Connect nonce parameter, which is echoed into ID Tokens.
+func (d *AuthorizeRequest) GetNonce() string {
+	return d.Nonce
+}
+
 func (d *AuthorizeRequest) SetResponseTypeHandled(name string) {
+	if d.HandledResponseTypes.Has(name) {
+		return
+	}
 	d.HandledResponseTypes = append(d.HandledResponseTypes, name)
 }
 
-func (d *AuthorizeRequest) DidHandleAllResponseTypes() bool {
+// GetUnhandledResponseTypes returns the requested response types which no handler has marked as handled yet.
+func (d *AuthorizeRequest) GetUnhandledResponseTypes() Arguments {
+	unhandled := Arguments{}
 	for _, rt := range d.ResponseTypes {
 		if !d.HandledResponseTypes.Has(rt) {
-			return false
+			unhandled = append(unhandled, rt)
 		}
 	}
+	return unhandled
+}
+
+// MarkFullyHandled marks every requested response type as handled and stops NewAuthorizeResponse from invoking the
+// handlers which follow the current one.
+func (d *AuthorizeRequest) MarkFullyHandled() {
+	for _, rt := range d.ResponseTypes {
+		d.SetResponseTypeHandled(rt)
+	}
+	d.fullyHandled = true
+}
+
+// IsFullyHandled returns true if a handler called MarkFullyHandled.
+func (d *AuthorizeRequest) IsFullyHandled() bool {
+	return d.fullyHandled
+}
+
+func (d *AuthorizeRequest) DidHandleAllResponseTypes() bool {
+	return len(d.ResponseTypes) > 0 && len(d.GetUnhandledResponseTypes()) == 0
+}
+
+// Sanitize returns a copy of the request which only keeps the form values of allowedParameters, see Request.Sanitize.
+// The response types, redirect URI, state and response mode are kept, while the OpenID Connect parameters such as the
+// nonce are only kept if their form parameter is allowed.
+func (d *AuthorizeRequest) Sanitize(allowedParameters []string) Requester {
+	b := &AuthorizeRequest{
+		ResponseTypes:        copyArguments(d.ResponseTypes),
+		State:                d.State,
+		HandledResponseTypes: copyArguments(d.HandledResponseTypes),
+		ResponseMode:         d.ResponseMode,
+		MaxAge:               -1,
+		Request:              *d.Request.sanitize(allowedParameters),
+	}
+	if d.RedirectURI != nil {
+		u := *d.RedirectURI
+		b.RedirectURI = &u
+	}
 
-	return len(d.ResponseTypes) > 0
+	form := b.GetRequestForm()
+	if _, ok := form["prompt"]; ok {
+		b.Prompt = copyArguments(d.Prompt)
+	}
+	if _, ok := form["max_age"]; ok {
+		b.MaxAge = d.MaxAge
+	}
+	if _, ok := form["login_hint"]; ok {
+		b.LoginHint = d.LoginHint
+	}
+	if _, ok := form["id_token_hint"]; ok {
+		b.IDTokenHint = d.IDTokenHint
+	}
+	if _, ok := form["acr_values"]; ok {
+		b.ACRValues = copyArguments(d.ACRValues)
+	}
+	if _, ok := form["display"]; ok {
+		b.Display = d.Display
+	}
+	if _, ok := form["device_hint"]; ok {
+		b.DeviceHint = d.DeviceHint
+	}
+	if _, ok := form["nonce"]; ok {
+		b.Nonce = d.Nonce
+	}
+	return b
 }
@@ -22,8 +22,13 @@
 package fosite
 
 import (
+	"encoding/json"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"context"
 
@@ -33,12 +38,32 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/ory/go-convenience/stringslice"
-	"github.com/ory/go-convenience/stringsx"
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 )
 
+// validateRequestObjectBinding makes sure that the request object was issued by the client of the request for this
+// authorization server, so that request objects of other clients or for other servers can not be replayed. If
+// IssuerConfig is set, signed request objects must carry the "aud" claim naming its issuer, see
+// https://tools.ietf.org/html/rfc9101#section-4; the other claims are only checked if they are present.
+func (f *Fosite) validateRequestObjectBinding(request *AuthorizeRequest, claims jwt.MapClaims, signed bool) error {
+	_, hasAudience := claims["aud"]
+	if iss, ok := claims["iss"]; ok && iss != request.GetClient().GetID() {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint(`The "iss" claim of the request object must be the client_id of the OAuth 2.0 Client.`))
+	} else if clientID, ok := claims["client_id"]; ok && clientID != request.GetClient().GetID() {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint(`The "client_id" claim of the request object must be the client_id of the OAuth 2.0 Client.`))
+	} else if f.IssuerConfig == nil {
+		return nil
+	} else if signed && !hasAudience {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint(`Signed request objects must contain the "aud" claim.`))
+	} else if hasAudience && !jwtAudienceContains(claims, f.IssuerConfig.Issuer) {
+		return errors.WithStack(ErrInvalidRequestObject.WithHintf(`The "aud" claim of the request object must contain the issuer "%s".`, f.IssuerConfig.Issuer))
+	}
+	return nil
+}
+
 func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *AuthorizeRequest) error {
-	var scope Arguments = stringsx.Splitx(request.Form.Get("scope"), " ")
+	scope := SplitArguments(request.Form.Get("scope"))
 
 	// Even if a scope parameter is present in the Request Object value, a scope parameter MUST always be passed using
 	// the OAuth 2.0 request syntax containing the openid scope value to indicate to the underlying OAuth 2.0 logic that this is an OpenID Connect request.
@@ -94,7 +119,12 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 		assertion = string(body)
 	}
 
-	token, err := jwt.ParseWithClaims(assertion, new(jwt.MapClaims), func(t *jwt.Token) (interface{}, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(assertion, new(jwt.MapClaims), func(t *jwt.Token) (interface{}, error) {
+		if err := f.requestObjectPolicy().CheckHeader(t.Header); err != nil {
+			return nil, errors.WithStack(ErrInvalidRequestObject.WithHint(err.Error()))
+		}
+
 		if oidcClient.GetRequestObjectSigningAlgorithm() != fmt.Sprintf("%s", t.Header["alg"]) {
 			return nil, errors.WithStack(ErrInvalidRequestObject.WithHintf(`The request object uses signing algorithm %s, but the requested OAuth 2.0 Client enforces signing algorithm %s.`, t.Header["alg"], oidcClient.GetRequestObjectSigningAlgorithm()))
 		}
@@ -135,31 +165,72 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 			return errors.WithStack(ErrInvalidRequestObject.WithHintf("Unable to verify the request object's signature.").WithDebug(err.Error()))
 		}
 		return err
-	} else if err := token.Claims.Valid(); err != nil {
-		return errors.WithStack(ErrInvalidRequestObject.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithDebug(err.Error()))
 	}
 
 	claims, ok := token.Claims.(*jwt.MapClaims)
 	if !ok {
 		return errors.WithStack(ErrInvalidRequestObject.WithHint("Unable to type assert claims from request object.").WithDebugf(`Got claims of type %T but expected type "*jwt.MapClaims".`, token.Claims))
+	} else if err := f.validateTimeClaims(*claims); err != nil {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithDebug(err.Error()))
+	}
+
+	if f.FAPIProfile != nil {
+		if err := f.FAPIProfile.validateRequestObject(token, *claims, Now(f.Clock)); err != nil {
+			return err
+		}
+	}
+
+	if err := f.validateRequestObjectBinding(request, *claims, token.Method != jwt.SigningMethodNone); err != nil {
+		return err
+	}
+
+	if f.FAPIProfile != nil {
+		request.Form = f.FAPIProfile.requestObjectForm(request.Form, *claims)
+		return nil
 	}
 
 	for k, v := range *claims {
-		request.Form.Set(k, fmt.Sprintf("%s", v))
+		request.Form.Set(k, requestObjectClaimValue(v))
 	}
 
-	claimScope := stringsx.Splitx(request.Form.Get("scope"), " ")
+	claimScope := SplitArguments(request.Form.Get("scope"))
 	for _, s := range scope {
 		if !stringslice.Has(claimScope, s) {
 			claimScope = append(claimScope, s)
 		}
 	}
 
-	request.Form.Set("scope", strings.Join(claimScope, " "))
+	request.Form.Set("scope", claimScope.String())
 	return nil
 }
 
-func (f *Fosite) validateAuthorizeRedirectURI(r *http.Request, request *AuthorizeRequest) error {
+// requestObjectClaimValue returns the value of a request object claim as an authorize request parameter. Numbers such
+// as "max_age" are formatted as integers where possible, arrays are joined by spaces and objects such as "claims" are
+// encoded as JSON.
+func requestObjectClaimValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		values := make([]string, len(v))
+		for k, value := range v {
+			values[k] = requestObjectClaimValue(value)
+		}
+		return strings.Join(values, " ")
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
+
+func (f *Fosite) validateAuthorizeRedirectURI(request *AuthorizeRequest) error {
 	// Fetch redirect URI from request
 	rawRedirURI, err := GetRedirectURIFromRequestValues(request.Form)
 	if err != nil {
@@ -172,15 +243,21 @@ func (f *Fosite) validateAuthorizeRedirectURI(r *http.Request, request *Authoriz
 		return err
 	} else if !IsValidRedirectURI(redirectURI) {
 		return errors.WithStack(ErrInvalidRequest.WithHintf(`The redirect URI "%s" contains an illegal character (for example #) or is otherwise invalid.`, redirectURI))
+	} else if err := f.redirectURIPolicy().Validate(redirectURI, request.Client); err != nil {
+		return err
 	}
 	request.RedirectURI = redirectURI
 	return nil
 }
 
-func (f *Fosite) validateAuthorizeScope(r *http.Request, request *AuthorizeRequest) error {
-	scope := removeEmpty(strings.Split(request.Form.Get("scope"), " "))
+func (f *Fosite) validateAuthorizeScope(ctx context.Context, request *AuthorizeRequest) error {
+	scope := SplitArguments(request.Form.Get("scope"))
+	if malformed, ok := malformedScope(scope); ok {
+		return errors.WithStack(ErrInvalidScope.WithHintf(`The requested scope %q contains characters which are not allowed in scope values.`, malformed))
+	}
+	scopeStrategy := EffectiveScopeStrategy(ctx, f.ConfigProvider, request.Client, f.ScopeStrategy)
 	for _, permission := range scope {
-		if !f.ScopeStrategy(request.Client.GetScopes(), permission) {
+		if !scopeStrategy(request.Client.GetScopes(), permission) {
 			return errors.WithStack(ErrInvalidScope.WithHintf(`The OAuth 2.0 Client is not allowed to request scope "%s".`, permission))
 		}
 	}
@@ -189,48 +266,149 @@ func (f *Fosite) validateAuthorizeScope(r *http.Request, request *AuthorizeReque
 	return nil
 }
 
-func (f *Fosite) validateResponseTypes(r *http.Request, request *AuthorizeRequest) error {
+func (f *Fosite) validateResponseTypes(request *AuthorizeRequest) error {
 	// https://tools.ietf.org/html/rfc6749#section-3.1.1
 	// Extension response types MAY contain a space-delimited (%x20) list of
 	// values, where the order of values does not matter (e.g., response
 	// type "a b" is the same as "b a").  The meaning of such composite
 	// response types is defined by their respective specifications.
-	responseTypes := removeEmpty(stringsx.Splitx(r.Form.Get("response_type"), " "))
+	responseTypes := SplitArguments(request.Form.Get("response_type"))
 	if len(responseTypes) == 0 {
 		return errors.WithStack(ErrUnsupportedResponseType.WithHint(`The request is missing the "response_type"" parameter.`))
 	}
 
 	var found bool
 	for _, t := range request.GetClient().GetResponseTypes() {
-		if Arguments(responseTypes).Matches(removeEmpty(stringsx.Splitx(t, " "))...) {
+		if responseTypes.Matches(t) {
 			found = true
 			break
 		}
 	}
 
 	if !found {
-		return errors.WithStack(ErrUnsupportedResponseType.WithHintf("The client is not allowed to request response_type \"%s\".", r.Form.Get("response_type")))
+		return errors.WithStack(ErrUnsupportedResponseType.WithHintf("The client is not allowed to request response_type \"%s\".", request.Form.Get("response_type")))
 	}
 
 	request.ResponseTypes = responseTypes
 	return nil
 }
 
+func (f *Fosite) parseAuthorizeOpenIDConnectParameters(request *AuthorizeRequest) error {
+	// prompt is case sensitive and space delimited, see http://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+	request.Prompt = SplitArguments(request.Form.Get("prompt"))
+
+	if maxAge := request.Form.Get("max_age"); maxAge != "" {
+		parsed, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil || parsed < 0 {
+			return errors.WithStack(ErrInvalidRequest.WithHintf(`Parameter "max_age" must be a non-negative integer but got "%s".`, maxAge))
+		}
+		request.MaxAge = parsed
+	}
+
+	request.LoginHint = request.Form.Get("login_hint")
+	request.IDTokenHint = request.Form.Get("id_token_hint")
+	request.ACRValues = SplitArguments(request.Form.Get("acr_values"))
+
+	// See http://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+	switch display := request.Form.Get("display"); display {
+	case "", "page", "popup", "touch", "wap":
+		request.Display = display
+	default:
+		return errors.WithStack(ErrInvalidRequest.WithHintf(`Parameter "display" must be one of "page", "popup", "touch" or "wap" but got "%s".`, display))
+	}
+
+	request.DeviceHint = request.Form.Get("device_hint")
+
+	// The nonce mitigates replay attacks and must not be guessable, just like the state.
+	if nonce := request.Form.Get("nonce"); nonce != "" {
+		if len(nonce) < f.minParameterEntropy() {
+			return errors.WithStack(ErrInsufficientEntropy.WithHintf(`Parameter "nonce" is set but does not satisfy the minimum entropy of %d characters.`, f.minParameterEntropy()))
+		} else if !isVisibleASCII(nonce) {
+			return errors.WithStack(ErrInvalidRequest.WithHint(`Parameter "nonce" must only contain printable ASCII characters.`))
+		}
+		request.Nonce = nonce
+	}
+	return nil
+}
+
 func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (AuthorizeRequester, error) {
+	return f.newAuthorizeRequest(ctx, r.Header, func() (url.Values, error) {
+		return f.parseAuthorizeRequestForm(r)
+	})
+}
+
+// parseAuthorizeRequestForm returns the parameters of an authorize request. They are sent in the query of GET
+// requests, or in the form serialized body of POST requests, see
+// https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+func (f *Fosite) parseAuthorizeRequestForm(r *http.Request) (url.Values, error) {
+	switch r.Method {
+	case "", http.MethodGet:
+	case http.MethodPost:
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/x-www-form-urlencoded" {
+			return nil, errors.WithStack(ErrInvalidRequest.WithHintf(`The body of POST authorize requests must be of Content-Type "application/x-www-form-urlencoded" but got "%s".`, r.Header.Get("Content-Type")))
+		}
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(nil, r.Body, f.maxRequestBodySize())
+		}
+	default:
+		return nil, errors.WithStack(ErrInvalidRequest.WithHintf(`HTTP method is "%s", expected "GET" or "POST".`, r.Method))
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithDebug(err.Error()))
+	}
+	return r.Form, nil
+}
+
+// NewAuthorizeRequestFromValues is like NewAuthorizeRequest, but takes the parameters and headers of the authorize
+// request instead of an *http.Request. It lets frameworks and runtimes which do not use net/http, or which already
+// parsed the request, drive fosite without synthesizing an *http.Request. form must contain the query parameters and,
+// for POST requests, the form body of the request.
+func (f *Fosite) NewAuthorizeRequestFromValues(ctx context.Context, form url.Values, header http.Header) (AuthorizeRequester, error) {
+	return f.newAuthorizeRequest(ctx, header, func() (url.Values, error) {
+		return form, nil
+	})
+}
+
+func (f *Fosite) newAuthorizeRequest(ctx context.Context, header http.Header, parseForm func() (url.Values, error)) (_ AuthorizeRequester, err error) {
 	request := &AuthorizeRequest{
 		ResponseTypes:        Arguments{},
 		HandledResponseTypes: Arguments{},
+		Prompt:               Arguments{},
+		MaxAge:               -1,
 		Request:              *NewRequest(),
 	}
-
-	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
-		return request, errors.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithDebug(err.Error()))
+	request.RequestedAt = Now(f.Clock)
+	request.ID = uuid.New()
+	start := time.Now()
+	ctx, span := f.startSpan(ctx, "fosite.NewAuthorizeRequest")
+	span.SetAttribute("fosite.request_id", request.ID)
+	defer func() {
+		span.End(err)
+		f.observeRequest("authorize", request.ResponseTypes, start, err)
+		f.audit(ctx, AuditAuthorizeRequestReceived, request, err)
+	}()
+
+	form, err := parseForm()
+	if err != nil {
+		return request, err
+	} else if form == nil {
+		form = url.Values{}
 	}
 
-	request.Form = r.Form
-	client, err := f.Store.GetClient(ctx, request.GetRequestForm().Get("client_id"))
+	request.Form = form
+	if err := f.validateRequestLimits(form); err != nil {
+		return request, err
+	}
+	if name, ok := duplicateParameter(form, f.RepeatableParameters); ok {
+		return request, errors.WithStack(ErrInvalidRequest.WithHintf("The request parameter \"%s\" must not be included more than once.", name))
+	}
+	request.Languages = requestLanguages(header, form)
+	client, err := f.getClient(ctx, request.GetRequestForm().Get("client_id"))
 	if err != nil {
 		return request, errors.WithStack(ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist."))
+	} else if !IsClientActive(client) {
+		return request, errors.WithStack(ErrInvalidClient.WithHint("The requested OAuth 2.0 Client has been deactivated."))
 	}
 	request.Client = client
 
@@ -238,11 +416,11 @@ func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (Auth
 		return request, err
 	}
 
-	if err := f.validateAuthorizeRedirectURI(r, request); err != nil {
+	if err := f.validateAuthorizeRedirectURI(request); err != nil {
 		return request, err
 	}
 
-	if err := f.validateAuthorizeScope(r, request); err != nil {
+	if err := f.validateAuthorizeScope(ctx, request); err != nil {
 		return request, err
 	}
 
@@ -250,7 +428,15 @@ func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (Auth
 		return request, errors.WithStack(ErrRegistrationNotSupported)
 	}
 
-	if err := f.validateResponseTypes(r, request); err != nil {
+	if err := f.validateResponseTypes(request); err != nil {
+		return request, err
+	}
+
+	if err := f.validateResponseMode(ctx, request); err != nil {
+		return request, err
+	}
+
+	if err := f.parseAuthorizeOpenIDConnectParameters(request); err != nil {
 		return request, err
 	}
 
@@ -261,11 +447,23 @@ func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (Auth
 	// https://tools.ietf.org/html/rfc6819#section-4.4.1.8
 	// The "state" parameter should not	be guessable
 	state := request.Form.Get("state")
-	if len(state) < MinParameterEntropy {
+	if len(state) < f.minParameterEntropy() {
 		// We're assuming that using less then 8 characters for the state can not be considered "unguessable"
-		return request, errors.WithStack(ErrInvalidState.WithHintf(`Request parameter "state" must be at least be %d characters long to ensure sufficient entropy.`, MinParameterEntropy))
+		return request, errors.WithStack(ErrInvalidState.WithHintf(`Request parameter "state" must be at least be %d characters long to ensure sufficient entropy.`, f.minParameterEntropy()))
+	} else if !isVisibleASCII(state) {
+		return request, errors.WithStack(ErrInvalidState.WithHint(`Request parameter "state" must only contain printable ASCII characters.`))
 	}
 	request.State = state
 
+	if f.FAPIProfile != nil {
+		if err := f.FAPIProfile.validateAuthorizeRequest(request); err != nil {
+			return request, err
+		}
+	}
+
+	if err := f.evaluatePolicy(ctx, PolicyStageAuthorize, request); err != nil {
+		return request, err
+	}
+
 	return request, nil
 }
@@ -30,6 +30,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
@@ -76,6 +77,11 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 	}
 
 	validRequestObject := mustGenerateAssertion(t, jwt.MapClaims{"scope": "foo", "foo": "bar", "baz": "baz"}, key, "kid-foo")
+	typedRequestObject := mustGenerateAssertion(t, jwt.MapClaims{
+		"max_age":    300,
+		"acr_values": []string{"urn:a", "urn:b"},
+		"claims":     map[string]interface{}{"id_token": map[string]interface{}{"auth_time": map[string]interface{}{"essential": true}}},
+	}, key, "kid-foo")
 	validNoneRequestObject := mustGenerateNoneAssertion(t, jwt.MapClaims{"scope": "foo", "foo": "bar", "baz": "baz"})
 
 	var reqH http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
@@ -163,6 +169,12 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
 			expectForm: url.Values{"scope": {"foo openid"}, "request": {validRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
 		},
+		{
+			d:          "should pass and convert typed claims of the request object",
+			form:       url.Values{"scope": {"openid"}, "request": {typedRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectForm: url.Values{"scope": {"openid"}, "request": {typedRequestObject}, "max_age": {"300"}, "acr_values": {"urn:a urn:b"}, "claims": {`{"id_token":{"auth_time":{"essential":true}}}`}},
+		},
 		{
 			d:          "should fail because request uri is not whitelisted",
 			form:       url.Values{"scope": {"openid"}, "request_uri": {reqTS.URL}},
@@ -204,3 +216,81 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizeRequestObjectConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	client := &DefaultOpenIDConnectClient{
+		DefaultClient:                 &DefaultClient{ID: "foo"},
+		JSONWebKeys:                   &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		RequestObjectSigningAlgorithm: "RS256",
+	}
+	f := &Fosite{IssuerConfig: &IssuerConfig{Issuer: "https://op.example.com"}}
+
+	requestObject := func(typ string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "kid-foo"
+		if typ != "" {
+			token.Header["typ"] = typ
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	for k, tc := range []struct {
+		request   string
+		expectErr error
+	}{
+		{request: requestObject("", jwt.MapClaims{"iss": "foo", "client_id": "foo", "aud": "https://op.example.com"})},
+		{request: requestObject("oauth-authz-req+jwt", jwt.MapClaims{"aud": []string{"https://op.example.com", "https://other.example.com"}})},
+		{request: requestObject("at+jwt", jwt.MapClaims{}), expectErr: ErrInvalidRequestObject},
+		{request: requestObject("", jwt.MapClaims{"iss": "bar"}), expectErr: ErrInvalidRequestObject},
+		{request: requestObject("", jwt.MapClaims{"client_id": "bar"}), expectErr: ErrInvalidRequestObject},
+		{request: requestObject("", jwt.MapClaims{"aud": "https://other.example.com"}), expectErr: ErrInvalidRequestObject},
+		{request: requestObject("", jwt.MapClaims{"scope": "foo"}), expectErr: ErrInvalidRequestObject},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			req := &AuthorizeRequest{Request: Request{Client: client, Form: url.Values{"scope": {"openid"}, "request": {tc.request}}}}
+			err := f.authorizeRequestParametersFromOpenIDConnectRequest(req)
+			if tc.expectErr != nil {
+				require.EqualError(t, err, tc.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthorizeRequestObjectClockSkew(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	client := &DefaultOpenIDConnectClient{
+		JSONWebKeys:                   &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		RequestObjectSigningAlgorithm: "RS256",
+	}
+	now := time.Now().UTC()
+	requestObject := mustGenerateAssertion(t, jwt.MapClaims{"nbf": now.Add(30 * time.Second).Unix(), "exp": now.Add(time.Minute).Unix()}, key, "kid-foo")
+
+	for k, tc := range []struct {
+		skew      time.Duration
+		now       time.Time
+		expectErr error
+	}{
+		{now: now, expectErr: ErrInvalidRequestObject},
+		{now: now, skew: time.Minute},
+		{now: now.Add(2 * time.Minute), skew: time.Minute},
+		{now: now.Add(3 * time.Minute), skew: time.Minute, expectErr: ErrInvalidRequestObject},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			f := &Fosite{ClockSkew: tc.skew, Clock: ClockFunc(func() time.Time { return tc.now })}
+			req := &AuthorizeRequest{Request: Request{Client: client, Form: url.Values{"scope": {"openid"}, "request": {requestObject}}}}
+			err := f.authorizeRequestParametersFromOpenIDConnectRequest(req)
+			if tc.expectErr != nil {
+				require.EqualError(t, err, tc.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
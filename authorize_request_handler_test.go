@@ -24,6 +24,7 @@ package fosite_test
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"context"
@@ -79,6 +80,14 @@ func TestNewAuthorizeRequest(t *testing.T) {
 				store.EXPECT().GetClient(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo"))
 			},
 		},
+		/* duplicate parameter */
+		{
+			desc:          "duplicate client_id fails",
+			conf:          &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query:         url.Values{"client_id": []string{"foo", "bar"}},
+			expectedError: ErrInvalidRequest,
+			mock:          func() {},
+		},
 		/* invalid client */
 		{
 			desc:          "invalid client fails",
@@ -190,12 +199,121 @@ func TestNewAuthorizeRequest(t *testing.T) {
 				RedirectURI:   redir,
 				ResponseTypes: []string{"code", "token"},
 				State:         "strong-state",
+				MaxAge:        -1,
 				Request: Request{
 					Client: &DefaultClient{ResponseTypes: []string{"code token"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo", "bar"}},
 					Scopes: []string{"foo", "bar"},
 				},
 			},
 		},
+		/* fails because max_age is negative */
+		{
+			desc: "should fail because max_age is negative",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"openid"},
+				"max_age":       {"-10"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}}, nil)
+			},
+			expectedError: ErrInvalidRequest,
+		},
+		/* fails because max_age is not a number */
+		{
+			desc: "should fail because max_age is not a number",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"openid"},
+				"max_age":       {"ten"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}}, nil)
+			},
+			expectedError: ErrInvalidRequest,
+		},
+		/* fails because response_mode is query but tokens are requested */
+		{
+			desc: "should fail because response_mode query is not allowed for token response types",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code token"},
+				"response_mode": {"query"},
+				"state":         {"strong-state"},
+				"scope":         {"foo"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code token"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"}}, nil)
+			},
+			expectedError: ErrInvalidRequest,
+		},
+		/* fails because display is invalid */
+		{
+			desc: "should fail because display is unknown",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"openid"},
+				"display":       {"hologram"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}}, nil)
+			},
+			expectedError: ErrInvalidRequest,
+		},
+		/* success case with OpenID Connect parameters */
+		{
+			desc: "should pass and parse the OpenID Connect parameters",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"openid"},
+				"prompt":        {"login  consent"},
+				"max_age":       {"60"},
+				"login_hint":    {"peter@foo.bar"},
+				"id_token_hint": {"some.id.token"},
+				"acr_values":    {"urn:mace:incommon:iap:silver 0"},
+				"display":       {"touch"},
+				"device_hint":   {"tv"},
+				"response_mode": {"form_post"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}}, nil)
+			},
+			expect: &AuthorizeRequest{
+				RedirectURI:   redir,
+				ResponseTypes: []string{"code"},
+				State:         "strong-state",
+				Prompt:        []string{"login", "consent"},
+				MaxAge:        60,
+				LoginHint:     "peter@foo.bar",
+				IDTokenHint:   "some.id.token",
+				ACRValues:     []string{"urn:mace:incommon:iap:silver", "0"},
+				Display:       "touch",
+				DeviceHint:    "tv",
+				ResponseMode:  "form_post",
+				Request: Request{
+					Client: &DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}},
+					Scopes: []string{"openid"},
+				},
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c.mock()
@@ -211,9 +329,109 @@ func TestNewAuthorizeRequest(t *testing.T) {
 				assert.EqualError(t, errors.Cause(err), c.expectedError.Error())
 			} else {
 				require.NoError(t, err)
-				AssertObjectKeysEqual(t, c.expect, ar, "ResponseTypes", "Scopes", "Client", "RedirectURI", "State")
+				AssertObjectKeysEqual(t, c.expect, ar, "ResponseTypes", "Scopes", "Client", "RedirectURI", "State", "Prompt", "MaxAge", "LoginHint", "IDTokenHint", "ACRValues", "Display", "DeviceHint", "ResponseMode")
 				assert.NotNil(t, ar.GetRequestedAt())
 			}
 		})
 	}
 }
+
+func TestNewAuthorizeRequestFromValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy}
+	store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"}}, nil)
+
+	ar, err := f.NewAuthorizeRequestFromValues(context.Background(), url.Values{
+		"redirect_uri":  {"https://foo.bar/cb"},
+		"client_id":     {"1234"},
+		"response_type": {"code"},
+		"state":         {"strong-state"},
+		"scope":         {"foo"},
+	}, http.Header{"Accept-Language": {"de-CH, en;q=0.5"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://foo.bar/cb", ar.GetRedirectURI().String())
+	assert.Equal(t, Arguments{"code"}, ar.GetResponseTypes())
+	assert.Equal(t, Arguments{"foo"}, ar.GetRequestedScopes())
+	assert.Equal(t, []string{"de-CH", "en"}, ar.(*AuthorizeRequest).GetLanguages())
+
+	// The parameters are validated like the ones of an HTTP request.
+	store.EXPECT().GetClient(gomock.Any(), "").Return(nil, ErrNotFound)
+	_, err = f.NewAuthorizeRequestFromValues(context.Background(), nil, nil)
+	assert.EqualError(t, errors.Cause(err), ErrInvalidClient.Error())
+}
+
+func TestNewAuthorizeRequest_Post(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, MaxRequestBodySize: 256}
+	body := url.Values{
+		"redirect_uri":  {"https://foo.bar/cb"},
+		"client_id":     {"1234"},
+		"response_type": {"code"},
+		"state":         {"strong-state"},
+		"scope":         {"foo"},
+	}.Encode()
+	newRequest := func(method, contentType, body string) *http.Request {
+		r, err := http.NewRequest(method, "https://auth.example/auth", strings.NewReader(body))
+		require.NoError(t, err)
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"}}, nil)
+	ar, err := f.NewAuthorizeRequest(context.Background(), newRequest("POST", "application/x-www-form-urlencoded; charset=UTF-8", body))
+	require.NoError(t, err)
+	assert.Equal(t, "https://foo.bar/cb", ar.GetRedirectURI().String())
+	assert.Equal(t, "strong-state", ar.GetState())
+
+	for k, r := range []*http.Request{
+		newRequest("POST", "application/json", body),
+		newRequest("POST", "", body),
+		newRequest("PUT", "application/x-www-form-urlencoded", body),
+		newRequest("POST", "application/x-www-form-urlencoded", body+"&foo="+strings.Repeat("a", 256)),
+	} {
+		_, err := f.NewAuthorizeRequest(context.Background(), r)
+		assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error(), "%d", k)
+	}
+}
+
+func TestNewAuthorizeRequest_Nonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, MinParameterEntropy: 12}
+	store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"openid"}}, nil).AnyTimes()
+
+	for k, c := range []struct {
+		nonce     string
+		state     string
+		expectErr error
+	}{
+		{nonce: "", state: "strong-state"},
+		{nonce: "long-enough-nonce", state: "strong-state"},
+		{nonce: "short-nonce", state: "strong-state", expectErr: ErrInsufficientEntropy},
+		{nonce: "long-enough\nnonce", state: "strong-state", expectErr: ErrInvalidRequest},
+		{nonce: "long-enough-nonce", state: "weak-state", expectErr: ErrInvalidState},
+	} {
+		ar, err := f.NewAuthorizeRequestFromValues(context.Background(), url.Values{
+			"redirect_uri":  {"https://foo.bar/cb"},
+			"client_id":     {"1234"},
+			"response_type": {"code"},
+			"scope":         {"openid"},
+			"state":         {c.state},
+			"nonce":         {c.nonce},
+		}, nil)
+		if c.expectErr != nil {
+			assert.EqualError(t, errors.Cause(err), c.expectErr.Error(), "%d", k)
+			continue
+		}
+		require.NoError(t, err, "%d", k)
+		assert.Equal(t, c.nonce, ar.(*AuthorizeRequest).GetNonce(), "%d", k)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuthorizeRequestState is the serializable state of an authorize request. It is used to hand an authorize request
+// over to a login or consent application and to resume it once the end-user has been authenticated and consent has
+// been given. Only the raw request parameters are persisted, the request is validated again when it is resumed.
+type AuthorizeRequestState struct {
+	ID          string     `json:"id"`
+	RequestedAt time.Time  `json:"requested_at"`
+	Form        url.Values `json:"form"`
+}
+
+// MarshalAuthorizeRequest serializes the authorize request.
+func MarshalAuthorizeRequest(ar AuthorizeRequester) ([]byte, error) {
+	out, err := json.Marshal(&AuthorizeRequestState{
+		ID:          ar.GetID(),
+		RequestedAt: ar.GetRequestedAt(),
+		Form:        ar.GetRequestForm(),
+	})
+	return out, errors.WithStack(err)
+}
+
+// UnmarshalAuthorizeRequest deserializes an authorize request serialized with MarshalAuthorizeRequest.
+func UnmarshalAuthorizeRequest(data []byte) (*AuthorizeRequestState, error) {
+	var state AuthorizeRequestState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &state, nil
+}
+
+// SignedConsentChallenge serializes the authorize request and signs it using HMAC-SHA256 and the given secret. The
+// challenge can be passed to the login or consent application as-is and must be verified with VerifyConsentChallenge
+// before the authorize request is resumed.
+func SignedConsentChallenge(secret []byte, ar AuthorizeRequester) (string, error) {
+	data, err := MarshalAuthorizeRequest(ar)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signConsentChallenge(secret, payload)), nil
+}
+
+// VerifyConsentChallenge verifies the signature of a challenge created by SignedConsentChallenge and returns the
+// authorize request state. If maxAge is larger than zero, challenges of authorize requests older than maxAge are
+// rejected.
+func VerifyConsentChallenge(secret []byte, challenge string, maxAge time.Duration) (*AuthorizeRequestState, error) {
+	return verifyConsentChallenge(secret, challenge, maxAge, SystemClock)
+}
+
+// VerifyConsentChallenge is like the function VerifyConsentChallenge, but takes the current time from f.Clock.
+func (f *Fosite) VerifyConsentChallenge(secret []byte, challenge string, maxAge time.Duration) (*AuthorizeRequestState, error) {
+	return verifyConsentChallenge(secret, challenge, maxAge, f.Clock)
+}
+
+func verifyConsentChallenge(secret []byte, challenge string, maxAge time.Duration, clock Clock) (*AuthorizeRequestState, error) {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 2 {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("The consent challenge is malformed."))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("The consent challenge is malformed.").WithDebug(err.Error()))
+	} else if !hmac.Equal(signature, signConsentChallenge(secret, parts[0])) {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("The consent challenge signature is invalid."))
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("The consent challenge is malformed.").WithDebug(err.Error()))
+	}
+
+	state, err := UnmarshalAuthorizeRequest(data)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHint("The consent challenge is malformed.").WithDebug(err.Error()))
+	}
+
+	if maxAge > 0 && state.RequestedAt.Add(maxAge).Before(Now(clock)) {
+		return nil, errors.WithStack(ErrInvalidRequest.WithHintf("The consent challenge expired at \"%s\".", state.RequestedAt.Add(maxAge)))
+	}
+
+	return state, nil
+}
+
+func signConsentChallenge(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// ResumeAuthorizeRequest restores an authorize request from its state. The request parameters are validated again,
+// exactly as NewAuthorizeRequest does, but the ID and the time of the original request are kept.
+func (f *Fosite) ResumeAuthorizeRequest(ctx context.Context, state *AuthorizeRequestState) (AuthorizeRequester, error) {
+	r := &http.Request{
+		Method: "GET",
+		Header: http.Header{},
+		URL:    &url.URL{RawQuery: state.Form.Encode()},
+	}
+
+	ar, err := f.NewAuthorizeRequest(ctx, r)
+	if err != nil {
+		return ar, err
+	}
+
+	request := ar.(*AuthorizeRequest)
+	request.ID = state.ID
+	request.RequestedAt = state.RequestedAt
+	return request, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/ory/fosite"
+	. "github.com/ory/fosite/internal"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	secret := []byte("some-super-secret-secret-secret")
+	ar := NewAuthorizeRequest()
+	ar.RequestedAt = time.Now().UTC().Round(time.Second)
+	ar.Form = url.Values{
+		"redirect_uri":  {"https://foo.bar/cb"},
+		"client_id":     {"1234"},
+		"response_type": {"code"},
+		"state":         {"strong-state"},
+		"scope":         {"foo"},
+	}
+
+	challenge, err := SignedConsentChallenge(secret, ar)
+	require.NoError(t, err)
+
+	_, err = VerifyConsentChallenge([]byte("another-secret"), challenge, 0)
+	assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+
+	_, err = VerifyConsentChallenge(secret, challenge+"a", 0)
+	assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+
+	_, err = VerifyConsentChallenge(secret, "foo", 0)
+	assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+
+	ar.RequestedAt = time.Now().UTC().Add(-time.Hour)
+	expired, err := SignedConsentChallenge(secret, ar)
+	require.NoError(t, err)
+	_, err = VerifyConsentChallenge(secret, expired, time.Minute)
+	assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+	_, err = (&Fosite{Clock: ClockFunc(func() time.Time { return ar.RequestedAt })}).VerifyConsentChallenge(secret, expired, time.Minute)
+	require.NoError(t, err, "the age of the challenge is measured with the clock of Fosite")
+
+	state, err := VerifyConsentChallenge(secret, challenge, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ar.ID, state.ID)
+	assert.Equal(t, ar.Form, state.Form)
+
+	client := &DefaultClient{ID: "1234", RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"}}
+	store.EXPECT().GetClient(gomock.Any(), "1234").Return(client, nil)
+
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy}
+	resumed, err := f.ResumeAuthorizeRequest(context.Background(), state)
+	require.NoError(t, err)
+	assert.Equal(t, ar.ID, resumed.GetID())
+	assert.Equal(t, state.RequestedAt, resumed.GetRequestedAt())
+	assert.Equal(t, client, resumed.GetClient())
+	assert.Equal(t, "strong-state", resumed.GetState())
+	assert.Equal(t, Arguments{"foo"}, resumed.GetRequestedScopes())
+}
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthorizeRequest(t *testing.T) {
@@ -124,3 +125,74 @@ func TestAuthorizeRequest(t *testing.T) {
 		assert.Equal(t, &DefaultSession{}, c.ar.GetSession())
 	}
 }
+
+func TestAuthorizeRequest_ResponseTypesHandled(t *testing.T) {
+	ar := NewAuthorizeRequest()
+	assert.False(t, ar.DidHandleAllResponseTypes())
+
+	ar.ResponseTypes = Arguments{"code", "id_token"}
+	assert.False(t, ar.DidHandleAllResponseTypes())
+	assert.Equal(t, Arguments{"code", "id_token"}, ar.GetUnhandledResponseTypes())
+
+	ar.SetResponseTypeHandled("code")
+	ar.SetResponseTypeHandled("code")
+	assert.False(t, ar.DidHandleAllResponseTypes())
+	assert.Equal(t, Arguments{"id_token"}, ar.GetUnhandledResponseTypes())
+	assert.Equal(t, Arguments{"code"}, ar.HandledResponseTypes)
+
+	ar.SetResponseTypeHandled("id_token")
+	assert.True(t, ar.DidHandleAllResponseTypes())
+	assert.Empty(t, ar.GetUnhandledResponseTypes())
+}
+
+func TestAuthorizeRequestOpenIDConnectParameters(t *testing.T) {
+	ar := NewAuthorizeRequest()
+	maxAge, ok := ar.GetMaxAge()
+	assert.False(t, ok)
+	assert.Equal(t, time.Duration(0), maxAge)
+	assert.Empty(t, ar.GetPrompt())
+
+	ar.Prompt = Arguments{"none"}
+	ar.MaxAge = 0
+	ar.LoginHint = "peter"
+	ar.IDTokenHint = "some.id.token"
+
+	maxAge, ok = ar.GetMaxAge()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), maxAge)
+	assert.Equal(t, Arguments{"none"}, ar.GetPrompt())
+	assert.Equal(t, "peter", ar.GetLoginHint())
+	assert.Equal(t, "some.id.token", ar.GetIDTokenHint())
+
+	ar.MaxAge = 60
+	maxAge, _ = ar.GetMaxAge()
+	assert.Equal(t, time.Minute, maxAge)
+}
+
+func TestAuthorizeRequestSanitize(t *testing.T) {
+	ar := NewAuthorizeRequest()
+	ar.ResponseTypes = Arguments{"code", "id_token"}
+	ar.RedirectURI, _ = url.Parse("https://foo.com/cb")
+	ar.State = "some-state"
+	ar.Scopes = Arguments{"openid"}
+	ar.Form = url.Values{"nonce": {"some-nonce"}, "id_token_hint": {"some.id.token"}}
+	ar.Nonce = "some-nonce"
+	ar.IDTokenHint = "some.id.token"
+
+	sanitized, ok := ar.Sanitize([]string{"nonce"}).(*AuthorizeRequest)
+	require.True(t, ok)
+	assert.Equal(t, ar.GetID(), sanitized.GetID())
+	assert.Equal(t, Arguments{"code", "id_token"}, sanitized.GetResponseTypes())
+	assert.Equal(t, "https://foo.com/cb", sanitized.GetRedirectURI().String())
+	assert.Equal(t, "some-state", sanitized.GetState())
+	assert.Equal(t, "some-nonce", sanitized.GetNonce())
+	assert.Empty(t, sanitized.GetIDTokenHint())
+	assert.Empty(t, sanitized.GetRequestForm().Get("id_token_hint"))
+
+	sanitized.ResponseTypes[0] = "token"
+	sanitized.Scopes[0] = "offline"
+	sanitized.RedirectURI.Host = "bar.com"
+	assert.Equal(t, Arguments{"code", "id_token"}, ar.GetResponseTypes())
+	assert.Equal(t, Arguments{"openid"}, ar.GetRequestedScopes())
+	assert.Equal(t, "foo.com", ar.GetRedirectURI().Host)
+}
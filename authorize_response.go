@@ -31,7 +31,12 @@ type AuthorizeResponse struct {
 	Header   http.Header
 	Query    url.Values
 	Fragment url.Values
-	code     string
+
+	// ResponseMode is the response mode of the authorize request, see EffectiveResponseMode. It decides where
+	// AddParameter places parameters.
+	ResponseMode string
+
+	code string
 }
 
 func NewAuthorizeResponse() *AuthorizeResponse {
@@ -75,3 +80,19 @@ func (a *AuthorizeResponse) AddFragment(key, value string) {
 	}
 	a.Fragment.Add(key, value)
 }
+
+// GetResponseMode returns the response mode of the authorize request the response is for.
+func (a *AuthorizeResponse) GetResponseMode() string {
+	return a.ResponseMode
+}
+
+// AddParameter adds a key value pair to the fragment if the response mode is "fragment" and to the query otherwise.
+// WriteAuthorizeResponse moves parameters to the requested response mode, so handlers which do not depend on a
+// specific component should use AddParameter.
+func (a *AuthorizeResponse) AddParameter(key, value string) {
+	if a.ResponseMode == ResponseModeFragment {
+		a.AddFragment(key, value)
+		return
+	}
+	a.AddQuery(key, value)
+}
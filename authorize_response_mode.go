@@ -0,0 +1,106 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Response modes as defined in https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#ResponseModes and
+// https://openid.net/specs/oauth-v2-form-post-response-mode-1_0.html
+const (
+	ResponseModeDefault  = ""
+	ResponseModeQuery    = "query"
+	ResponseModeFragment = "fragment"
+	ResponseModeFormPost = "form_post"
+)
+
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<html>
+<head><title>Submit This Form</title></head>
+<body onload="javascript:document.forms[0].submit()">
+<form method="post" action="{{ .RedirectURI }}">
+{{ range $key, $values := .Parameters }}{{ range $values }}<input type="hidden" name="{{ $key }}" value="{{ . }}"/>
+{{ end }}{{ end }}</form>
+</body>
+</html>`))
+
+func (f *Fosite) validateResponseMode(ctx context.Context, request *AuthorizeRequest) error {
+	switch mode := request.Form.Get("response_mode"); mode {
+	case ResponseModeDefault, ResponseModeFragment, ResponseModeFormPost:
+		request.ResponseMode = mode
+	case ResponseModeQuery:
+		// https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#Combinations
+		// Tokens must not be returned in the query component of the redirect URI.
+		if request.ResponseTypes.HasOneOf("token", "id_token") {
+			return errors.WithStack(ErrInvalidRequest.WithHint(`Parameter "response_mode" must not be "query" when "response_type" contains "token" or "id_token".`))
+		}
+		request.ResponseMode = mode
+	default:
+		return errors.WithStack(ErrInvalidRequest.WithHintf(`Parameter "response_mode" must be one of "query", "fragment" or "form_post" but got "%s".`, mode))
+	}
+
+	if f.ConfigProvider != nil {
+		if allowed := f.ConfigProvider.GetResponseModes(ctx, request.Client); allowed != nil {
+			if mode := EffectiveResponseMode(request); !Arguments(allowed).Has(mode) {
+				return errors.WithStack(ErrInvalidRequest.WithHintf(`The OAuth 2.0 Client is not allowed to use response mode "%s".`, mode))
+			}
+		}
+	}
+	return nil
+}
+
+// EffectiveResponseMode returns the response mode of the authorize request, which is the requested one or, if none was
+// requested, "query" for the "code" response type and "fragment" for all others, see
+// https://openid.net/specs/oauth-v2-multiple-response-types-1_0.html#ResponseModes
+func EffectiveResponseMode(ar AuthorizeRequester) string {
+	if mode := ar.GetResponseMode(); mode != ResponseModeDefault {
+		return mode
+	}
+	return defaultResponseMode(ar.GetResponseTypes())
+}
+
+func defaultResponseMode(responseTypes Arguments) string {
+	if len(responseTypes) == 0 || responseTypes.Exact("code") {
+		return ResponseModeQuery
+	}
+	return ResponseModeFragment
+}
+
+// writeFormPostResponse writes an HTML page which auto-submits the parameters to the redirect URI.
+func writeFormPostResponse(rw http.ResponseWriter, redirectURI *url.URL, parameters url.Values) {
+	rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	_ = formPostTemplate.Execute(rw, struct {
+		RedirectURI string
+		Parameters  url.Values
+	}{
+		RedirectURI: redirectURI.String(),
+		Parameters:  parameters,
+	})
+}
@@ -42,3 +42,16 @@ func TestAuthorizeResponse(t *testing.T) {
 	assert.Equal(t, "baz", ar.GetQuery().Get("foo"))
 	assert.Equal(t, "foo", ar.GetHeader().Get("foo"))
 }
+
+func TestAuthorizeResponse_AddParameter(t *testing.T) {
+	ar := NewAuthorizeResponse()
+	ar.AddParameter("foo", "bar")
+	assert.Equal(t, "bar", ar.GetQuery().Get("foo"))
+
+	ar = NewAuthorizeResponse()
+	ar.ResponseMode = ResponseModeFragment
+	ar.AddParameter("code", "bar")
+	assert.Equal(t, "bar", ar.GetFragment().Get("code"))
+	assert.Equal(t, "bar", ar.GetCode())
+	assert.Empty(t, ar.GetQuery())
+}
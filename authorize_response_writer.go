@@ -24,29 +24,81 @@ package fosite
 import (
 	"net/http"
 	"net/url"
+	"strings"
 
 	"context"
 
 	"github.com/pkg/errors"
 )
 
-func (f *Fosite) NewAuthorizeResponse(ctx context.Context, ar AuthorizeRequester, session Session) (AuthorizeResponder, error) {
+func (f *Fosite) NewAuthorizeResponse(ctx context.Context, ar AuthorizeRequester, session Session) (_ AuthorizeResponder, err error) {
+	ctx, span := f.startSpan(ctx, "fosite.NewAuthorizeResponse")
+	defer func() { span.End(err) }()
+
 	var resp = &AuthorizeResponse{
-		Header:   http.Header{},
-		Query:    url.Values{},
-		Fragment: url.Values{},
+		Header:       http.Header{},
+		Query:        url.Values{},
+		Fragment:     url.Values{},
+		ResponseMode: EffectiveResponseMode(ar),
 	}
 
 	ar.SetSession(session)
+	if err := f.includeGrantedScopes(ctx, ar); err != nil {
+		return nil, err
+	}
+
 	for _, h := range f.AuthorizeEndpointHandlers {
-		if err := h.HandleAuthorizeEndpointRequest(ctx, ar, resp); err != nil {
+		err := f.runHandler(ctx, "authorize", h, ar, func(ctx context.Context) error {
+			return h.HandleAuthorizeEndpointRequest(ctx, ar, resp)
+		})
+		if err != nil {
 			return nil, err
 		}
+
+		if fh, ok := ar.(FullyHandledAuthorizeRequester); ok && fh.IsFullyHandled() {
+			break
+		}
 	}
 
+	if err := aggregateResponseParameters(resp.Query); err != nil {
+		return nil, err
+	}
+	if err := aggregateResponseParameters(resp.Fragment); err != nil {
+		return nil, err
+	}
+
+	// Every requested response type must have been handled, otherwise the response would be missing some of the
+	// credentials the client asked for, for example the id_token of a "code id_token" hybrid request.
 	if !ar.DidHandleAllResponseTypes() {
+		if u, ok := ar.(interface{ GetUnhandledResponseTypes() Arguments }); ok && len(u.GetUnhandledResponseTypes()) > 0 {
+			return nil, errors.WithStack(ErrUnsupportedResponseType.WithHintf("The response types \"%s\" were requested but could not be handled.", strings.Join(u.GetUnhandledResponseTypes(), " ")))
+		}
 		return nil, errors.WithStack(ErrUnsupportedResponseType)
 	}
 
+	if err := f.recordGrantedScopes(ctx, ar); err != nil {
+		return nil, err
+	}
+
+	f.audit(ctx, AuditConsentGranted, ar, nil)
 	return resp, nil
 }
+
+// aggregateResponseParameters merges the values which several authorize endpoint handlers added for the same response
+// parameter. Equal values are collapsed into one, while different values can not be answered deterministically and
+// result in ErrServerError.
+func aggregateResponseParameters(values url.Values) error {
+	for key, vs := range values {
+		if len(vs) < 2 {
+			continue
+		}
+
+		for _, v := range vs[1:] {
+			if v != vs[0] {
+				return errors.WithStack(ErrServerError.WithDebugf("The authorize endpoint handlers set different values for the response parameter \"%s\".", key))
+			}
+		}
+		values[key] = vs[:1]
+	}
+	return nil
+}
@@ -22,6 +22,7 @@
 package fosite_test
 
 import (
+	"fmt"
 	"testing"
 
 	"context"
@@ -31,6 +32,7 @@ import (
 	. "github.com/ory/fosite/internal"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewAuthorizeResponse(t *testing.T) {
@@ -47,6 +49,8 @@ func TestNewAuthorizeResponse(t *testing.T) {
 		AuthorizeEndpointHandlers: AuthorizeEndpointHandlers{handlers[0], handlers[0]},
 	}
 	ar.EXPECT().SetSession(gomock.Eq(new(DefaultSession))).AnyTimes()
+	ar.EXPECT().GetResponseMode().Return(ResponseModeDefault).AnyTimes()
+	ar.EXPECT().GetResponseTypes().Return(Arguments{"code"}).AnyTimes()
 	fooErr := errors.New("foo")
 	for k, c := range []struct {
 		isErr     bool
@@ -98,3 +102,79 @@ func TestNewAuthorizeResponse(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestNewAuthorizeResponse_UnhandledResponseTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewMockAuthorizeEndpointHandler(ctrl)
+	h.EXPECT().HandleAuthorizeEndpointRequest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ar AuthorizeRequester, _ AuthorizeResponder) error {
+		ar.SetResponseTypeHandled("code")
+		return nil
+	})
+
+	ar := NewAuthorizeRequest()
+	ar.ResponseTypes = Arguments{"code", "id_token"}
+	_, err := (&Fosite{AuthorizeEndpointHandlers: AuthorizeEndpointHandlers{h}}).NewAuthorizeResponse(context.Background(), ar, new(DefaultSession))
+	require.Error(t, err)
+	assert.Equal(t, ErrUnsupportedResponseType.Name, ErrorToRFC6749Error(err).Name)
+	assert.Contains(t, ErrorToRFC6749Error(err).Hint, `"id_token"`)
+}
+
+func TestNewAuthorizeResponse_FullyHandled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	first := NewMockAuthorizeEndpointHandler(ctrl)
+	first.EXPECT().HandleAuthorizeEndpointRequest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ar AuthorizeRequester, resp AuthorizeResponder) error {
+		resp.AddQuery("code", "foo")
+		ar.(FullyHandledAuthorizeRequester).MarkFullyHandled()
+		return nil
+	})
+	// The second handler must not be invoked, gomock fails the test otherwise.
+	second := NewMockAuthorizeEndpointHandler(ctrl)
+
+	ar := NewAuthorizeRequest()
+	ar.ResponseTypes = Arguments{"code", "id_token"}
+	resp, err := (&Fosite{AuthorizeEndpointHandlers: AuthorizeEndpointHandlers{first, second}}).NewAuthorizeResponse(context.Background(), ar, new(DefaultSession))
+	require.NoError(t, err)
+	assert.True(t, ar.IsFullyHandled())
+	assert.True(t, ar.DidHandleAllResponseTypes())
+	assert.Equal(t, "foo", resp.GetQuery().Get("code"))
+}
+
+func TestNewAuthorizeResponse_AggregateResponseParameters(t *testing.T) {
+	for k, c := range []struct {
+		first, second string
+		expectErr     bool
+	}{
+		{first: "foo", second: "foo"},
+		{first: "foo", second: "bar", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler := func(state string) *MockAuthorizeEndpointHandler {
+				h := NewMockAuthorizeEndpointHandler(ctrl)
+				h.EXPECT().HandleAuthorizeEndpointRequest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ar AuthorizeRequester, resp AuthorizeResponder) error {
+					resp.AddFragment("state", state)
+					ar.SetResponseTypeHandled("token")
+					return nil
+				})
+				return h
+			}
+
+			ar := NewAuthorizeRequest()
+			ar.ResponseTypes = Arguments{"token"}
+			resp, err := (&Fosite{AuthorizeEndpointHandlers: AuthorizeEndpointHandlers{handler(c.first), handler(c.second)}}).NewAuthorizeResponse(context.Background(), ar, new(DefaultSession))
+			if c.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, ErrServerError.Name, ErrorToRFC6749Error(err).Name)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{c.first}, resp.GetFragment()["state"])
+		})
+	}
+}
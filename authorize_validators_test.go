@@ -23,7 +23,6 @@ package fosite
 
 import (
 	"fmt"
-	"net/http"
 	"net/url"
 	"testing"
 
@@ -83,14 +82,14 @@ func TestValidateResponseTypes(t *testing.T) {
 		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			r := &http.Request{Form: url.Values{"response_type": {tc.rt}}}
+			ar := NewAuthorizeRequest()
+			ar.Form = url.Values{"response_type": {tc.rt}}
 			if tc.rt == "disable" {
-				r = &http.Request{Form: url.Values{}}
+				ar.Form = url.Values{}
 			}
-			ar := NewAuthorizeRequest()
 			ar.Request.Client = &DefaultClient{ResponseTypes: tc.art}
 
-			err := f.validateResponseTypes(r, ar)
+			err := f.validateResponseTypes(ar)
 			if tc.expectErr {
 				require.Error(t, err)
 			} else {
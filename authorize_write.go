@@ -22,7 +22,9 @@
 package fosite
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 	"regexp"
 )
 
@@ -31,35 +33,60 @@ var (
 	plusMatch = regexp.MustCompile("\\+")
 )
 
+// WriteAuthorizeResponse writes the response like WriteAuthorizeResponseWithContext. It does not know the tenant of the
+// request, so that the "iss" parameter is left out if TenantResolver is set.
 func (f *Fosite) WriteAuthorizeResponse(rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
-	redir := ar.GetRedirectURI()
+	f.WriteAuthorizeResponseWithContext(f.unknownTenantContext(), rw, ar, resp)
+}
 
-	// Explicit grants
-	q := redir.Query()
-	rq := resp.GetQuery()
-	for k := range rq {
-		q.Set(k, rq.Get(k))
-	}
-	redir.RawQuery = q.Encode()
+// WriteAuthorizeResponseWithContext writes the redirect returned by NewAuthorizeRedirect for ctx, which is the context
+// of the request.
+func (f *Fosite) WriteAuthorizeResponseWithContext(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	f.authorizeResponseWriter().WriteAuthorizeRedirect(rw, f.NewAuthorizeRedirect(ctx, ar, resp))
+}
 
-	// Set custom headers, e.g. "X-MySuperCoolCustomHeader" or "X-DONT-CACHE-ME"...
-	wh := rw.Header()
-	rh := resp.GetHeader()
-	for k := range rh {
-		wh.Set(k, rh.Get(k))
+// NewAuthorizeRedirect returns the redirect which returns resp to the client. If a response mode was requested, all
+// parameters are returned the way it defines, regardless of where the handlers placed them. Otherwise the parameters
+// are returned where the handlers placed them, which is the query for explicit and the fragment for implicit and
+// hybrid grants. The "iss" parameter, if enabled, names the issuer of the tenant of ctx, see TenantFromContext.
+func (f *Fosite) NewAuthorizeRedirect(ctx context.Context, ar AuthorizeRequester, resp AuthorizeResponder) *AuthorizeRedirect {
+	redirect := &AuthorizeRedirect{
+		RedirectURI:  ar.GetRedirectURI(),
+		ResponseMode: ar.GetResponseMode(),
+		Query:        resp.GetQuery(),
+		Fragment:     resp.GetFragment(),
+		Header:       resp.GetHeader(),
+		escapeSpaces: true,
 	}
 
-	// Implicit grants
-	redir.Fragment = resp.GetFragment().Encode()
+	// The issuer is returned next to the other parameters, which is the fragment for implicit and hybrid grants.
+	if iss := f.authorizationResponseIssuer(ctx); iss != "" {
+		if len(redirect.Fragment) > 0 {
+			redirect.Fragment = mergeValues(redirect.Fragment, url.Values{"iss": {iss}})
+		} else {
+			redirect.Query = mergeValues(redirect.Query, url.Values{"iss": {iss}})
+		}
+	}
 
-	u := redir.String()
-	u = plusMatch.ReplaceAllString(u, "%20")
+	switch redirect.ResponseMode {
+	case ResponseModeQuery:
+		redirect.Query, redirect.Fragment = redirect.Parameters(), url.Values{}
+	case ResponseModeFragment:
+		redirect.Query, redirect.Fragment = url.Values{}, redirect.Parameters()
+	}
+	return redirect
+}
 
-	// https://tools.ietf.org/html/rfc6749#section-4.1.1
-	// When a decision is established, the authorization server directs the
-	// user-agent to the provided client redirection URI using an HTTP
-	// redirection response, or by other means available to it via the
-	// user-agent.
-	wh.Set("Location", u)
-	rw.WriteHeader(http.StatusFound)
+// authorizationResponseIssuer returns the value of the "iss" parameter of authorize responses, which is the issuer of
+// the tenant of ctx or of IssuerConfig, or an empty string if it is not enabled or the tenant is unknown, see
+// https://tools.ietf.org/html/rfc9207#section-2
+func (f *Fosite) authorizationResponseIssuer(ctx context.Context) string {
+	if !f.AuthorizationResponseIssParameter || isUnknownTenant(ctx) {
+		return ""
+	} else if tenant := TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+		return tenant.Issuer
+	} else if f.IssuerConfig == nil {
+		return ""
+	}
+	return f.IssuerConfig.Issuer
 }
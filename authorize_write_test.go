@@ -22,7 +22,10 @@
 package fosite_test
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -30,6 +33,7 @@ import (
 	. "github.com/ory/fosite"
 	. "github.com/ory/fosite/internal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteAuthorizeResponse(t *testing.T) {
@@ -49,6 +53,7 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 			setup: func() {
 				redir, _ := url.Parse("https://foobar.com/?foo=bar")
 				ar.EXPECT().GetRedirectURI().Return(redir)
+				ar.EXPECT().GetResponseMode().Return(ResponseModeDefault)
 				resp.EXPECT().GetFragment().Return(url.Values{})
 				resp.EXPECT().GetHeader().Return(http.Header{})
 				resp.EXPECT().GetQuery().Return(url.Values{})
@@ -66,6 +71,7 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 			setup: func() {
 				redir, _ := url.Parse("https://foobar.com/?foo=bar")
 				ar.EXPECT().GetRedirectURI().Return(redir)
+				ar.EXPECT().GetResponseMode().Return(ResponseModeDefault)
 				resp.EXPECT().GetFragment().Return(url.Values{"bar": {"baz"}})
 				resp.EXPECT().GetHeader().Return(http.Header{})
 				resp.EXPECT().GetQuery().Return(url.Values{})
@@ -83,6 +89,7 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 			setup: func() {
 				redir, _ := url.Parse("https://foobar.com/?foo=bar")
 				ar.EXPECT().GetRedirectURI().Return(redir)
+				ar.EXPECT().GetResponseMode().Return(ResponseModeDefault)
 				resp.EXPECT().GetFragment().Return(url.Values{"bar": {"baz"}})
 				resp.EXPECT().GetHeader().Return(http.Header{})
 				resp.EXPECT().GetQuery().Return(url.Values{"bar": {"baz"}})
@@ -100,6 +107,7 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 			setup: func() {
 				redir, _ := url.Parse("https://foobar.com/?foo=bar")
 				ar.EXPECT().GetRedirectURI().Return(redir)
+				ar.EXPECT().GetResponseMode().Return(ResponseModeDefault)
 				resp.EXPECT().GetFragment().Return(url.Values{"bar": {"baz"}, "scope": {"a b"}})
 				resp.EXPECT().GetHeader().Return(http.Header{"X-Bar": {"baz"}})
 				resp.EXPECT().GetQuery().Return(url.Values{"bar": {"b+az"}, "scope": {"a b"}})
@@ -114,6 +122,25 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 				}, header)
 			},
 		},
+		{
+			setup: func() {
+				redir, _ := url.Parse("https://foobar.com/?foo=bar&state=registered")
+				ar.EXPECT().GetRedirectURI().Return(redir)
+				ar.EXPECT().GetResponseMode().Return(ResponseModeDefault)
+				resp.EXPECT().GetFragment().Return(url.Values{})
+				resp.EXPECT().GetHeader().Return(http.Header{})
+				resp.EXPECT().GetQuery().Return(url.Values{"code": {"abc"}, "state": {"xyz"}, "foo": {"baz"}})
+
+				rw.EXPECT().Header().Return(header)
+				rw.EXPECT().WriteHeader(http.StatusFound)
+			},
+			expect: func() {
+				// The parameters of the registered redirect URI are never overwritten.
+				assert.Equal(t, http.Header{
+					"Location": {"https://foobar.com/?code=abc&foo=bar&foo=baz&state=registered&state=xyz"},
+				}, header)
+			},
+		},
 	} {
 		t.Logf("Starting test case %d", k)
 		c.setup()
@@ -123,3 +150,124 @@ func TestWriteAuthorizeResponse(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestWriteAuthorizeResponse_ResponseMode(t *testing.T) {
+	newResponse := func() *AuthorizeResponse {
+		resp := NewAuthorizeResponse()
+		resp.AddQuery("code", "abc")
+		resp.AddFragment("id_token", "xyz")
+		resp.AddHeader("X-Foo", "a")
+		resp.AddHeader("X-Foo", "b")
+		return resp
+	}
+
+	for k, c := range []struct {
+		mode   string
+		expect string
+	}{
+		{mode: ResponseModeDefault, expect: "https://foobar.com/?code=abc&foo=bar#id_token=xyz"},
+		{mode: ResponseModeQuery, expect: "https://foobar.com/?code=abc&foo=bar&id_token=xyz"},
+		{mode: ResponseModeFragment, expect: "https://foobar.com/?foo=bar#code=abc&id_token=xyz"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			ar := NewAuthorizeRequest()
+			ar.RedirectURI, _ = url.Parse("https://foobar.com/?foo=bar")
+			ar.ResponseMode = c.mode
+
+			rec := httptest.NewRecorder()
+			(&Fosite{}).WriteAuthorizeResponse(rec, ar, newResponse())
+			assert.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, c.expect, rec.Header().Get("Location"))
+			assert.Equal(t, []string{"a", "b"}, rec.Header()["X-Foo"])
+		})
+	}
+
+	t.Run("mode=form_post", func(t *testing.T) {
+		ar := NewAuthorizeRequest()
+		ar.RedirectURI, _ = url.Parse("https://foobar.com/cb")
+		ar.ResponseMode = ResponseModeFormPost
+
+		rec := httptest.NewRecorder()
+		(&Fosite{}).WriteAuthorizeResponse(rec, ar, newResponse())
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+		assert.Equal(t, []string{"a", "b"}, rec.Header()["X-Foo"])
+		assert.Contains(t, rec.Body.String(), `action="https://foobar.com/cb"`)
+		assert.Contains(t, rec.Body.String(), `name="code" value="abc"`)
+		assert.Contains(t, rec.Body.String(), `name="id_token" value="xyz"`)
+	})
+}
+
+func TestAuthorizeResponseIssParameter(t *testing.T) {
+	f := &Fosite{IssuerConfig: &IssuerConfig{Issuer: "https://op.example.com"}, AuthorizationResponseIssParameter: true}
+	newRequest := func(responseTypes ...string) *AuthorizeRequest {
+		ar := NewAuthorizeRequest()
+		ar.RedirectURI, _ = url.Parse("https://foobar.com/cb")
+		ar.Client = &DefaultClient{RedirectURIs: []string{"https://foobar.com/cb"}}
+		ar.ResponseTypes = responseTypes
+		return ar
+	}
+
+	resp := NewAuthorizeResponse()
+	resp.AddQuery("code", "foo")
+	redirect := f.NewAuthorizeRedirect(context.Background(), newRequest("code"), resp)
+	assert.Equal(t, "https://op.example.com", redirect.Query.Get("iss"))
+	assert.Empty(t, redirect.Fragment)
+	assert.Empty(t, resp.GetQuery().Get("iss"), "the response of the handlers is left untouched")
+
+	resp = NewAuthorizeResponse()
+	resp.AddFragment("access_token", "foo")
+	redirect = f.NewAuthorizeRedirect(context.Background(), newRequest("token"), resp)
+	assert.Equal(t, "https://op.example.com", redirect.Fragment.Get("iss"))
+	assert.Empty(t, redirect.Query)
+
+	ar := newRequest("token")
+	ar.ResponseMode = ResponseModeFormPost
+	redirect = f.NewAuthorizeRedirect(context.Background(), ar, resp)
+	assert.Equal(t, "https://op.example.com", redirect.Parameters().Get("iss"))
+
+	redirect, _ = f.NewAuthorizeErrorRedirect(context.Background(), newRequest("code"), ErrAccessDenied)
+	assert.Equal(t, "https://op.example.com", redirect.Query.Get("iss"))
+	redirect, _ = f.NewAuthorizeErrorRedirect(context.Background(), newRequest("token"), ErrAccessDenied)
+	assert.Equal(t, "https://op.example.com", redirect.Fragment.Get("iss"))
+
+	ctx := ContextWithTenant(context.Background(), &Tenant{ID: "acme", Issuer: "https://acme.example.com"})
+	redirect = f.NewAuthorizeRedirect(ctx, newRequest("code"), resp)
+	assert.Equal(t, "https://acme.example.com", redirect.Parameters().Get("iss"), "the issuer of the tenant is preferred")
+	redirect, _ = f.NewAuthorizeErrorRedirect(ctx, newRequest("code"), ErrAccessDenied)
+	assert.Equal(t, "https://acme.example.com", redirect.Query.Get("iss"))
+
+	// The writers use the issuer of the tenant of the request, and leave it out if the tenant is unknown.
+	f.TenantResolver = HostTenantResolver{}
+	resp = NewAuthorizeResponse()
+	resp.AddQuery("code", "foo")
+	location := func(write func(rw http.ResponseWriter)) url.Values {
+		rw := httptest.NewRecorder()
+		write(rw)
+		u, err := url.Parse(rw.Header().Get("Location"))
+		require.NoError(t, err)
+		return u.Query()
+	}
+	assert.Equal(t, "https://acme.example.com", location(func(rw http.ResponseWriter) {
+		f.WriteAuthorizeResponseWithContext(ctx, rw, newRequest("code"), resp)
+	}).Get("iss"))
+	assert.Equal(t, "https://acme.example.com", location(func(rw http.ResponseWriter) {
+		f.WriteAuthorizeErrorWithContext(ctx, rw, newRequest("code"), ErrAccessDenied)
+	}).Get("iss"))
+	assert.Empty(t, location(func(rw http.ResponseWriter) {
+		f.WriteAuthorizeResponse(rw, newRequest("code"), resp)
+	}).Get("iss"))
+	assert.Empty(t, location(func(rw http.ResponseWriter) {
+		f.WriteAuthorizeError(rw, newRequest("code"), ErrAccessDenied)
+	}).Get("iss"))
+	assert.NoError(t, f.CheckMetadataWithContext(ctx, &Metadata{Issuer: "https://acme.example.com", AuthorizationResponseIssParameterSupported: true}))
+	assert.Error(t, f.CheckMetadataWithContext(ctx, &Metadata{Issuer: "https://op.example.com"}))
+	f.TenantResolver = nil
+
+	f.AuthorizationResponseIssParameter = false
+	redirect = f.NewAuthorizeRedirect(context.Background(), newRequest("code"), resp)
+	assert.Empty(t, redirect.Parameters().Get("iss"))
+	redirect, _ = f.NewAuthorizeErrorRedirect(context.Background(), newRequest("code"), ErrAccessDenied)
+	assert.Empty(t, redirect.Query.Get("iss"))
+	assert.Error(t, f.CheckMetadata(&Metadata{AuthorizationResponseIssParameterSupported: true}))
+}
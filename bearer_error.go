@@ -0,0 +1,91 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WriteBearerError responds to a request made to a resource server whose bearer token could not be validated, for
+// example because ValidateToken failed, as defined in https://tools.ietf.org/html/rfc6750#section-3
+//
+// If the request lacks any authentication information, the resource server answers with HTTP 401 and
+// no error code. Otherwise:
+// * invalid_request is answered with HTTP 400 (Bad Request),
+// * invalid_token is answered with HTTP 401 (Unauthorized),
+// * insufficient_scope is answered with HTTP 403 (Forbidden) and the scopes are included in the challenge,
+// * insufficient_user_authentication is answered with HTTP 401 (Unauthorized), see NewStepUpError.
+//
+// The scopes are the ones required to access the protected resource. The acr_values and max_age required to access it
+// are included in the challenge of errors created by NewStepUpError.
+func (f *Fosite) WriteBearerError(rw http.ResponseWriter, err error, scopes ...string) {
+	rfcerr := *ErrorToRFC6749Error(err)
+
+	switch {
+	case rfcerr.Unwrap() == ErrMissingBearerToken:
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	case rfcerr.Name == errServerErrorName, rfcerr.Name == errTemporarilyUnavailableName, rfcerr.Name == errUnknownErrorName:
+		f.writeJsonError(rw, &rfcerr)
+		return
+	case rfcerr.Name == errInvalidRequestName:
+		rfcerr.Code = http.StatusBadRequest
+	case rfcerr.Name == errScopeNotGrantedName || rfcerr.Name == errInvalidScopeName:
+		rfcerr.Name = "insufficient_scope"
+		rfcerr.Code = http.StatusForbidden
+	case rfcerr.Name == errInsufficientUserAuthName:
+		rfcerr.Code = http.StatusUnauthorized
+	default:
+		rfcerr.Name = "invalid_token"
+		rfcerr.Code = http.StatusUnauthorized
+	}
+
+	challenge := fmt.Sprintf(`Bearer error="%s", error_description="%s"`, rfcerr.Name, bearerChallengeValue(rfcerr.Description))
+	if rfcerr.Name == "insufficient_scope" && len(scopes) > 0 {
+		challenge += fmt.Sprintf(`, scope="%s"`, bearerChallengeValue(strings.Join(scopes, " ")))
+	}
+	if stepUp, ok := rfcerr.Unwrap().(*StepUpChallenge); ok && rfcerr.Name == errInsufficientUserAuthName {
+		if len(stepUp.ACRValues) > 0 {
+			challenge += fmt.Sprintf(`, acr_values="%s"`, bearerChallengeValue(strings.Join(stepUp.ACRValues, " ")))
+		}
+		if stepUp.MaxAge >= 0 {
+			challenge += fmt.Sprintf(`, max_age="%d"`, stepUp.MaxAge)
+		}
+	}
+
+	rw.Header().Set("WWW-Authenticate", challenge)
+	f.writeJsonError(rw, &rfcerr)
+}
+
+// bearerChallengeValue removes the characters which are not allowed in error_description and scope values, see
+// https://tools.ietf.org/html/rfc6750#section-3
+func bearerChallengeValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+func TestWriteBearerError(t *testing.T) {
+	f := new(Fosite)
+	noToken, _ := http.NewRequest("GET", "http://example.com/test", nil)
+	_, missing := f.ValidateToken(nil, noToken, nil)
+
+	for k, c := range []struct {
+		err       error
+		scopes    []string
+		code      int
+		challenge string
+	}{
+		{
+			err:       missing,
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer`,
+		},
+		{
+			err:       errors.WithStack(ErrRequestUnauthorized),
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer error="invalid_token", error_description="The request could not be authorized"`,
+		},
+		{
+			err:       errors.WithStack(ErrTokenExpired),
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer error="invalid_token", error_description="Token expired"`,
+		},
+		{
+			err:       errors.WithStack(ErrInvalidRequest),
+			code:      http.StatusBadRequest,
+			challenge: `Bearer error="invalid_request", error_description="The request is missing a required parameter, includes an invalid parameter value, includes a parameter more than once, or is otherwise malformed"`,
+		},
+		{
+			err:       errors.WithStack(ErrScopeNotGranted),
+			scopes:    []string{"foo", "bar"},
+			code:      http.StatusForbidden,
+			challenge: `Bearer error="insufficient_scope", error_description="The token was not granted the requested scope", scope="foo bar"`,
+		},
+		{
+			err:       NewStepUpError([]string{"urn:example:mfa", "phr"}, 300),
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer error="insufficient_user_authentication", error_description="The authentication event associated with the access token presented with the request does not meet the authentication requirements of the protected resource", acr_values="urn:example:mfa phr", max_age="300"`,
+		},
+		{
+			err:       NewStepUpError(nil, -1),
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer error="insufficient_user_authentication", error_description="The authentication event associated with the access token presented with the request does not meet the authentication requirements of the protected resource"`,
+		},
+		{
+			err:  errors.New("database down"),
+			code: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			f.WriteBearerError(rw, c.err, c.scopes...)
+			assert.Equal(t, c.code, rw.Code)
+			assert.Equal(t, c.challenge, rw.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
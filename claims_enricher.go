@@ -0,0 +1,68 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ClaimsEnricher adds custom claims, for example the tenant, roles or entitlements of the subject, to JSON Web
+// Tokens right before they are signed. Unlike claims set on the session, enriched claims are computed for every
+// token and are not stored.
+type ClaimsEnricher interface {
+	// EnrichClaims returns the claims to add to the token of tokenType issued for requester, based for example on
+	// its session and granted scopes. Registered claims, see ProtectedClaims, must not be returned.
+	EnrichClaims(ctx context.Context, tokenType TokenType, requester Requester) (map[string]interface{}, error)
+}
+
+// ClaimsEnricherFunc adapts a function to the ClaimsEnricher interface.
+type ClaimsEnricherFunc func(ctx context.Context, tokenType TokenType, requester Requester) (map[string]interface{}, error)
+
+func (f ClaimsEnricherFunc) EnrichClaims(ctx context.Context, tokenType TokenType, requester Requester) (map[string]interface{}, error) {
+	return f(ctx, tokenType, requester)
+}
+
+// ProtectedClaims are the claims a ClaimsEnricher must not set, because fosite or the validation of the token
+// relies on them.
+var ProtectedClaims = []string{"iss", "sub", "aud", "exp", "iat", "nbf", "jti", "scp", "nonce", "at_hash", "c_hash", "s_hash", "auth_time", "azp"}
+
+// EnrichClaims adds the claims of enricher to claims. It does nothing if enricher is nil.
+func EnrichClaims(ctx context.Context, enricher ClaimsEnricher, tokenType TokenType, requester Requester, claims map[string]interface{}) error {
+	if enricher == nil {
+		return nil
+	}
+
+	extra, err := enricher.EnrichClaims(ctx, tokenType, requester)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range extra {
+		if StringInSlice(k, ProtectedClaims) {
+			return errors.WithStack(ErrServerError.WithDebugf("The claims enricher must not set the registered claim \"%s\".", k))
+		}
+		claims[k] = v
+	}
+	return nil
+}
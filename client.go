@@ -22,6 +22,8 @@
 package fosite
 
 import (
+	"time"
+
 	"gopkg.in/square/go-jose.v2"
 )
 
@@ -76,6 +78,87 @@ type OpenIDConnectClient interface {
 	GetTokenEndpointAuthSigningAlgorithm() string
 }
 
+// ClientWithCustomTokenLifespans is a Client that overrides the globally configured token lifespans.
+type ClientWithCustomTokenLifespans interface {
+	// GetTokenLifespan returns the lifespan of a token of type tokenType issued through grant type grantType. A
+	// return value of zero means that the globally configured lifespan applies.
+	GetTokenLifespan(grantType string, tokenType TokenType) time.Duration
+}
+
+// GetEffectiveLifespan returns the lifespan of a token of type tokenType issued to client c through grant type grantType.
+// If the client does not implement ClientWithCustomTokenLifespans or does not override the lifespan, fallback is returned.
+func GetEffectiveLifespan(c Client, grantType string, tokenType TokenType, fallback time.Duration) time.Duration {
+	if clc, ok := c.(ClientWithCustomTokenLifespans); ok {
+		if lifespan := clc.GetTokenLifespan(grantType, tokenType); lifespan != 0 {
+			return lifespan
+		}
+	}
+	return fallback
+}
+
+// DeactivatableClient is a Client which can be deactivated (soft-deleted). A deactivated client may no longer
+// authorize or obtain new tokens, but tokens issued to it stay valid until its grace period is over.
+type DeactivatableClient interface {
+	// GetDeactivatedAt returns the time the client was deactivated at, or the zero time if the client is active.
+	GetDeactivatedAt() time.Time
+
+	// GetDeactivationGracePeriod returns how long tokens issued to the client stay valid after its deactivation.
+	GetDeactivationGracePeriod() time.Duration
+}
+
+// DisableableClient is a Client which can be disabled, for example because it was compromised. Unlike a deactivated
+// client, a disabled client is shut off at once: it may no longer authorize or obtain tokens, and the tokens issued to
+// it are rejected regardless of any grace period.
+type DisableableClient interface {
+	// IsDisabled returns true if the client is disabled.
+	IsDisabled() bool
+}
+
+// IsClientDisabled returns true if the client was disabled.
+func IsClientDisabled(c Client) bool {
+	if dc, ok := c.(DisableableClient); ok {
+		return dc.IsDisabled()
+	}
+	return false
+}
+
+// IsClientActive returns false if the client was deactivated or disabled.
+func IsClientActive(c Client) bool {
+	if IsClientDisabled(c) {
+		return false
+	} else if dc, ok := c.(DeactivatableClient); ok {
+		return dc.GetDeactivatedAt().IsZero()
+	}
+	return true
+}
+
+// IsClientTokenValid returns false if the client was disabled, or if it was deactivated and its grace period is over
+// at the given time.
+func IsClientTokenValid(c Client, now time.Time) bool {
+	if IsClientDisabled(c) {
+		return false
+	} else if dc, ok := c.(DeactivatableClient); ok && !dc.GetDeactivatedAt().IsZero() {
+		return now.Before(dc.GetDeactivatedAt().Add(dc.GetDeactivationGracePeriod()))
+	}
+	return true
+}
+
+// DeactivatedClient wraps a client which was deactivated. Client managers may return it from GetClient until the
+// client is restored.
+type DeactivatedClient struct {
+	Client
+	DeactivatedAt time.Time     `json:"deactivated_at"`
+	GracePeriod   time.Duration `json:"grace_period"`
+}
+
+func (c *DeactivatedClient) GetDeactivatedAt() time.Time {
+	return c.DeactivatedAt
+}
+
+func (c *DeactivatedClient) GetDeactivationGracePeriod() time.Duration {
+	return c.GracePeriod
+}
+
 // DefaultClient is a simple default implementation of the Client interface.
 type DefaultClient struct {
 	ID            string   `json:"id"`
@@ -85,6 +168,7 @@ type DefaultClient struct {
 	ResponseTypes []string `json:"response_types"`
 	Scopes        []string `json:"scopes"`
 	Public        bool     `json:"public"`
+	Disabled      bool     `json:"disabled,omitempty"`
 }
 
 type DefaultOpenIDConnectClient struct {
@@ -96,6 +180,16 @@ type DefaultOpenIDConnectClient struct {
 	RequestObjectSigningAlgorithm string              `json:"request_object_signing_alg"`
 }
 
+// DefaultClientWithCustomTokenLifespans is a DefaultClient which overrides token lifespans, keyed by grant type and token type.
+type DefaultClientWithCustomTokenLifespans struct {
+	*DefaultClient
+	TokenLifespans map[string]map[TokenType]time.Duration `json:"token_lifespans"`
+}
+
+func (c *DefaultClientWithCustomTokenLifespans) GetTokenLifespan(grantType string, tokenType TokenType) time.Duration {
+	return c.TokenLifespans[grantType][tokenType]
+}
+
 func (c *DefaultClient) GetID() string {
 	return c.ID
 }
@@ -104,6 +198,10 @@ func (c *DefaultClient) IsPublic() bool {
 	return c.Public
 }
 
+func (c *DefaultClient) IsDisabled() bool {
+	return c.Disabled
+}
+
 func (c *DefaultClient) GetRedirectURIs() []string {
 	return c.RedirectURIs
 }
@@ -163,3 +261,31 @@ func (c *DefaultOpenIDConnectClient) GetTokenEndpointAuthMethod() string {
 func (c *DefaultOpenIDConnectClient) GetRequestURIs() []string {
 	return c.RequestURIs
 }
+
+func (c *DeactivatedClient) IsDisabled() bool {
+	return IsClientDisabled(c.Client)
+}
+
+// DisabledClient wraps a client which was disabled. Client managers may return it from GetClient until the client is
+// enabled again.
+type DisabledClient struct {
+	Client
+}
+
+func (c *DisabledClient) IsDisabled() bool {
+	return true
+}
+
+func (c *DisabledClient) GetDeactivatedAt() time.Time {
+	if dc, ok := c.Client.(DeactivatableClient); ok {
+		return dc.GetDeactivatedAt()
+	}
+	return time.Time{}
+}
+
+func (c *DisabledClient) GetDeactivationGracePeriod() time.Duration {
+	if dc, ok := c.Client.(DeactivatableClient); ok {
+		return dc.GetDeactivationGracePeriod()
+	}
+	return 0
+}
@@ -61,8 +61,34 @@ func (f *Fosite) findClientPublicJWK(oidcClient OpenIDConnectClient, t *jwt.Toke
 	return nil, errors.WithStack(ErrInvalidClient.WithHint("The OAuth 2.0 Client has no JSON Web Keys set registered, but they are needed to complete the request."))
 }
 
-func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form url.Values) (Client, error) {
+// AuthenticateClient authenticates the client of the request. Failures are reported to the AuditLogger. If a
+// ThrottlingStrategy is set, it is consulted first and told about the outcome.
+func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form url.Values) (_ Client, err error) {
+	defer func() {
+		if err != nil {
+			f.auditAuthenticationFailure(ctx, r, form, err)
+		}
+	}()
+
+	if f.ThrottlingStrategy != nil {
+		key := ThrottlingKey{Kind: ThrottleClientAuthentication, ClientID: presentedClientID(r, form), RemoteIP: RemoteIP(r)}
+		if err := f.ThrottlingStrategy.Allow(ctx, key); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err == nil {
+				f.ThrottlingStrategy.Record(ctx, key, true)
+			} else if ErrorToRFC6749Error(err).Name == errInvalidClientName {
+				f.ThrottlingStrategy.Record(ctx, key, false)
+			}
+		}()
+	}
+
 	if assertionType := form.Get("client_assertion_type"); assertionType == clientAssertionJWTBearerType {
+		if _, _, ok := r.BasicAuth(); ok {
+			return nil, errors.WithStack(ErrInvalidRequest.WithHint("Client credentials were presented in both the HTTP Authorization header and a client_assertion, but the client must not use more than one authentication method."))
+		}
+
 		assertion := form.Get("client_assertion")
 		if len(assertion) == 0 {
 			return nil, errors.WithStack(ErrInvalidRequest.WithHintf("The client_assertion request parameter must be set when using client_assertion_type of \"%s\".", clientAssertionJWTBearerType))
@@ -71,7 +97,13 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 		var clientID string
 		var client Client
 
-		token, err := jwt.ParseWithClaims(assertion, new(jwt.MapClaims), func(t *jwt.Token) (interface{}, error) {
+		// The time based claims are validated below, taking the configured clock skew into account.
+		parser := &jwt.Parser{SkipClaimsValidation: true}
+		token, err := parser.ParseWithClaims(assertion, new(jwt.MapClaims), func(t *jwt.Token) (interface{}, error) {
+			if err := f.clientAssertionPolicy().CheckHeader(t.Header); err != nil {
+				return nil, errors.WithStack(ErrInvalidClient.WithHint(err.Error()))
+			}
+
 			var err error
 			clientID, _, err = clientCredentialsFromRequestBody(form, false)
 			if err != nil {
@@ -88,9 +120,11 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 				}
 			}
 
-			client, err = f.Store.GetClient(ctx, clientID)
+			client, err = f.getClient(ctx, clientID)
 			if err != nil {
 				return nil, errors.WithStack(ErrInvalidClient.WithDebug(err.Error()))
+			} else if !IsClientActive(client) {
+				return nil, errors.WithStack(ErrInvalidClient.WithHint("The OAuth 2.0 Client has been deactivated."))
 			}
 
 			oidcClient, ok := client.(OpenIDConnectClient)
@@ -133,40 +167,49 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 				return nil, errors.WithStack(ErrInvalidClient.WithHint("Unable to verify the integrity of the \"client_assertion\" value.").WithDebug(err.Error()))
 			}
 			return nil, err
-		} else if err := token.Claims.Valid(); err != nil {
-			return nil, errors.WithStack(ErrInvalidClient.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithDebug(err.Error()))
 		}
 
 		claims, ok := token.Claims.(*jwt.MapClaims)
 		if !ok {
 			return nil, errors.WithStack(ErrInvalidClient.WithHint("Unable to type assert claims from request parameter \"client_assertion\".").WithDebugf(`Got claims of type %T but expected type "*jwt.MapClaims".`, token.Claims))
+		} else if err := f.validateTimeClaims(*claims); err != nil {
+			return nil, errors.WithStack(ErrInvalidClient.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithDebug(err.Error()))
 		}
 
 		if !claims.VerifyIssuer(clientID, true) {
 			return nil, errors.WithStack(ErrInvalidClient.WithHint("Claim \"iss\" from \"client_assertion\" must match the \"client_id\" of the OAuth 2.0 Client."))
-		} else if f.TokenURL == "" {
+		} else if f.tokenURL(ctx) == "" {
 			return nil, errors.WithStack(ErrMisconfiguration.WithHint("The authorization server's token endpoint URL has not been set."))
 		} else if sub, ok := (*claims)["sub"].(string); !ok || sub != clientID {
 			return nil, errors.WithStack(ErrInvalidClient.WithHint("Claim \"sub\" from \"client_assertion\" must match the \"client_id\" of the OAuth 2.0 Client."))
-		} else if jti, ok := (*claims)["jti"].(string); !ok || len(jti) == 0 {
+		}
+
+		jti, ok := (*claims)["jti"].(string)
+		if !ok || len(jti) == 0 {
 			return nil, errors.WithStack(ErrInvalidClient.WithHint("Claim \"jti\" from \"client_assertion\" must be set but is not."))
 		}
 
 		if auds, ok := (*claims)["aud"].([]interface{}); !ok {
-			if !claims.VerifyAudience(f.TokenURL, true) {
-				return nil, errors.WithStack(ErrInvalidClient.WithHintf("Claim \"audience\" from \"client_assertion\" must match the authorization server's token endpoint \"%s\".", f.TokenURL))
+			if !claims.VerifyAudience(f.tokenURL(ctx), true) {
+				return nil, errors.WithStack(ErrInvalidClient.WithHintf("Claim \"audience\" from \"client_assertion\" must match the authorization server's token endpoint \"%s\".", f.tokenURL(ctx)))
 			}
 		} else {
 			var found bool
 			for _, aud := range auds {
-				if a, ok := aud.(string); ok && a == f.TokenURL {
+				if a, ok := aud.(string); ok && a == f.tokenURL(ctx) {
 					found = true
 					break
 				}
 			}
 
 			if !found {
-				return nil, errors.WithStack(ErrInvalidClient.WithHintf("Claim \"audience\" from \"client_assertion\" must match the authorization server's token endpoint \"%s\".", f.TokenURL))
+				return nil, errors.WithStack(ErrInvalidClient.WithHintf("Claim \"audience\" from \"client_assertion\" must match the authorization server's token endpoint \"%s\".", f.tokenURL(ctx)))
+			}
+		}
+
+		if f.JTIStore != nil {
+			if err := f.useClientAssertionJTI(ctx, clientID, jti, *claims); err != nil {
+				return nil, err
 			}
 		}
 
@@ -180,9 +223,11 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 		return nil, err
 	}
 
-	client, err := f.Store.GetClient(ctx, clientID)
+	client, err := f.getClient(ctx, clientID)
 	if err != nil {
 		return nil, errors.WithStack(ErrInvalidClient.WithDebug(err.Error()))
+	} else if !IsClientActive(client) {
+		return nil, errors.WithStack(ErrInvalidClient.WithHint("The OAuth 2.0 Client has been deactivated."))
 	}
 
 	if oidcClient, ok := client.(OpenIDConnectClient); !ok {
@@ -200,13 +245,37 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 	}
 
 	// Enforce client authentication
-	if err := f.Hasher.Compare(client.GetHashedSecret(), []byte(clientSecret)); err != nil {
+	if err := f.compareClientSecret(ctx, client, []byte(clientSecret)); err != nil {
 		return nil, errors.WithStack(ErrInvalidClient.WithDebug(err.Error()))
 	}
 
 	return client, nil
 }
 
+// compareClientSecret compares the secret presented by the client with the hashed one, or any of its currently valid
+// secrets if the client implements ClientWithRotatedSecrets.
+func (f *Fosite) compareClientSecret(ctx context.Context, client Client, secret []byte) (err error) {
+	_, span := f.startSpan(ctx, "fosite.Hasher.Compare")
+	defer func() { span.End(err) }()
+	if rotated, ok := client.(ClientWithRotatedSecrets); ok {
+		return f.compareRotatedClientSecrets(client, rotated, secret)
+	}
+	return f.Hasher.Compare(client.GetHashedSecret(), secret)
+}
+
+// validateTimeClaims validates the "exp", "nbf" and "iat" claims, tolerating a clock skew of f.ClockSkew.
+func (f *Fosite) validateTimeClaims(claims jwt.MapClaims) error {
+	now := Now(f.Clock)
+	if !claims.VerifyExpiresAt(now.Add(-f.ClockSkew).Unix(), false) {
+		return errors.New("Token is expired")
+	} else if !claims.VerifyNotBefore(now.Add(f.ClockSkew).Unix(), false) {
+		return errors.New("Token is not valid yet")
+	} else if !claims.VerifyIssuedAt(now.Add(f.ClockSkew).Unix(), false) {
+		return errors.New("Token used before issued")
+	}
+	return nil
+}
+
 func findPublicKey(t *jwt.Token, set *jose.JSONWebKeySet) (*rsa.PublicKey, error) {
 	kid, ok := t.Header["kid"].(string)
 	if !ok {
@@ -230,8 +299,15 @@ func findPublicKey(t *jwt.Token, set *jose.JSONWebKeySet) (*rsa.PublicKey, error
 	return nil, errors.WithStack(ErrInvalidRequest.WithHintf("Unable to find RSA public key with use=\"sig\" for kid \"%s\" in JSON Web Key Set.", kid))
 }
 
+// clientCredentialsFromRequest implements https://tools.ietf.org/html/rfc6749#section-2.3.1
+// Credentials in the HTTP Authorization header take precedence. They are encoded using the
+// "application/x-www-form-urlencoded" encoding algorithm before being used as the user-id and password of the HTTP
+// Basic authentication scheme, see https://tools.ietf.org/html/rfc6749#appendix-B . A client secret in the request
+// body is rejected if the header is present, because "the client MUST NOT use more than one authentication method
+// in each request".
 func clientCredentialsFromRequest(r *http.Request, form url.Values) (clientID, clientSecret string, err error) {
-	if id, secret, ok := r.BasicAuth(); !ok {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
 		return clientCredentialsFromRequestBody(form, true)
 	} else if clientID, err = url.QueryUnescape(id); err != nil {
 		return "", "", errors.WithStack(ErrInvalidRequest.WithHint(`The client id in the HTTP authorization header could not be decoded from "application/x-www-form-urlencoded".`).WithDebug(err.Error()))
@@ -239,9 +315,17 @@ func clientCredentialsFromRequest(r *http.Request, form url.Values) (clientID, c
 		return "", "", errors.WithStack(ErrInvalidRequest.WithHint(`The client secret in the HTTP authorization header could not be decoded from "application/x-www-form-urlencoded".`).WithDebug(err.Error()))
 	}
 
+	if form.Get("client_secret") != "" {
+		return "", "", errors.WithStack(ErrInvalidRequest.WithHint("Client credentials were presented in both the HTTP Authorization header and the HTTP POST body, but the client must not use more than one authentication method."))
+	} else if bodyID := form.Get("client_id"); bodyID != "" && bodyID != clientID {
+		return "", "", errors.WithStack(ErrInvalidRequest.WithHint("The client_id in the HTTP POST body does not match the client id in the HTTP Authorization header."))
+	}
+
 	return clientID, clientSecret, nil
 }
 
+// clientCredentialsFromRequestBody returns the client credentials from the request body. The form values have
+// already been decoded when the body was parsed and must not be decoded again.
 func clientCredentialsFromRequestBody(form url.Values, forceID bool) (clientID, clientSecret string, err error) {
 	clientID = form.Get("client_id")
 	clientSecret = form.Get("client_secret")
@@ -250,11 +334,5 @@ func clientCredentialsFromRequestBody(form url.Values, forceID bool) (clientID,
 		return "", "", errors.WithStack(ErrInvalidRequest.WithHint("Client credentials missing or malformed in both HTTP Authorization header and HTTP POST body."))
 	}
 
-	if clientID, err = url.QueryUnescape(clientID); err != nil {
-		return "", "", errors.WithStack(ErrInvalidRequest.WithHint(`The client id in the HTTP authorization header could not be decoded from "application/x-www-form-urlencoded".`).WithDebug(err.Error()))
-	} else if clientSecret, err = url.QueryUnescape(clientSecret); err != nil {
-		return "", "", errors.WithStack(ErrInvalidRequest.WithHint(`The client secret in the HTTP authorization header could not be decoded from "application/x-www-form-urlencoded".`).WithDebug(err.Error()))
-	}
-
 	return clientID, clientSecret, nil
 }
@@ -78,6 +78,10 @@ func TestAuthenticateClient(t *testing.T) {
 	barSecret, err := hasher.Hash([]byte("bar"))
 	require.NoError(t, err)
 
+	// complexSecret is encoded as "b+a%2Br%25%3A" in the HTTP authorization header
+	complexSecret, err := hasher.Hash([]byte("b a+r%:"))
+	require.NoError(t, err)
+
 	key := internal.MustRSAKey()
 	jwks := &jose.JSONWebKeySet{
 		Keys: []jose.JSONWebKey{
@@ -193,6 +197,52 @@ func TestAuthenticateClient(t *testing.T) {
 			r:         &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar"))}}},
 			expectErr: ErrInvalidClient,
 		},
+		{
+			d:      "should pass because client id and secret are decoded from application/x-www-form-urlencoded in header",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo:bar baz", Secret: complexSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:   url.Values{},
+			r:      &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape("foo:bar baz")+":"+url.QueryEscape("b a+r%:")))}}},
+		},
+		{
+			d:         "should fail because a plus sign in the header is decoded to a space",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: complexSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:      url.Values{},
+			r:         &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:b a+r%25%3A"))}}},
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:      "should pass because client secret in body is not decoded twice",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: complexSecret}, TokenEndpointAuthMethod: "client_secret_post"},
+			form:   url.Values{"client_id": []string{"foo"}, "client_secret": []string{"b a+r%:"}},
+			r:      new(http.Request),
+		},
+		{
+			d:         "should fail because client credentials are in both header and body",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:      url.Values{"client_id": []string{"foo"}, "client_secret": []string{"bar"}},
+			r:         &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar"))}}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:      "should pass because client id in body matches the one in header",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:   url.Values{"client_id": []string{"foo"}},
+			r:      &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar"))}}},
+		},
+		{
+			d:         "should fail because client id in body does not match the one in header",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:      url.Values{"client_id": []string{"bar"}},
+			r:         &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar"))}}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because client_assertion is used together with the header",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "private_key_jwt"},
+			form:      url.Values{"client_assertion": {"foo"}, "client_assertion_type": []string{at}},
+			r:         &http.Request{Header: http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar"))}}},
+			expectErr: ErrInvalidRequest,
+		},
 		{
 			d:         "should fail because client_assertion but client_assertion is missing",
 			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "private_key_jwt"},
@@ -383,3 +433,158 @@ func TestAuthenticateClient(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticateDeactivatedClient(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+	f := &Fosite{Store: store, Hasher: &BCrypt{WorkFactor: 6}}
+
+	_, err := f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}})
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeactivateClient(nil, "foo", time.Hour))
+	_, err = f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}})
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+
+	require.NoError(t, store.RestoreClient(nil, "foo"))
+	_, err = f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}})
+	require.NoError(t, err)
+
+	store.Clients["foo"].(*DefaultClient).Disabled = true
+	_, err = f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}})
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+}
+
+func TestAuthenticateClientJTIReplay(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	store := storage.NewMemoryStore()
+	store.Clients["bar"] = &DefaultOpenIDConnectClient{
+		DefaultClient:           &DefaultClient{ID: "bar"},
+		JSONWebKeys:             &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+	f := &Fosite{Store: store, JTIStore: store, TokenURL: "token-url"}
+
+	assertion := func(jti string, exp interface{}) url.Values {
+		claims := jwt.MapClaims{"sub": "bar", "iss": "bar", "jti": jti, "aud": "token-url"}
+		if exp != nil {
+			claims["exp"] = exp
+		}
+		return url.Values{"client_assertion": {mustGenerateAssertion(t, claims, key, "kid-foo")}, "client_assertion_type": {at}}
+	}
+
+	form := assertion("12345", time.Now().Add(time.Hour).Unix())
+	_, err := f.AuthenticateClient(nil, new(http.Request), form)
+	require.NoError(t, err)
+
+	_, err = f.AuthenticateClient(nil, new(http.Request), form)
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("67890", time.Now().Add(time.Hour).Unix()))
+	require.NoError(t, err)
+
+	// Without an expiry the jti could never be forgotten.
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("abcde", nil))
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+
+	// Neither could it with an expiry far in the future.
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("fghij", time.Now().Add(48*time.Hour).Unix()))
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+
+	// The store forgets the jti once the assertion expired, according to its clock.
+	store.Clock = ClockFunc(func() time.Time { return time.Now().Add(2 * time.Hour) })
+	require.NoError(t, store.UseJTI(nil, "bar", "12345", time.Now().Add(3*time.Hour)))
+	assert.EqualError(t, store.UseJTI(nil, "bar", "12345", time.Now().Add(3*time.Hour)), ErrJTIKnown.Error())
+}
+
+func TestAuthenticateClientAssertionType(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	store := storage.NewMemoryStore()
+	store.Clients["bar"] = &DefaultOpenIDConnectClient{
+		DefaultClient:           &DefaultClient{ID: "bar"},
+		JSONWebKeys:             &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+	f := &Fosite{Store: store, TokenURL: "token-url"}
+
+	assertion := func(typ string) url.Values {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "bar", "iss": "bar", "jti": typ, "aud": "token-url", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = "kid-foo"
+		token.Header["typ"] = typ
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return url.Values{"client_assertion": {signed}, "client_assertion_type": {at}}
+	}
+
+	_, err := f.AuthenticateClient(nil, new(http.Request), assertion("JWT"))
+	require.NoError(t, err)
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("client-authentication+jwt"))
+	require.NoError(t, err)
+
+	// A token issued for another purpose, such as a JWT access token, is not accepted as a client assertion.
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("at+jwt"))
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+
+	f.ClientAssertionPolicy = &JWTValidationPolicy{Types: []string{"JWT"}, Algorithms: []string{"PS256"}}
+	_, err = f.AuthenticateClient(nil, new(http.Request), assertion("JWT"))
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+}
+
+func TestAuthenticateClientWithRotatedSecrets(t *testing.T) {
+	hasher := &BCrypt{WorkFactor: 6}
+	hash := func(secret string) []byte {
+		h, err := hasher.Hash([]byte(secret))
+		require.NoError(t, err)
+		return h
+	}
+
+	now := time.Now().UTC()
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClientWithRotatedSecrets{
+		DefaultClient:   &DefaultClient{ID: "foo", Secret: hash("new")},
+		SecretExpiresAt: now.Add(time.Hour),
+		RotatedSecrets: []ClientSecret{
+			{Hash: hash("old"), ExpiresAt: now.Add(time.Minute)},
+			{Hash: hash("older"), ExpiresAt: now.Add(-time.Minute)},
+			{Hash: hash("oldest")},
+		},
+	}
+	f := &Fosite{Store: store, Hasher: hasher, Clock: ClockFunc(func() time.Time { return now })}
+
+	for secret, valid := range map[string]bool{"new": true, "old": true, "older": false, "oldest": true, "unknown": false} {
+		_, err := f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}, "client_secret": {secret}})
+		if valid {
+			assert.NoError(t, err, secret)
+		} else {
+			assert.EqualError(t, err, ErrInvalidClient.Error(), secret)
+		}
+	}
+
+	f.Clock = ClockFunc(func() time.Time { return now.Add(2 * time.Hour) })
+	_, err := f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}, "client_secret": {"new"}})
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+	assert.Contains(t, ErrorToRFC6749Error(err).Debug, "unexpired")
+
+	// Expired secrets are not compared at all.
+	counter := &countingHasher{Hasher: hasher}
+	f.Hasher = counter
+	store.Clients["foo"].(*DefaultClientWithRotatedSecrets).RotatedSecrets = []ClientSecret{{Hash: hash("old"), ExpiresAt: now.Add(time.Minute)}}
+	_, err = f.AuthenticateClient(nil, new(http.Request), url.Values{"client_id": {"foo"}, "client_secret": {"old"}})
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+	assert.Contains(t, ErrorToRFC6749Error(err).Debug, "All secrets of the client have expired")
+	assert.Zero(t, counter.compared)
+}
+
+type countingHasher struct {
+	Hasher
+	compared int
+}
+
+func (h *countingHasher) Compare(hash, data []byte) error {
+	h.compared++
+	return h.Hasher.Compare(hash, data)
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+type countingClientManager struct {
+	*storage.MemoryStore
+	lookups map[string]int
+}
+
+func (m *countingClientManager) GetClient(ctx context.Context, id string) (Client, error) {
+	m.lookups[id]++
+	return m.MemoryStore.GetClient(ctx, id)
+}
+
+func TestClientCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo"}
+	store.Clients["bar"] = &DefaultClient{ID: "bar"}
+	manager := &countingClientManager{MemoryStore: store, lookups: map[string]int{}}
+	cache := storage.NewClientCache(manager, 1, time.Minute)
+	cache.Clock = ClockFunc(func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		c, err := cache.GetClient(ctx, "foo")
+		require.NoError(t, err)
+		assert.Equal(t, "foo", c.GetID())
+	}
+	assert.Equal(t, 1, manager.lookups["foo"])
+
+	// Errors are not cached.
+	_, err := cache.GetClient(ctx, "baz")
+	assert.EqualError(t, err, ErrNotFound.Error())
+	_, err = cache.GetClient(ctx, "baz")
+	assert.EqualError(t, err, ErrNotFound.Error())
+	assert.Equal(t, 2, manager.lookups["baz"])
+
+	// The least recently used client is evicted once the cache is full.
+	_, err = cache.GetClient(ctx, "bar")
+	require.NoError(t, err)
+	_, err = cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, manager.lookups["foo"])
+
+	// Clients expire after the TTL.
+	now = now.Add(time.Minute)
+	_, err = cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 3, manager.lookups["foo"])
+
+	cache.Invalidate("foo")
+	_, err = cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 4, manager.lookups["foo"])
+
+	// Deactivating a client through the cache invalidates it.
+	require.NoError(t, cache.DeactivateClient(ctx, "foo", time.Hour))
+	c, err := cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.False(t, IsClientActive(c))
+
+	// So does disabling and enabling it.
+	require.NoError(t, cache.RestoreClient(ctx, "foo"))
+	require.NoError(t, cache.DisableClient(ctx, "foo"))
+	c, err = cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.True(t, IsClientDisabled(c))
+	require.NoError(t, cache.EnableClient(ctx, "foo"))
+	c, err = cache.GetClient(ctx, "foo")
+	require.NoError(t, err)
+	assert.True(t, IsClientActive(c))
+}
+
+func TestClientCache_Tenant(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo"}
+	store.TenantClients["acme"] = map[string]Client{"foo": &DefaultClient{ID: "foo"}}
+	cache := storage.NewClientCache(store, 10, time.Minute)
+
+	for _, tenantID := range []string{"acme", "other"} {
+		c, err := cache.GetTenantClient(ctx, tenantID, "foo")
+		require.NoError(t, err)
+		assert.True(t, IsClientActive(c), tenantID)
+	}
+
+	// Disabling a client through the cache invalidates it for every tenant.
+	require.NoError(t, cache.DisableClient(ctx, "foo"))
+	for _, tenantID := range []string{"acme", "other"} {
+		c, err := cache.GetTenantClient(ctx, tenantID, "foo")
+		require.NoError(t, err)
+		assert.True(t, IsClientDisabled(c), tenantID)
+	}
+
+	require.NoError(t, cache.EnableClient(ctx, "foo"))
+	require.NoError(t, cache.DeactivateClient(ctx, "foo", time.Hour))
+	for _, tenantID := range []string{"acme", "other"} {
+		c, err := cache.GetTenantClient(ctx, tenantID, "foo")
+		require.NoError(t, err)
+		assert.False(t, IsClientActive(c), tenantID)
+	}
+}
+
+func TestFositeClientManager(t *testing.T) {
+	store := storage.NewMemoryStore()
+	manager := &countingClientManager{MemoryStore: store, lookups: map[string]int{}}
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+
+	f := &Fosite{Store: store, ClientManager: storage.NewClientCache(manager, 10, time.Minute), Hasher: &BCrypt{WorkFactor: 6}}
+	for i := 0; i < 2; i++ {
+		_, err := f.AuthenticateClient(context.Background(), new(http.Request), url.Values{"client_id": {"foo"}})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, manager.lookups["foo"])
+}
@@ -21,7 +21,10 @@
 
 package fosite
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ClientManager defines the (persistent) manager interface for clients.
 type ClientManager interface {
@@ -29,3 +32,23 @@ type ClientManager interface {
 	// if the client does not exist or another error occurred.
 	GetClient(ctx context.Context, id string) (Client, error)
 }
+
+// ClientDeactivator is implemented by client managers which can deactivate (soft-delete) and restore clients.
+// GetClient must keep returning deactivated clients, with DeactivatableClient reporting their status.
+type ClientDeactivator interface {
+	// DeactivateClient deactivates the client. Tokens issued before the deactivation stay valid for gracePeriod.
+	DeactivateClient(ctx context.Context, id string, gracePeriod time.Duration) error
+
+	// RestoreClient reactivates a deactivated client.
+	RestoreClient(ctx context.Context, id string) error
+}
+
+// ClientDisabler is implemented by client managers which can disable and enable clients, see DisableableClient.
+// GetClient must keep returning disabled clients, with DisableableClient reporting their status.
+type ClientDisabler interface {
+	// DisableClient disables the client. The tokens issued to it are rejected at once.
+	DisableClient(ctx context.Context, id string) error
+
+	// EnableClient enables a disabled client.
+	EnableClient(ctx context.Context, id string) error
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ClientSecret is a hashed client secret which is accepted until it expires.
+type ClientSecret struct {
+	// Hash is the hashed secret, see Hasher.
+	Hash []byte `json:"hash"`
+
+	// ExpiresAt is the time after which the secret is no longer accepted. The zero value never expires.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func (s ClientSecret) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// ClientWithRotatedSecrets is implemented by clients whose secret expires or which have more than one valid secret.
+// This allows rotating a secret without downtime: the new secret becomes the hashed secret of the client, the old
+// one is kept as rotated secret with an expiry until all deployments of the client have been updated.
+type ClientWithRotatedSecrets interface {
+	// GetSecretExpiresAt returns the time after which the hashed secret of the client is no longer accepted. The
+	// zero value never expires.
+	GetSecretExpiresAt() time.Time
+
+	// GetRotatedSecrets returns further secrets which are accepted until they expire.
+	GetRotatedSecrets() []ClientSecret
+}
+
+// DefaultClientWithRotatedSecrets is a DefaultClient with an expiring secret and rotated secrets.
+type DefaultClientWithRotatedSecrets struct {
+	*DefaultClient
+	SecretExpiresAt time.Time      `json:"client_secret_expires_at,omitempty"`
+	RotatedSecrets  []ClientSecret `json:"rotated_client_secrets"`
+}
+
+func (c *DefaultClientWithRotatedSecrets) GetSecretExpiresAt() time.Time {
+	return c.SecretExpiresAt
+}
+
+func (c *DefaultClientWithRotatedSecrets) GetRotatedSecrets() []ClientSecret {
+	return c.RotatedSecrets
+}
+
+// compareRotatedClientSecrets accepts secret if it matches any of the secrets of client which have not expired yet.
+// Expired secrets are skipped before they are compared, so that they do not cost a hash comparison.
+func (f *Fosite) compareRotatedClientSecrets(client Client, rotated ClientWithRotatedSecrets, secret []byte) error {
+	now := Now(f.Clock)
+	secrets := append([]ClientSecret{{Hash: client.GetHashedSecret(), ExpiresAt: rotated.GetSecretExpiresAt()}}, rotated.GetRotatedSecrets()...)
+
+	compared := false
+	for _, s := range secrets {
+		if len(s.Hash) == 0 || s.expired(now) {
+			continue
+		}
+		compared = true
+		if err := f.Hasher.Compare(s.Hash, secret); err == nil {
+			return nil
+		}
+	}
+
+	if !compared {
+		return errors.New("All secrets of the client have expired")
+	}
+	return errors.New("The client secret does not match any of the unexpired secrets of the client")
+}
@@ -23,6 +23,7 @@ package fosite
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -49,3 +50,45 @@ func TestDefaultClient(t *testing.T) {
 	assert.Equal(t, "code", sc.GetResponseTypes()[0])
 	assert.Equal(t, "authorization_code", sc.GetGrantTypes()[0])
 }
+
+func TestGetEffectiveLifespan(t *testing.T) {
+	c := &DefaultClientWithCustomTokenLifespans{
+		DefaultClient: &DefaultClient{ID: "foo"},
+		TokenLifespans: map[string]map[TokenType]time.Duration{
+			"client_credentials": {AccessToken: time.Minute},
+		},
+	}
+
+	assert.Equal(t, time.Minute, GetEffectiveLifespan(c, "client_credentials", AccessToken, time.Hour))
+	assert.Equal(t, time.Hour, GetEffectiveLifespan(c, "client_credentials", RefreshToken, time.Hour))
+	assert.Equal(t, time.Hour, GetEffectiveLifespan(c, "authorization_code", AccessToken, time.Hour))
+	assert.Equal(t, time.Hour, GetEffectiveLifespan(c.DefaultClient, "client_credentials", AccessToken, time.Hour))
+}
+
+func TestClientDeactivation(t *testing.T) {
+	active := &DefaultClient{ID: "foo"}
+	assert.True(t, IsClientActive(active))
+	assert.True(t, IsClientTokenValid(active, time.Now()))
+
+	deactivated := &DeactivatedClient{Client: active, DeactivatedAt: time.Now(), GracePeriod: time.Hour}
+	assert.False(t, IsClientActive(deactivated))
+	assert.True(t, IsClientTokenValid(deactivated, time.Now()))
+	assert.False(t, IsClientTokenValid(deactivated, time.Now().Add(time.Hour*2)))
+
+	deactivated.GracePeriod = 0
+	assert.False(t, IsClientTokenValid(deactivated, time.Now()))
+}
+
+func TestClientDisabled(t *testing.T) {
+	client := &DefaultClient{ID: "foo", Disabled: true}
+	assert.True(t, IsClientDisabled(client))
+	assert.False(t, IsClientActive(client))
+	assert.False(t, IsClientTokenValid(client, time.Now()))
+
+	deactivated := &DeactivatedClient{Client: client, DeactivatedAt: time.Now(), GracePeriod: time.Hour}
+	assert.False(t, IsClientTokenValid(deactivated, time.Now()), "disabling ignores the grace period")
+
+	client.Disabled = false
+	assert.False(t, IsClientDisabled(client))
+	assert.True(t, IsClientActive(client))
+}
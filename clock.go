@@ -0,0 +1,54 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "time"
+
+// Clock provides the current time. Replace it to make time-dependent behaviour deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock used where none is configured.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Now returns the current time of clock in UTC, or the one of SystemClock if clock is nil.
+func Now(clock Clock) time.Time {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return clock.Now().UTC()
+}
+
+// IsExpired returns true if exp lies more than leeway before the current time of clock. A zero exp never expires.
+// The leeway tolerates clock skew between the machines issuing and validating a token.
+func IsExpired(clock Clock, leeway time.Duration, exp time.Time) bool {
+	return !exp.IsZero() && exp.Add(leeway).Before(Now(clock))
+}
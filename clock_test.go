@@ -0,0 +1,44 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	clock := ClockFunc(func() time.Time { return now })
+
+	assert.Equal(t, now.UTC(), Now(clock))
+	assert.Equal(t, time.UTC, Now(clock).Location())
+	assert.WithinDuration(t, time.Now(), Now(nil), time.Second)
+
+	assert.False(t, IsExpired(clock, 0, time.Time{}))
+	assert.False(t, IsExpired(clock, 0, now.Add(time.Second)))
+	assert.True(t, IsExpired(clock, 0, now.Add(-time.Second)))
+	assert.False(t, IsExpired(clock, time.Minute, now.Add(-time.Second)))
+	assert.True(t, IsExpired(clock, time.Minute, now.Add(-time.Minute*2)))
+}
@@ -23,6 +23,8 @@ package compose
 
 import (
 	"crypto/rsa"
+	"fmt"
+	"strings"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/jwt"
@@ -52,7 +54,13 @@ type Factory func(config *Config, storage interface{}, strategy interface{}) int
 //  )
 //
 // Compose makes use of interface{} types in order to be able to handle a all types of stores, strategies and handlers.
+// It panics if the config is invalid, use Config.Validate to check it beforehand.
 func Compose(config *Config, storage interface{}, strategy interface{}, hasher fosite.Hasher, factories ...Factory) fosite.OAuth2Provider {
+	if err := config.Validate(); err != nil {
+		rfcerr := fosite.ErrorToRFC6749Error(err)
+		panic(strings.TrimSpace(fmt.Sprintf("compose: %s %s", rfcerr.Hint, rfcerr.Debug)))
+	}
+
 	if hasher == nil {
 		hasher = &fosite.BCrypt{WorkFactor: config.GetHashCost()}
 	}
@@ -66,8 +74,23 @@ func Compose(config *Config, storage interface{}, strategy interface{}, hasher f
 		Hasher:                     hasher,
 		ScopeStrategy:              config.GetScopeStrategy(),
 		SendDebugMessagesToClients: config.SendDebugMessagesToClients,
-		TokenURL:                   config.TokenURL,
+		ErrorHook:                  config.ErrorHook,
+		PanicHook:                  config.PanicHook,
+		TokenURL:                   config.GetTokenURL(),
+		IssuerConfig:               config.IssuerConfig,
 		JWKSFetcherStrategy:        config.GetJWKSFetcherStrategy(),
+		PolicyEngine:               config.PolicyEngine,
+		Clock:                      config.Clock,
+		ClockSkew:                  config.ClockSkew,
+		ThrottlingStrategy:         config.ThrottlingStrategy,
+		TokenPrefixes:              config.TokenPrefixes,
+		FAPIProfile:                config.FAPIProfile,
+		MinParameterEntropy:        config.MinParameterEntropy,
+		RedirectURIPolicy:          config.RedirectURIPolicy,
+		ConfigProvider:             config.ConfigProvider,
+		SecretPolicy:               config.SecretPolicy,
+
+		AuthorizationResponseIssParameter: config.AuthorizationResponseIssParameter,
 	}
 
 	for _, factory := range factories {
@@ -96,9 +119,11 @@ func ComposeAllEnabled(config *Config, storage interface{}, secret []byte, key *
 		storage,
 		&CommonStrategy{
 			CoreStrategy:               NewOAuth2HMACStrategy(config, secret),
-			OpenIDConnectTokenStrategy: NewOpenIDConnectStrategy(key),
+			OpenIDConnectTokenStrategy: NewOpenIDConnectStrategyWithConfig(config, key),
 			JWTStrategy: &jwt.RS256JWTStrategy{
 				PrivateKey: key,
+				Clock:      config.Clock,
+				ClockSkew:  config.ClockSkew,
 			},
 		},
 		nil,
@@ -22,6 +22,7 @@
 package compose
 
 import (
+	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
 )
 
@@ -33,10 +34,14 @@ func OAuth2AuthorizeExplicitFactory(config *Config, storage interface{}, strateg
 		RefreshTokenStrategy:   strategy.(oauth2.RefreshTokenStrategy),
 		AuthorizeCodeStrategy:  strategy.(oauth2.AuthorizeCodeStrategy),
 		CoreStorage:            storage.(oauth2.CoreStorage),
-		AuthCodeLifespan:       config.GetAuthorizeCodeLifespan(),
-		AccessTokenLifespan:    config.GetAccessTokenLifespan(),
+		AuthCodeLifespan:       config.GetTokenLifespan("authorization_code", fosite.AuthorizeCode),
+		AccessTokenLifespan:    config.GetTokenLifespan("authorization_code", fosite.AccessToken),
+		RefreshTokenLifespan:   config.GetTokenLifespan("authorization_code", fosite.RefreshToken),
 		ScopeStrategy:          config.GetScopeStrategy(),
 		TokenRevocationStorage: storage.(oauth2.TokenRevocationStorage),
+		Clock:                  config.Clock,
+		RefreshTokenPolicy:     config.RefreshTokenPolicy,
+		ConfigProvider:         config.ConfigProvider,
 	}
 }
 
@@ -47,12 +52,33 @@ func OAuth2ClientCredentialsGrantFactory(config *Config, storage interface{}, st
 		HandleHelper: &oauth2.HandleHelper{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetTokenLifespan("client_credentials", fosite.AccessToken),
+			Clock:               config.Clock,
+			ConfigProvider:      config.ConfigProvider,
 		},
 		ScopeStrategy: config.GetScopeStrategy(),
 	}
 }
 
+// OAuth2JWTBearerGrantFactory creates an OAuth2 JWT bearer grant (RFC 7523) handler which accepts assertions of
+// config.JWTBearerTrustedIssuers. The audience of the assertions must be the token URL, see Config.GetTokenURL.
+func OAuth2JWTBearerGrantFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &oauth2.JWTBearerGrantHandler{
+		HandleHelper: &oauth2.HandleHelper{
+			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
+			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
+			AccessTokenLifespan: config.GetTokenLifespan(oauth2.GrantTypeJWTBearer, fosite.AccessToken),
+			Clock:               config.Clock,
+			ConfigProvider:      config.ConfigProvider,
+		},
+		ScopeStrategy:  config.GetScopeStrategy(),
+		TrustedIssuers: config.JWTBearerTrustedIssuers,
+		JWKSFetcher:    config.GetJWKSFetcherStrategy(),
+		TokenURL:       config.GetTokenURL(),
+		ClockSkew:      config.ClockSkew,
+	}
+}
+
 // OAuth2RefreshTokenGrantFactory creates an OAuth2 refresh grant handler and registers
 // an access token, refresh token and authorize code validator.
 func OAuth2RefreshTokenGrantFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
@@ -60,7 +86,12 @@ func OAuth2RefreshTokenGrantFactory(config *Config, storage interface{}, strateg
 		AccessTokenStrategy:    strategy.(oauth2.AccessTokenStrategy),
 		RefreshTokenStrategy:   strategy.(oauth2.RefreshTokenStrategy),
 		TokenRevocationStorage: storage.(oauth2.TokenRevocationStorage),
-		AccessTokenLifespan:    config.GetAccessTokenLifespan(),
+		AccessTokenLifespan:    config.GetTokenLifespan("refresh_token", fosite.AccessToken),
+		RefreshTokenLifespan:   config.GetTokenLifespan("refresh_token", fosite.RefreshToken),
+		Clock:                  config.Clock,
+		ScopeStrategy:          config.GetScopeStrategy(),
+		RefreshTokenPolicy:     config.RefreshTokenPolicy,
+		ConfigProvider:         config.ConfigProvider,
 	}
 }
 
@@ -70,8 +101,10 @@ func OAuth2AuthorizeImplicitFactory(config *Config, storage interface{}, strateg
 	return &oauth2.AuthorizeImplicitGrantTypeHandler{
 		AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 		AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-		AccessTokenLifespan: config.GetAccessTokenLifespan(),
+		AccessTokenLifespan: config.GetTokenLifespan("implicit", fosite.AccessToken),
 		ScopeStrategy:       config.GetScopeStrategy(),
+		Clock:               config.Clock,
+		ConfigProvider:      config.ConfigProvider,
 	}
 }
 
@@ -83,10 +116,15 @@ func OAuth2ResourceOwnerPasswordCredentialsFactory(config *Config, storage inter
 		HandleHelper: &oauth2.HandleHelper{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetTokenLifespan("password", fosite.AccessToken),
+			Clock:               config.Clock,
+			ConfigProvider:      config.ConfigProvider,
 		},
 		RefreshTokenStrategy: strategy.(oauth2.RefreshTokenStrategy),
 		ScopeStrategy:        config.GetScopeStrategy(),
+		RefreshTokenLifespan: config.GetTokenLifespan("password", fosite.RefreshToken),
+		ThrottlingStrategy:   config.ThrottlingStrategy,
+		RefreshTokenPolicy:   config.RefreshTokenPolicy,
 	}
 }
 
@@ -22,6 +22,7 @@
 package compose
 
 import (
+	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/fosite/token/jwt"
@@ -36,7 +37,7 @@ func OpenIDConnectExplicitFactory(config *Config, storage interface{}, strategy
 		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
 			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
 		},
-		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)),
+		OpenIDConnectRequestValidator: newOpenIDConnectRequestValidator(config, strategy),
 	}
 }
 
@@ -59,13 +60,16 @@ func OpenIDConnectImplicitFactory(config *Config, storage interface{}, strategy
 		AuthorizeImplicitGrantTypeHandler: &oauth2.AuthorizeImplicitGrantTypeHandler{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetTokenLifespan("implicit", fosite.AccessToken),
+			Clock:               config.Clock,
+			ConfigProvider:      config.ConfigProvider,
 		},
 		ScopeStrategy: config.GetScopeStrategy(),
 		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
 			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
 		},
-		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)),
+		OpenIDConnectRequestValidator: newOpenIDConnectRequestValidator(config, strategy),
+		ConfigProvider:                config.ConfigProvider,
 	}
 }
 
@@ -79,19 +83,32 @@ func OpenIDConnectHybridFactory(config *Config, storage interface{}, strategy in
 			RefreshTokenStrategy:  strategy.(oauth2.RefreshTokenStrategy),
 			AuthorizeCodeStrategy: strategy.(oauth2.AuthorizeCodeStrategy),
 			CoreStorage:           storage.(oauth2.CoreStorage),
-			AuthCodeLifespan:      config.GetAuthorizeCodeLifespan(),
-			AccessTokenLifespan:   config.GetAccessTokenLifespan(),
+			AuthCodeLifespan:      config.GetTokenLifespan("authorization_code", fosite.AuthorizeCode),
+			AccessTokenLifespan:   config.GetTokenLifespan("authorization_code", fosite.AccessToken),
+			Clock:                 config.Clock,
+			ConfigProvider:        config.ConfigProvider,
 		},
 		ScopeStrategy: config.GetScopeStrategy(),
 		AuthorizeImplicitGrantTypeHandler: &oauth2.AuthorizeImplicitGrantTypeHandler{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetTokenLifespan("implicit", fosite.AccessToken),
+			Clock:               config.Clock,
+			ConfigProvider:      config.ConfigProvider,
 		},
 		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
 			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
 		},
 		OpenIDConnectRequestStorage:   storage.(openid.OpenIDConnectRequestStorage),
-		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)),
+		OpenIDConnectRequestValidator: newOpenIDConnectRequestValidator(config, strategy),
+		ConfigProvider:                config.ConfigProvider,
 	}
 }
+
+func newOpenIDConnectRequestValidator(config *Config, strategy interface{}) *openid.OpenIDConnectRequestValidator {
+	validator := openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy))
+	validator.Clock = config.Clock
+	validator.Issuers = config.Issuers
+	validator.SubjectIdentifierAlgorithms = config.SubjectIdentifierAlgorithms
+	return validator
+}
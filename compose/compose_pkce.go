@@ -31,7 +31,8 @@ func OAuth2PKCEFactory(config *Config, storage interface{}, strategy interface{}
 	return &pkce.Handler{
 		AuthorizeCodeStrategy: strategy.(oauth2.AuthorizeCodeStrategy),
 		Storage:               storage.(pkce.PKCERequestStorage),
-		Force:                 config.EnforcePKCE,
-		EnablePlainChallengeMethod: config.EnablePKCEPlainChallengeMethod,
+		Force:                 config.EnforcePKCE || config.FAPIProfile != nil,
+		EnablePlainChallengeMethod: config.EnablePKCEPlainChallengeMethod && config.FAPIProfile == nil,
+		ConfigProvider:             config.ConfigProvider,
 	}
 }
@@ -23,7 +23,9 @@ package compose
 
 import (
 	"crypto/rsa"
+	"time"
 
+	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/fosite/token/hmac"
@@ -37,12 +39,28 @@ type CommonStrategy struct {
 }
 
 func NewOAuth2HMACStrategy(config *Config, secret []byte) *oauth2.HMACSHAStrategy {
+	var tenantEnigmas map[string]*hmac.HMACStrategy
+	if len(config.TenantHMACSecrets) > 0 {
+		tenantEnigmas = make(map[string]*hmac.HMACStrategy, len(config.TenantHMACSecrets))
+		for id, tenantSecret := range config.TenantHMACSecrets {
+			tenantEnigmas[id] = &hmac.HMACStrategy{
+				GlobalSecret:    tenantSecret,
+				AuthCodeEntropy: config.TokenEntropy,
+			}
+		}
+	}
+
 	return &oauth2.HMACSHAStrategy{
 		Enigma: &hmac.HMACStrategy{
-			GlobalSecret: secret,
+			GlobalSecret:    secret,
+			AuthCodeEntropy: config.TokenEntropy,
 		},
 		AccessTokenLifespan:   config.GetAccessTokenLifespan(),
 		AuthorizeCodeLifespan: config.GetAuthorizeCodeLifespan(),
+		Clock:                 config.Clock,
+		ClockSkew:             config.ClockSkew,
+		TokenPrefixes:         config.TokenPrefixes,
+		TenantEnigmas:         tenantEnigmas,
 	}
 }
 
@@ -55,6 +73,24 @@ func NewOAuth2JWTStrategy(key *rsa.PrivateKey, strategy *oauth2.HMACSHAStrategy)
 	}
 }
 
+// NewOAuth2JWTStrategyWithConfig is like NewOAuth2JWTStrategy, but also applies the clock, the issuer and the claims
+// enricher of config to JSON Web Token access tokens.
+func NewOAuth2JWTStrategyWithConfig(config *Config, key *rsa.PrivateKey, strategy *oauth2.HMACSHAStrategy) *oauth2.DefaultJWTStrategy {
+	return &oauth2.DefaultJWTStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: key,
+			Clock:      config.Clock,
+			ClockSkew:  config.ClockSkew,
+		},
+		HMACSHAStrategy: strategy,
+		Issuer:          config.GetIssuer(),
+		Clock:           config.Clock,
+		ClockSkew:       config.ClockSkew,
+		Issuers:         config.Issuers,
+		ClaimsEnricher:  config.ClaimsEnricher,
+	}
+}
+
 func NewOpenIDConnectStrategy(key *rsa.PrivateKey) *openid.DefaultStrategy {
 	return &openid.DefaultStrategy{
 		JWTStrategy: &jwt.RS256JWTStrategy{
@@ -62,3 +98,34 @@ func NewOpenIDConnectStrategy(key *rsa.PrivateKey) *openid.DefaultStrategy {
 		},
 	}
 }
+
+// NewOpenIDConnectStrategyWithConfig is like NewOpenIDConnectStrategy, but also applies the ID token lifespans, the
+// clock, the issuer and the other ID token settings of config.
+func NewOpenIDConnectStrategyWithConfig(config *Config, key *rsa.PrivateKey) *openid.DefaultStrategy {
+	var grantTypeExpiry map[string]time.Duration
+	for grantType, lifespans := range config.GrantTypeTokenLifespans {
+		if lifespan := lifespans[fosite.IDToken]; lifespan != 0 {
+			if grantTypeExpiry == nil {
+				grantTypeExpiry = map[string]time.Duration{}
+			}
+			grantTypeExpiry[grantType] = lifespan
+		}
+	}
+
+	return &openid.DefaultStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: key,
+			Clock:      config.Clock,
+			ClockSkew:  config.ClockSkew,
+		},
+		Expiry:                      config.GetIDTokenLifespan(),
+		GrantTypeExpiry:             grantTypeExpiry,
+		Issuer:                      config.GetIssuer(),
+		Clock:                       config.Clock,
+		Issuers:                     config.Issuers,
+		SubjectIdentifierAlgorithms: config.SubjectIdentifierAlgorithms,
+		JWKSFetcher:                 config.GetJWKSFetcherStrategy(),
+		ClaimsEnricher:              config.ClaimsEnricher,
+		ConfigProvider:              config.ConfigProvider,
+	}
+}
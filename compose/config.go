@@ -25,6 +25,9 @@ import (
 	"time"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/pkg/errors"
 )
 
 type Config struct {
@@ -37,6 +40,15 @@ type Config struct {
 	// IDTokenLifespan sets how long an id token is going to be valid. Defaults to one hour.
 	IDTokenLifespan time.Duration
 
+	// RefreshTokenLifespan sets how long a refresh token is going to be valid. Defaults to zero, which means that refresh
+	// tokens do not expire.
+	RefreshTokenLifespan time.Duration
+
+	// GrantTypeTokenLifespans overrides the lifespans above for tokens issued through a specific grant type, for example
+	// {"client_credentials": {fosite.AccessToken: time.Minute * 5}}. Clients implementing
+	// fosite.ClientWithCustomTokenLifespans take precedence over these values.
+	GrantTypeTokenLifespans map[string]map[fosite.TokenType]time.Duration
+
 	// HashCost sets the cost of the password hashing cost. Defaults to 12.
 	HashCost int
 
@@ -48,6 +60,13 @@ type Config struct {
 	// codes or other information. Proceed with caution!
 	SendDebugMessagesToClients bool
 
+	// ErrorHook, if set, receives every error before it is written to a client, for example to log the debug details
+	// which are hidden from clients, see fosite.ErrorHook.
+	ErrorHook fosite.ErrorHook
+
+	// PanicHook, if set, receives panics recovered from endpoint handlers, see fosite.PanicHook.
+	PanicHook fosite.PanicHook
+
 	// ScopeStrategy sets the scope strategy that should be supported, for example fosite.WildcardScopeStrategy.
 	ScopeStrategy fosite.ScopeStrategy
 
@@ -65,9 +84,112 @@ type Config struct {
 	// this value MUST be set.
 	TokenURL string
 
+	// IssuerConfig, if set, is the issuer and the endpoint URLs of the authorization server, see fosite.IssuerConfig.
+	// Its issuer is used as the "iss" claim of ID tokens and its token endpoint as TokenURL unless TokenURL is set.
+	// Compose panics if it is invalid, see Validate.
+	IssuerConfig *fosite.IssuerConfig
+
+	// AuthorizationResponseIssParameter, if set to true, adds the issuer of IssuerConfig as the "iss" parameter to
+	// authorize responses (RFC 9207). Compose panics if it is set without IssuerConfig. Defaults to false.
+	AuthorizationResponseIssParameter bool
+
 	// JWKSFetcherStrategy is responsible for fetching JSON Web Keys from remote URLs. This is required when the private_key_jwt
 	// client authentication method is used. Defaults to fosite.DefaultJWKSFetcherStrategy.
 	JWKSFetcher fosite.JWKSFetcherStrategy
+
+	// PolicyEngine, if set, is consulted before authorize and access requests are accepted. Defaults to nil.
+	PolicyEngine fosite.PolicyEngine
+
+	// Clock provides the current time to handlers and strategies, for example to make tests deterministic. Defaults to
+	// fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ClockSkew is the leeway applied when validating token expiry and the "exp", "nbf" and "iat" claims of JSON Web
+	// Tokens, to tolerate clocks of servers and clients drifting apart. Defaults to zero.
+	ClockSkew time.Duration
+
+	// Issuers, if set, is the issuer of new ID tokens and JSON Web Token access tokens while tokens issued by legacy
+	// issuers are still accepted, for example during a domain migration.
+	Issuers *fosite.IssuerSet
+
+	// ThrottlingStrategy, if set, is consulted before client credentials and resource owner passwords are checked, see
+	// fosite.MemoryThrottlingStrategy.
+	ThrottlingStrategy fosite.ThrottlingStrategy
+
+	// TokenEntropy sets the number of random bytes of opaque tokens. Defaults to (and may not be less than) 32.
+	TokenEntropy int
+
+	// TokenPrefixes are prepended to opaque tokens by token type, see oauth2.DefaultTokenPrefixes. Defaults to none.
+	TokenPrefixes map[fosite.TokenType]string
+
+	// TenantHMACSecrets are the HMAC secrets of opaque tokens by tenant ID, see fosite.Tenant. Tenants without a secret
+	// use the secret passed to NewOAuth2HMACStrategy.
+	TenantHMACSecrets map[string][]byte
+
+	// SubjectIdentifierAlgorithms computes the sub claim of ID tokens by the subject type of the client, for example
+	// openid.PairwiseSubjectIdentifierAlgorithm for openid.SubjectTypePairwise. Defaults to public subjects only.
+	SubjectIdentifierAlgorithms map[string]openid.SubjectIdentifierAlgorithm
+
+	// FAPIProfile, if set, restricts the server to the FAPI 1.0 Advanced security profile, see fosite.FAPIProfile. It
+	// also enforces PKCE and disables the plain challenge method.
+	FAPIProfile *fosite.FAPIProfile
+
+	// RedirectURIPolicy restricts the schemes of redirect URIs, see fosite.RedirectURIPolicy. If it is not set, only
+	// the fosite.DefaultForbiddenRedirectURISchemes are rejected.
+	RedirectURIPolicy *fosite.RedirectURIPolicy
+
+	// JWTBearerTrustedIssuers are the issuers whose assertions are exchanged for access tokens by the JWT bearer
+	// grant, see OAuth2JWTBearerGrantFactory.
+	JWTBearerTrustedIssuers []oauth2.TrustedJWTIssuer
+
+	// ClaimsEnricher, if set, adds custom claims to ID tokens issued by NewOpenIDConnectStrategyWithConfig and to
+	// JSON Web Token access tokens issued by NewOAuth2JWTStrategyWithConfig.
+	ClaimsEnricher fosite.ClaimsEnricher
+
+	// MinParameterEntropy sets the minimum number of characters of the state and nonce parameters. Defaults to
+	// fosite.MinParameterEntropy.
+	MinParameterEntropy int
+
+	// RefreshTokenPolicy decides whether refresh tokens are issued, for example oauth2.PlainOAuth2RefreshTokenPolicy to
+	// issue them to plain OAuth 2.0 grants without the scope "offline_access". Defaults to
+	// oauth2.OfflineAccessRefreshTokenPolicy.
+	RefreshTokenPolicy oauth2.RefreshTokenPolicy
+
+	// SecretPolicy is the minimum strength of client secrets hashed by fosite.Fosite.HashClientSecret. Defaults to
+	// fosite.DefaultSecretPolicy.
+	SecretPolicy *fosite.SecretPolicy
+
+	// ConfigProvider, if set, overrides the token lifespans, the scope strategy, EnforcePKCE and the allowed response
+	// modes per request, for example per tenant. See fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
+}
+
+// Validate returns an error if the config can not be composed: if IssuerConfig is set but invalid, see
+// fosite.IssuerConfig.Validate, or if AuthorizationResponseIssParameter is set without IssuerConfig. Compose panics
+// with this error, so call Validate first to handle configuration errors gracefully.
+func (c *Config) Validate() error {
+	if c.IssuerConfig != nil {
+		return c.IssuerConfig.Validate()
+	} else if c.AuthorizationResponseIssParameter {
+		return errors.WithStack(fosite.ErrMisconfiguration.WithHint("AuthorizationResponseIssParameter requires IssuerConfig."))
+	}
+	return nil
+}
+
+// GetTokenURL returns the URL of the token endpoint. Defaults to the token endpoint of IssuerConfig.
+func (c *Config) GetTokenURL() string {
+	if c.TokenURL == "" && c.IssuerConfig != nil {
+		return c.IssuerConfig.TokenEndpoint
+	}
+	return c.TokenURL
+}
+
+// GetIssuer returns the issuer of IssuerConfig, or an empty string if it is not set.
+func (c *Config) GetIssuer() string {
+	if c.IssuerConfig == nil {
+		return ""
+	}
+	return c.IssuerConfig.Issuer
 }
 
 // GetScopeStrategy returns the scope strategy to be used. Defaults to glob scope strategy.
@@ -102,6 +224,32 @@ func (c *Config) GetAccessTokenLifespan() time.Duration {
 	return c.AccessTokenLifespan
 }
 
+// GetRefreshTokenLifespan returns how long a refresh token should be valid. Defaults to zero, which means that refresh
+// tokens do not expire.
+func (c *Config) GetRefreshTokenLifespan() time.Duration {
+	return c.RefreshTokenLifespan
+}
+
+// GetTokenLifespan returns how long a token of the given type, issued through the given grant type, should be valid.
+// Falls back to the lifespan configured for the token type if there is no override for the grant type.
+func (c *Config) GetTokenLifespan(grantType string, tokenType fosite.TokenType) time.Duration {
+	if lifespan := c.GrantTypeTokenLifespans[grantType][tokenType]; lifespan != 0 {
+		return lifespan
+	}
+
+	switch tokenType {
+	case fosite.AccessToken:
+		return c.GetAccessTokenLifespan()
+	case fosite.RefreshToken:
+		return c.GetRefreshTokenLifespan()
+	case fosite.AuthorizeCode:
+		return c.GetAuthorizeCodeLifespan()
+	case fosite.IDToken:
+		return c.GetIDTokenLifespan()
+	}
+	return 0
+}
+
 // GetAccessTokenLifespan returns how long a refresh token should be valid. Defaults to one hour.
 func (c *Config) GetHashCost() int {
 	if c.HashCost == 0 {
@@ -0,0 +1,203 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ConfigProvider resolves configuration for each request, for example by the tenant of the context (see
+// TenantFromContext) or by the client. Handlers fall back to their own configuration for everything the provider
+// leaves unset, so providers only need to override what differs.
+//
+// Providers are consulted on every request, which allows changing the configuration at runtime, see
+// DynamicConfigProvider.
+type ConfigProvider interface {
+	// GetTokenLifespan returns the lifespan of tokens of the type issued to the client through the grant type, or zero
+	// to use the default. Refresh tokens do not expire if the lifespan is negative. Lifespans of clients implementing
+	// ClientWithCustomTokenLifespans take precedence.
+	GetTokenLifespan(ctx context.Context, client Client, grantType string, tokenType TokenType) time.Duration
+
+	// GetScopeStrategy returns the scope strategy which decides whether the client may request a scope, or nil to use
+	// the default.
+	GetScopeStrategy(ctx context.Context, client Client) ScopeStrategy
+
+	// GetEnforcePKCE returns whether the client must use PKCE. If ok is false, the default is used.
+	GetEnforcePKCE(ctx context.Context, client Client) (enforce bool, ok bool)
+
+	// GetResponseModes returns the response modes the client may use, or nil to allow all of them.
+	GetResponseModes(ctx context.Context, client Client) []string
+}
+
+// EffectiveLifespan returns the lifespan of tokens of the type issued to the client through the grant type, as
+// configured by the client, the provider or fallback in that order. The provider may be nil.
+func EffectiveLifespan(ctx context.Context, p ConfigProvider, c Client, grantType string, tokenType TokenType, fallback time.Duration) time.Duration {
+	if p != nil {
+		if lifespan := p.GetTokenLifespan(ctx, c, grantType, tokenType); lifespan != 0 {
+			fallback = lifespan
+		}
+	}
+	return GetEffectiveLifespan(c, grantType, tokenType, fallback)
+}
+
+// EffectiveScopeStrategy returns the scope strategy of the provider for the client, or fallback. The provider may be
+// nil.
+func EffectiveScopeStrategy(ctx context.Context, p ConfigProvider, c Client, fallback ScopeStrategy) ScopeStrategy {
+	if p != nil {
+		if strategy := p.GetScopeStrategy(ctx, c); strategy != nil {
+			return strategy
+		}
+	}
+	return fallback
+}
+
+// EffectiveEnforcePKCE returns whether the provider enforces PKCE for the client, or fallback. The provider may be nil.
+func EffectiveEnforcePKCE(ctx context.Context, p ConfigProvider, c Client, fallback bool) bool {
+	if p != nil {
+		if enforce, ok := p.GetEnforcePKCE(ctx, c); ok {
+			return enforce
+		}
+	}
+	return fallback
+}
+
+// StaticConfigProvider is a ConfigProvider which returns the same configuration for every request. Unset fields use
+// the defaults.
+type StaticConfigProvider struct {
+	// TokenLifespans are the lifespans of the token types, regardless of the grant type.
+	TokenLifespans map[TokenType]time.Duration
+
+	ScopeStrategy ScopeStrategy
+
+	// EnforcePKCE, if set, decides whether all clients must use PKCE.
+	EnforcePKCE *bool
+
+	ResponseModes []string
+}
+
+func (p *StaticConfigProvider) GetTokenLifespan(_ context.Context, _ Client, _ string, tokenType TokenType) time.Duration {
+	return p.TokenLifespans[tokenType]
+}
+
+func (p *StaticConfigProvider) GetScopeStrategy(context.Context, Client) ScopeStrategy {
+	return p.ScopeStrategy
+}
+
+func (p *StaticConfigProvider) GetEnforcePKCE(context.Context, Client) (bool, bool) {
+	if p.EnforcePKCE == nil {
+		return false, false
+	}
+	return *p.EnforcePKCE, true
+}
+
+func (p *StaticConfigProvider) GetResponseModes(context.Context, Client) []string {
+	return p.ResponseModes
+}
+
+// TenantConfigProvider selects the ConfigProvider by the ID of the tenant of the request. Requests without a tenant
+// or of tenants without a provider use the defaults.
+type TenantConfigProvider map[string]ConfigProvider
+
+func (p TenantConfigProvider) provider(ctx context.Context) ConfigProvider {
+	if tenant := TenantFromContext(ctx); tenant != nil {
+		return p[tenant.ID]
+	}
+	return nil
+}
+
+func (p TenantConfigProvider) GetTokenLifespan(ctx context.Context, client Client, grantType string, tokenType TokenType) time.Duration {
+	if tp := p.provider(ctx); tp != nil {
+		return tp.GetTokenLifespan(ctx, client, grantType, tokenType)
+	}
+	return 0
+}
+
+func (p TenantConfigProvider) GetScopeStrategy(ctx context.Context, client Client) ScopeStrategy {
+	if tp := p.provider(ctx); tp != nil {
+		return tp.GetScopeStrategy(ctx, client)
+	}
+	return nil
+}
+
+func (p TenantConfigProvider) GetEnforcePKCE(ctx context.Context, client Client) (bool, bool) {
+	if tp := p.provider(ctx); tp != nil {
+		return tp.GetEnforcePKCE(ctx, client)
+	}
+	return false, false
+}
+
+func (p TenantConfigProvider) GetResponseModes(ctx context.Context, client Client) []string {
+	if tp := p.provider(ctx); tp != nil {
+		return tp.GetResponseModes(ctx, client)
+	}
+	return nil
+}
+
+// DynamicConfigProvider delegates to a ConfigProvider which can be replaced at runtime, for example when the
+// configuration file changed. Until a provider is set, the defaults are used.
+type DynamicConfigProvider struct {
+	mu       sync.RWMutex
+	provider ConfigProvider
+}
+
+// Set replaces the provider. Requests which are already being handled may still use the previous one.
+func (p *DynamicConfigProvider) Set(provider ConfigProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.provider = provider
+}
+
+func (p *DynamicConfigProvider) get() ConfigProvider {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.provider
+}
+
+func (p *DynamicConfigProvider) GetTokenLifespan(ctx context.Context, client Client, grantType string, tokenType TokenType) time.Duration {
+	if dp := p.get(); dp != nil {
+		return dp.GetTokenLifespan(ctx, client, grantType, tokenType)
+	}
+	return 0
+}
+
+func (p *DynamicConfigProvider) GetScopeStrategy(ctx context.Context, client Client) ScopeStrategy {
+	if dp := p.get(); dp != nil {
+		return dp.GetScopeStrategy(ctx, client)
+	}
+	return nil
+}
+
+func (p *DynamicConfigProvider) GetEnforcePKCE(ctx context.Context, client Client) (bool, bool) {
+	if dp := p.get(); dp != nil {
+		return dp.GetEnforcePKCE(ctx, client)
+	}
+	return false, false
+}
+
+func (p *DynamicConfigProvider) GetResponseModes(ctx context.Context, client Client) []string {
+	if dp := p.get(); dp != nil {
+		return dp.GetResponseModes(ctx, client)
+	}
+	return nil
+}
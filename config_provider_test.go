@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	. "github.com/ory/fosite/internal"
+)
+
+func TestConfigProvider(t *testing.T) {
+	enforce := true
+	acme := &StaticConfigProvider{
+		TokenLifespans: map[TokenType]time.Duration{AccessToken: time.Minute},
+		ScopeStrategy:  WildcardScopeStrategy,
+		EnforcePKCE:    &enforce,
+	}
+	dynamic := new(DynamicConfigProvider)
+	ctx := ContextWithTenant(context.Background(), &Tenant{ID: "acme"})
+	client := &DefaultClient{}
+
+	// Without a provider, the defaults are used.
+	assert.Equal(t, time.Hour, EffectiveLifespan(ctx, dynamic, client, "implicit", AccessToken, time.Hour))
+	assert.False(t, EffectiveEnforcePKCE(ctx, dynamic, client, false))
+	assert.Nil(t, EffectiveScopeStrategy(ctx, nil, client, nil))
+
+	// The provider can be replaced at runtime and selects the configuration by tenant.
+	dynamic.Set(TenantConfigProvider{"acme": acme})
+	assert.Equal(t, time.Minute, EffectiveLifespan(ctx, dynamic, client, "implicit", AccessToken, time.Hour))
+	assert.Equal(t, time.Hour, EffectiveLifespan(ctx, dynamic, client, "implicit", RefreshToken, time.Hour))
+	assert.Equal(t, time.Hour, EffectiveLifespan(context.Background(), dynamic, client, "implicit", AccessToken, time.Hour))
+	assert.True(t, EffectiveEnforcePKCE(ctx, dynamic, client, false))
+	assert.False(t, EffectiveEnforcePKCE(context.Background(), dynamic, client, false))
+	assert.True(t, EffectiveScopeStrategy(ctx, dynamic, client, ExactScopeStrategy)([]string{"foo.*"}, "foo.bar"))
+	assert.False(t, EffectiveScopeStrategy(context.Background(), dynamic, client, ExactScopeStrategy)([]string{"foo.*"}, "foo.bar"))
+
+	// Lifespans of the client take precedence.
+	custom := &DefaultClientWithCustomTokenLifespans{DefaultClient: client, TokenLifespans: map[string]map[TokenType]time.Duration{
+		"implicit": {AccessToken: time.Second},
+	}}
+	assert.Equal(t, time.Second, EffectiveLifespan(ctx, dynamic, custom, "implicit", AccessToken, time.Hour))
+}
+
+func TestNewAuthorizeRequest_ConfigProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	provider := new(DynamicConfigProvider)
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, ConfigProvider: provider}
+	store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code"}, RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo.*"}}, nil).AnyTimes()
+
+	newRequest := func(scope, responseMode string) error {
+		_, err := f.NewAuthorizeRequestFromValues(context.Background(), url.Values{
+			"redirect_uri":  {"https://foo.bar/cb"},
+			"client_id":     {"1234"},
+			"response_type": {"code"},
+			"state":         {"strong-state"},
+			"scope":         {scope},
+			"response_mode": {responseMode},
+		}, nil)
+		return errors.Cause(err)
+	}
+
+	assert.EqualError(t, newRequest("foo.bar", ""), ErrInvalidScope.Error())
+	require.NoError(t, newRequest("foo.*", "form_post"))
+
+	provider.Set(&StaticConfigProvider{ScopeStrategy: WildcardScopeStrategy, ResponseModes: []string{ResponseModeQuery}})
+	require.NoError(t, newRequest("foo.bar", ""))
+	require.NoError(t, newRequest("foo.bar", "query"))
+	assert.EqualError(t, newRequest("foo.bar", "form_post"), ErrInvalidRequest.Error())
+}
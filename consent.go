@@ -0,0 +1,177 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Consent is the decision of an end-user to grant scopes and audiences to a client, which is remembered so that the
+// end-user does not have to be asked again.
+type Consent struct {
+	ClientID  string    `json:"client_id"`
+	Subject   string    `json:"subject"`
+	Scopes    Arguments `json:"scopes"`
+	Audiences Arguments `json:"audiences,omitempty"`
+	GrantedAt time.Time `json:"granted_at"`
+
+	// ExpiresAt is the time after which the end-user is asked again. The consent does not expire if it is zero.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Covers returns true if the consent has not expired at now and includes all scopes and audiences.
+func (c *Consent) Covers(scopes, audiences Arguments, now time.Time) bool {
+	if !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt) {
+		return false
+	}
+	return c.Scopes.Has(scopes...) && c.Audiences.Has(audiences...)
+}
+
+// ConsentStore persists the consent decisions of end-users, see Fosite.ConsentStore.
+type ConsentStore interface {
+	// SaveConsent creates or replaces the consent of the subject for the client.
+	SaveConsent(ctx context.Context, consent *Consent) (err error)
+
+	// GetConsent returns the consent of the subject for the client, or ErrNotFound.
+	GetConsent(ctx context.Context, clientID, subject string) (consent *Consent, err error)
+
+	// RevokeConsent removes the consent of the subject for the client. Removing a consent which does not exist is not
+	// an error.
+	RevokeConsent(ctx context.Context, clientID, subject string) (err error)
+
+	// RevokeSubjectConsents removes the consents of the subject for all clients.
+	RevokeSubjectConsents(ctx context.Context, subject string) (err error)
+}
+
+// PriorConsent returns the remembered consent of subject if it covers the scopes and the audiences requested by the
+// authorize request, according to the scope strategy, in which case the consent screen may be skipped and the scopes
+// of the consent granted. It returns nil if the end-user must be asked, because there is no such consent or the
+// request asks for it with prompt=consent.
+func (f *Fosite) PriorConsent(ctx context.Context, ar AuthorizeRequester, subject string, audiences Arguments) (*Consent, error) {
+	if f.ConsentStore == nil {
+		return nil, errors.WithStack(ErrMisconfiguration.WithDebug("ConsentStore is not configured."))
+	} else if ar.GetPrompt().Has("consent") {
+		return nil, nil
+	}
+
+	consent, err := f.ConsentStore.GetConsent(ctx, ar.GetClient().GetID(), subject)
+	if errors.Cause(err) == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithStack(ErrServerError.WithWrap(err))
+	}
+
+	if !consent.Covers(nil, audiences, Now(f.Clock)) {
+		return nil, nil
+	}
+
+	scopeStrategy := EffectiveScopeStrategy(ctx, f.ConfigProvider, ar.GetClient(), f.ScopeStrategy)
+	for _, scope := range ar.GetRequestedScopes() {
+		if !scopeStrategy(consent.Scopes, scope) {
+			return nil, nil
+		}
+	}
+	return consent, nil
+}
+
+// RememberConsent records that subject granted the granted scopes of the authorize request and the audiences to the
+// client, in addition to what the subject consented to before. The consent expires after lifespan, or never if
+// lifespan is zero.
+func (f *Fosite) RememberConsent(ctx context.Context, ar AuthorizeRequester, subject string, audiences Arguments, lifespan time.Duration) error {
+	if f.ConsentStore == nil {
+		return errors.WithStack(ErrMisconfiguration.WithDebug("ConsentStore is not configured."))
+	}
+
+	now := Now(f.Clock)
+	consent := &Consent{ClientID: ar.GetClient().GetID(), Subject: subject, GrantedAt: now}
+	if lifespan > 0 {
+		consent.ExpiresAt = now.Add(lifespan)
+	}
+
+	prior, err := f.ConsentStore.GetConsent(ctx, consent.ClientID, subject)
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	} else if err == nil && prior.Covers(nil, nil, now) {
+		consent.Scopes = append(consent.Scopes, prior.Scopes...)
+		consent.Audiences = append(consent.Audiences, prior.Audiences...)
+	}
+
+	for _, scope := range ar.GetGrantedScopes() {
+		if !consent.Scopes.Has(scope) {
+			consent.Scopes = append(consent.Scopes, scope)
+		}
+	}
+	for _, audience := range audiences {
+		if !consent.Audiences.Has(audience) {
+			consent.Audiences = append(consent.Audiences, audience)
+		}
+	}
+
+	if err := f.ConsentStore.SaveConsent(ctx, consent); err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+	return nil
+}
+
+// RevokeConsent removes the consent of subject for the client and the scopes subject granted to the client (see
+// GrantStore), so that neither skips the consent screen nor is included with include_granted_scopes=true again.
+// Tokens already issued are not revoked, see RevokeSubjectClientTokens.
+func (f *Fosite) RevokeConsent(ctx context.Context, clientID, subject string) error {
+	if f.ConsentStore == nil && f.GrantStore == nil {
+		return errors.WithStack(ErrMisconfiguration.WithDebug("Neither ConsentStore nor GrantStore is configured."))
+	}
+
+	if f.ConsentStore != nil {
+		if err := f.ConsentStore.RevokeConsent(ctx, clientID, subject); err != nil {
+			return errors.WithStack(ErrServerError.WithWrap(err))
+		}
+	}
+	if f.GrantStore != nil {
+		if err := f.GrantStore.RevokeGrantedScopes(ctx, clientID, subject); err != nil {
+			return errors.WithStack(ErrServerError.WithWrap(err))
+		}
+	}
+	return nil
+}
+
+// RevokeSubjectConsents removes the consents of subject and the scopes subject granted for all clients. See
+// RevokeConsent.
+func (f *Fosite) RevokeSubjectConsents(ctx context.Context, subject string) error {
+	if f.ConsentStore == nil && f.GrantStore == nil {
+		return errors.WithStack(ErrMisconfiguration.WithDebug("Neither ConsentStore nor GrantStore is configured."))
+	}
+
+	if f.ConsentStore != nil {
+		if err := f.ConsentStore.RevokeSubjectConsents(ctx, subject); err != nil {
+			return errors.WithStack(ErrServerError.WithWrap(err))
+		}
+	}
+	if f.GrantStore != nil {
+		if err := f.GrantStore.RevokeSubjectGrantedScopes(ctx, subject); err != nil {
+			return errors.WithStack(ErrServerError.WithWrap(err))
+		}
+	}
+	return nil
+}
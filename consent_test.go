@@ -0,0 +1,139 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestConsent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	store := storage.NewMemoryStore()
+	f := &Fosite{
+		ConsentStore:  store,
+		ScopeStrategy: HierarchicScopeStrategy,
+		Clock:         ClockFunc(func() time.Time { return now }),
+	}
+
+	request := func(clientID string, scopes ...string) *AuthorizeRequest {
+		ar := NewAuthorizeRequest()
+		ar.Client = &DefaultClient{ID: clientID}
+		for _, scope := range scopes {
+			ar.AppendRequestedScope(scope)
+			ar.GrantScope(scope)
+		}
+		return ar
+	}
+
+	consent, err := f.PriorConsent(ctx, request("foo", "photos"), "peter", nil)
+	require.NoError(t, err)
+	assert.Nil(t, consent, "nothing was consented to yet")
+
+	require.NoError(t, f.RememberConsent(ctx, request("foo", "photos"), "peter", Arguments{"https://api.example.com"}, time.Hour))
+	require.NoError(t, f.RememberConsent(ctx, request("foo", "contacts"), "peter", nil, time.Hour))
+
+	consent, err = f.PriorConsent(ctx, request("foo", "photos.read", "contacts"), "peter", Arguments{"https://api.example.com"})
+	require.NoError(t, err)
+	require.NotNil(t, consent)
+	assert.Equal(t, Arguments{"photos", "contacts"}, consent.Scopes)
+	assert.Equal(t, now.Add(time.Hour), consent.ExpiresAt)
+
+	for k, c := range []struct {
+		ar        *AuthorizeRequest
+		subject   string
+		audiences Arguments
+	}{
+		{ar: request("foo", "calendar"), subject: "peter"},
+		{ar: request("foo", "photos"), subject: "peter", audiences: Arguments{"https://other.example.com"}},
+		{ar: request("foo", "photos"), subject: "alice"},
+		{ar: request("bar", "photos"), subject: "peter"},
+		{ar: func() *AuthorizeRequest { ar := request("foo", "photos"); ar.Prompt = Arguments{"consent"}; return ar }(), subject: "peter"},
+	} {
+		consent, err := f.PriorConsent(ctx, c.ar, c.subject, c.audiences)
+		require.NoError(t, err)
+		assert.Nil(t, consent, "case %d", k)
+	}
+
+	now = now.Add(2 * time.Hour)
+	consent, err = f.PriorConsent(ctx, request("foo", "photos"), "peter", nil)
+	require.NoError(t, err)
+	assert.Nil(t, consent, "the consent expired")
+
+	require.NoError(t, f.RememberConsent(ctx, request("foo", "calendar"), "peter", nil, 0))
+	consent, err = store.GetConsent(ctx, "foo", "peter")
+	require.NoError(t, err)
+	assert.Equal(t, Arguments{"calendar"}, consent.Scopes, "expired consents are not carried over")
+	assert.True(t, consent.ExpiresAt.IsZero())
+
+	require.NoError(t, f.RememberConsent(ctx, request("bar", "photos"), "peter", nil, 0))
+	require.NoError(t, store.RevokeConsent(ctx, "foo", "peter"))
+	_, err = store.GetConsent(ctx, "foo", "peter")
+	assert.EqualError(t, err, ErrNotFound.Error())
+	_, err = store.GetConsent(ctx, "bar", "peter")
+	require.NoError(t, err)
+
+	require.NoError(t, store.RevokeSubjectConsents(ctx, "peter"))
+	_, err = store.GetConsent(ctx, "bar", "peter")
+	assert.EqualError(t, err, ErrNotFound.Error())
+
+	_, err = (&Fosite{}).PriorConsent(ctx, request("foo"), "peter", nil)
+	assert.EqualError(t, err, ErrMisconfiguration.Error())
+}
+
+func TestRevokeConsent(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	f := &Fosite{ConsentStore: store, GrantStore: store, ScopeStrategy: ExactScopeStrategy}
+
+	for _, clientID := range []string{"foo", "bar"} {
+		require.NoError(t, store.SaveConsent(ctx, &Consent{ClientID: clientID, Subject: "peter", Scopes: Arguments{"photos"}}))
+		require.NoError(t, store.AddGrantedScopes(ctx, clientID, "peter", Arguments{"photos"}))
+	}
+
+	require.NoError(t, f.RevokeConsent(ctx, "foo", "peter"))
+	_, err := store.GetConsent(ctx, "foo", "peter")
+	assert.EqualError(t, err, ErrNotFound.Error())
+	scopes, err := store.GetGrantedScopes(ctx, "foo", "peter")
+	require.NoError(t, err)
+	assert.Empty(t, scopes, "revoked scopes must not be included with include_granted_scopes=true")
+	scopes, err = store.GetGrantedScopes(ctx, "bar", "peter")
+	require.NoError(t, err)
+	assert.Equal(t, Arguments{"photos"}, scopes)
+
+	require.NoError(t, f.RevokeSubjectConsents(ctx, "peter"))
+	_, err = store.GetConsent(ctx, "bar", "peter")
+	assert.EqualError(t, err, ErrNotFound.Error())
+	scopes, err = store.GetGrantedScopes(ctx, "bar", "peter")
+	require.NoError(t, err)
+	assert.Empty(t, scopes)
+
+	assert.EqualError(t, (&Fosite{}).RevokeConsent(ctx, "foo", "peter"), ErrMisconfiguration.Error())
+}
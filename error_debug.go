@@ -0,0 +1,79 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"strings"
+)
+
+// ErrorHook is called with every error before it is written to a client, for example to log it. The error still
+// carries its debug message and wrapped errors, which are only sent to clients if SendDebugMessagesToClients is
+// enabled.
+type ErrorHook func(rfcerr *RFC6749Error)
+
+// ErrorChain returns the messages of err and of the errors it wraps, outermost first. Messages which are already
+// part of an outer message, as is the case for errors wrapped using github.com/pkg/errors, are left out.
+func ErrorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		msg := err.Error()
+		if e, ok := err.(*RFC6749Error); ok {
+			msg = e.Debug
+		}
+		if msg != "" && !strings.Contains(strings.Join(chain, "\n"), msg) {
+			chain = append(chain, msg)
+		}
+		err = unwrapError(err)
+	}
+	return chain
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+// clientError returns the copy of err which is sent to the client. Unless SendDebugMessagesToClients is enabled, the
+// debug message is removed. Otherwise it contains the chain of wrapped errors, see ErrorChain. Errors without an
+// error_uri link to the ErrorURI of IssuerConfig, if set.
+func (f *Fosite) clientError(err error) RFC6749Error {
+	rfcerr := *ErrorToRFC6749Error(err)
+	if rfcerr.URI == "" && f.IssuerConfig != nil {
+		rfcerr.URI = f.IssuerConfig.ErrorURIFor(rfcerr.Name)
+	}
+	if f.ErrorHook != nil {
+		hooked := rfcerr
+		f.ErrorHook(&hooked)
+	}
+
+	if !f.SendDebugMessagesToClients {
+		rfcerr.Debug = ""
+	} else {
+		rfcerr.Debug = strings.Join(ErrorChain(&rfcerr), ": ")
+	}
+	return rfcerr
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestErrorChain(t *testing.T) {
+	assert.Empty(t, ErrorChain(nil))
+	assert.Equal(t, []string{"connection refused"}, ErrorChain(errors.New("connection refused")))
+	assert.Equal(t, []string{"unable to load client: connection refused"}, ErrorChain(errors.Wrap(errors.New("connection refused"), "unable to load client")))
+	assert.Equal(t, []string{"the client could not be loaded", "unable to load client: connection refused"}, ErrorChain(
+		ErrServerError.WithDebug("the client could not be loaded").WithWrap(errors.Wrap(errors.New("connection refused"), "unable to load client")),
+	))
+}
+
+func TestErrorVerbosity(t *testing.T) {
+	err := errors.WithStack(ErrServerError.WithDebug("the client could not be loaded").WithWrap(errors.New("connection refused")))
+
+	var hooked []*RFC6749Error
+	f := &Fosite{ErrorHook: func(rfcerr *RFC6749Error) { hooked = append(hooked, rfcerr) }}
+
+	write := func() map[string]interface{} {
+		rec := httptest.NewRecorder()
+		f.WriteAccessError(rec, nil, err)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+
+	body := write()
+	assert.NotContains(t, body, "error_debug")
+	require.Len(t, hooked, 1)
+	assert.Equal(t, "the client could not be loaded", hooked[0].Debug)
+	assert.EqualError(t, hooked[0].Unwrap(), "connection refused")
+
+	f.SendDebugMessagesToClients = true
+	body = write()
+	assert.Equal(t, "the client could not be loaded: connection refused", body["error_debug"])
+	assert.Len(t, hooked, 2)
+}
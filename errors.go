@@ -29,10 +29,14 @@ import (
 )
 
 var (
+	// ErrMissingBearerToken is wrapped by the error ValidateToken returns if the request carries no bearer token.
+	ErrMissingBearerToken = errors.New("The request does not carry a bearer token")
 	// ErrInvalidatedAuthorizeCode is an error indicating that an authorization code has been
 	// used previously.
 	ErrInvalidatedAuthorizeCode = errors.New("Authorization code has ben invalidated")
-	ErrUnknownRequest           = &RFC6749Error{
+	// ErrJTIKnown is returned by JTIStore if a JWT ID has already been used.
+	ErrJTIKnown       = errors.New("The jti has already been used")
+	ErrUnknownRequest = &RFC6749Error{
 		Name:        errUnknownErrorName,
 		Description: "The handler is not responsible for this request",
 		Code:        http.StatusBadRequest,
@@ -203,6 +207,21 @@ var (
 		Name:        errInvalidRequestObject,
 		Code:        http.StatusBadRequest,
 	}
+	ErrTooManyRequests = &RFC6749Error{
+		Description: "Too many failed attempts were made, please try again later",
+		Name:        errTooManyRequestsName,
+		Code:        http.StatusTooManyRequests,
+	}
+	ErrInsufficientUserAuthentication = &RFC6749Error{
+		Description: "The authentication event associated with the access token presented with the request does not meet the authentication requirements of the protected resource",
+		Name:        errInsufficientUserAuthName,
+		Code:        http.StatusUnauthorized,
+	}
+	ErrInvalidClientMetadata = &RFC6749Error{
+		Description: "The value of one of the client metadata fields is invalid and the server has rejected this request",
+		Name:        errInvalidClientMetadataName,
+		Code:        http.StatusBadRequest,
+	}
 )
 
 const (
@@ -239,6 +258,9 @@ const (
 	errRequestNotSupportedName      = "request_not_supported"
 	errRequestURINotSupportedName   = "request_uri_not_supported"
 	errRegistrationNotSupportedName = "registration_not_supported"
+	errTooManyRequestsName          = "too_many_requests"
+	errInsufficientUserAuthName     = "insufficient_user_authentication"
+	errInvalidClientMetadataName    = "invalid_client_metadata"
 )
 
 func ErrorToRFC6749Error(err error) *RFC6749Error {
@@ -252,15 +274,22 @@ func ErrorToRFC6749Error(err error) *RFC6749Error {
 		Description: "The error is unrecognizable.",
 		Debug:       err.Error(),
 		Code:        http.StatusInternalServerError,
+		cause:       err,
 	}
 }
 
 type RFC6749Error struct {
 	Name        string `json:"error"`
 	Description string `json:"error_description"`
+	URI         string `json:"error_uri,omitempty"`
 	Hint        string `json:"error_hint,omitempty"`
 	Code        int    `json:"status_code,omitempty"`
 	Debug       string `json:"error_debug,omitempty"`
+
+	// ID is the ID of the request which caused the error, see WithRequestID.
+	ID string `json:"request_id,omitempty"`
+
+	cause error
 }
 
 func (e *RFC6749Error) Status() string {
@@ -272,7 +301,15 @@ func (e *RFC6749Error) Error() string {
 }
 
 func (e *RFC6749Error) RequestID() string {
-	return ""
+	return e.ID
+}
+
+// WithRequestID returns a copy of the error carrying the ID of the request which caused it, so that the error can
+// be correlated with audit logs and traces.
+func (e *RFC6749Error) WithRequestID(id string) *RFC6749Error {
+	err := *e
+	err.ID = id
+	return &err
 }
 
 func (e *RFC6749Error) Reason() string {
@@ -307,6 +344,31 @@ func (e *RFC6749Error) WithDebugf(debug string, args ...interface{}) *RFC6749Err
 	return e.WithDebug(fmt.Sprintf(debug, args...))
 }
 
+// WithWrap returns a copy of the error which wraps cause, for example the storage error that lead to this error.
+// If no debug message is set yet, the message of cause is used as debug message. The cause can be retrieved
+// using Unwrap.
+func (e *RFC6749Error) WithWrap(cause error) *RFC6749Error {
+	err := *e
+	err.cause = cause
+	if err.Debug == "" && cause != nil {
+		err.Debug = cause.Error()
+	}
+	return &err
+}
+
+// Unwrap returns the error wrapped using WithWrap, if any.
+func (e *RFC6749Error) Unwrap() error {
+	return e.cause
+}
+
+// WithURI returns a copy of the error with the error_uri set, which identifies a human-readable web page with
+// information about the error, see https://tools.ietf.org/html/rfc6749#section-5.2
+func (e *RFC6749Error) WithURI(uri string) *RFC6749Error {
+	err := *e
+	err.URI = uri
+	return &err
+}
+
 func (e *RFC6749Error) WithDescription(description string) *RFC6749Error {
 	err := *e
 	err.Description = description
@@ -22,8 +22,10 @@
 package fosite
 
 import (
+	"net/http"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +35,25 @@ func TestAddDebug(t *testing.T) {
 	assert.Empty(t, ErrRevokationClientMismatch.Debug)
 	assert.NotEmpty(t, err.Debug)
 }
+
+func TestWithWrap(t *testing.T) {
+	cause := errors.New("database is gone")
+	err := ErrServerError.WithWrap(cause)
+	assert.Equal(t, cause, err.Unwrap())
+	assert.Equal(t, "database is gone", err.Debug)
+	assert.Nil(t, ErrServerError.Unwrap())
+	assert.Empty(t, ErrServerError.Debug)
+
+	err = ErrServerError.WithDebug("some debug").WithWrap(cause)
+	assert.Equal(t, "some debug", err.Debug)
+
+	unknown := ErrorToRFC6749Error(cause)
+	assert.Equal(t, cause, unknown.Unwrap())
+	assert.Equal(t, http.StatusInternalServerError, unknown.StatusCode())
+}
+
+func TestWithURI(t *testing.T) {
+	err := ErrInvalidRequest.WithURI("https://foo.bar/errors")
+	assert.Equal(t, "https://foo.bar/errors", err.URI)
+	assert.Empty(t, ErrInvalidRequest.URI)
+}
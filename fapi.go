@@ -0,0 +1,129 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// DefaultFAPIRequestObjectMaxLifetime is the maximum lifetime of request objects under the FAPI profile.
+const DefaultFAPIRequestObjectMaxLifetime = time.Hour
+
+// FAPIProfile bundles the restrictions of the FAPI 1.0 Advanced security profile, see
+// https://openid.net/specs/openid-financial-api-part-2-1_0.html#authorization-server. Setting Fosite.FAPIProfile
+// enforces that:
+//
+//   - Authorize requests are passed by value or by reference in a signed request object (JAR) which contains the
+//     "exp" and "nbf" claims, and is valid for at most RequestObjectMaxLifetime. Parameters outside of the request
+//     object are ignored.
+//   - The hybrid flow with response type "code id_token" is used, ID tokens issued at the authorization endpoint then
+//     contain the "s_hash" claim. JARM is not supported.
+//   - The redirect_uri parameter is always present.
+//   - PKCE is used with the S256 code challenge method.
+//   - Only confidential clients, which authenticate using private_key_jwt, are served.
+//
+// Pushed authorization requests and mutual TLS are not part of this library and need to be added by the
+// implementer if they are required.
+type FAPIProfile struct {
+	// RequestObjectMaxLifetime is the maximum time between the "nbf" and "exp" claims of request objects. Defaults to
+	// DefaultFAPIRequestObjectMaxLifetime.
+	RequestObjectMaxLifetime time.Duration
+}
+
+func (p *FAPIProfile) requestObjectMaxLifetime() time.Duration {
+	if p.RequestObjectMaxLifetime == 0 {
+		return DefaultFAPIRequestObjectMaxLifetime
+	}
+	return p.RequestObjectMaxLifetime
+}
+
+// validateRequestObject checks the signature algorithm and the lifetime of a request object.
+func (p *FAPIProfile) validateRequestObject(token *jwt.Token, claims jwt.MapClaims, now time.Time) error {
+	if token.Method == jwt.SigningMethodNone {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint("The request object must be signed."))
+	}
+
+	exp, hasExp := claims["exp"].(float64)
+	nbf, hasNbf := claims["nbf"].(float64)
+	if !hasExp || !hasNbf {
+		return errors.WithStack(ErrInvalidRequestObject.WithHint(`The request object must contain the "exp" and "nbf" claims.`))
+	}
+
+	expiresAt, notBefore := time.Unix(int64(exp), 0), time.Unix(int64(nbf), 0)
+	if expiresAt.Sub(notBefore) > p.requestObjectMaxLifetime() {
+		return errors.WithStack(ErrInvalidRequestObject.WithHintf(`The request object must not be valid for longer than %s.`, p.requestObjectMaxLifetime()))
+	} else if now.Sub(notBefore) > p.requestObjectMaxLifetime() {
+		return errors.WithStack(ErrInvalidRequestObject.WithHintf(`The "nbf" claim of the request object must not be more than %s in the past.`, p.requestObjectMaxLifetime()))
+	}
+	return nil
+}
+
+// requestObjectForm returns the parameters of the authorize request which are used under the profile: those of the
+// request object and the parameters which pass or identify it. Parameters outside of the request object are not
+// signed and are dropped, see https://openid.net/specs/openid-financial-api-part-2-1_0.html#authorization-server
+func (p *FAPIProfile) requestObjectForm(form url.Values, claims jwt.MapClaims) url.Values {
+	result := url.Values{}
+	for _, name := range []string{"client_id", "request", "request_uri"} {
+		if value := form.Get(name); value != "" {
+			result.Set(name, value)
+		}
+	}
+	for k, v := range claims {
+		result.Set(k, requestObjectClaimValue(v))
+	}
+	return result
+}
+
+// validateAuthorizeRequest checks the authorize request against the profile. The request object has been unpacked
+// into the form at this point, see requestObjectForm.
+func (p *FAPIProfile) validateAuthorizeRequest(request *AuthorizeRequest) error {
+	if request.GetClient().IsPublic() {
+		return errors.WithStack(ErrUnauthorizedClient.WithHint("Public clients are not allowed to use this server."))
+	} else if request.Form.Get("request") == "" && request.Form.Get("request_uri") == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint(`The authorize request must be passed in a signed request object using the "request" or "request_uri" parameter.`))
+	} else if !request.GetRequestedScopes().Has("openid") {
+		return errors.WithStack(ErrInvalidScope.WithHint(`The scope "openid" must be requested.`))
+	} else if !request.GetResponseTypes().Matches("code", "id_token") {
+		return errors.WithStack(ErrUnsupportedResponseType.WithHint(`The response type must be "code id_token".`))
+	} else if request.Form.Get("redirect_uri") == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint(`The "redirect_uri" parameter is required.`))
+	} else if request.Form.Get("code_challenge") == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint(`The "code_challenge" parameter is required.`))
+	} else if request.Form.Get("code_challenge_method") != "S256" {
+		return errors.WithStack(ErrInvalidRequest.WithHint(`The "code_challenge_method" parameter must be "S256".`))
+	}
+	return nil
+}
+
+// validateClientAuthentication checks that the client authenticated at the token endpoint using private_key_jwt.
+func (p *FAPIProfile) validateClientAuthentication(client Client, form url.Values) error {
+	if client.IsPublic() {
+		return errors.WithStack(ErrUnauthorizedClient.WithHint("Public clients are not allowed to use this server."))
+	} else if form.Get("client_assertion_type") != clientAssertionJWTBearerType {
+		return errors.WithStack(ErrInvalidClient.WithHint(`The client must authenticate using "private_key_jwt".`))
+	}
+	return nil
+}
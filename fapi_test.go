@@ -0,0 +1,126 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFAPIProfile_validateRequestObject(t *testing.T) {
+	p := new(FAPIProfile)
+	now := time.Now()
+	signed := &jwt.Token{Method: jwt.SigningMethodRS256}
+
+	for k, c := range []struct {
+		token     *jwt.Token
+		claims    jwt.MapClaims
+		expectErr bool
+	}{
+		{token: signed, claims: jwt.MapClaims{"nbf": float64(now.Unix()), "exp": float64(now.Add(time.Minute).Unix())}},
+		{token: &jwt.Token{Method: jwt.SigningMethodNone}, claims: jwt.MapClaims{"nbf": float64(now.Unix()), "exp": float64(now.Add(time.Minute).Unix())}, expectErr: true},
+		{token: signed, claims: jwt.MapClaims{"exp": float64(now.Add(time.Minute).Unix())}, expectErr: true},
+		{token: signed, claims: jwt.MapClaims{"nbf": float64(now.Unix())}, expectErr: true},
+		{token: signed, claims: jwt.MapClaims{"nbf": float64(now.Unix()), "exp": float64(now.Add(2 * time.Hour).Unix())}, expectErr: true},
+		{token: signed, claims: jwt.MapClaims{"nbf": float64(now.Add(-2 * time.Hour).Unix()), "exp": float64(now.Add(-90 * time.Minute).Unix())}, expectErr: true},
+	} {
+		err := p.validateRequestObject(c.token, c.claims, now)
+		assert.Equal(t, c.expectErr, err != nil, "%d: %v", k, err)
+	}
+}
+
+func TestFAPIProfile_requestObjectForm(t *testing.T) {
+	p := new(FAPIProfile)
+	form := url.Values{
+		"client_id":    {"foo"},
+		"request":      {"eyJ..."},
+		"scope":        {"openid"},
+		"redirect_uri": {"https://evil.example.com/cb"},
+		"state":        {"unsigned-state"},
+	}
+
+	assert.Equal(t, url.Values{
+		"client_id":    {"foo"},
+		"request":      {"eyJ..."},
+		"scope":        {"openid accounts"},
+		"redirect_uri": {"https://foo.example.com/cb"},
+	}, p.requestObjectForm(form, jwt.MapClaims{"scope": "openid accounts", "redirect_uri": "https://foo.example.com/cb"}))
+
+	// Claims are converted by their JSON type.
+	assert.Equal(t, url.Values{
+		"client_id":  {"foo"},
+		"request":    {"eyJ..."},
+		"max_age":    {"300"},
+		"acr_values": {"urn:a urn:b"},
+		"claims":     {`{"id_token":{"acr":{"essential":true}}}`},
+	}, p.requestObjectForm(form, jwt.MapClaims{
+		"max_age":    float64(300),
+		"acr_values": []interface{}{"urn:a", "urn:b"},
+		"claims":     map[string]interface{}{"id_token": map[string]interface{}{"acr": map[string]interface{}{"essential": true}}},
+	}))
+}
+
+func TestFAPIProfile_validateAuthorizeRequest(t *testing.T) {
+	p := new(FAPIProfile)
+	valid := func() *AuthorizeRequest {
+		ar := NewAuthorizeRequest()
+		ar.Client = &DefaultClient{ID: "foo"}
+		ar.ResponseTypes = Arguments{"id_token", "code"}
+		ar.SetRequestedScopes(Arguments{"openid"})
+		ar.Form = url.Values{
+			"request":               {"eyJ..."},
+			"redirect_uri":          {"https://foo.example.com/cb"},
+			"code_challenge":        {"challenge"},
+			"code_challenge_method": {"S256"},
+		}
+		return ar
+	}
+
+	assert.NoError(t, p.validateAuthorizeRequest(valid()))
+
+	for k, modify := range []func(ar *AuthorizeRequest){
+		func(ar *AuthorizeRequest) { ar.Client = &DefaultClient{Public: true} },
+		func(ar *AuthorizeRequest) { ar.Form.Del("request") },
+		func(ar *AuthorizeRequest) { ar.SetRequestedScopes(Arguments{"foo"}) },
+		func(ar *AuthorizeRequest) { ar.ResponseTypes = Arguments{"code"} },
+		func(ar *AuthorizeRequest) { ar.Form.Del("redirect_uri") },
+		func(ar *AuthorizeRequest) { ar.Form.Del("code_challenge") },
+		func(ar *AuthorizeRequest) { ar.Form.Set("code_challenge_method", "plain") },
+	} {
+		ar := valid()
+		modify(ar)
+		assert.Error(t, p.validateAuthorizeRequest(ar), "%d", k)
+	}
+}
+
+func TestFAPIProfile_validateClientAuthentication(t *testing.T) {
+	p := new(FAPIProfile)
+	form := url.Values{"client_assertion_type": {clientAssertionJWTBearerType}}
+
+	assert.NoError(t, p.validateClientAuthentication(&DefaultClient{}, form))
+	assert.Error(t, p.validateClientAuthentication(&DefaultClient{Public: true}, form))
+	assert.Error(t, p.validateClientAuthentication(&DefaultClient{}, url.Values{}))
+}
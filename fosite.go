@@ -23,63 +23,43 @@ package fosite
 
 import (
 	"net/http"
-	"reflect"
+	"time"
 )
 
 // AuthorizeEndpointHandlers is a list of AuthorizeEndpointHandler
 type AuthorizeEndpointHandlers []AuthorizeEndpointHandler
 
-// Append adds an AuthorizeEndpointHandler to this list. Ignores duplicates based on reflect.TypeOf.
+// Append adds an AuthorizeEndpointHandler to this list, ordered by its priority, see PrioritizedHandler. Ignores
+// duplicates based on reflect.TypeOf.
 func (a *AuthorizeEndpointHandlers) Append(h AuthorizeEndpointHandler) {
-	for _, this := range *a {
-		if reflect.TypeOf(this) == reflect.TypeOf(h) {
-			return
-		}
-	}
-
-	*a = append(*a, h)
+	appendHandler(a, h)
 }
 
 // TokenEndpointHandlers is a list of TokenEndpointHandler
 type TokenEndpointHandlers []TokenEndpointHandler
 
-// Append adds an TokenEndpointHandler to this list. Ignores duplicates based on reflect.TypeOf.
+// Append adds an TokenEndpointHandler to this list, ordered by its priority, see PrioritizedHandler. Ignores
+// duplicates based on reflect.TypeOf.
 func (t *TokenEndpointHandlers) Append(h TokenEndpointHandler) {
-	for _, this := range *t {
-		if reflect.TypeOf(this) == reflect.TypeOf(h) {
-			return
-		}
-	}
-
-	*t = append(*t, h)
+	appendHandler(t, h)
 }
 
 // TokenIntrospectionHandlers is a list of TokenValidator
 type TokenIntrospectionHandlers []TokenIntrospector
 
-// Add adds an AccessTokenValidator to this list. Ignores duplicates based on reflect.TypeOf.
+// Add adds an AccessTokenValidator to this list, ordered by its priority, see PrioritizedHandler. Ignores duplicates
+// based on reflect.TypeOf.
 func (t *TokenIntrospectionHandlers) Append(h TokenIntrospector) {
-	for _, this := range *t {
-		if reflect.TypeOf(this) == reflect.TypeOf(h) {
-			return
-		}
-	}
-
-	*t = append(*t, h)
+	appendHandler(t, h)
 }
 
 // RevocationHandlers is a list of RevocationHandler
 type RevocationHandlers []RevocationHandler
 
-// Append adds an RevocationHandler to this list. Ignores duplicates based on reflect.TypeOf.
+// Append adds an RevocationHandler to this list, ordered by its priority, see PrioritizedHandler. Ignores
+// duplicates based on reflect.TypeOf.
 func (t *RevocationHandlers) Append(h RevocationHandler) {
-	for _, this := range *t {
-		if reflect.TypeOf(this) == reflect.TypeOf(h) {
-			return
-		}
-	}
-
-	*t = append(*t, h)
+	appendHandler(t, h)
 }
 
 // Fosite implements OAuth2Provider.
@@ -94,11 +74,172 @@ type Fosite struct {
 	JWKSFetcherStrategy        JWKSFetcherStrategy
 	HTTPClient                 *http.Client
 
-	// TokenURL is the the URL of the Authorization Server's Token Endpoint.
+	// TokenURL is the the URL of the Authorization Server's Token Endpoint. Defaults to the token endpoint of
+	// IssuerConfig.
 	TokenURL string
 
+	// IssuerConfig, if set, is the issuer and the endpoint URLs of the authorization server. It is used to link error
+	// responses to its ErrorURI and to check the advertised metadata, see CheckMetadata.
+	IssuerConfig *IssuerConfig
+
+	// AuthorizationResponseIssParameter, if set to true, adds the issuer of the tenant or of IssuerConfig as the "iss"
+	// parameter to authorize responses and errors, which protects clients talking to several authorization servers
+	// against mix-up attacks, see https://tools.ietf.org/html/rfc9207
+	AuthorizationResponseIssParameter bool
+
 	// SendDebugMessagesToClients if set to true, includes error debug messages in response payloads. Be aware that sensitive
 	// data may be exposed, depending on your implementation of Fosite. Such sensitive data might include database error
 	// codes or other information. Proceed with caution!
 	SendDebugMessagesToClients bool
+
+	// ErrorHook, if set, receives every error before it is written to a client, including the debug details which are
+	// hidden from clients unless SendDebugMessagesToClients is enabled.
+	ErrorHook ErrorHook
+
+	// PanicHook, if set, receives panics recovered from endpoint handlers. Such panics are always answered with a
+	// server_error.
+	PanicHook PanicHook
+
+	// AccessResponseExtender, if set, adds custom members to successful token responses, see
+	// AccessResponseExtender.
+	AccessResponseExtender AccessResponseExtender
+
+	// AccessErrorStatusCodes overrides the HTTP status code written by WriteAccessError for the given error names,
+	// for example `map[string]int{"invalid_client": http.StatusBadRequest}`. Errors not listed here are answered with
+	// the status codes defined in https://tools.ietf.org/html/rfc6749#section-5.2.
+	AccessErrorStatusCodes map[string]int
+
+	// PolicyEngine, if set, is consulted before authorize and access requests are returned to the caller and may
+	// reject them. See OPAPolicyEngine for an implementation backed by Open Policy Agent.
+	PolicyEngine PolicyEngine
+
+	// PendingAuthorizationNotifier, if set, is notified when an authorize request is parked using ParkAuthorizeRequest.
+	PendingAuthorizationNotifier PendingAuthorizationNotifier
+
+	// SessionFactories create the session of access requests, keyed by grant type, if NewAccessRequest is called
+	// without a session. See ClientCredentialsSessionFactory for an example.
+	SessionFactories map[string]SessionFactory
+
+	// Clock provides the current time. Defaults to SystemClock.
+	Clock Clock
+
+	// ClockSkew is the leeway applied when validating the "exp", "nbf" and "iat" claims of JSON Web Tokens presented
+	// to the authorization server, such as client assertions.
+	ClockSkew time.Duration
+
+	// ScopeDescriptions, if set, is used by CheckMetadata to make sure every advertised scope is described.
+	ScopeDescriptions *ScopeDescriptions
+
+	// AuditLogger, if set, receives audit events for authorize requests, consent, token issuance, refresh and
+	// revocation, and failed client authentication.
+	AuditLogger AuditLogger
+
+	// Metrics, if set, receives request, handler and client lookup metrics. See PrometheusMetricsCollector.
+	Metrics MetricsCollector
+
+	// Tracer, if set, creates spans for the public API, endpoint handlers, client lookups, client secret comparisons
+	// and token generation. Other storage calls are not traced by fosite; the tracer is passed on to handlers and
+	// storage through the context, so that storage implementations can add their own spans, see StartSpan.
+	Tracer Tracer
+
+	// ThrottlingStrategy, if set, is consulted before client credentials are checked and may slow down or block
+	// brute-force attempts. See MemoryThrottlingStrategy.
+	ThrottlingStrategy ThrottlingStrategy
+
+	// TokenPrefixes are the prefixes of tokens by token type, they must match the ones the token strategies use. They
+	// are used by IntrospectTokenType.
+	TokenPrefixes map[TokenType]string
+
+	// MessageCatalog, if set, localizes the descriptions and hints of authorize and access error responses to the
+	// languages preferred by the end-user, see RequestLanguages. Defaults to English.
+	MessageCatalog MessageCatalog
+
+	// RepeatableParameters are the parameters which may be included more than once in authorize and token requests,
+	// for example "resource" (RFC 8707). All other parameters are rejected if they are repeated, as required by
+	// https://tools.ietf.org/html/rfc6749#section-3.1
+	RepeatableParameters []string
+
+	// FAPIProfile, if set, restricts authorize and token requests to the FAPI 1.0 Advanced security profile, see
+	// FAPIProfile.
+	FAPIProfile *FAPIProfile
+
+	// IdempotencyStorage, if set, stores token responses so that retried token requests return the originally issued
+	// tokens, see IdempotencyStorage.
+	IdempotencyStorage IdempotencyStorage
+
+	// IdempotencyWindow is the time during which retried token requests return the original response. Defaults to
+	// DefaultIdempotencyWindow.
+	IdempotencyWindow time.Duration
+
+	// RequestObjectPolicy restricts the "typ" and "alg" headers of request objects. Defaults to
+	// DefaultRequestObjectPolicy.
+	RequestObjectPolicy *JWTValidationPolicy
+
+	// ClientAssertionPolicy restricts the "typ" and "alg" headers of client assertions. Defaults to
+	// DefaultClientAssertionPolicy.
+	ClientAssertionPolicy *JWTValidationPolicy
+
+	// JTIStore, if set, rejects client assertions whose "jti" has already been used, see JTIStore.
+	JTIStore JTIStore
+
+	// ClientAssertionMaxLifetime is how far in the future client assertions may expire if JTIStore is set, which
+	// bounds how long their "jti" has to be remembered. Defaults to DefaultClientAssertionMaxLifetime.
+	ClientAssertionMaxLifetime time.Duration
+
+	// SecretPolicy is the minimum strength of client secrets passed to HashClientSecret. Defaults to
+	// DefaultSecretPolicy.
+	SecretPolicy *SecretPolicy
+
+	// GrantStore, if set, records the scopes granted by end-users so that authorize requests with
+	// include_granted_scopes=true are granted the scopes granted before as well, see GrantStore.
+	GrantStore GrantStore
+
+	// ConsentStore, if set, remembers the consent decisions of end-users, see PriorConsent and RememberConsent.
+	ConsentStore ConsentStore
+
+	// RedirectURIPolicy restricts the schemes of redirect URIs, see RedirectURIPolicy. If it is not set, only the
+	// DefaultForbiddenRedirectURISchemes are rejected.
+	RedirectURIPolicy *RedirectURIPolicy
+
+	// ClientManager, if set, loads clients instead of Store, for example a storage.ClientCache wrapping Store.
+	ClientManager ClientManager
+
+	// TenantResolver resolves the tenant of requests passed through TenantHandler, see Tenant.
+	TenantResolver TenantResolver
+
+	// ConfigProvider, if set, overrides the scope strategy and restricts the response modes per request, see
+	// ConfigProvider.
+	ConfigProvider ConfigProvider
+
+	// AuthorizeResponseWriter, if set, renders authorize responses and errors instead of
+	// DefaultAuthorizeResponseWriter.
+	AuthorizeResponseWriter AuthorizeResponseWriter
+
+	// MaxRequestBodySize is the maximum size in bytes of the body of POST authorize and token requests. Defaults to
+	// DefaultMaxRequestBodySize.
+	MaxRequestBodySize int64
+
+	// MaxRequestParameters is the maximum number of parameter values in authorize and token requests. Defaults to
+	// DefaultMaxRequestParameters.
+	MaxRequestParameters int
+
+	// MaxScopes is the maximum number of scopes requested in authorize and token requests. Defaults to
+	// DefaultMaxScopes.
+	MaxScopes int
+
+	// MaxScopeLength is the maximum length in bytes of a scope requested in authorize and token requests. Defaults
+	// to DefaultMaxScopeLength.
+	MaxScopeLength int
+
+	// MinParameterEntropy is the minimum number of characters of the state and nonce parameters. Defaults to
+	// MinParameterEntropy. It is passed on to the endpoint handlers through the context, see
+	// MinParameterEntropyFromContext.
+	MinParameterEntropy int
+}
+
+func (f *Fosite) minParameterEntropy() int {
+	if f.MinParameterEntropy == 0 {
+		return MinParameterEntropy
+	}
+	return f.MinParameterEntropy
 }
@@ -53,6 +53,36 @@ func TestTokenEndpointHandlers(t *testing.T) {
 	assert.Equal(t, hs[0], h)
 }
 
+type earlyAuthorizeHandler struct {
+	oauth2.AuthorizeExplicitGrantHandler
+}
+
+func (*earlyAuthorizeHandler) HandlerPriority() int { return -10 }
+
+type lateAuthorizeHandler struct {
+	oauth2.AuthorizeExplicitGrantHandler
+}
+
+func (*lateAuthorizeHandler) HandlerPriority() int { return 10 }
+
+type otherAuthorizeHandler struct {
+	oauth2.AuthorizeExplicitGrantHandler
+}
+
+func TestAuthorizeEndpointHandlersPriority(t *testing.T) {
+	late := &lateAuthorizeHandler{}
+	explicit := &oauth2.AuthorizeExplicitGrantHandler{}
+	other := &otherAuthorizeHandler{}
+	early := &earlyAuthorizeHandler{}
+
+	hs := AuthorizeEndpointHandlers{}
+	hs.Append(late)
+	hs.Append(explicit)
+	hs.Append(other)
+	hs.Append(early)
+	assert.Equal(t, AuthorizeEndpointHandlers{early, explicit, other, late}, hs)
+}
+
 func TestAuthorizedRequestValidators(t *testing.T) {
 	h := &oauth2.CoreValidator{}
 	hs := TokenIntrospectionHandlers{}
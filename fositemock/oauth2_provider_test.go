@@ -0,0 +1,58 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fositemock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ fosite.OAuth2Provider = new(MockOAuth2Provider)
+
+func TestMockOAuth2Provider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := NewMockOAuth2Provider(ctrl)
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		if _, err := provider.ValidateToken(r.Context(), r, nil, "photos"); err != nil {
+			provider.WriteBearerError(rw, err, "photos")
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}
+
+	req := httptest.NewRequest("GET", "/photos", nil)
+	provider.EXPECT().ValidateToken(gomock.Any(), req, nil, "photos").Return(nil, fosite.ErrRequestUnauthorized)
+	provider.EXPECT().WriteBearerError(gomock.Any(), fosite.ErrRequestUnauthorized, "photos").Do(func(rw http.ResponseWriter, _ error, _ ...string) {
+		rw.WriteHeader(http.StatusUnauthorized)
+	})
+
+	rw := httptest.NewRecorder()
+	handler(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
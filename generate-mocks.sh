@@ -26,5 +26,6 @@ mockgen -package internal -destination internal/access_request.go github.com/ory
 mockgen -package internal -destination internal/access_response.go github.com/ory/fosite AccessResponder
 mockgen -package internal -destination internal/authorize_request.go github.com/ory/fosite AuthorizeRequester
 mockgen -package internal -destination internal/authorize_response.go github.com/ory/fosite AuthorizeResponder
+mockgen -package fositemock -destination fositemock/oauth2_provider.go github.com/ory/fosite OAuth2Provider
 
-goimports -w internal/
\ No newline at end of file
+goimports -w internal/ fositemock/
\ No newline at end of file
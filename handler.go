@@ -23,6 +23,7 @@ package fosite
 
 import (
 	"context"
+	"reflect"
 )
 
 type AuthorizeEndpointHandler interface {
@@ -67,3 +68,52 @@ type RevocationHandler interface {
 	// RevokeToken handles access and refresh token revocation.
 	RevokeToken(ctx context.Context, token string, tokenType TokenType, client Client) error
 }
+
+// ResponseTypesHandler may be implemented by an AuthorizeEndpointHandler to declare the response types it handles,
+// for example "code" or "code id_token". It is used by CheckMetadata.
+type ResponseTypesHandler interface {
+	SupportedResponseTypes() []string
+}
+
+// GrantTypesHandler may be implemented by a TokenEndpointHandler to declare the grant types it handles, for example
+// "refresh_token". It is used by CheckMetadata.
+type GrantTypesHandler interface {
+	SupportedGrantTypes() []string
+}
+
+// DefaultHandlerPriority is the priority of handlers which do not implement PrioritizedHandler.
+const DefaultHandlerPriority = 0
+
+// PrioritizedHandler may be implemented by any handler to control the order in which it is invoked. The handler lists
+// of Fosite keep their handlers sorted by ascending priority, handlers with equal priorities are invoked in the order
+// in which they were appended.
+type PrioritizedHandler interface {
+	HandlerPriority() int
+}
+
+func handlerPriority(h interface{}) int {
+	if p, ok := h.(PrioritizedHandler); ok {
+		return p.HandlerPriority()
+	}
+	return DefaultHandlerPriority
+}
+
+// appendHandler inserts h into the handler list pointed to by list, after all handlers with a lower or equal
+// priority. Nothing is inserted if the list already contains a handler of the same type.
+func appendHandler(list interface{}, h interface{}) {
+	handlers := reflect.ValueOf(list).Elem()
+	for i := 0; i < handlers.Len(); i++ {
+		if reflect.TypeOf(handlers.Index(i).Interface()) == reflect.TypeOf(h) {
+			return
+		}
+	}
+
+	i := handlers.Len()
+	for i > 0 && handlerPriority(handlers.Index(i-1).Interface()) > handlerPriority(h) {
+		i--
+	}
+
+	handlers.Set(reflect.Append(handlers, reflect.Zero(handlers.Type().Elem())))
+	reflect.Copy(handlers.Slice(i+1, handlers.Len()), handlers.Slice(i, handlers.Len()-1))
+	handlers.Index(i).Set(reflect.ValueOf(h))
+}
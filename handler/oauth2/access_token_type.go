@@ -0,0 +1,53 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+)
+
+// Access token types as registered in https://tools.ietf.org/html/rfc6749#section-7.1 and
+// https://datatracker.ietf.org/doc/html/draft-ietf-oauth-dpop
+const (
+	AccessTokenTypeBearer = "bearer"
+	AccessTokenTypeDPoP   = "DPoP"
+	AccessTokenTypeMAC    = "mac"
+)
+
+// AccessTokenTypeStrategy is implemented by AccessTokenStrategies which issue access tokens that are not bearer
+// tokens, for example DPoP-bound or MAC tokens, so that the token_type of the response tells the client how to use
+// the token. Access tokens of strategies which do not implement it are bearer tokens.
+type AccessTokenTypeStrategy interface {
+	// AccessTokenType returns the token_type of the access token issued for requester.
+	AccessTokenType(ctx context.Context, requester fosite.Requester) string
+}
+
+func accessTokenType(ctx context.Context, strategy AccessTokenStrategy, requester fosite.Requester) string {
+	if s, ok := strategy.(AccessTokenTypeStrategy); ok {
+		if tokenType := s.AccessTokenType(ctx, requester); tokenType != "" {
+			return tokenType
+		}
+	}
+	return AccessTokenTypeBearer
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// VerifyCodeChallenge checks the PKCE code verifier sent to the token endpoint against the code challenge and method
+// of the authorize request, see https://tools.ietf.org/html/rfc7636#section-4.6
+//
+// The verifier is hashed with SHA-256 if method is "S256", any other method compares the verifier and the challenge
+// directly.
+func VerifyCodeChallenge(challenge, method, verifier string) error {
+	if method != "S256" {
+		if subtle.ConstantTimeCompare([]byte(verifier), []byte(challenge)) != 1 {
+			return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The PKCE code challenge did not match the code verifier."))
+		}
+		return nil
+	}
+
+	// NOTE: The code verifier SHOULD have enough entropy to make it
+	//	impractical to guess the value.  It is RECOMMENDED that the output of
+	//	a suitable random number generator be used to create a 32-octet
+	//	sequence.  The octet sequence is then base64url-encoded to produce a
+	//	43-octet URL safe string to use as the code verifier.
+	verifierLength := base64.RawURLEncoding.DecodedLen(len(verifier))
+	if verifierLength < 32 {
+		return errors.WithStack(fosite.ErrInsufficientEntropy.WithHint("The PKCE code verifier must contain at least 32 octets."))
+	}
+
+	if _, err := base64.RawURLEncoding.DecodeString(verifier); err != nil {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHint("Unable to decode code_verifier using base64 url decoding without padding.").WithDebug(err.Error()))
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(hash[:])), []byte(challenge)) != 1 {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The PKCE code challenge did not match the code verifier."))
+	}
+	return nil
+}
+
+// verifyCodeBinding checks that the code verifier of the token request matches the code challenge the authorize code
+// was bound to. A code verifier sent for an authorize code without code challenge is rejected as well, as it indicates
+// an attempt to inject a code which was not protected by PKCE. Nothing is checked if the code challenge is not kept
+// with authorize codes, see GetSanitationWhiteList.
+func (c *AuthorizeExplicitGrantHandler) verifyCodeBinding(authorizeRequest, request fosite.Requester) error {
+	if !fosite.StringInSlice("code_challenge", c.GetSanitationWhiteList()) {
+		return nil
+	}
+
+	challenge := authorizeRequest.GetRequestForm().Get("code_challenge")
+	verifier := request.GetRequestForm().Get("code_verifier")
+	if challenge == "" && verifier != "" {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The \"code_verifier\" parameter was sent, but the authorization code is not bound to a PKCE code challenge."))
+	} else if challenge == "" {
+		return nil
+	} else if verifier == "" {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The authorization code is bound to a PKCE code challenge, but the \"code_verifier\" parameter is missing."))
+	}
+	return VerifyCodeChallenge(challenge, authorizeRequest.GetRequestForm().Get("code_challenge_method"), verifier)
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	verifier := "11111111111111111111111111111111111111111111111111111111111111111111"
+	hash := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	for k, c := range []struct {
+		challenge string
+		method    string
+		verifier  string
+		expectErr error
+	}{
+		{challenge: challenge, method: "S256", verifier: verifier},
+		{challenge: challenge, method: "S256", verifier: verifier + "2", expectErr: fosite.ErrInvalidGrant},
+		{challenge: challenge, method: "S256", verifier: "short", expectErr: fosite.ErrInsufficientEntropy},
+		{challenge: challenge, method: "S256", verifier: verifier + "=", expectErr: fosite.ErrInvalidGrant},
+		{challenge: "foo", method: "plain", verifier: "foo"},
+		{challenge: "foo", method: "", verifier: "foo"},
+		{challenge: "foo", method: "plain", verifier: "bar", expectErr: fosite.ErrInvalidGrant},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := VerifyCodeChallenge(c.challenge, c.method, c.verifier)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyCodeBinding(t *testing.T) {
+	request := func(form url.Values) fosite.Requester {
+		r := fosite.NewRequest()
+		r.Form = form
+		return r
+	}
+
+	for k, c := range []struct {
+		d         string
+		whitelist []string
+		authorize url.Values
+		token     url.Values
+		expectErr error
+	}{
+		{
+			d: "passes because no code challenge was used",
+		},
+		{
+			d:         "passes because the code verifier matches",
+			authorize: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"plain"}},
+			token:     url.Values{"code_verifier": {"foo"}},
+		},
+		{
+			d:         "fails because the code verifier does not match",
+			authorize: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"plain"}},
+			token:     url.Values{"code_verifier": {"bar"}},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			d:         "fails because the code verifier is missing",
+			authorize: url.Values{"code_challenge": {"foo"}, "code_challenge_method": {"plain"}},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			d:         "fails because a code verifier was sent for a code without code challenge",
+			token:     url.Values{"code_verifier": {"foo"}},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			d:         "passes because the code challenge is not kept with authorize codes",
+			whitelist: []string{"redirect_uri"},
+			token:     url.Values{"code_verifier": {"foo"}},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			h := &AuthorizeExplicitGrantHandler{SanitationWhiteList: c.whitelist}
+			err := h.verifyCodeBinding(request(c.authorize), request(c.token))
+			if c.expectErr != nil {
+				assert.EqualError(t, err, c.expectErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -46,6 +46,9 @@ type AuthorizeExplicitGrantHandler struct {
 	// AccessTokenLifespan defines the lifetime of an access token.
 	AccessTokenLifespan time.Duration
 
+	// RefreshTokenLifespan defines the lifetime of a refresh token. Refresh tokens do not expire if set to zero.
+	RefreshTokenLifespan time.Duration
+
 	ScopeStrategy fosite.ScopeStrategy
 
 	// SanitationWhiteList is a whitelist of form values that are required by the token endpoint. These values
@@ -53,6 +56,15 @@ type AuthorizeExplicitGrantHandler struct {
 	SanitationWhiteList []string
 
 	TokenRevocationStorage TokenRevocationStorage
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// RefreshTokenPolicy decides whether refresh tokens are issued. Defaults to OfflineAccessRefreshTokenPolicy.
+	RefreshTokenPolicy RefreshTokenPolicy
+
+	// ConfigProvider, if set, overrides the lifespans and the scope strategy per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 func (c *AuthorizeExplicitGrantHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -66,13 +78,17 @@ func (c *AuthorizeExplicitGrantHandler) HandleAuthorizeEndpointRequest(ctx conte
 	// 	 return errors.WithStack(fosite.ErrInvalidGrant)
 	// }
 
+	if !ar.GetClient().GetGrantTypes().Has("authorization_code") {
+		return errors.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant \"authorization_code\"."))
+	}
+
 	if !fosite.IsRedirectURISecure(ar.GetRedirectURI()) {
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Redirect URL is using an insecure protocol, http is only allowed for hosts with suffix `localhost`, for example: http://myapp.localhost/."))
 	}
 
 	client := ar.GetClient()
 	for _, scope := range ar.GetRequestedScopes() {
-		if !c.ScopeStrategy(client.GetScopes(), scope) {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
 			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
 		}
 	}
@@ -86,7 +102,7 @@ func (c *AuthorizeExplicitGrantHandler) IssueAuthorizeCode(ctx context.Context,
 		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
 	}
 
-	ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, time.Now().UTC().Add(c.AuthCodeLifespan))
+	ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, ar.GetClient(), "authorization_code", fosite.AuthorizeCode, c.AuthCodeLifespan)))
 	if err := c.CoreStorage.CreateAuthorizeCodeSession(ctx, signature, ar.Sanitize(c.GetSanitationWhiteList())); err != nil {
 		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
 	}
@@ -105,5 +121,12 @@ func (c *AuthorizeExplicitGrantHandler) GetSanitationWhiteList() []string {
 	return []string{
 		"code",
 		"redirect_uri",
+		"code_challenge",
+		"code_challenge_method",
 	}
 }
+
+// SupportedResponseTypes implements fosite.ResponseTypesHandler.
+func (c *AuthorizeExplicitGrantHandler) SupportedResponseTypes() []string {
+	return []string{"code"}
+}
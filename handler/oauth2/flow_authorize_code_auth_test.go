@@ -85,6 +85,21 @@ func TestAuthorizeCode_HandleAuthorizeEndpointRequest(t *testing.T) {
 					description: "should fail because redirect uri is not https",
 					expectErr:   fosite.ErrInvalidRequest,
 				},
+				{
+					areq: &fosite.AuthorizeRequest{
+						ResponseTypes: fosite.Arguments{"code"},
+						Request: fosite.Request{
+							Client: &fosite.DefaultClient{
+								ResponseTypes: fosite.Arguments{"code"},
+								GrantTypes:    fosite.Arguments{"implicit"},
+								RedirectURIs:  []string{"https://asdf.de/cb"},
+							},
+						},
+						RedirectURI: parseUrl("https://asdf.de/cb"),
+					},
+					description: "should fail because client is not allowed to use the authorization code grant",
+					expectErr:   fosite.ErrUnauthorizedClient,
+				},
 				{
 					areq: &fosite.AuthorizeRequest{
 						ResponseTypes: fosite.Arguments{"code"},
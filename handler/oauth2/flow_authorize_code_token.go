@@ -23,9 +23,9 @@ package oauth2
 
 import (
 	"context"
-	"time"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
 	"github.com/pkg/errors"
 )
 
@@ -46,25 +46,7 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 	signature := c.AuthorizeCodeStrategy.AuthorizeCodeSignature(code)
 	authorizeRequest, err := c.CoreStorage.GetAuthorizeCodeSession(ctx, signature, request.GetSession())
 	if errors.Cause(err) == fosite.ErrInvalidatedAuthorizeCode {
-		if authorizeRequest == nil {
-			return fosite.ErrServerError.
-				WithHint("Misconfigured code lead to an error that prohibited the OAuth 2.0 Framework from processing this request.").
-				WithDebug("GetAuthorizeCodeSession must return a value for \"fosite.Requester\" when returning \"ErrInvalidatedAuthorizeCode\".")
-		}
-
-		//If an authorize code is used twice, we revoke all refresh and access tokens associated with this request.
-		reqID := authorizeRequest.GetID()
-		hint := "The authorization code has already been used."
-		debug := ""
-		if revErr := c.TokenRevocationStorage.RevokeAccessToken(ctx, reqID); revErr != nil {
-			hint += " Additionally, an error occurred during processing the access token revocation."
-			debug += "Revokation of access_token lead to error " + revErr.Error() + "."
-		}
-		if revErr := c.TokenRevocationStorage.RevokeRefreshToken(ctx, reqID); revErr != nil {
-			hint += " Additionally, an error occurred during processing the refresh token revocation."
-			debug += "Revokation of refresh_token lead to error " + revErr.Error() + "."
-		}
-		return errors.WithStack(fosite.ErrInvalidGrant.WithHint(hint).WithDebug(debug))
+		return c.revokeReusedAuthorizeCode(ctx, authorizeRequest)
 	} else if err != nil && errors.Cause(err).Error() == fosite.ErrNotFound.Error() {
 		return errors.WithStack(fosite.ErrInvalidGrant.WithDebug(err.Error()))
 	} else if err != nil {
@@ -84,7 +66,7 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 	// confidential client, or if the client is public, ensure that the
 	// code was issued to "client_id" in the request,
 	if authorizeRequest.GetClient().GetID() != request.GetClient().GetID() {
-		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the authorize request."))
+		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("The authorization code was issued to another OAuth 2.0 Client than the one of this request."))
 	}
 
 	// ensure that the "redirect_uri" parameter is present if the
@@ -92,17 +74,26 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 	// request as described in Section 4.1.1, and if included ensure that
 	// their values are identical.
 	forcedRedirectURI := authorizeRequest.GetRequestForm().Get("redirect_uri")
-	if forcedRedirectURI != "" && forcedRedirectURI != request.GetRequestForm().Get("redirect_uri") {
+	if forcedRedirectURI != "" && request.GetRequestForm().Get("redirect_uri") == "" {
+		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("The \"redirect_uri\" parameter is required because it was included in the authorize request."))
+	} else if forcedRedirectURI != "" && !fosite.EqualRedirectURIs(forcedRedirectURI, request.GetRequestForm().Get("redirect_uri")) {
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("The \"redirect_uri\" from this request does not match the one from the authorize request."))
 	}
 
+	// The code verifier must match the code challenge the authorization code is bound to, see
+	// https://tools.ietf.org/html/rfc7636#section-4.6
+	if err := c.verifyCodeBinding(authorizeRequest, request); err != nil {
+		return err
+	}
+
 	// Checking of POST client_id skipped, because:
 	// If the client type is confidential or the client was issued client
 	// credentials (or assigned other authentication requirements), the
 	// client MUST authenticate with the authorization server as described
 	// in Section 3.2.1.
 	request.SetSession(authorizeRequest.GetSession())
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, request.GetClient(), "authorization_code", fosite.AccessToken, c.AccessTokenLifespan)))
+	setRefreshTokenExpiry(ctx, c.ConfigProvider, request, "authorization_code", c.RefreshTokenLifespan, fosite.Now(c.Clock))
 	request.SetID(authorizeRequest.GetID())
 	return nil
 }
@@ -117,7 +108,10 @@ func (c *AuthorizeExplicitGrantHandler) PopulateTokenEndpointResponse(ctx contex
 	code := requester.GetRequestForm().Get("code")
 	signature := c.AuthorizeCodeStrategy.AuthorizeCodeSignature(code)
 	authorizeRequest, err := c.CoreStorage.GetAuthorizeCodeSession(ctx, signature, requester.GetSession())
-	if err != nil {
+	if errors.Cause(err) == fosite.ErrInvalidatedAuthorizeCode {
+		// The authorization code was redeemed by a concurrent request after this request was validated.
+		return c.revokeReusedAuthorizeCode(ctx, authorizeRequest)
+	} else if err != nil {
 		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
 	} else if err := c.AuthorizeCodeStrategy.ValidateAuthorizeCode(ctx, requester, code); err != nil {
 		// This needs to happen after store retrieval for the session to be hydrated properly
@@ -134,26 +128,46 @@ func (c *AuthorizeExplicitGrantHandler) PopulateTokenEndpointResponse(ctx contex
 	}
 
 	var refresh, refreshSignature string
-	if authorizeRequest.GetGrantedScopes().HasOneOf("offline", "offline_access") {
+	if canRefresh(c.RefreshTokenPolicy, authorizeRequest) {
 		refresh, refreshSignature, err = c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
 		if err != nil {
 			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
 		}
 	}
 
-	if err := c.CoreStorage.InvalidateAuthorizeCodeSession(ctx, signature); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if err := c.CoreStorage.CreateAccessTokenSession(ctx, accessSignature, requester.Sanitize([]string{})); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if refreshSignature != "" {
-		if err := c.CoreStorage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
+	if err := storage.RunInTransaction(ctx, c.CoreStorage, func(ctx context.Context) error {
+		if err := c.CoreStorage.InvalidateAuthorizeCodeSession(ctx, signature); errors.Cause(err) == fosite.ErrInvalidatedAuthorizeCode {
+			return err
+		} else if err != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		} else if err := c.CoreStorage.CreateAccessTokenSession(ctx, accessSignature, requester.Sanitize([]string{})); err != nil {
 			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		} else if refreshSignature != "" {
+			if err := c.CoreStorage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			}
 		}
+
+		if lineage, ok := c.CoreStorage.(TokenLineageStorage); ok {
+			if err := recordTokenLineage(ctx, lineage, requester.GetID(), signature, map[fosite.TokenType]string{
+				fosite.AccessToken:  accessSignature,
+				fosite.RefreshToken: refreshSignature,
+			}, fosite.Now(c.Clock)); err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			}
+		}
+		return nil
+	}); errors.Cause(err) == fosite.ErrInvalidatedAuthorizeCode {
+		// A concurrent request redeemed the code in the meantime. The tokens are revoked after the transaction
+		// was rolled back, so that the revocation is not rolled back with it.
+		return c.revokeReusedAuthorizeCode(ctx, authorizeRequest)
+	} else if err != nil {
+		return err
 	}
 
 	responder.SetAccessToken(access)
-	responder.SetTokenType("bearer")
-	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetTokenType(accessTokenType(ctx, c.AccessTokenStrategy, requester))
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, fosite.Now(c.Clock)))
 	responder.SetScopes(requester.GetGrantedScopes())
 	if refresh != "" {
 		responder.SetExtra("refresh_token", refresh)
@@ -161,3 +175,38 @@ func (c *AuthorizeExplicitGrantHandler) PopulateTokenEndpointResponse(ctx contex
 
 	return nil
 }
+
+// revokeReusedAuthorizeCode implements https://tools.ietf.org/html/rfc6749#section-4.1.2
+// If an authorization code is used more than once, the authorization server MUST deny the request and SHOULD revoke
+// (when possible) all tokens previously issued based on that authorization code.
+func (c *AuthorizeExplicitGrantHandler) revokeReusedAuthorizeCode(ctx context.Context, authorizeRequest fosite.Requester) error {
+	if authorizeRequest == nil {
+		return fosite.ErrServerError.
+			WithHint("Misconfigured code lead to an error that prohibited the OAuth 2.0 Framework from processing this request.").
+			WithDebug("GetAuthorizeCodeSession must return a value for \"fosite.Requester\" when returning \"ErrInvalidatedAuthorizeCode\".")
+	}
+
+	//If an authorize code is used twice, we revoke all refresh and access tokens associated with this request.
+	ctx = fosite.ContextWithRevocationReason(ctx, fosite.RevocationReasonCompromise)
+	reqID := authorizeRequest.GetID()
+	hint := "The authorization code has already been used."
+	debug := ""
+	if revErr := c.TokenRevocationStorage.RevokeAccessToken(ctx, reqID); revErr != nil {
+		hint += " Additionally, an error occurred during processing the access token revocation."
+		debug += "Revokation of access_token lead to error " + revErr.Error() + "."
+	}
+	if revErr := c.TokenRevocationStorage.RevokeRefreshToken(ctx, reqID); revErr != nil {
+		hint += " Additionally, an error occurred during processing the refresh token revocation."
+		debug += "Revokation of refresh_token lead to error " + revErr.Error() + "."
+	}
+	if revErr := revokeTokenLineage(ctx, c.TokenRevocationStorage, reqID); revErr != nil {
+		hint += " Additionally, an error occurred during processing the token lineage revocation."
+		debug += "Revokation of token lineage lead to error " + revErr.Error() + "."
+	}
+	return errors.WithStack(fosite.ErrInvalidGrant.WithHint(hint).WithDebug(debug))
+}
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *AuthorizeExplicitGrantHandler) SupportedGrantTypes() []string {
+	return []string{"authorization_code"}
+}
@@ -23,6 +23,7 @@ package oauth2
 
 import (
 	"net/url"
+	"sync"
 	"testing"
 	//"time"
 
@@ -284,6 +285,32 @@ func TestAuthorizeCode_HandleTokenEndpointRequest(t *testing.T) {
 					},
 					expectErr: fosite.ErrInvalidRequest,
 				},
+				{
+					areq: &fosite.AccessRequest{
+						GrantTypes: fosite.Arguments{"authorization_code"},
+						Request: fosite.Request{
+							Client:      &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}},
+							Session:     &fosite.DefaultSession{},
+							RequestedAt: time.Now().UTC(),
+						},
+					},
+					authreq: &fosite.AuthorizeRequest{
+						Request: fosite.Request{
+							Client:  &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}},
+							Form:    url.Values{"redirect_uri": []string{"https://foo.com/cb"}},
+							Session: &fosite.DefaultSession{},
+						},
+					},
+					description: "should fail because redirect uri of the /token call does not exactly match the one of the /authorize call",
+					setup: func(t *testing.T, areq *fosite.AccessRequest, authreq *fosite.AuthorizeRequest) {
+						token, signature, err := strategy.GenerateAuthorizeCode(nil, nil)
+						require.NoError(t, err)
+						areq.Form = url.Values{"code": {token}, "redirect_uri": {"https://foo.com/cb/"}}
+
+						require.NoError(t, store.CreateAuthorizeCodeSession(nil, signature, authreq))
+					},
+					expectErr: fosite.ErrInvalidRequest,
+				},
 				{
 					areq: &fosite.AccessRequest{
 						GrantTypes: fosite.Arguments{"authorization_code"},
@@ -354,3 +381,253 @@ func TestAuthorizeCode_HandleTokenEndpointRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizeCode_ReuseRevokesTokenLineage(t *testing.T) {
+	store := storage.NewMemoryStore()
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code", "refresh_token"}}
+	code := AuthorizeExplicitGrantHandler{
+		CoreStorage:            store,
+		AuthorizeCodeStrategy:  hmacshaStrategy,
+		AccessTokenStrategy:    hmacshaStrategy,
+		RefreshTokenStrategy:   hmacshaStrategy,
+		ScopeStrategy:          fosite.HierarchicScopeStrategy,
+		TokenRevocationStorage: store,
+		AuthCodeLifespan:       time.Minute,
+		AccessTokenLifespan:    time.Hour,
+	}
+	refresh := RefreshTokenGrantHandler{
+		TokenRevocationStorage: store,
+		AccessTokenStrategy:    hmacshaStrategy,
+		RefreshTokenStrategy:   hmacshaStrategy,
+		AccessTokenLifespan:    time.Hour,
+	}
+
+	authorizeCode, codeSignature, err := hmacshaStrategy.GenerateAuthorizeCode(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAuthorizeCodeSession(nil, codeSignature, &fosite.Request{
+		ID:            "req-id",
+		Client:        client,
+		GrantedScopes: fosite.Arguments{"offline"},
+		Session:       &fosite.DefaultSession{},
+		RequestedAt:   time.Now().UTC(),
+	}))
+
+	exchange := func(h fosite.TokenEndpointHandler, grantType string, form url.Values) (*fosite.AccessResponse, error) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{grantType}
+		areq.Client = client
+		areq.Form = form
+		if err := h.HandleTokenEndpointRequest(nil, areq); err != nil {
+			return nil, err
+		}
+
+		aresp := fosite.NewAccessResponse()
+		return aresp, h.PopulateTokenEndpointResponse(nil, areq, aresp)
+	}
+
+	first, err := exchange(&code, "authorization_code", url.Values{"code": {authorizeCode}})
+	require.NoError(t, err)
+	firstRefresh := first.ToMap()["refresh_token"].(string)
+	second, err := exchange(&refresh, "refresh_token", url.Values{"refresh_token": {firstRefresh}})
+	require.NoError(t, err)
+
+	lineage := store.TokenLineages["req-id"]
+	require.Len(t, lineage, 4)
+	assert.Equal(t, fosite.AccessToken, lineage[0].TokenType)
+	assert.Equal(t, codeSignature, lineage[0].ParentSignature)
+	assert.Equal(t, fosite.RefreshToken, lineage[1].TokenType)
+	assert.Equal(t, hmacshaStrategy.RefreshTokenSignature(firstRefresh), lineage[1].Signature)
+	assert.Equal(t, lineage[1].Signature, lineage[3].ParentSignature)
+	assert.Equal(t, hmacshaStrategy.RefreshTokenSignature(second.ToMap()["refresh_token"].(string)), lineage[3].Signature)
+
+	// Simulate a storage which does not index tokens by request ID, the lineage must still revoke all tokens.
+	store.AccessTokenRequestIDs = map[string]string{}
+	store.RefreshTokenRequestIDs = map[string]string{}
+
+	_, err = exchange(&code, "authorization_code", url.Values{"code": {authorizeCode}})
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	assert.Empty(t, store.AccessTokens)
+	assert.Empty(t, store.RefreshTokens)
+}
+
+func TestAuthorizeCode_ConcurrentRedemptionRevokesTokens(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:            store,
+		AuthorizeCodeStrategy:  hmacshaStrategy,
+		AccessTokenStrategy:    hmacshaStrategy,
+		RefreshTokenStrategy:   hmacshaStrategy,
+		ScopeStrategy:          fosite.HierarchicScopeStrategy,
+		TokenRevocationStorage: store,
+		AuthCodeLifespan:       time.Minute,
+		AccessTokenLifespan:    time.Hour,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+
+	code, sig, err := hmacshaStrategy.GenerateAuthorizeCode(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAuthorizeCodeSession(nil, sig, &fosite.Request{
+		ID:          "req-id",
+		Client:      client,
+		Session:     &fosite.DefaultSession{},
+		RequestedAt: time.Now().UTC(),
+	}))
+
+	areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+	areq.GrantTypes = fosite.Arguments{"authorization_code"}
+	areq.Client = client
+	areq.Form = url.Values{"code": {code}}
+	require.NoError(t, h.HandleTokenEndpointRequest(nil, areq))
+
+	// A concurrent request redeems the code before this request issues its tokens.
+	require.NoError(t, store.InvalidateAuthorizeCodeSession(nil, sig))
+	require.NoError(t, store.CreateAccessTokenSession(nil, "concurrent", &fosite.Request{ID: "req-id"}))
+
+	err = h.PopulateTokenEndpointResponse(nil, areq, fosite.NewAccessResponse())
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	assert.Empty(t, store.AccessTokens)
+	assert.Equal(t, fosite.RevocationReasonCompromise, store.RevocationReasons["req-id"])
+}
+
+type transactionalStore struct {
+	*storage.MemoryStore
+	calls []string
+	fail  bool
+}
+
+func (s *transactionalStore) BeginTX(ctx context.Context) (context.Context, error) {
+	s.calls = append(s.calls, "begin")
+	return ctx, nil
+}
+
+func (s *transactionalStore) Commit(context.Context) error {
+	s.calls = append(s.calls, "commit")
+	return nil
+}
+
+func (s *transactionalStore) Rollback(context.Context) error {
+	s.calls = append(s.calls, "rollback")
+	return nil
+}
+
+func (s *transactionalStore) CreateRefreshTokenSession(ctx context.Context, signature string, req fosite.Requester) error {
+	if s.fail {
+		return errors.New("refresh token storage failed")
+	}
+	return s.MemoryStore.CreateRefreshTokenSession(ctx, signature, req)
+}
+
+func TestRunInTransactionRollsBackOnPanic(t *testing.T) {
+	store := &transactionalStore{MemoryStore: storage.NewMemoryStore()}
+	assert.Panics(t, func() {
+		storage.RunInTransaction(context.Background(), store, func(context.Context) error {
+			panic("handler failed")
+		})
+	})
+	assert.Equal(t, []string{"begin", "rollback"}, store.calls)
+}
+
+func TestAuthorizeCode_PopulateTokenEndpointResponseInTransaction(t *testing.T) {
+	for k, c := range []struct {
+		fail   bool
+		expect []string
+	}{
+		{fail: false, expect: []string{"begin", "commit"}},
+		{fail: true, expect: []string{"begin", "rollback"}},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			store := &transactionalStore{MemoryStore: storage.NewMemoryStore(), fail: c.fail}
+			h := AuthorizeExplicitGrantHandler{
+				CoreStorage:            store,
+				AuthorizeCodeStrategy:  hmacshaStrategy,
+				AccessTokenStrategy:    hmacshaStrategy,
+				RefreshTokenStrategy:   hmacshaStrategy,
+				ScopeStrategy:          fosite.HierarchicScopeStrategy,
+				TokenRevocationStorage: store,
+				AuthCodeLifespan:       time.Minute,
+				AccessTokenLifespan:    time.Hour,
+			}
+			client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+
+			code, sig, err := hmacshaStrategy.GenerateAuthorizeCode(nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, store.CreateAuthorizeCodeSession(nil, sig, &fosite.Request{
+				ID:            "req-id",
+				Client:        client,
+				GrantedScopes: fosite.Arguments{"offline"},
+				Session:       &fosite.DefaultSession{},
+				RequestedAt:   time.Now().UTC(),
+			}))
+
+			areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+			areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			areq.Client = client
+			areq.Form = url.Values{"code": {code}}
+			require.NoError(t, h.HandleTokenEndpointRequest(nil, areq))
+
+			err = h.PopulateTokenEndpointResponse(nil, areq, fosite.NewAccessResponse())
+			if c.fail {
+				assert.EqualError(t, errors.Cause(err), fosite.ErrServerError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expect, store.calls)
+		})
+	}
+}
+
+func TestAuthorizeCode_ParallelRedemptionIssuesTokensOnce(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:            store,
+		AuthorizeCodeStrategy:  hmacshaStrategy,
+		AccessTokenStrategy:    hmacshaStrategy,
+		RefreshTokenStrategy:   hmacshaStrategy,
+		ScopeStrategy:          fosite.HierarchicScopeStrategy,
+		TokenRevocationStorage: store,
+		AuthCodeLifespan:       time.Minute,
+		AccessTokenLifespan:    time.Hour,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+
+	code, sig, err := hmacshaStrategy.GenerateAuthorizeCode(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAuthorizeCodeSession(nil, sig, &fosite.Request{
+		ID:          "req-id",
+		Client:      client,
+		Session:     &fosite.DefaultSession{},
+		RequestedAt: time.Now().UTC(),
+	}))
+
+	// All requests are validated before any of them redeems the code.
+	requests := make([]*fosite.AccessRequest, 10)
+	for i := range requests {
+		requests[i] = fosite.NewAccessRequest(&fosite.DefaultSession{})
+		requests[i].GrantTypes = fosite.Arguments{"authorization_code"}
+		requests[i].Client = client
+		requests[i].Form = url.Values{"code": {code}}
+		require.NoError(t, h.HandleTokenEndpointRequest(nil, requests[i]))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(requests))
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = h.PopulateTokenEndpointResponse(nil, requests[i], fosite.NewAccessResponse())
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, fosite.RevocationReasonCompromise, store.RevocationReasons["req-id"])
+}
@@ -44,6 +44,12 @@ type AuthorizeImplicitGrantTypeHandler struct {
 	AccessTokenLifespan time.Duration
 
 	ScopeStrategy fosite.ScopeStrategy
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ConfigProvider, if set, overrides the lifespans and the scope strategy per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -63,7 +69,7 @@ func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx c
 
 	client := ar.GetClient()
 	for _, scope := range ar.GetRequestedScopes() {
-		if !c.ScopeStrategy(client.GetScopes(), scope) {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
 			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
 		}
 	}
@@ -75,7 +81,7 @@ func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx c
 }
 
 func (c *AuthorizeImplicitGrantTypeHandler) IssueImplicitAccessToken(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
-	ar.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	ar.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, ar.GetClient(), "implicit", fosite.AccessToken, c.AccessTokenLifespan)))
 
 	// Generate the code
 	token, signature, err := c.AccessTokenStrategy.GenerateAccessToken(ctx, ar)
@@ -88,11 +94,16 @@ func (c *AuthorizeImplicitGrantTypeHandler) IssueImplicitAccessToken(ctx context
 	}
 
 	resp.AddFragment("access_token", token)
-	resp.AddFragment("expires_in", strconv.FormatInt(int64(getExpiresIn(ar, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC())/time.Second), 10))
-	resp.AddFragment("token_type", "bearer")
+	resp.AddFragment("expires_in", strconv.FormatInt(int64(getExpiresIn(ar, fosite.AccessToken, c.AccessTokenLifespan, fosite.Now(c.Clock))/time.Second), 10))
+	resp.AddFragment("token_type", accessTokenType(ctx, c.AccessTokenStrategy, ar))
 	resp.AddFragment("state", ar.GetState())
 	resp.AddFragment("scope", strings.Join(ar.GetGrantedScopes(), " "))
 	ar.SetResponseTypeHandled("token")
 
 	return nil
 }
+
+// SupportedResponseTypes implements fosite.ResponseTypesHandler.
+func (c *AuthorizeImplicitGrantTypeHandler) SupportedResponseTypes() []string {
+	return []string{"token"}
+}
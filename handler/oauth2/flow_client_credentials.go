@@ -23,7 +23,6 @@ package oauth2
 
 import (
 	"context"
-	"time"
 
 	"github.com/ory/fosite"
 	"github.com/pkg/errors"
@@ -35,7 +34,7 @@ type ClientCredentialsGrantHandler struct {
 }
 
 // IntrospectTokenEndpointRequest implements https://tools.ietf.org/html/rfc6749#section-4.4.2
-func (c *ClientCredentialsGrantHandler) HandleTokenEndpointRequest(_ context.Context, request fosite.AccessRequester) error {
+func (c *ClientCredentialsGrantHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
 	// grant_type REQUIRED.
 	// Value MUST be set to "client_credentials".
 	if !request.GetGrantTypes().Exact("client_credentials") {
@@ -44,7 +43,7 @@ func (c *ClientCredentialsGrantHandler) HandleTokenEndpointRequest(_ context.Con
 
 	client := request.GetClient()
 	for _, scope := range request.GetRequestedScopes() {
-		if !c.ScopeStrategy(client.GetScopes(), scope) {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
 			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
 		}
 	}
@@ -57,7 +56,7 @@ func (c *ClientCredentialsGrantHandler) HandleTokenEndpointRequest(_ context.Con
 	}
 	// if the client is not public, he has already been authenticated by the access request handler.
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, client, "client_credentials", fosite.AccessToken, c.AccessTokenLifespan)))
 	return nil
 }
 
@@ -73,3 +72,8 @@ func (c *ClientCredentialsGrantHandler) PopulateTokenEndpointResponse(ctx contex
 
 	return c.IssueAccessToken(ctx, request, response)
 }
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *ClientCredentialsGrantHandler) SupportedGrantTypes() []string {
+	return []string{"client_credentials"}
+}
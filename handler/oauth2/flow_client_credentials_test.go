@@ -87,6 +87,27 @@ func TestClientCredentials_HandleTokenEndpointRequest(t *testing.T) {
 	}
 }
 
+func TestClientCredentials_HandleTokenEndpointRequest_ClientLifespan(t *testing.T) {
+	h := ClientCredentialsGrantHandler{
+		HandleHelper: &HandleHelper{
+			AccessTokenLifespan: time.Hour,
+		},
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+	}
+
+	areq := fosite.NewAccessRequest(new(fosite.DefaultSession))
+	areq.GrantTypes = fosite.Arguments{"client_credentials"}
+	areq.Client = &fosite.DefaultClientWithCustomTokenLifespans{
+		DefaultClient: &fosite.DefaultClient{GrantTypes: fosite.Arguments{"client_credentials"}},
+		TokenLifespans: map[string]map[fosite.TokenType]time.Duration{
+			"client_credentials": {fosite.AccessToken: time.Minute},
+		},
+	}
+
+	require.NoError(t, h.HandleTokenEndpointRequest(nil, areq))
+	require.WithinDuration(t, time.Now().UTC().Add(time.Minute), areq.GetSession().GetExpiresAt(fosite.AccessToken), time.Second)
+}
+
 func TestClientCredentials_PopulateTokenEndpointResponse(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	store := internal.NewMockClientCredentialsGrantStorage(ctrl)
@@ -0,0 +1,277 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/go-convenience/stringslice"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// GrantTypeJWTBearer is the grant type of the JWT bearer authorization grant, see
+// https://tools.ietf.org/html/rfc7523#section-2.1
+const GrantTypeJWTBearer = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// TrustedJWTIssuer is an issuer whose assertions are exchanged for access tokens by the JWTBearerGrantHandler.
+type TrustedJWTIssuer struct {
+	// Issuer is the "iss" claim of the assertions.
+	Issuer string
+
+	// JSONWebKeys contains the public keys the assertions are signed with. If it is nil, the keys are resolved from
+	// JSONWebKeysURI.
+	JSONWebKeys    *jose.JSONWebKeySet
+	JSONWebKeysURI string
+
+	// Subjects, if set, are the only subjects the issuer may issue assertions for.
+	Subjects []string
+
+	// Scopes are the scopes which may be requested using assertions of the issuer, in addition to being allowed for
+	// the client.
+	Scopes fosite.Arguments
+}
+
+// JWTBearerSessionMapper maps the claims of a verified assertion onto the session of the access request.
+type JWTBearerSessionMapper func(ctx context.Context, claims jwtgo.MapClaims, session fosite.Session) error
+
+// DefaultJWTBearerSessionMapper sets the subject of *fosite.DefaultSession and *JWTSession to the "sub" claim and
+// fails for other sessions, which require a custom JWTBearerSessionMapper.
+func DefaultJWTBearerSessionMapper(_ context.Context, claims jwtgo.MapClaims, session fosite.Session) error {
+	sub, _ := claims["sub"].(string)
+	switch s := session.(type) {
+	case *fosite.DefaultSession:
+		s.Subject = sub
+	case *JWTSession:
+		s.Subject = sub
+		s.GetJWTClaims().Subject = sub
+	default:
+		return errors.WithStack(fosite.ErrServerError.WithDebugf("Unable to map the assertion onto session of type %T, a JWTBearerSessionMapper is required.", session))
+	}
+	return nil
+}
+
+// JWTBearerGrantHandler exchanges assertions signed by a trusted issuer for access tokens, see
+// https://tools.ietf.org/html/rfc7523#section-2.1
+type JWTBearerGrantHandler struct {
+	*HandleHelper
+	ScopeStrategy fosite.ScopeStrategy
+
+	// TrustedIssuers are the issuers whose assertions are accepted.
+	TrustedIssuers []TrustedJWTIssuer
+
+	// JWKSFetcher resolves the JSONWebKeysURI of trusted issuers.
+	JWKSFetcher fosite.JWKSFetcherStrategy
+
+	// TokenURL is the URL of the token endpoint, which the "aud" claim of assertions must contain.
+	TokenURL string
+
+	// MaxLifetime is the maximum time until an assertion expires. Defaults to one hour.
+	MaxLifetime time.Duration
+
+	// ClockSkew is the leeway applied when validating the "exp", "nbf" and "iat" claims.
+	ClockSkew time.Duration
+
+	// SessionMapper maps the claims of the assertion onto the session. Defaults to DefaultJWTBearerSessionMapper.
+	SessionMapper JWTBearerSessionMapper
+
+	// Policy restricts the "typ" and "alg" headers of assertions. Defaults to fosite.DefaultJWTBearerGrantPolicy.
+	Policy *fosite.JWTValidationPolicy
+}
+
+// HandleTokenEndpointRequest implements https://tools.ietf.org/html/rfc7523#section-2.1
+func (c *JWTBearerGrantHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	if !request.GetGrantTypes().Exact(GrantTypeJWTBearer) {
+		return errors.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	client := request.GetClient()
+	if !client.GetGrantTypes().Has(GrantTypeJWTBearer) {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The OAuth 2.0 Client is not allowed to use authorization grant \"%s\".", GrantTypeJWTBearer))
+	}
+
+	assertion := request.GetRequestForm().Get("assertion")
+	if assertion == "" {
+		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("The \"assertion\" parameter is required."))
+	}
+
+	issuer, claims, err := c.verifyAssertion(assertion)
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range request.GetRequestedScopes() {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
+			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
+		} else if !c.ScopeStrategy(issuer.Scopes, scope) {
+			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The issuer of the assertion is not allowed to grant scope \"%s\".", scope))
+		}
+	}
+
+	mapper := c.SessionMapper
+	if mapper == nil {
+		mapper = DefaultJWTBearerSessionMapper
+	}
+	if err := mapper(ctx, claims, request.GetSession()); err != nil {
+		return err
+	}
+
+	request.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, client, GrantTypeJWTBearer, fosite.AccessToken, c.AccessTokenLifespan)))
+	return nil
+}
+
+// PopulateTokenEndpointResponse implements https://tools.ietf.org/html/rfc7523#section-2.1
+func (c *JWTBearerGrantHandler) PopulateTokenEndpointResponse(ctx context.Context, request fosite.AccessRequester, response fosite.AccessResponder) error {
+	if !request.GetGrantTypes().Exact(GrantTypeJWTBearer) {
+		return errors.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	if !request.GetClient().GetGrantTypes().Has(GrantTypeJWTBearer) {
+		return errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The OAuth 2.0 Client is not allowed to use authorization grant \"%s\".", GrantTypeJWTBearer))
+	}
+
+	return c.IssueAccessToken(ctx, request, response)
+}
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *JWTBearerGrantHandler) SupportedGrantTypes() []string {
+	return []string{GrantTypeJWTBearer}
+}
+
+// verifyAssertion verifies the signature and claims of the assertion as required by
+// https://tools.ietf.org/html/rfc7523#section-3
+func (c *JWTBearerGrantHandler) verifyAssertion(assertion string) (*TrustedJWTIssuer, jwtgo.MapClaims, error) {
+	var issuer *TrustedJWTIssuer
+	claims := jwtgo.MapClaims{}
+	parser := &jwtgo.Parser{SkipClaimsValidation: true}
+	if _, err := parser.ParseWithClaims(assertion, claims, func(t *jwtgo.Token) (interface{}, error) {
+		if err := c.policy().CheckHeader(t.Header); err != nil {
+			return nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint(err.Error()))
+		}
+
+		iss, _ := claims["iss"].(string)
+		for k := range c.TrustedIssuers {
+			if c.TrustedIssuers[k].Issuer == iss {
+				issuer = &c.TrustedIssuers[k]
+			}
+		}
+		if issuer == nil {
+			return nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The issuer \"%s\" of the assertion is not trusted.", iss))
+		}
+
+		switch t.Method.(type) {
+		case *jwtgo.SigningMethodRSA, *jwtgo.SigningMethodRSAPSS, *jwtgo.SigningMethodECDSA:
+		default:
+			return nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The assertion uses the unsupported signing algorithm \"%s\".", t.Header["alg"]))
+		}
+		return c.findIssuerKey(issuer, t)
+	}); err != nil {
+		if e, ok := errors.Cause(err).(*jwtgo.ValidationError); ok && e.Inner != nil {
+			if _, ok := errors.Cause(e.Inner).(*fosite.RFC6749Error); ok {
+				return nil, nil, e.Inner
+			}
+		}
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("Unable to verify the signature of the assertion.").WithDebug(err.Error()))
+	}
+
+	now := fosite.Now(c.Clock)
+	maxLifetime := c.MaxLifetime
+	if maxLifetime == 0 {
+		maxLifetime = time.Hour
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The assertion must contain the \"sub\" claim."))
+	} else if len(issuer.Subjects) > 0 && !stringslice.Has(issuer.Subjects, sub) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The issuer of the assertion is not allowed to assert subject \"%s\".", sub))
+	} else if c.TokenURL == "" {
+		return nil, nil, errors.WithStack(fosite.ErrServerError.WithDebug("The token URL must be set to verify the audience of JWT bearer assertions."))
+	} else if !verifyAudience(claims, c.TokenURL) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The \"aud\" claim of the assertion must contain the token endpoint \"%s\".", c.TokenURL))
+	} else if _, ok := claims["exp"]; !ok {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The assertion must contain the \"exp\" claim."))
+	} else if !claims.VerifyExpiresAt(now.Add(-c.ClockSkew).Unix(), true) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The assertion has expired."))
+	} else if claims.VerifyExpiresAt(now.Add(maxLifetime).Unix(), true) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("The assertion must expire within %s.", maxLifetime))
+	} else if !claims.VerifyNotBefore(now.Add(c.ClockSkew).Unix(), false) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The assertion is not valid yet."))
+	} else if !claims.VerifyIssuedAt(now.Add(c.ClockSkew).Unix(), false) {
+		return nil, nil, errors.WithStack(fosite.ErrInvalidGrant.WithHint("The assertion was issued in the future."))
+	}
+
+	return issuer, claims, nil
+}
+
+func (c *JWTBearerGrantHandler) policy() *fosite.JWTValidationPolicy {
+	if c.Policy == nil {
+		return fosite.DefaultJWTBearerGrantPolicy()
+	}
+	return c.Policy
+}
+
+// findIssuerKey returns the public key identified by the "kid" header of the assertion.
+func (c *JWTBearerGrantHandler) findIssuerKey(issuer *TrustedJWTIssuer, t *jwtgo.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	find := func(set *jose.JSONWebKeySet) interface{} {
+		for _, key := range set.Keys {
+			if (kid == "" || key.KeyID == kid) && (key.Use == "" || key.Use == "sig") && key.IsPublic() {
+				return key.Key
+			}
+		}
+		return nil
+	}
+
+	if issuer.JSONWebKeys != nil {
+		if key := find(issuer.JSONWebKeys); key != nil {
+			return key, nil
+		}
+	} else if issuer.JSONWebKeysURI != "" && c.JWKSFetcher != nil {
+		for _, forceRefresh := range []bool{false, true} {
+			set, err := c.JWKSFetcher.Resolve(issuer.JSONWebKeysURI, forceRefresh)
+			if err != nil {
+				return nil, errors.WithStack(fosite.ErrServerError.WithDebugf("Unable to fetch the JSON Web Keys of issuer \"%s\": %s", issuer.Issuer, err))
+			} else if key := find(set); key != nil {
+				return key, nil
+			}
+		}
+	}
+
+	return nil, errors.WithStack(fosite.ErrInvalidGrant.WithHintf("Unable to find the key \"%s\" of issuer \"%s\".", kid, issuer.Issuer))
+}
+
+func verifyAudience(claims jwtgo.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestJWTBearer_HandleTokenEndpointRequest(t *testing.T) {
+	key := internal.MustRSAKey()
+	h := &JWTBearerGrantHandler{
+		HandleHelper:  &HandleHelper{AccessTokenLifespan: time.Hour},
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		TokenURL:      "https://auth.example.com/token",
+		TrustedIssuers: []TrustedJWTIssuer{{
+			Issuer:      "https://issuer.example.com",
+			JSONWebKeys: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "issuer-key", Use: "sig"}}},
+			Subjects:    []string{"peter"},
+			Scopes:      fosite.Arguments{"foo"},
+		}},
+	}
+
+	sign := func(claims jwtgo.MapClaims, signingKey interface{}) string {
+		token := jwtgo.NewWithClaims(jwtgo.SigningMethodRS256, claims)
+		token.Header["kid"] = "issuer-key"
+		assertion, err := token.SignedString(signingKey)
+		require.NoError(t, err)
+		return assertion
+	}
+	validClaims := func() jwtgo.MapClaims {
+		return jwtgo.MapClaims{
+			"iss": "https://issuer.example.com",
+			"sub": "peter",
+			"aud": []string{"https://auth.example.com/token"},
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	for k, c := range []struct {
+		description string
+		grantType   string
+		assertion   func() string
+		scopes      fosite.Arguments
+		expectErr   error
+	}{
+		{description: "should fail because not responsible", grantType: "client_credentials", assertion: func() string { return "" }, expectErr: fosite.ErrUnknownRequest},
+		{description: "should fail because the assertion is missing", assertion: func() string { return "" }, expectErr: fosite.ErrInvalidRequest},
+		{
+			description: "should fail because the issuer is not trusted",
+			assertion: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return sign(claims, key)
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the assertion is an access token",
+			assertion: func() string {
+				token := jwtgo.NewWithClaims(jwtgo.SigningMethodRS256, validClaims())
+				token.Header["kid"] = "issuer-key"
+				token.Header["typ"] = "at+jwt"
+				assertion, err := token.SignedString(key)
+				require.NoError(t, err)
+				return assertion
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the signature is invalid",
+			assertion:   func() string { return sign(validClaims(), internal.MustRSAKey()) },
+			expectErr:   fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the audience does not match",
+			assertion: func() string {
+				claims := validClaims()
+				claims["aud"] = "https://other.example.com/token"
+				return sign(claims, key)
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the assertion expired",
+			assertion: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return sign(claims, key)
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the assertion is valid for too long",
+			assertion: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(2 * time.Hour).Unix()
+				return sign(claims, key)
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the subject is not allowed",
+			assertion: func() string {
+				claims := validClaims()
+				claims["sub"] = "alice"
+				return sign(claims, key)
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the issuer may not grant the scope",
+			assertion:   func() string { return sign(validClaims(), key) },
+			scopes:      fosite.Arguments{"bar"},
+			expectErr:   fosite.ErrInvalidScope,
+		},
+		{
+			description: "should pass",
+			assertion:   func() string { return sign(validClaims(), key) },
+			scopes:      fosite.Arguments{"foo"},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			session := new(fosite.DefaultSession)
+			areq := fosite.NewAccessRequest(session)
+			areq.GrantTypes = fosite.Arguments{GrantTypeJWTBearer}
+			if c.grantType != "" {
+				areq.GrantTypes = fosite.Arguments{c.grantType}
+			}
+			areq.Client = &fosite.DefaultClient{GrantTypes: fosite.Arguments{GrantTypeJWTBearer}, Scopes: fosite.Arguments{"foo", "bar"}}
+			areq.Form.Set("assertion", c.assertion())
+			areq.SetRequestedScopes(c.scopes)
+
+			err := h.HandleTokenEndpointRequest(context.Background(), areq)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "peter", session.Subject)
+			assert.False(t, session.GetExpiresAt(fosite.AccessToken).IsZero())
+		})
+	}
+}
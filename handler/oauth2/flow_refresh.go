@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
 	"github.com/pkg/errors"
 )
 
@@ -36,6 +37,28 @@ type RefreshTokenGrantHandler struct {
 
 	// AccessTokenLifespan defines the lifetime of an access token.
 	AccessTokenLifespan time.Duration
+
+	// RefreshTokenLifespan defines the lifetime of a refresh token. Refresh tokens do not expire if set to zero.
+	RefreshTokenLifespan time.Duration
+
+	// RefreshTokenReuseGracePeriod applies if TokenRevocationStorage implements RefreshTokenReuseStorage. Presenting
+	// a refresh token again after it was exchanged revokes all tokens of the grant, unless it happens within this
+	// period, in which case the refresh token is exchanged again to tolerate network retries.
+	RefreshTokenReuseGracePeriod time.Duration
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ScopeStrategy decides whether a scope requested to narrow the access token was originally granted. Defaults to
+	// fosite.ExactScopeStrategy.
+	ScopeStrategy fosite.ScopeStrategy
+
+	// RefreshTokenPolicy decides whether the refresh tokens of a grant may be used. It must match the policy of the
+	// handlers issuing refresh tokens. Defaults to OfflineAccessRefreshTokenPolicy.
+	RefreshTokenPolicy RefreshTokenPolicy
+
+	// ConfigProvider, if set, overrides the lifespans and the scope strategy per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 // HandleTokenEndpointRequest implements https://tools.ietf.org/html/rfc6749#section-6
@@ -53,20 +76,17 @@ func (c *RefreshTokenGrantHandler) HandleTokenEndpointRequest(ctx context.Contex
 	refresh := request.GetRequestForm().Get("refresh_token")
 
 	signature := c.RefreshTokenStrategy.RefreshTokenSignature(refresh)
-	originalRequest, err := c.TokenRevocationStorage.GetRefreshTokenSession(ctx, signature, request.GetSession())
-	if errors.Cause(err) == fosite.ErrNotFound {
-		return errors.WithStack(fosite.ErrInvalidRequest.WithDebug(err.Error()))
-	} else if err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if err := c.RefreshTokenStrategy.ValidateRefreshToken(ctx, request, refresh); err != nil {
+	originalRequest, err := c.getRefreshTokenSession(ctx, signature, request.GetSession())
+	if err != nil {
+		return c.revokeReusedRefreshToken(ctx, err)
+	} else if err := c.RefreshTokenStrategy.ValidateRefreshToken(ctx, originalRequest, refresh); err != nil {
 		// The authorization server MUST ... validate the refresh token.
 		// This needs to happen after store retrieval for the session to be hydrated properly
 		return errors.WithStack(fosite.ErrInvalidRequest.WithDebug(err.Error()))
 	}
 
-	if !originalRequest.GetGrantedScopes().HasOneOf("offline", "offline_access") {
+	if !canRefresh(c.RefreshTokenPolicy, originalRequest) {
 		return errors.WithStack(fosite.ErrScopeNotGranted.WithHint("The OAuth 2.0 Client was not granted scope \"offline\" or \"offline_access\" and may thus not perform the \"refresh_token\" authorization grant."))
-
 	}
 
 	// The authorization server MUST ... and ensure that the refresh token was issued to the authenticated client
@@ -75,12 +95,25 @@ func (c *RefreshTokenGrantHandler) HandleTokenEndpointRequest(ctx context.Contex
 	}
 
 	request.SetSession(originalRequest.GetSession().Clone())
-	request.SetRequestedScopes(originalRequest.GetRequestedScopes())
-	for _, scope := range originalRequest.GetGrantedScopes() {
-		request.GrantScope(scope)
+
+	// The requested scope MUST NOT include any scope not originally granted by the resource owner, and if omitted is
+	// treated as equal to the scope originally granted by the resource owner.
+	if scopes := request.GetRequestedScopes(); len(scopes) > 0 {
+		for _, scope := range scopes {
+			if !c.scopeStrategy(ctx, request.GetClient())(originalRequest.GetGrantedScopes(), scope) {
+				return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The requested scope \"%s\" was not originally granted by the resource owner.", scope))
+			}
+			request.GrantScope(scope)
+		}
+	} else {
+		request.SetRequestedScopes(originalRequest.GetRequestedScopes())
+		for _, scope := range originalRequest.GetGrantedScopes() {
+			request.GrantScope(scope)
+		}
 	}
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, request.GetClient(), "refresh_token", fosite.AccessToken, c.AccessTokenLifespan)))
+	setRefreshTokenExpiry(ctx, c.ConfigProvider, request, "refresh_token", c.RefreshTokenLifespan, fosite.Now(c.Clock))
 	return nil
 }
 
@@ -101,27 +134,133 @@ func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Con
 	}
 
 	signature := c.RefreshTokenStrategy.RefreshTokenSignature(requester.GetRequestForm().Get("refresh_token"))
-	ts, err := c.TokenRevocationStorage.GetRefreshTokenSession(ctx, signature, nil)
-	if err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if err := c.TokenRevocationStorage.RevokeAccessToken(ctx, ts.GetID()); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if err := c.TokenRevocationStorage.RevokeRefreshToken(ctx, ts.GetID()); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	}
+	if err := storage.RunInTransaction(ctx, c.TokenRevocationStorage, func(ctx context.Context) error {
+		ts, err := c.getRefreshTokenSession(ctx, signature, nil)
+		if err != nil {
+			return err
+		}
 
-	storeReq := requester.Sanitize([]string{})
-	storeReq.SetID(ts.GetID())
-	if err := c.TokenRevocationStorage.CreateAccessTokenSession(ctx, accessSignature, storeReq); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-	} else if err := c.TokenRevocationStorage.CreateRefreshTokenSession(ctx, refreshSignature, storeReq); err != nil {
-		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		if reuse, ok := c.TokenRevocationStorage.(RefreshTokenReuseStorage); ok {
+			if err := reuse.MarkRefreshTokenUsed(ctx, signature, ts, fosite.Now(c.Clock)); err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			}
+		}
+
+		if err := c.TokenRevocationStorage.RevokeAccessToken(ctx, ts.GetID()); err != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		} else if err := c.TokenRevocationStorage.RevokeRefreshToken(ctx, ts.GetID()); err != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		}
+
+		storeReq := requester.Sanitize([]string{})
+		storeReq.SetID(ts.GetID())
+
+		// The scope of the new refresh token MUST be identical to that of the refresh token included in the request,
+		// even if the access token was issued with a narrowed scope.
+		refreshStoreReq := requester.Sanitize([]string{})
+		refreshStoreReq.SetID(ts.GetID())
+		switch r := refreshStoreReq.(type) {
+		case *fosite.AccessRequest:
+			r.Scopes, r.GrantedScopes = ts.GetRequestedScopes(), ts.GetGrantedScopes()
+		case *fosite.Request:
+			r.Scopes, r.GrantedScopes = ts.GetRequestedScopes(), ts.GetGrantedScopes()
+		}
+
+		if err := c.TokenRevocationStorage.CreateAccessTokenSession(ctx, accessSignature, storeReq); err != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		} else if err := c.TokenRevocationStorage.CreateRefreshTokenSession(ctx, refreshSignature, refreshStoreReq); err != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+		} else if lineage, ok := c.TokenRevocationStorage.(TokenLineageStorage); ok {
+			if err := recordTokenLineage(ctx, lineage, ts.GetID(), signature, map[fosite.TokenType]string{
+				fosite.AccessToken:  accessSignature,
+				fosite.RefreshToken: refreshSignature,
+			}, fosite.Now(c.Clock)); err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			}
+		}
+		return nil
+	}); err != nil {
+		// A reused refresh token is revoked after the transaction was rolled back, so that the revocation is not
+		// rolled back with it.
+		return c.revokeReusedRefreshToken(ctx, err)
 	}
 
 	responder.SetAccessToken(accessToken)
-	responder.SetTokenType("bearer")
-	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetTokenType(accessTokenType(ctx, c.AccessTokenStrategy, requester))
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, fosite.Now(c.Clock)))
 	responder.SetScopes(requester.GetGrantedScopes())
 	responder.SetExtra("refresh_token", refreshToken)
 	return nil
 }
+
+// refreshTokenReusedError is returned by getRefreshTokenSession for a refresh token which was exchanged again after
+// the reuse grace period, see revokeReusedRefreshToken.
+type refreshTokenReusedError struct {
+	used fosite.Requester
+}
+
+func (e *refreshTokenReusedError) Error() string {
+	return "The refresh token has already been used."
+}
+
+// getRefreshTokenSession returns the request of a refresh token. A refresh token which was already exchanged is
+// accepted within the reuse grace period. After that, a refreshTokenReusedError is returned.
+func (c *RefreshTokenGrantHandler) getRefreshTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	request, err := c.TokenRevocationStorage.GetRefreshTokenSession(ctx, signature, session)
+	if err == nil {
+		return request, nil
+	} else if errors.Cause(err) != fosite.ErrNotFound {
+		return nil, errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+
+	reuse, ok := c.TokenRevocationStorage.(RefreshTokenReuseStorage)
+	if !ok {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithDebug(err.Error()))
+	}
+
+	used, usedAt, uerr := reuse.GetUsedRefreshToken(ctx, signature, session)
+	if errors.Cause(uerr) == fosite.ErrNotFound {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithDebug(err.Error()))
+	} else if uerr != nil {
+		return nil, errors.WithStack(fosite.ErrServerError.WithDebug(uerr.Error()))
+	}
+
+	if fosite.Now(c.Clock).Before(usedAt.Add(c.RefreshTokenReuseGracePeriod)) {
+		return used, nil
+	}
+	return nil, errors.WithStack(&refreshTokenReusedError{used: used})
+}
+
+// revokeReusedRefreshToken revokes all tokens of the grant if err is a refreshTokenReusedError, as the refresh token
+// has likely been leaked, see https://tools.ietf.org/html/draft-ietf-oauth-security-topics-13#section-4.12
+// Other errors are returned as they are. It must not be called within a transaction which is rolled back because of
+// err.
+func (c *RefreshTokenGrantHandler) revokeReusedRefreshToken(ctx context.Context, err error) error {
+	reused, ok := errors.Cause(err).(*refreshTokenReusedError)
+	if !ok {
+		return err
+	}
+
+	ctx = fosite.ContextWithRevocationReason(ctx, fosite.RevocationReasonCompromise)
+	if err := c.TokenRevocationStorage.RevokeAccessToken(ctx, reused.used.GetID()); err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	} else if err := c.TokenRevocationStorage.RevokeRefreshToken(ctx, reused.used.GetID()); err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	} else if err := revokeTokenLineage(ctx, c.TokenRevocationStorage, reused.used.GetID()); err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+
+	return errors.WithStack(fosite.ErrInvalidGrant.WithHint("The refresh token has already been used, all tokens issued for this grant have been revoked."))
+}
+
+func (c *RefreshTokenGrantHandler) scopeStrategy(ctx context.Context, client fosite.Client) fosite.ScopeStrategy {
+	if strategy := fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy); strategy != nil {
+		return strategy
+	}
+	return fosite.ExactScopeStrategy
+}
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *RefreshTokenGrantHandler) SupportedGrantTypes() []string {
+	return []string{"refresh_token"}
+}
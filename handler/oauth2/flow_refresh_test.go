@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"net/url"
 	"testing"
 	"time"
@@ -100,6 +101,33 @@ func TestRefreshFlow_HandleTokenEndpointRequest(t *testing.T) {
 						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
 							Client:        &fosite.DefaultClient{ID: ""},
 							GrantedScopes: []string{"offline"},
+							Session:       sess,
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrInvalidRequest,
+				},
+				{
+					description: "should fail because token is expired",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:        &fosite.DefaultClient{ID: "foo"},
+							GrantedScopes: fosite.Arguments{"foo", "offline"},
+							Session: &fosite.DefaultSession{
+								ExpiresAt: map[fosite.TokenType]time.Time{
+									fosite.RefreshToken: time.Now().UTC().Add(-time.Hour),
+								},
+							},
 						})
 						require.NoError(t, err)
 					},
@@ -239,3 +267,234 @@ func TestRefreshFlow_PopulateTokenEndpointResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshFlow_ReuseDetection(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := RefreshTokenGrantHandler{
+		TokenRevocationStorage:       store,
+		RefreshTokenStrategy:         &hmacshaStrategy,
+		AccessTokenStrategy:          &hmacshaStrategy,
+		AccessTokenLifespan:          time.Hour,
+		RefreshTokenReuseGracePeriod: time.Minute,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"refresh_token"}}
+
+	token, sig, err := hmacshaStrategy.GenerateRefreshToken(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+		ID:            "req-id",
+		Client:        client,
+		GrantedScopes: fosite.Arguments{"offline"},
+		Session:       &fosite.DefaultSession{},
+	}))
+
+	exchange := func(refresh string) (string, error) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{"refresh_token"}
+		areq.Client = client
+		areq.Form = url.Values{"refresh_token": {refresh}}
+		if err := h.HandleTokenEndpointRequest(nil, areq); err != nil {
+			return "", err
+		}
+
+		aresp := fosite.NewAccessResponse()
+		if err := h.PopulateTokenEndpointResponse(nil, areq, aresp); err != nil {
+			return "", err
+		}
+		return aresp.ToMap()["refresh_token"].(string), nil
+	}
+
+	first, err := exchange(token)
+	require.NoError(t, err)
+
+	// A retry within the grace period is exchanged again and replaces the tokens of the first exchange.
+	second, err := exchange(token)
+	require.NoError(t, err)
+	_, err = store.GetRefreshTokenSession(nil, hmacshaStrategy.RefreshTokenSignature(first), nil)
+	assert.EqualError(t, err, fosite.ErrNotFound.Error())
+
+	// After the grace period, reusing the refresh token revokes the whole grant.
+	used := store.UsedRefreshTokens[sig]
+	used.UsedAt = used.UsedAt.Add(-time.Hour)
+	store.UsedRefreshTokens[sig] = used
+
+	_, err = exchange(token)
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	_, err = store.GetRefreshTokenSession(nil, hmacshaStrategy.RefreshTokenSignature(second), nil)
+	assert.EqualError(t, err, fosite.ErrNotFound.Error())
+	assert.Equal(t, fosite.RevocationReasonCompromise, store.RevocationReasons["req-id"])
+
+	_, err = exchange(second)
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidRequest.Error())
+}
+
+func TestRefreshFlow_ScopeNarrowing(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := RefreshTokenGrantHandler{
+		TokenRevocationStorage: store,
+		RefreshTokenStrategy:   &hmacshaStrategy,
+		AccessTokenStrategy:    &hmacshaStrategy,
+		AccessTokenLifespan:    time.Hour,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"refresh_token"}}
+
+	token, sig, err := hmacshaStrategy.GenerateRefreshToken(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+		ID:            "req-id",
+		Client:        client,
+		Scopes:        fosite.Arguments{"foo", "bar", "offline"},
+		GrantedScopes: fosite.Arguments{"foo", "bar", "offline"},
+		Session:       &fosite.DefaultSession{},
+	}))
+
+	exchange := func(refresh string, scopes ...string) (*fosite.AccessResponse, error) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{"refresh_token"}
+		areq.Client = client
+		areq.Form = url.Values{"refresh_token": {refresh}}
+		areq.SetRequestedScopes(scopes)
+		if err := h.HandleTokenEndpointRequest(nil, areq); err != nil {
+			return nil, err
+		}
+
+		aresp := fosite.NewAccessResponse()
+		if err := h.PopulateTokenEndpointResponse(nil, areq, aresp); err != nil {
+			return nil, err
+		}
+		return aresp, nil
+	}
+
+	_, err = exchange(token, "foo", "baz")
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidScope.Error())
+
+	aresp, err := exchange(token, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", aresp.ToMap()["scope"])
+	at, err := store.GetAccessTokenSession(nil, hmacshaStrategy.AccessTokenSignature(aresp.GetAccessToken()), nil)
+	require.NoError(t, err)
+	assert.Equal(t, fosite.Arguments{"foo"}, at.GetGrantedScopes())
+
+	// The new refresh token keeps the original scope.
+	refresh := aresp.ToMap()["refresh_token"].(string)
+	rt, err := store.GetRefreshTokenSession(nil, hmacshaStrategy.RefreshTokenSignature(refresh), nil)
+	require.NoError(t, err)
+	assert.Equal(t, fosite.Arguments{"foo", "bar", "offline"}, rt.GetGrantedScopes())
+
+	aresp, err = exchange(refresh)
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar offline", aresp.ToMap()["scope"])
+}
+
+func TestRefreshFlow_RefreshTokenPolicy(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := RefreshTokenGrantHandler{
+		TokenRevocationStorage: store,
+		RefreshTokenStrategy:   &hmacshaStrategy,
+		AccessTokenStrategy:    &hmacshaStrategy,
+		AccessTokenLifespan:    time.Hour,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"refresh_token"}}
+
+	token, sig, err := hmacshaStrategy.GenerateRefreshToken(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+		Client:        client,
+		GrantedScopes: fosite.Arguments{"foo"},
+		Session:       &fosite.DefaultSession{},
+	}))
+
+	newRequest := func() *fosite.AccessRequest {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{"refresh_token"}
+		areq.Client = client
+		areq.Form = url.Values{"refresh_token": {token}}
+		return areq
+	}
+
+	err = h.HandleTokenEndpointRequest(nil, newRequest())
+	assert.EqualError(t, errors.Cause(err), fosite.ErrScopeNotGranted.Error())
+
+	h.RefreshTokenPolicy = PlainOAuth2RefreshTokenPolicy
+	assert.NoError(t, h.HandleTokenEndpointRequest(nil, newRequest()))
+}
+
+// rollbackStore is a storage.Transactional store which restores its tokens and revocation reasons when a
+// transaction is rolled back.
+type rollbackStore struct {
+	*storage.MemoryStore
+	accessTokens, refreshTokens map[string]fosite.Requester
+	revocationReasons           map[string]fosite.RevocationReason
+}
+
+func (s *rollbackStore) BeginTX(ctx context.Context) (context.Context, error) {
+	s.accessTokens, s.refreshTokens = map[string]fosite.Requester{}, map[string]fosite.Requester{}
+	s.revocationReasons = map[string]fosite.RevocationReason{}
+	for k, v := range s.AccessTokens {
+		s.accessTokens[k] = v
+	}
+	for k, v := range s.RefreshTokens {
+		s.refreshTokens[k] = v
+	}
+	for k, v := range s.RevocationReasons {
+		s.revocationReasons[k] = v
+	}
+	return ctx, nil
+}
+
+func (s *rollbackStore) Commit(context.Context) error {
+	return nil
+}
+
+func (s *rollbackStore) Rollback(context.Context) error {
+	s.AccessTokens, s.RefreshTokens, s.RevocationReasons = s.accessTokens, s.refreshTokens, s.revocationReasons
+	return nil
+}
+
+func TestRefreshFlow_ReuseDetectionInTransaction(t *testing.T) {
+	store := &rollbackStore{MemoryStore: storage.NewMemoryStore()}
+	h := RefreshTokenGrantHandler{
+		TokenRevocationStorage:       store,
+		RefreshTokenStrategy:         &hmacshaStrategy,
+		AccessTokenStrategy:          &hmacshaStrategy,
+		AccessTokenLifespan:          time.Hour,
+		RefreshTokenReuseGracePeriod: time.Minute,
+	}
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"refresh_token"}}
+
+	token, sig, err := hmacshaStrategy.GenerateRefreshToken(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+		ID:            "req-id",
+		Client:        client,
+		GrantedScopes: fosite.Arguments{"offline"},
+		Session:       &fosite.DefaultSession{},
+	}))
+
+	newRequest := func() *fosite.AccessRequest {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{"refresh_token"}
+		areq.Client = client
+		areq.Form = url.Values{"refresh_token": {token}}
+		require.NoError(t, h.HandleTokenEndpointRequest(nil, areq))
+		return areq
+	}
+
+	// Both requests are validated before either of them exchanges the refresh token.
+	first, second := newRequest(), newRequest()
+	aresp := fosite.NewAccessResponse()
+	require.NoError(t, h.PopulateTokenEndpointResponse(nil, first, aresp))
+	issued := hmacshaStrategy.RefreshTokenSignature(aresp.ToMap()["refresh_token"].(string))
+
+	// The second request exchanges the refresh token after the grace period, which revokes the grant although the
+	// transaction of the exchange is rolled back.
+	used := store.UsedRefreshTokens[sig]
+	used.UsedAt = used.UsedAt.Add(-time.Hour)
+	store.UsedRefreshTokens[sig] = used
+
+	err = h.PopulateTokenEndpointResponse(nil, second, fosite.NewAccessResponse())
+	assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	_, err = store.GetRefreshTokenSession(nil, issued, nil)
+	assert.EqualError(t, err, fosite.ErrNotFound.Error())
+	assert.Equal(t, fosite.RevocationReasonCompromise, store.RevocationReasons["req-id"])
+}
@@ -27,6 +27,7 @@ import (
 	"context"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
 	"github.com/pkg/errors"
 )
 
@@ -37,6 +38,16 @@ type ResourceOwnerPasswordCredentialsGrantHandler struct {
 	RefreshTokenStrategy RefreshTokenStrategy
 	ScopeStrategy        fosite.ScopeStrategy
 
+	// RefreshTokenLifespan defines the lifetime of a refresh token. Refresh tokens do not expire if set to zero.
+	RefreshTokenLifespan time.Duration
+
+	// ThrottlingStrategy, if set, is consulted before the username and password are checked and may slow down or
+	// block brute-force attempts.
+	ThrottlingStrategy fosite.ThrottlingStrategy
+
+	// RefreshTokenPolicy decides whether refresh tokens are issued. Defaults to OfflineAccessRefreshTokenPolicy.
+	RefreshTokenPolicy RefreshTokenPolicy
+
 	*HandleHelper
 }
 
@@ -56,15 +67,34 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) HandleTokenEndpointReques
 	password := request.GetRequestForm().Get("password")
 	if username == "" || password == "" {
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Username or password are missing from the POST body."))
-	} else if err := c.ResourceOwnerPasswordCredentialsGrantStorage.Authenticate(ctx, username, password); errors.Cause(err) == fosite.ErrNotFound {
+	}
+
+	key := fosite.ThrottlingKey{
+		Kind:     fosite.ThrottleResourceOwnerPassword,
+		ClientID: request.GetClient().GetID(),
+		RemoteIP: fosite.RemoteIPFromContext(ctx),
+		Username: username,
+	}
+	if c.ThrottlingStrategy != nil {
+		if err := c.ThrottlingStrategy.Allow(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	if err := c.ResourceOwnerPasswordCredentialsGrantStorage.Authenticate(ctx, username, password); errors.Cause(err) == fosite.ErrNotFound {
+		if c.ThrottlingStrategy != nil {
+			c.ThrottlingStrategy.Record(ctx, key, false)
+		}
 		return errors.WithStack(fosite.ErrRequestUnauthorized.WithHint("Unable to authenticate the provided username and password credentials.").WithDebug(err.Error()))
 	} else if err != nil {
 		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	} else if c.ThrottlingStrategy != nil {
+		c.ThrottlingStrategy.Record(ctx, key, true)
 	}
 
 	client := request.GetClient()
 	for _, scope := range request.GetRequestedScopes() {
-		if !c.ScopeStrategy(client.GetScopes(), scope) {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
 			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
 		}
 	}
@@ -72,7 +102,8 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) HandleTokenEndpointReques
 	// Credentials must not be passed around, potentially leaking to the database!
 	delete(request.GetRequestForm(), "password")
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, fosite.Now(c.Clock).Add(fosite.EffectiveLifespan(ctx, c.ConfigProvider, client, "password", fosite.AccessToken, c.AccessTokenLifespan)))
+	setRefreshTokenExpiry(ctx, c.ConfigProvider, request, "password", c.RefreshTokenLifespan, fosite.Now(c.Clock))
 	return nil
 }
 
@@ -82,18 +113,21 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) PopulateTokenEndpointResp
 		return errors.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	var refresh, refreshSignature string
-	if requester.GetGrantedScopes().HasOneOf("offline", "offline_access") {
-		var err error
-		refresh, refreshSignature, err = c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
-		if err != nil {
-			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-		} else if err := c.ResourceOwnerPasswordCredentialsGrantStorage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
-			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	var refresh string
+	if err := storage.RunInTransaction(ctx, c.ResourceOwnerPasswordCredentialsGrantStorage, func(ctx context.Context) error {
+		if canRefresh(c.RefreshTokenPolicy, requester) {
+			var refreshSignature string
+			var err error
+			refresh, refreshSignature, err = c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
+			if err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			} else if err := c.ResourceOwnerPasswordCredentialsGrantStorage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
+				return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+			}
 		}
-	}
 
-	if err := c.IssueAccessToken(ctx, requester, responder); err != nil {
+		return c.IssueAccessToken(ctx, requester, responder)
+	}); err != nil {
 		return err
 	}
 
@@ -103,3 +137,8 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) PopulateTokenEndpointResp
 
 	return nil
 }
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *ResourceOwnerPasswordCredentialsGrantHandler) SupportedGrantTypes() []string {
+	return []string{"password"}
+}
@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"net/url"
 	"testing"
 	"time"
@@ -110,6 +111,34 @@ func TestResourceOwnerFlow_HandleTokenEndpointRequest(t *testing.T) {
 	}
 }
 
+func TestResourceOwnerFlow_Throttling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := internal.NewMockResourceOwnerPasswordCredentialsGrantStorage(ctrl)
+	defer ctrl.Finish()
+
+	h := ResourceOwnerPasswordCredentialsGrantHandler{
+		ResourceOwnerPasswordCredentialsGrantStorage: store,
+		HandleHelper:       &HandleHelper{AccessTokenLifespan: time.Hour},
+		ScopeStrategy:      fosite.HierarchicScopeStrategy,
+		ThrottlingStrategy: &fosite.MemoryThrottlingStrategy{MaxFailures: 1},
+	}
+
+	ctx := fosite.ContextWithRemoteIP(context.Background(), "10.0.0.1")
+	areq := fosite.NewAccessRequest(new(fosite.DefaultSession))
+	areq.GrantTypes = fosite.Arguments{"password"}
+	areq.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"password"}}
+	areq.Form = url.Values{"username": {"peter"}, "password": {"pan"}}
+
+	store.EXPECT().Authenticate(ctx, "peter", "pan").Return(fosite.ErrNotFound)
+	require.EqualError(t, h.HandleTokenEndpointRequest(ctx, areq), fosite.ErrRequestUnauthorized.Error())
+
+	// The storage is not consulted once the attempts are throttled.
+	require.EqualError(t, h.HandleTokenEndpointRequest(ctx, areq), fosite.ErrTooManyRequests.Error())
+
+	store.EXPECT().Authenticate(context.Background(), "peter", "pan").Return(nil)
+	require.NoError(t, h.HandleTokenEndpointRequest(context.Background(), areq))
+}
+
 func TestResourceOwnerFlow_PopulateTokenEndpointResponse(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	store := internal.NewMockResourceOwnerPasswordCredentialsGrantStorage(ctrl)
@@ -33,6 +33,12 @@ type HandleHelper struct {
 	AccessTokenStrategy AccessTokenStrategy
 	AccessTokenStorage  AccessTokenStorage
 	AccessTokenLifespan time.Duration
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ConfigProvider, if set, overrides the lifespans and the scope strategy per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 func (h *HandleHelper) IssueAccessToken(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
@@ -44,12 +50,20 @@ func (h *HandleHelper) IssueAccessToken(ctx context.Context, requester fosite.Ac
 	}
 
 	responder.SetAccessToken(token)
-	responder.SetTokenType("bearer")
-	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, h.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetTokenType(accessTokenType(ctx, h.AccessTokenStrategy, requester))
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, h.AccessTokenLifespan, fosite.Now(h.Clock)))
 	responder.SetScopes(requester.GetGrantedScopes())
 	return nil
 }
 
+// setRefreshTokenExpiry sets the expiry of the refresh token issued through grantType, unless the effective lifespan
+// is zero in which case the refresh token does not expire.
+func setRefreshTokenExpiry(ctx context.Context, p fosite.ConfigProvider, r fosite.Requester, grantType string, lifespan time.Duration, now time.Time) {
+	if lifespan = fosite.EffectiveLifespan(ctx, p, r.GetClient(), grantType, fosite.RefreshToken, lifespan); lifespan > 0 {
+		r.GetSession().SetExpiresAt(fosite.RefreshToken, now.Add(lifespan))
+	}
+}
+
 func getExpiresIn(r fosite.Requester, key fosite.TokenType, defaultLifespan time.Duration, now time.Time) time.Duration {
 	if r.GetSession().GetExpiresAt(key).IsZero() {
 		return defaultLifespan
@@ -22,6 +22,8 @@
 package oauth2
 
 import (
+	"context"
+	"net/url"
 	"testing"
 	"time"
 
@@ -91,3 +93,41 @@ func TestIssueAccessToken(t *testing.T) {
 		}
 	}
 }
+
+type dpopAccessTokenStrategy struct {
+	AccessTokenStrategy
+}
+
+func (dpopAccessTokenStrategy) AccessTokenType(_ context.Context, requester fosite.Requester) string {
+	if requester.GetRequestForm().Get("dpop_jkt") == "" {
+		return ""
+	}
+	return AccessTokenTypeDPoP
+}
+
+func TestIssueAccessToken_TokenType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	accessStrat := internal.NewMockAccessTokenStrategy(ctrl)
+	accessStore := internal.NewMockAccessTokenStorage(ctrl)
+	defer ctrl.Finish()
+
+	accessStrat.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).Return("token", "signature", nil).Times(3)
+	accessStore.EXPECT().CreateAccessTokenSession(gomock.Any(), "signature", gomock.Any()).Return(nil).Times(3)
+
+	for k, c := range []struct {
+		strategy AccessTokenStrategy
+		form     url.Values
+		expect   string
+	}{
+		{strategy: accessStrat, form: url.Values{"dpop_jkt": {"thumbprint"}}, expect: AccessTokenTypeBearer},
+		{strategy: dpopAccessTokenStrategy{accessStrat}, form: url.Values{}, expect: AccessTokenTypeBearer},
+		{strategy: dpopAccessTokenStrategy{accessStrat}, form: url.Values{"dpop_jkt": {"thumbprint"}}, expect: AccessTokenTypeDPoP},
+	} {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Form = c.form
+		aresp := fosite.NewAccessResponse()
+		helper := HandleHelper{AccessTokenStorage: accessStore, AccessTokenStrategy: c.strategy, AccessTokenLifespan: time.Hour}
+		require.NoError(t, helper.IssueAccessToken(context.Background(), areq, aresp))
+		assert.Equal(t, c.expect, aresp.GetTokenType(), "%d", k)
+	}
+}
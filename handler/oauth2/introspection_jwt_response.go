@@ -0,0 +1,99 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+
+	jwtx "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/pkg/errors"
+)
+
+// ContentTypeTokenIntrospectionJWT is the media type of introspection responses which are signed JWTs, see
+// https://tools.ietf.org/html/draft-ietf-oauth-jwt-introspection-response
+const ContentTypeTokenIntrospectionJWT = "application/token-introspection+jwt"
+
+// AcceptsJWTIntrospectionResponse returns true if the client asked for an introspection response in the form of a
+// signed JWT using the Accept header.
+func AcceptsJWTIntrospectionResponse(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == ContentTypeTokenIntrospectionJWT {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTIntrospectionResponseWriter writes introspection responses as signed JWTs, which allows resource servers to
+// prove what the authorization server said about an opaque token, as required by FAPI 2.0. The members of the
+// introspection response (see fosite.IntrospectionResponseClaims) are the "token_introspection" claim of the JWT.
+type JWTIntrospectionResponseWriter struct {
+	JWTStrategy jwt.JWTStrategy
+
+	// Issuer is the issuer of the responses. Overridden by the issuer of the tenant of the request, see fosite.Tenant.
+	Issuer string
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+}
+
+// GenerateIntrospectionResponse returns the signed introspection response of r for the resource server audience,
+// which is usually the client ID of the resource server.
+func (w *JWTIntrospectionResponseWriter) GenerateIntrospectionResponse(ctx context.Context, r fosite.IntrospectionResponder, audience string) (string, error) {
+	issuer := w.Issuer
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+		issuer = tenant.Issuer
+	}
+
+	claims := jwtx.MapClaims{
+		"iss":                 issuer,
+		"aud":                 audience,
+		"iat":                 fosite.Now(w.Clock).Unix(),
+		"token_introspection": fosite.IntrospectionResponseClaims(r),
+	}
+	token, _, err := jwt.Generate(ctx, w.JWTStrategy, claims, jwt.NewTypedHeaders("token-introspection+jwt"))
+	if err != nil {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+	return token, nil
+}
+
+// WriteIntrospectionResponse writes the signed introspection response of r for the resource server audience. Nothing
+// is written if the response can not be signed.
+func (w *JWTIntrospectionResponseWriter) WriteIntrospectionResponse(ctx context.Context, rw http.ResponseWriter, r fosite.IntrospectionResponder, audience string) error {
+	token, err := w.GenerateIntrospectionResponse(ctx, r, audience)
+	if err != nil {
+		return err
+	}
+
+	rw.Header().Set("Content-Type", ContentTypeTokenIntrospectionJWT)
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte(token))
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsJWTIntrospectionResponse(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                                    false,
+		"application/json":                    false,
+		"application/token-introspection+jwt": true,
+		"application/json, application/token-introspection+jwt; q=0.9": true,
+	} {
+		r := httptest.NewRequest("POST", "/introspect", nil)
+		r.Header.Set("Accept", accept)
+		assert.Equal(t, expected, AcceptsJWTIntrospectionResponse(r), "%s", accept)
+	}
+}
+
+func TestJWTIntrospectionResponseWriter(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	w := &JWTIntrospectionResponseWriter{
+		JWTStrategy: j.JWTStrategy,
+		Issuer:      "https://auth.example.com",
+		Clock:       fosite.ClockFunc(func() time.Time { return now }),
+	}
+
+	ar := fosite.NewAccessRequest(&fosite.DefaultSession{
+		Subject:   "peter",
+		ExpiresAt: map[fosite.TokenType]time.Time{fosite.AccessToken: now.Add(time.Hour)},
+	})
+	ar.Client = &fosite.DefaultClient{ID: "foo"}
+	ar.RequestedAt = now
+	ar.GrantScope("read")
+	ar.GrantScope("write")
+
+	rw := httptest.NewRecorder()
+	require.NoError(t, w.WriteIntrospectionResponse(context.Background(), rw, &fosite.IntrospectionResponse{Active: true, AccessRequester: ar}, "resource-server"))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, ContentTypeTokenIntrospectionJWT, rw.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rw.Header().Get("Cache-Control"))
+
+	token, err := j.JWTStrategy.Decode(rw.Body.String())
+	require.NoError(t, err)
+	assert.Equal(t, "token-introspection+jwt", token.Header["typ"])
+	claims := token.Claims.(jwtgo.MapClaims)
+	assert.Equal(t, "https://auth.example.com", claims["iss"])
+	assert.Equal(t, "resource-server", claims["aud"])
+	assert.Equal(t, map[string]interface{}{
+		"active":    true,
+		"client_id": "foo",
+		"scope":     "read write",
+		"sub":       "peter",
+		"iat":       float64(now.Unix()),
+		"exp":       float64(now.Add(time.Hour).Unix()),
+	}, claims["token_introspection"])
+
+	// Inactive tokens disclose nothing but their state.
+	token, err = j.JWTStrategy.Decode(mustGenerateIntrospectionResponse(t, w, &fosite.IntrospectionResponse{Active: false}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"active": false}, token.Claims.(jwtgo.MapClaims)["token_introspection"])
+}
+
+func mustGenerateIntrospectionResponse(t *testing.T, w *JWTIntrospectionResponseWriter, r fosite.IntrospectionResponder) string {
+	token, err := w.GenerateIntrospectionResponse(context.Background(), r, "resource-server")
+	require.NoError(t, err)
+	return token
+}
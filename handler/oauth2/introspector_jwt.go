@@ -33,13 +33,25 @@ type JWTAccessTokenStrategy interface {
 	JWTStrategy
 }
 
+// ContextJWTStrategy is implemented by JWT strategies which validate tokens depending on the context of the request,
+// for example by its fosite.Tenant. StatelessJWTValidator prefers it over JWTStrategy.
+type ContextJWTStrategy interface {
+	ValidateJWTWithContext(ctx context.Context, tokenType fosite.TokenType, token string) (requester fosite.Requester, err error)
+}
+
 type StatelessJWTValidator struct {
 	JWTAccessTokenStrategy
 	ScopeStrategy fosite.ScopeStrategy
 }
 
 func (v *StatelessJWTValidator) IntrospectToken(ctx context.Context, token string, tokenType fosite.TokenType, accessRequest fosite.AccessRequester, scopes []string) (fosite.TokenType, error) {
-	or, err := v.JWTAccessTokenStrategy.ValidateJWT(fosite.AccessToken, token)
+	var or fosite.Requester
+	var err error
+	if cs, ok := v.JWTAccessTokenStrategy.(ContextJWTStrategy); ok {
+		or, err = cs.ValidateJWTWithContext(ctx, fosite.AccessToken, token)
+	} else {
+		or, err = v.JWTAccessTokenStrategy.ValidateJWT(fosite.AccessToken, token)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,75 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// recordTokenLineage records the tokens derived from parentSignature. Tokens with an empty signature are skipped.
+func recordTokenLineage(ctx context.Context, lineage TokenLineageStorage, requestID, parentSignature string, tokens map[fosite.TokenType]string, now time.Time) error {
+	for _, tokenType := range []fosite.TokenType{fosite.AccessToken, fosite.RefreshToken} {
+		if tokens[tokenType] == "" {
+			continue
+		}
+		if err := lineage.RecordTokenLineage(ctx, requestID, fosite.TokenLineageEntry{
+			TokenType:       tokenType,
+			Signature:       tokens[tokenType],
+			ParentSignature: parentSignature,
+			CreatedAt:       now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeTokenLineage deletes every access and refresh token recorded in the lineage of the request if store
+// implements TokenLineageStorage.
+func revokeTokenLineage(ctx context.Context, store TokenRevocationStorage, requestID string) error {
+	lineage, ok := store.(TokenLineageStorage)
+	if !ok {
+		return nil
+	}
+
+	entries, err := lineage.GetTokenLineage(ctx, requestID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch entry.TokenType {
+		case fosite.AccessToken:
+			err = store.DeleteAccessTokenSession(ctx, entry.Signature)
+		case fosite.RefreshToken:
+			err = store.DeleteRefreshTokenSession(ctx, entry.Signature)
+		}
+		if err != nil && errors.Cause(err) != fosite.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import "github.com/ory/fosite"
+
+// RefreshTokenPolicy decides whether refresh tokens are issued for, and may be used by, the grant of requester.
+type RefreshTokenPolicy func(requester fosite.Requester) bool
+
+// OfflineAccessRefreshTokenPolicy issues refresh tokens only if the scope "offline" or "offline_access" was granted,
+// as required by OpenID Connect. This is the default policy.
+func OfflineAccessRefreshTokenPolicy(requester fosite.Requester) bool {
+	return requester.GetGrantedScopes().HasOneOf("offline", "offline_access")
+}
+
+// PlainOAuth2RefreshTokenPolicy always issues refresh tokens for plain OAuth 2.0 grants. Grants which include the
+// scope "openid" still require "offline" or "offline_access", as defined by OpenID Connect.
+func PlainOAuth2RefreshTokenPolicy(requester fosite.Requester) bool {
+	return !requester.GetGrantedScopes().Has("openid") || OfflineAccessRefreshTokenPolicy(requester)
+}
+
+func canRefresh(policy RefreshTokenPolicy, requester fosite.Requester) bool {
+	if policy == nil {
+		return OfflineAccessRefreshTokenPolicy(requester)
+	}
+	return policy(requester)
+}
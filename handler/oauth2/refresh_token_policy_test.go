@@ -0,0 +1,51 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenPolicy(t *testing.T) {
+	for k, c := range []struct {
+		granted fosite.Arguments
+		offline bool
+		plain   bool
+	}{
+		{granted: fosite.Arguments{"foo"}, offline: false, plain: true},
+		{granted: fosite.Arguments{"foo", "offline"}, offline: true, plain: true},
+		{granted: fosite.Arguments{"foo", "offline_access"}, offline: true, plain: true},
+		{granted: fosite.Arguments{"openid"}, offline: false, plain: false},
+		{granted: fosite.Arguments{"openid", "offline_access"}, offline: true, plain: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := &fosite.Request{GrantedScopes: c.granted}
+			assert.Equal(t, c.offline, OfflineAccessRefreshTokenPolicy(r))
+			assert.Equal(t, c.offline, canRefresh(nil, r))
+			assert.Equal(t, c.plain, PlainOAuth2RefreshTokenPolicy(r))
+		})
+	}
+}
@@ -23,6 +23,7 @@ package oauth2
 
 import (
 	"context"
+	"time"
 
 	"github.com/ory/fosite"
 )
@@ -47,7 +48,13 @@ type AuthorizeCodeStorage interface {
 
 	// InvalidateAuthorizeCodeSession is called when an authorize code is being used. The state of the authorization
 	// code should be set to invalid and consecutive requests to GetAuthorizeCodeSession should return the
-	// ErrInvalidatedAuthorizeCode error.
+	// ErrInvalidatedAuthorizeCode error. The authorization request must be kept, because all tokens issued with
+	// its request ID are revoked when the authorization code is presented again.
+	//
+	// The invalidation must be atomic: if the code was already invalidated, for example by a concurrent request
+	// redeeming the same code, it must return ErrInvalidatedAuthorizeCode, so that only one of the requests receives
+	// tokens. SQL storages can for example use "UPDATE ... SET active = false WHERE signature = ? AND active = true"
+	// and check the number of affected rows.
 	InvalidateAuthorizeCodeSession(ctx context.Context, code string) (err error)
 }
 
@@ -66,3 +73,26 @@ type RefreshTokenStorage interface {
 
 	DeleteRefreshTokenSession(ctx context.Context, signature string) (err error)
 }
+
+// RefreshTokenReuseStorage is an optional extension of TokenRevocationStorage which remembers rotated refresh tokens,
+// so that a refresh token which is presented again after it was exchanged can be detected.
+type RefreshTokenReuseStorage interface {
+	// MarkRefreshTokenUsed records that the refresh token with the given signature was exchanged at usedAt. If the
+	// refresh token was already marked as used, the original time of use must be kept.
+	MarkRefreshTokenUsed(ctx context.Context, signature string, request fosite.Requester, usedAt time.Time) (err error)
+
+	// GetUsedRefreshToken returns the request and the time of use of a refresh token marked as used, or
+	// fosite.ErrNotFound.
+	GetUsedRefreshToken(ctx context.Context, signature string, session fosite.Session) (request fosite.Requester, usedAt time.Time, err error)
+}
+
+// TokenLineageStorage is an optional extension of CoreStorage and TokenRevocationStorage which records all tokens
+// issued for a request, from the authorize code to every rotated refresh token. Revoking a request, for example when
+// an authorize code is used twice, then also removes tokens which are no longer indexed by the request ID.
+type TokenLineageStorage interface {
+	// RecordTokenLineage appends the entry to the lineage of the request.
+	RecordTokenLineage(ctx context.Context, requestID string, entry fosite.TokenLineageEntry) (err error)
+
+	// GetTokenLineage returns the lineage of the request in the order it was recorded.
+	GetTokenLineage(ctx context.Context, requestID string) (entries []fosite.TokenLineageEntry, err error)
+}
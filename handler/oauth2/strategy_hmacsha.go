@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"strings"
 	"time"
 
 	"context"
@@ -35,6 +36,29 @@ type HMACSHAStrategy struct {
 	Enigma                *enigma.HMACStrategy
 	AccessTokenLifespan   time.Duration
 	AuthorizeCodeLifespan time.Duration
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ClockSkew is the leeway applied when checking whether a token expired.
+	ClockSkew time.Duration
+
+	// TokenPrefixes are prepended to generated tokens by token type, for example DefaultTokenPrefixes, so that the
+	// type of a token can be told in logs and by secret scanners. Tokens of a type with a prefix are only valid if
+	// they carry it. The signatures of tokens do not include the prefix.
+	TokenPrefixes map[fosite.TokenType]string
+
+	// TenantEnigmas generates and validates the tokens of the tenants by tenant ID, so that every tenant has its own
+	// HMAC secret and tokens of one tenant are invalid for the others, see fosite.Tenant. Tenants without a strategy
+	// use Enigma. The strategies must use the same Encoding as Enigma.
+	TenantEnigmas map[string]*enigma.HMACStrategy
+}
+
+// DefaultTokenPrefixes are the recommended token prefixes, see HMACSHAStrategy.TokenPrefixes.
+var DefaultTokenPrefixes = map[fosite.TokenType]string{
+	fosite.AccessToken:   "ory_at_",
+	fosite.RefreshToken:  "ory_rt_",
+	fosite.AuthorizeCode: "ory_ac_",
 }
 
 func (h HMACSHAStrategy) AccessTokenSignature(token string) string {
@@ -47,41 +71,72 @@ func (h HMACSHAStrategy) AuthorizeCodeSignature(token string) string {
 	return h.Enigma.Signature(token)
 }
 
-func (h HMACSHAStrategy) GenerateAccessToken(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+func (h HMACSHAStrategy) GenerateAccessToken(ctx context.Context, _ fosite.Requester) (token string, signature string, err error) {
+	return h.generateToken(ctx, fosite.AccessToken)
 }
 
-func (h HMACSHAStrategy) ValidateAccessToken(_ context.Context, r fosite.Requester, token string) (err error) {
+func (h HMACSHAStrategy) ValidateAccessToken(ctx context.Context, r fosite.Requester, token string) (err error) {
 	var exp = r.GetSession().GetExpiresAt(fosite.AccessToken)
-	if exp.IsZero() && r.GetRequestedAt().Add(h.AccessTokenLifespan).Before(time.Now().UTC()) {
+	if exp.IsZero() && fosite.IsExpired(h.Clock, h.ClockSkew, r.GetRequestedAt().Add(h.AccessTokenLifespan)) {
 		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Access token expired at \"%s\".", r.GetRequestedAt().Add(h.AccessTokenLifespan)))
 	}
-	if !exp.IsZero() && exp.Before(time.Now().UTC()) {
+	if fosite.IsExpired(h.Clock, h.ClockSkew, exp) {
 		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Access token expired at \"%s\".", exp))
 	}
-	return h.Enigma.Validate(token)
+	return h.validateToken(ctx, fosite.AccessToken, token)
 }
 
-func (h HMACSHAStrategy) GenerateRefreshToken(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+func (h HMACSHAStrategy) GenerateRefreshToken(ctx context.Context, _ fosite.Requester) (token string, signature string, err error) {
+	return h.generateToken(ctx, fosite.RefreshToken)
 }
 
-func (h HMACSHAStrategy) ValidateRefreshToken(_ context.Context, _ fosite.Requester, token string) (err error) {
-	return h.Enigma.Validate(token)
+func (h HMACSHAStrategy) ValidateRefreshToken(ctx context.Context, r fosite.Requester, token string) (err error) {
+	if exp := r.GetSession().GetExpiresAt(fosite.RefreshToken); fosite.IsExpired(h.Clock, h.ClockSkew, exp) {
+		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Refresh token expired at \"%s\".", exp))
+	}
+	return h.validateToken(ctx, fosite.RefreshToken, token)
 }
 
-func (h HMACSHAStrategy) GenerateAuthorizeCode(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+func (h HMACSHAStrategy) GenerateAuthorizeCode(ctx context.Context, _ fosite.Requester) (token string, signature string, err error) {
+	return h.generateToken(ctx, fosite.AuthorizeCode)
 }
 
-func (h HMACSHAStrategy) ValidateAuthorizeCode(_ context.Context, r fosite.Requester, token string) (err error) {
+func (h HMACSHAStrategy) ValidateAuthorizeCode(ctx context.Context, r fosite.Requester, token string) (err error) {
 	var exp = r.GetSession().GetExpiresAt(fosite.AuthorizeCode)
-	if exp.IsZero() && r.GetRequestedAt().Add(h.AuthorizeCodeLifespan).Before(time.Now().UTC()) {
+	if exp.IsZero() && fosite.IsExpired(h.Clock, h.ClockSkew, r.GetRequestedAt().Add(h.AuthorizeCodeLifespan)) {
 		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Authorize code expired at \"%s\".", r.GetRequestedAt().Add(h.AuthorizeCodeLifespan)))
 	}
-	if !exp.IsZero() && exp.Before(time.Now().UTC()) {
+	if fosite.IsExpired(h.Clock, h.ClockSkew, exp) {
 		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Authorize code expired at \"%s\".", exp))
 	}
 
-	return h.Enigma.Validate(token)
+	return h.validateToken(ctx, fosite.AuthorizeCode, token)
+}
+
+func (h HMACSHAStrategy) generateToken(ctx context.Context, tokenType fosite.TokenType) (token string, signature string, err error) {
+	_, span := fosite.StartSpan(ctx, "fosite.hmac.Generate")
+	token, signature, err = h.enigma(ctx).Generate()
+	span.End(err)
+	if err != nil {
+		return "", "", err
+	}
+	return h.TokenPrefixes[tokenType] + token, signature, nil
+}
+
+func (h HMACSHAStrategy) validateToken(ctx context.Context, tokenType fosite.TokenType, token string) error {
+	prefix := h.TokenPrefixes[tokenType]
+	if !strings.HasPrefix(token, prefix) {
+		return errors.WithStack(fosite.ErrInvalidTokenFormat.WithHintf("The token does not carry the prefix \"%s\".", prefix))
+	}
+	return h.enigma(ctx).Validate(strings.TrimPrefix(token, prefix))
+}
+
+// enigma returns the HMAC strategy of the tenant of ctx, or Enigma.
+func (h HMACSHAStrategy) enigma(ctx context.Context) *enigma.HMACStrategy {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil {
+		if e, ok := h.TenantEnigmas[tenant.ID]; ok {
+			return e
+		}
+	}
+	return h.Enigma
 }
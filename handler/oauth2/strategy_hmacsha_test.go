@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/hmac"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var hmacshaStrategy = HMACSHAStrategy{
@@ -47,6 +49,7 @@ var hmacExpiredCase = fosite.Request{
 		ExpiresAt: map[fosite.TokenType]time.Time{
 			fosite.AccessToken:   time.Now().UTC().Add(-time.Hour),
 			fosite.AuthorizeCode: time.Now().UTC().Add(-time.Hour),
+			fosite.RefreshToken:  time.Now().UTC().Add(-time.Hour),
 		},
 	},
 }
@@ -59,6 +62,7 @@ var hmacValidCase = fosite.Request{
 		ExpiresAt: map[fosite.TokenType]time.Time{
 			fosite.AccessToken:   time.Now().UTC().Add(time.Hour),
 			fosite.AuthorizeCode: time.Now().UTC().Add(time.Hour),
+			fosite.RefreshToken:  time.Now().UTC().Add(time.Hour),
 		},
 	},
 }
@@ -95,14 +99,41 @@ func TestHMACAccessToken(t *testing.T) {
 }
 
 func TestHMACRefreshToken(t *testing.T) {
-	token, signature, err := hmacshaStrategy.GenerateRefreshToken(nil, &hmacValidCase)
-	assert.NoError(t, err)
-	assert.Equal(t, strings.Split(token, ".")[1], signature)
+	for k, c := range []struct {
+		r    fosite.Request
+		pass bool
+	}{
+		{
+			r:    hmacValidCase,
+			pass: true,
+		},
+		{
+			r:    hmacExpiredCase,
+			pass: false,
+		},
+		{
+			r: fosite.Request{
+				Client:  &fosite.DefaultClient{},
+				Session: &fosite.DefaultSession{},
+			},
+			pass: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			token, signature, err := hmacshaStrategy.GenerateRefreshToken(nil, &c.r)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Split(token, ".")[1], signature)
 
-	validate := hmacshaStrategy.Enigma.Signature(token)
-	err = hmacshaStrategy.ValidateRefreshToken(nil, &hmacValidCase, token)
-	assert.NoError(t, err)
-	assert.Equal(t, signature, validate)
+			err = hmacshaStrategy.ValidateRefreshToken(nil, &c.r, token)
+			if c.pass {
+				assert.NoError(t, err)
+				validate := hmacshaStrategy.Enigma.Signature(token)
+				assert.Equal(t, signature, validate)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
 }
 
 func TestHMACAuthorizeCode(t *testing.T) {
@@ -135,3 +166,93 @@ func TestHMACAuthorizeCode(t *testing.T) {
 		})
 	}
 }
+
+func TestHMACClockSkew(t *testing.T) {
+	now := time.Now().UTC()
+	s := hmacshaStrategy
+	s.Clock = fosite.ClockFunc(func() time.Time { return now })
+
+	token, _, err := s.GenerateAccessToken(nil, nil)
+	assert.NoError(t, err)
+
+	r := &fosite.Request{
+		RequestedAt: now.Add(-time.Hour),
+		Session: &fosite.DefaultSession{
+			ExpiresAt: map[fosite.TokenType]time.Time{
+				fosite.AccessToken:   now.Add(-time.Second * 30),
+				fosite.RefreshToken:  now.Add(-time.Second * 30),
+				fosite.AuthorizeCode: now.Add(-time.Second * 30),
+			},
+		},
+	}
+
+	assert.Error(t, s.ValidateAccessToken(nil, r, token))
+	assert.Error(t, s.ValidateRefreshToken(nil, r, token))
+	assert.Error(t, s.ValidateAuthorizeCode(nil, r, token))
+
+	s.ClockSkew = time.Minute
+	assert.NoError(t, s.ValidateAccessToken(nil, r, token))
+	assert.NoError(t, s.ValidateRefreshToken(nil, r, token))
+	assert.NoError(t, s.ValidateAuthorizeCode(nil, r, token))
+}
+
+func TestHMACTokenPrefixes(t *testing.T) {
+	strategy := hmacshaStrategy
+	strategy.TokenPrefixes = DefaultTokenPrefixes
+
+	for _, c := range []struct {
+		tokenType fosite.TokenType
+		generate  func() (string, string, error)
+		validate  func(token string) error
+	}{
+		{
+			tokenType: fosite.AccessToken,
+			generate:  func() (string, string, error) { return strategy.GenerateAccessToken(nil, &hmacValidCase) },
+			validate:  func(token string) error { return strategy.ValidateAccessToken(nil, &hmacValidCase, token) },
+		},
+		{
+			tokenType: fosite.RefreshToken,
+			generate:  func() (string, string, error) { return strategy.GenerateRefreshToken(nil, &hmacValidCase) },
+			validate:  func(token string) error { return strategy.ValidateRefreshToken(nil, &hmacValidCase, token) },
+		},
+		{
+			tokenType: fosite.AuthorizeCode,
+			generate:  func() (string, string, error) { return strategy.GenerateAuthorizeCode(nil, &hmacValidCase) },
+			validate:  func(token string) error { return strategy.ValidateAuthorizeCode(nil, &hmacValidCase, token) },
+		},
+	} {
+		t.Run("type="+string(c.tokenType), func(t *testing.T) {
+			token, signature, err := c.generate()
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(token, DefaultTokenPrefixes[c.tokenType]), "%s", token)
+			assert.Equal(t, signature, strategy.AccessTokenSignature(token))
+			assert.NoError(t, c.validate(token))
+
+			// Tokens without the prefix, or with the prefix of another type, are rejected.
+			assert.Error(t, c.validate(strings.TrimPrefix(token, DefaultTokenPrefixes[c.tokenType])))
+			assert.Error(t, c.validate("ory_xx_"+strings.TrimPrefix(token, DefaultTokenPrefixes[c.tokenType])))
+		})
+	}
+}
+
+func TestHMACTenantSecrets(t *testing.T) {
+	strategy := hmacshaStrategy
+	strategy.TenantEnigmas = map[string]*hmac.HMACStrategy{
+		"acme": {GlobalSecret: []byte("acmeacmeacmeacmeacmeacmeacmeacmeacmeacme")},
+	}
+	acme := fosite.ContextWithTenant(context.Background(), &fosite.Tenant{ID: "acme"})
+	other := fosite.ContextWithTenant(context.Background(), &fosite.Tenant{ID: "other"})
+
+	token, _, err := strategy.GenerateAccessToken(acme, &hmacValidCase)
+	require.NoError(t, err)
+	assert.NoError(t, strategy.ValidateAccessToken(acme, &hmacValidCase, token))
+
+	// Tenants without a secret of their own, and requests without a tenant, use the default secret.
+	assert.Error(t, strategy.ValidateAccessToken(other, &hmacValidCase, token))
+	assert.Error(t, strategy.ValidateAccessToken(context.Background(), &hmacValidCase, token))
+
+	token, _, err = strategy.GenerateAccessToken(other, &hmacValidCase)
+	require.NoError(t, err)
+	assert.NoError(t, strategy.ValidateAccessToken(context.Background(), &hmacValidCase, token))
+	assert.Error(t, strategy.ValidateAccessToken(acme, &hmacValidCase, token))
+}
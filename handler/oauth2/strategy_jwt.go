@@ -38,6 +38,31 @@ type DefaultJWTStrategy struct {
 	jwt.JWTStrategy
 	HMACSHAStrategy *HMACSHAStrategy
 	Issuer          string
+
+	// ClaimsProfile controls which claims are written to access tokens. Clients implementing ClientWithClaimsProfile
+	// may override it. Defaults to ClaimsProfileVerbose.
+	ClaimsProfile ClaimsProfile
+
+	// AudienceClaimsProfiles restricts the claims of access tokens issued to the given audiences. If the profile is more
+	// verbose than the one of the client, it is ignored.
+	AudienceClaimsProfiles map[string]ClaimsProfile
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ClockSkew is the leeway applied when validating the "exp", "nbf" and "iat" claims.
+	ClockSkew time.Duration
+
+	// Issuers, if set, overrides Issuer for new tokens and rejects tokens which were not issued by one of its issuers.
+	Issuers *fosite.IssuerSet
+
+	// ClaimsEnricher, if set, adds custom claims to access tokens. They are added after the claims profile has been
+	// applied.
+	ClaimsEnricher fosite.ClaimsEnricher
+
+	// TenantJWTStrategies signs and verifies the tokens of the tenants by tenant ID, see fosite.Tenant. Tenants
+	// without a strategy use JWTStrategy. Tokens of tenants with an issuer are issued by and must be issued by it.
+	TenantJWTStrategies map[string]jwt.JWTStrategy
 }
 
 func (h DefaultJWTStrategy) signature(token string) string {
@@ -53,17 +78,25 @@ func (h DefaultJWTStrategy) AccessTokenSignature(token string) string {
 	return h.signature(token)
 }
 
-func (h *DefaultJWTStrategy) GenerateAccessToken(_ context.Context, requester fosite.Requester) (token string, signature string, err error) {
-	return h.generate(fosite.AccessToken, requester)
+func (h *DefaultJWTStrategy) GenerateAccessToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	_, span := fosite.StartSpan(ctx, "fosite.jwt.Sign")
+	token, signature, err = h.generate(ctx, fosite.AccessToken, requester)
+	span.End(err)
+	return token, signature, err
 }
 
-func (h *DefaultJWTStrategy) ValidateAccessToken(_ context.Context, _ fosite.Requester, token string) error {
-	_, err := h.validate(token)
+func (h *DefaultJWTStrategy) ValidateAccessToken(ctx context.Context, _ fosite.Requester, token string) error {
+	_, err := h.validate(ctx, token)
 	return err
 }
 
 func (h *DefaultJWTStrategy) ValidateJWT(tokenType fosite.TokenType, token string) (requester fosite.Requester, err error) {
-	t, err := h.validate(token)
+	return h.ValidateJWTWithContext(context.Background(), tokenType, token)
+}
+
+// ValidateJWTWithContext implements ContextJWTStrategy.
+func (h *DefaultJWTStrategy) ValidateJWTWithContext(ctx context.Context, tokenType fosite.TokenType, token string) (requester fosite.Requester, err error) {
+	t, err := h.validate(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -115,11 +148,35 @@ func (h *DefaultJWTStrategy) ValidateAuthorizeCode(ctx context.Context, req fosi
 	return h.HMACSHAStrategy.ValidateAuthorizeCode(ctx, req, token)
 }
 
-func (h *DefaultJWTStrategy) validate(token string) (t *jwtx.Token, err error) {
-	t, err = h.JWTStrategy.Decode(token)
+// jwtStrategy returns the strategy of the tenant of ctx, or JWTStrategy.
+func (h *DefaultJWTStrategy) jwtStrategy(ctx context.Context) jwt.JWTStrategy {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil {
+		if strategy, ok := h.TenantJWTStrategies[tenant.ID]; ok {
+			return strategy
+		}
+	}
+	return h.JWTStrategy
+}
+
+func (h *DefaultJWTStrategy) validate(ctx context.Context, token string) (t *jwtx.Token, err error) {
+	t, err = h.jwtStrategy(ctx).Decode(token)
 
 	if err == nil {
-		err = t.Claims.Valid()
+		err = jwt.ValidateTimeClaims(t.Claims, fosite.Now(h.Clock), h.ClockSkew)
+	}
+
+	if tenant := fosite.TenantFromContext(ctx); err == nil && tenant != nil && tenant.Issuer != "" {
+		if claims, ok := t.Claims.(jwtx.MapClaims); ok {
+			if iss, _ := claims["iss"].(string); iss != tenant.Issuer {
+				return nil, errors.WithStack(fosite.ErrTokenClaim.WithDebugf("Token was issued by \"%s\" but the tenant's issuer is \"%s\".", iss, tenant.Issuer))
+			}
+		}
+	} else if err == nil && h.Issuers != nil {
+		if claims, ok := t.Claims.(jwtx.MapClaims); ok {
+			if iss, _ := claims["iss"].(string); !h.Issuers.Accepts(iss) {
+				return nil, errors.WithStack(fosite.ErrTokenClaim.WithDebugf("Token was issued by \"%s\" which is not an accepted issuer.", iss))
+			}
+		}
 	}
 
 	if err != nil {
@@ -154,7 +211,7 @@ func (h *DefaultJWTStrategy) validate(token string) (t *jwtx.Token, err error) {
 	return
 }
 
-func (h *DefaultJWTStrategy) generate(tokenType fosite.TokenType, requester fosite.Requester) (string, string, error) {
+func (h *DefaultJWTStrategy) generate(ctx context.Context, tokenType fosite.TokenType, requester fosite.Requester) (string, string, error) {
 	if jwtSession, ok := requester.GetSession().(JWTSessionContainer); !ok {
 		return "", "", errors.New("Session must be of type JWTSessionContainer")
 	} else if jwtSession.GetJWTClaims() == nil {
@@ -164,15 +221,38 @@ func (h *DefaultJWTStrategy) generate(tokenType fosite.TokenType, requester fosi
 		claims.ExpiresAt = jwtSession.GetExpiresAt(tokenType)
 
 		if claims.IssuedAt.IsZero() {
-			claims.IssuedAt = time.Now().UTC()
+			claims.IssuedAt = fosite.Now(h.Clock)
 		}
 
-		if claims.Issuer == "" {
+		if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+			claims.Issuer = tenant.Issuer
+		} else if h.Issuers != nil {
+			claims.Issuer = h.Issuers.Migrate(claims.Issuer)
+		} else if claims.Issuer == "" {
 			claims.Issuer = h.Issuer
 		}
 
 		claims.Scope = requester.GetGrantedScopes()
 
-		return h.JWTStrategy.Generate(claims.ToMapClaims(), jwtSession.GetJWTHeader())
+		mapClaims := claims.ToMapClaims()
+		if tokenType == fosite.AccessToken {
+			profile, err := h.claimsProfile(requester, claims.Audience)
+			if err != nil {
+				return "", "", err
+			}
+			if mapClaims, err = applyClaimsProfile(profile, mapClaims); err != nil {
+				return "", "", err
+			}
+		}
+
+		if err := fosite.EnrichClaims(ctx, h.ClaimsEnricher, tokenType, requester, mapClaims); err != nil {
+			return "", "", err
+		}
+
+		var header jwt.Mapper = jwtSession.GetJWTHeader()
+		if tokenType == fosite.AccessToken {
+			header = &jwt.TypedHeaders{Headers: jwtSession.GetJWTHeader(), Type: fosite.JWTTypeAccessToken}
+		}
+		return jwt.Generate(ctx, h.jwtStrategy(ctx), mapClaims, header)
 	}
 }
@@ -0,0 +1,117 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	jwtx "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// ClaimsProfile controls which claims are written to JWT access tokens.
+type ClaimsProfile string
+
+const (
+	// ClaimsProfileMinimal only keeps the claims needed to validate the token and its scopes: iss, aud, exp, iat,
+	// nbf, jti and scp.
+	ClaimsProfileMinimal ClaimsProfile = "minimal"
+	// ClaimsProfileStandard keeps the claims of ClaimsProfileMinimal and the subject.
+	ClaimsProfileStandard ClaimsProfile = "standard"
+	// ClaimsProfileVerbose keeps all claims, including the extra claims of the session. This is the default.
+	ClaimsProfileVerbose ClaimsProfile = "verbose"
+)
+
+var claimsProfileClaims = map[ClaimsProfile][]string{
+	ClaimsProfileMinimal:  {"iss", "aud", "exp", "iat", "nbf", "jti", "scp"},
+	ClaimsProfileStandard: {"iss", "aud", "exp", "iat", "nbf", "jti", "scp", "sub"},
+}
+
+var claimsProfileVerbosity = map[ClaimsProfile]int{
+	ClaimsProfileMinimal:  0,
+	ClaimsProfileStandard: 1,
+	ClaimsProfileVerbose:  2,
+}
+
+// ClientWithClaimsProfile is a client which overrides the claims profile of JWT access tokens issued to it.
+type ClientWithClaimsProfile interface {
+	// GetAccessTokenClaimsProfile returns the claims profile, or an empty string to use the default.
+	GetAccessTokenClaimsProfile() ClaimsProfile
+}
+
+// lessVerbose returns the less verbose of both profiles, which must be known.
+func lessVerbose(a, b ClaimsProfile) ClaimsProfile {
+	if claimsProfileVerbosity[b] < claimsProfileVerbosity[a] {
+		return b
+	}
+	return a
+}
+
+func validateClaimsProfile(profile ClaimsProfile) error {
+	if _, ok := claimsProfileVerbosity[profile]; !ok {
+		return errors.WithStack(fosite.ErrServerError.WithDebugf("The access token claims profile \"%s\" is unknown.", profile))
+	}
+	return nil
+}
+
+// claimsProfile returns the profile applying to an access token issued for the requester to the given audiences.
+// The client's profile replaces the default profile, while audience profiles can only make tokens less verbose. An
+// unknown profile is an error rather than falling back to the verbose profile.
+func (h *DefaultJWTStrategy) claimsProfile(requester fosite.Requester, audiences []string) (ClaimsProfile, error) {
+	profile := h.ClaimsProfile
+	if c, ok := requester.GetClient().(ClientWithClaimsProfile); ok && c.GetAccessTokenClaimsProfile() != "" {
+		profile = c.GetAccessTokenClaimsProfile()
+	}
+	if profile == "" {
+		profile = ClaimsProfileVerbose
+	}
+	if err := validateClaimsProfile(profile); err != nil {
+		return "", err
+	}
+
+	for _, audience := range audiences {
+		if p, ok := h.AudienceClaimsProfiles[audience]; ok {
+			if err := validateClaimsProfile(p); err != nil {
+				return "", err
+			}
+			profile = lessVerbose(profile, p)
+		}
+	}
+	return profile, nil
+}
+
+func applyClaimsProfile(profile ClaimsProfile, claims jwtx.MapClaims) (jwtx.MapClaims, error) {
+	if err := validateClaimsProfile(profile); err != nil {
+		return nil, err
+	}
+	keep, ok := claimsProfileClaims[profile]
+	if !ok {
+		return claims, nil
+	}
+
+	minimized := jwtx.MapClaims{}
+	for _, claim := range keep {
+		if value, ok := claims[claim]; ok {
+			minimized[claim] = value
+		}
+	}
+	return minimized, nil
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"fmt"
+	"testing"
+
+	jwtx "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type claimsProfileClient struct {
+	*fosite.DefaultClient
+	profile ClaimsProfile
+}
+
+func (c *claimsProfileClient) GetAccessTokenClaimsProfile() ClaimsProfile {
+	return c.profile
+}
+
+func TestAccessTokenClaimsProfile(t *testing.T) {
+	for k, c := range []struct {
+		d         string
+		profile   ClaimsProfile
+		audiences map[string]ClaimsProfile
+		client    ClaimsProfile
+		expectSub bool
+		expectPII bool
+		expectErr bool
+	}{
+		{d: "verbose by default", expectSub: true, expectPII: true},
+		{d: "standard drops extra claims", profile: ClaimsProfileStandard, expectSub: true},
+		{d: "minimal drops the subject", profile: ClaimsProfileMinimal},
+		{d: "client overrides the default", profile: ClaimsProfileMinimal, client: ClaimsProfileVerbose, expectSub: true, expectPII: true},
+		{d: "audience narrows the client", client: ClaimsProfileVerbose, audiences: map[string]ClaimsProfile{"group0": ClaimsProfileStandard}, expectSub: true},
+		{d: "audience can not widen the default", profile: ClaimsProfileMinimal, audiences: map[string]ClaimsProfile{"group0": ClaimsProfileVerbose}},
+		{d: "other audiences are ignored", audiences: map[string]ClaimsProfile{"group1": ClaimsProfileMinimal}, expectSub: true, expectPII: true},
+		{d: "unknown default profile fails", profile: "minimum", expectErr: true},
+		{d: "unknown client profile fails", client: "verbos", expectErr: true},
+		{d: "unknown audience profile fails", audiences: map[string]ClaimsProfile{"group0": "none"}, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			s := &DefaultJWTStrategy{
+				JWTStrategy:            j.JWTStrategy,
+				ClaimsProfile:          c.profile,
+				AudienceClaimsProfiles: c.audiences,
+			}
+
+			r := jwtValidCase(fosite.AccessToken)
+			r.Client = &claimsProfileClient{DefaultClient: r.Client.(*fosite.DefaultClient), profile: c.client}
+			r.Session.(*JWTSession).JWTClaims.Add("email", "peter@example.org")
+			r.GrantedScopes = fosite.Arguments{"foo"}
+
+			token, _, err := s.GenerateAccessToken(nil, r)
+			if c.expectErr {
+				assert.EqualError(t, err, fosite.ErrServerError.Error())
+				return
+			}
+			require.NoError(t, err)
+			decoded, err := s.JWTStrategy.Decode(token)
+			require.NoError(t, err)
+			claims := decoded.Claims.(jwtx.MapClaims)
+
+			assert.Equal(t, "fosite", claims["iss"])
+			assert.NotEmpty(t, claims["exp"])
+			assert.Equal(t, []interface{}{"foo"}, claims["scp"])
+			_, ok := claims["sub"]
+			assert.Equal(t, c.expectSub, ok)
+			_, ok = claims["email"]
+			assert.Equal(t, c.expectPII, ok)
+		})
+	}
+}
@@ -55,6 +55,11 @@ func (j *JWTSession) GetJWTClaims() *jwt.JWTClaims {
 	return j.JWTClaims
 }
 
+// SetExtraClaim adds a claim to the JSON Web Token.
+func (j *JWTSession) SetExtraClaim(key string, value interface{}) {
+	j.GetJWTClaims().Add(key, value)
+}
+
 func (j *JWTSession) GetJWTHeader() *jwt.Headers {
 	if j.JWTHeader == nil {
 		j.JWTHeader = &jwt.Headers{}
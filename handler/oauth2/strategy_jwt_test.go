@@ -22,16 +22,19 @@
 package oauth2
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
 
 	"fmt"
 
+	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
 	"github.com/ory/fosite/token/jwt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var j = &DefaultJWTStrategy{
@@ -119,9 +122,102 @@ func TestAccessToken(t *testing.T) {
 			if c.pass {
 				assert.NoError(t, err)
 				assert.Equal(t, signature, validate)
+
+				decoded, err := j.JWTStrategy.Decode(token)
+				require.NoError(t, err)
+				assert.Equal(t, fosite.JWTTypeAccessToken, decoded.Header["typ"])
 			} else {
 				assert.Error(t, err)
 			}
 		})
 	}
 }
+
+func TestAccessTokenIssuerMigration(t *testing.T) {
+	legacy := &DefaultJWTStrategy{JWTStrategy: j.JWTStrategy}
+	migrated := &DefaultJWTStrategy{
+		JWTStrategy: j.JWTStrategy,
+		Issuers:     &fosite.IssuerSet{Issuer: "https://new.example.com", LegacyIssuers: []string{"fosite"}},
+	}
+
+	// Tokens issued before the migration keep validating and their usage is counted.
+	token, _, err := legacy.GenerateAccessToken(nil, jwtValidCase(fosite.AccessToken))
+	assert.NoError(t, err)
+	assert.NoError(t, migrated.ValidateAccessToken(nil, nil, token))
+	assert.Equal(t, map[string]uint64{"fosite": 1}, migrated.Issuers.LegacyUsage())
+
+	// New tokens use the new issuer, even if the session still references the legacy one.
+	r := jwtValidCase(fosite.AccessToken)
+	token, _, err = migrated.GenerateAccessToken(nil, r)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://new.example.com", r.Session.(*JWTSession).JWTClaims.Issuer)
+	assert.NoError(t, migrated.ValidateAccessToken(nil, nil, token))
+	assert.Equal(t, map[string]uint64{"fosite": 1}, migrated.Issuers.LegacyUsage())
+
+	// Tokens of unknown issuers are rejected.
+	r = jwtValidCase(fosite.AccessToken)
+	r.Session.(*JWTSession).JWTClaims.Issuer = "https://evil.example.com"
+	token, _, err = legacy.GenerateAccessToken(nil, r)
+	assert.NoError(t, err)
+	assert.Error(t, migrated.ValidateAccessToken(nil, nil, token))
+}
+
+func TestAccessTokenTenants(t *testing.T) {
+	acme := &fosite.Tenant{ID: "acme", Issuer: "https://acme.example.com"}
+	s := &DefaultJWTStrategy{
+		JWTStrategy: j.JWTStrategy,
+		Issuer:      "fosite",
+		TenantJWTStrategies: map[string]jwt.JWTStrategy{
+			acme.ID: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()},
+		},
+	}
+	ctx := fosite.ContextWithTenant(context.Background(), acme)
+
+	// Tokens of the tenant are issued by its issuer and signed with its key.
+	r := jwtValidCase(fosite.AccessToken)
+	token, _, err := s.GenerateAccessToken(ctx, r)
+	require.NoError(t, err)
+	assert.Equal(t, acme.Issuer, r.Session.(*JWTSession).JWTClaims.Issuer)
+	assert.NoError(t, s.ValidateAccessToken(ctx, nil, token))
+	assert.Error(t, s.ValidateAccessToken(context.Background(), nil, token))
+
+	or, err := s.ValidateJWTWithContext(ctx, fosite.AccessToken, token)
+	require.NoError(t, err)
+	assert.Equal(t, "peter", or.GetSession().GetSubject())
+
+	// Tokens of other tenants are rejected, even if they share the signing key.
+	other := fosite.ContextWithTenant(context.Background(), &fosite.Tenant{ID: "other", Issuer: "https://other.example.com"})
+	token, _, err = s.GenerateAccessToken(other, jwtValidCase(fosite.AccessToken))
+	require.NoError(t, err)
+	assert.NoError(t, s.ValidateAccessToken(other, nil, token))
+	assert.Error(t, s.ValidateAccessToken(ctx, nil, token))
+	assert.Error(t, s.ValidateAccessToken(fosite.ContextWithTenant(context.Background(), &fosite.Tenant{ID: "third", Issuer: "https://third.example.com"}), nil, token))
+}
+
+func TestAccessTokenClaimsEnricher(t *testing.T) {
+	s := &DefaultJWTStrategy{JWTStrategy: j.JWTStrategy}
+
+	r := jwtValidCase(fosite.AccessToken)
+	r.GrantScope("admin")
+	s.ClaimsEnricher = fosite.ClaimsEnricherFunc(func(_ context.Context, tokenType fosite.TokenType, requester fosite.Requester) (map[string]interface{}, error) {
+		assert.Equal(t, fosite.AccessToken, tokenType)
+		return map[string]interface{}{"tenant": "acme", "roles": requester.GetGrantedScopes()}, nil
+	})
+
+	token, _, err := s.GenerateAccessToken(nil, r)
+	require.NoError(t, err)
+	parsed, err := s.JWTStrategy.Decode(token)
+	require.NoError(t, err)
+	claims := parsed.Claims.(jwtgo.MapClaims)
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, []interface{}{"admin"}, claims["roles"])
+	assert.Equal(t, "peter", claims["sub"])
+	assert.Empty(t, r.Session.(*JWTSession).JWTClaims.Extra, "enriched claims must not be stored in the session")
+
+	// Registered claims can not be overridden.
+	s.ClaimsEnricher = fosite.ClaimsEnricherFunc(func(context.Context, fosite.TokenType, fosite.Requester) (map[string]interface{}, error) {
+		return map[string]interface{}{"sub": "admin"}, nil
+	})
+	_, _, err = s.GenerateAccessToken(nil, jwtValidCase(fosite.AccessToken))
+	assert.EqualError(t, err, fosite.ErrServerError.Error())
+}
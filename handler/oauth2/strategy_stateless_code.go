@@ -0,0 +1,243 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+var b64 = base64.RawURLEncoding
+
+// StatelessAuthorizeCodeStrategy issues authorize codes which carry the authorize request itself, signed using
+// HMAC-SHA256, so that the authorize code flow can run without shared storage for authorize codes. It implements
+// AuthorizeCodeStrategy as well as AuthorizeCodeStorage, see StatelessCoreStorage.
+//
+// The code is signed but not encrypted: do not store confidential data in the session. Used codes are remembered in
+// memory until they expire to detect replays, which is only effective if a single instance redeems codes.
+type StatelessAuthorizeCodeStrategy struct {
+	// Secret is used to sign authorize codes and must be at least 32 bytes long.
+	Secret []byte
+
+	// AuthorizeCodeLifespan defines the lifetime of an authorize code.
+	AuthorizeCodeLifespan time.Duration
+
+	// Clients loads the client an authorize code was issued to.
+	Clients fosite.ClientManager
+
+	// FormParameters are the parameters of the authorize request carried by the code. Defaults to "redirect_uri",
+	// "code_challenge" and "code_challenge_method", which bind the code to the PKCE code challenge.
+	FormParameters []string
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	sync.Mutex
+	used map[string]time.Time
+}
+
+// StatelessCoreStorage is a CoreStorage which uses a StatelessAuthorizeCodeStrategy for authorize codes.
+type StatelessCoreStorage struct {
+	*StatelessAuthorizeCodeStrategy
+	AccessTokenStorage
+	RefreshTokenStorage
+}
+
+type statelessAuthorizeCode struct {
+	ID            string          `json:"id"`
+	RequestedAt   time.Time       `json:"requested_at"`
+	ClientID      string          `json:"client_id"`
+	Scopes        []string        `json:"scopes"`
+	GrantedScopes []string        `json:"granted_scopes"`
+	Form          url.Values      `json:"form"`
+	Session       json.RawMessage `json:"session"`
+}
+
+func (h *StatelessAuthorizeCodeStrategy) formParameters() []string {
+	if len(h.FormParameters) > 0 {
+		return h.FormParameters
+	}
+	return []string{"redirect_uri", "code_challenge", "code_challenge_method"}
+}
+
+func (h *StatelessAuthorizeCodeStrategy) sign(payload string) string {
+	mac := hmac.New(sha256.New, h.Secret)
+	mac.Write([]byte(payload))
+	return b64.EncodeToString(mac.Sum(nil))
+}
+
+func (h *StatelessAuthorizeCodeStrategy) decode(code string) (*statelessAuthorizeCode, error) {
+	parts := strings.Split(code, ".")
+	if len(parts) != 2 {
+		return nil, errors.WithStack(fosite.ErrInvalidTokenFormat)
+	} else if !hmac.Equal([]byte(parts[1]), []byte(h.sign(parts[0]))) {
+		return nil, errors.WithStack(fosite.ErrTokenSignatureMismatch)
+	}
+
+	data, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.WithStack(fosite.ErrInvalidTokenFormat.WithDebug(err.Error()))
+	}
+
+	var payload statelessAuthorizeCode
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.WithStack(fosite.ErrInvalidTokenFormat.WithDebug(err.Error()))
+	}
+	return &payload, nil
+}
+
+// AuthorizeCodeSignature returns the code itself, as the code is the only place the authorize request is kept.
+func (h *StatelessAuthorizeCodeStrategy) AuthorizeCodeSignature(token string) string {
+	return token
+}
+
+func (h *StatelessAuthorizeCodeStrategy) GenerateAuthorizeCode(_ context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	if len(h.Secret) < 32 {
+		return "", "", errors.Errorf("Secret for signing authorize codes is expected to be 32 byte long, got %d byte", len(h.Secret))
+	}
+
+	session, err := json.Marshal(requester.GetSession())
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	form := url.Values{}
+	for _, key := range h.formParameters() {
+		if values, ok := requester.GetRequestForm()[key]; ok {
+			form[key] = values
+		}
+	}
+
+	data, err := json.Marshal(&statelessAuthorizeCode{
+		ID:            requester.GetID(),
+		RequestedAt:   requester.GetRequestedAt(),
+		ClientID:      requester.GetClient().GetID(),
+		Scopes:        requester.GetRequestedScopes(),
+		GrantedScopes: requester.GetGrantedScopes(),
+		Form:          form,
+		Session:       session,
+	})
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	payload := b64.EncodeToString(data)
+	token = payload + "." + h.sign(payload)
+	return token, token, nil
+}
+
+func (h *StatelessAuthorizeCodeStrategy) ValidateAuthorizeCode(_ context.Context, requester fosite.Requester, token string) (err error) {
+	payload, err := h.decode(token)
+	if err != nil {
+		return err
+	}
+
+	exp := payload.RequestedAt.Add(fosite.GetEffectiveLifespan(requester.GetClient(), "authorization_code", fosite.AuthorizeCode, h.AuthorizeCodeLifespan))
+	if exp.Before(fosite.Now(h.Clock)) {
+		return errors.WithStack(fosite.ErrTokenExpired.WithHintf("Authorize code expired at \"%s\".", exp))
+	}
+	return nil
+}
+
+// CreateAuthorizeCodeSession does nothing, the authorize request is carried by the code.
+func (h *StatelessAuthorizeCodeStrategy) CreateAuthorizeCodeSession(_ context.Context, _ string, _ fosite.Requester) (err error) {
+	return nil
+}
+
+// GetAuthorizeCodeSession restores the authorize request from the code and hydrates the session with it.
+func (h *StatelessAuthorizeCodeStrategy) GetAuthorizeCodeSession(ctx context.Context, code string, session fosite.Session) (request fosite.Requester, err error) {
+	payload, err := h.decode(code)
+	if err != nil {
+		return nil, errors.WithStack(fosite.ErrNotFound.WithDebug(err.Error()))
+	}
+
+	client, err := h.Clients.GetClient(ctx, payload.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		if err := json.Unmarshal(payload.Session, session); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	request = &fosite.Request{
+		ID:            payload.ID,
+		RequestedAt:   payload.RequestedAt,
+		Client:        client,
+		Scopes:        payload.Scopes,
+		GrantedScopes: payload.GrantedScopes,
+		Form:          payload.Form,
+		Session:       session,
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	if _, ok := h.used[code]; ok {
+		return request, errors.WithStack(fosite.ErrInvalidatedAuthorizeCode)
+	}
+	return request, nil
+}
+
+// InvalidateAuthorizeCodeSession remembers the code as used until it expires.
+func (h *StatelessAuthorizeCodeStrategy) InvalidateAuthorizeCodeSession(ctx context.Context, code string) (err error) {
+	payload, err := h.decode(code)
+	if err != nil {
+		return errors.WithStack(fosite.ErrNotFound.WithDebug(err.Error()))
+	}
+
+	client, err := h.Clients.GetClient(ctx, payload.ClientID)
+	if err != nil {
+		return err
+	}
+	exp := payload.RequestedAt.Add(fosite.GetEffectiveLifespan(client, "authorization_code", fosite.AuthorizeCode, h.AuthorizeCodeLifespan))
+
+	h.Lock()
+	defer h.Unlock()
+
+	if _, ok := h.used[code]; ok {
+		return errors.WithStack(fosite.ErrInvalidatedAuthorizeCode)
+	}
+
+	now := fosite.Now(h.Clock)
+	if h.used == nil {
+		h.used = map[string]time.Time{}
+	}
+	for used, exp := range h.used {
+		if exp.Before(now) {
+			delete(h.used, used)
+		}
+	}
+	h.used[code] = exp
+	return nil
+}
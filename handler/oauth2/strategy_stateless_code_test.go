@@ -0,0 +1,108 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatelessAuthorizeCode(t *testing.T) {
+	store := storage.NewMemoryStore()
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}, ResponseTypes: fosite.Arguments{"code"}}
+	store.Clients["foo"] = client
+
+	codes := &StatelessAuthorizeCodeStrategy{
+		Secret:                []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar"),
+		AuthorizeCodeLifespan: time.Minute,
+		Clients:               store,
+	}
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:            &StatelessCoreStorage{StatelessAuthorizeCodeStrategy: codes, AccessTokenStorage: store, RefreshTokenStorage: store},
+		AuthorizeCodeStrategy:  codes,
+		AccessTokenStrategy:    &hmacshaStrategy,
+		RefreshTokenStrategy:   &hmacshaStrategy,
+		ScopeStrategy:          fosite.HierarchicScopeStrategy,
+		AccessTokenLifespan:    time.Minute,
+		TokenRevocationStorage: store,
+	}
+
+	authorize := func(requestedAt time.Time) string {
+		ar := fosite.NewAuthorizeRequest()
+		ar.RequestedAt = requestedAt
+		ar.Client = client
+		ar.Form = url.Values{"redirect_uri": {"https://foo.bar/cb"}, "client_secret": {"must-not-leak"}}
+		ar.RedirectURI, _ = url.Parse("https://foo.bar/cb")
+		ar.GrantScope("foo")
+		ar.Session = &fosite.DefaultSession{Subject: "peter"}
+
+		resp := fosite.NewAuthorizeResponse()
+		require.NoError(t, h.IssueAuthorizeCode(nil, ar, resp))
+		return resp.GetQuery().Get("code")
+	}
+
+	redeem := func(code string) (*fosite.AccessRequest, error) {
+		areq := fosite.NewAccessRequest(new(fosite.DefaultSession))
+		areq.GrantTypes = fosite.Arguments{"authorization_code"}
+		areq.Client = client
+		areq.Form = url.Values{"code": {code}, "redirect_uri": {"https://foo.bar/cb"}}
+		if err := h.HandleTokenEndpointRequest(nil, areq); err != nil {
+			return areq, err
+		}
+		return areq, h.PopulateTokenEndpointResponse(nil, areq, fosite.NewAccessResponse())
+	}
+
+	t.Run("case=redeem and replay", func(t *testing.T) {
+		code := authorize(time.Now().UTC())
+		assert.Empty(t, store.AuthorizeCodes)
+		assert.NotContains(t, code, "must-not-leak")
+
+		areq, err := redeem(code)
+		require.NoError(t, err)
+		assert.Equal(t, "peter", areq.GetSession().GetSubject())
+		assert.Equal(t, fosite.Arguments{"foo"}, areq.GetGrantedScopes())
+		assert.Len(t, store.AccessTokens, 1)
+
+		_, err = redeem(code)
+		assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+		assert.Len(t, store.AccessTokens, 0, "a replayed code revokes the tokens issued for it")
+	})
+
+	t.Run("case=tampered", func(t *testing.T) {
+		code := authorize(time.Now().UTC())
+		_, err := redeem("e30" + code)
+		assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	})
+
+	t.Run("case=expired", func(t *testing.T) {
+		code := authorize(time.Now().UTC().Add(-time.Hour))
+		_, err := redeem(code)
+		assert.EqualError(t, errors.Cause(err), fosite.ErrInvalidGrant.Error())
+	})
+}
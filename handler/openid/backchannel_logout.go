@@ -0,0 +1,167 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// BackChannelLogoutEvent is the member of the "events" claim which identifies a logout token.
+const BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// ClientWithBackChannelLogout is implemented by clients which registered the back-channel logout metadata of
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#BCRegistration
+type ClientWithBackChannelLogout interface {
+	// GetBackChannelLogoutURI returns the URI logout tokens are POSTed to.
+	GetBackChannelLogoutURI() string
+
+	// GetBackChannelLogoutSessionRequired returns true if the client requires the "sid" claim in logout tokens.
+	GetBackChannelLogoutSessionRequired() bool
+}
+
+// GenerateLogoutToken generates a signed logout token for the client, which logs the end-user identified by subject
+// and/or the session identified by sessionID out, see
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+func (h DefaultStrategy) GenerateLogoutToken(ctx context.Context, client fosite.Client, subject, sessionID string) (token string, err error) {
+	_, span := fosite.StartSpan(ctx, "fosite.openid.GenerateLogoutToken")
+	defer func() { span.End(err) }()
+
+	if subject == "" && sessionID == "" {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebug("A logout token requires a subject or a session ID."))
+	} else if bc, ok := client.(ClientWithBackChannelLogout); ok && bc.GetBackChannelLogoutSessionRequired() && sessionID == "" {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebugf("The client \"%s\" requires a session ID in logout tokens.", client.GetID()))
+	}
+
+	issuer := h.Issuer
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+		issuer = tenant.Issuer
+	} else if h.Issuers != nil {
+		issuer = h.Issuers.Issuer
+	}
+
+	now := fosite.Now(h.Clock)
+	claims := jwtgo.MapClaims{
+		"iss":    issuer,
+		"aud":    []string{client.GetID()},
+		"iat":    now.Unix(),
+		"exp":    now.Add(2 * time.Minute).Unix(),
+		"jti":    uuid.New(),
+		"events": map[string]interface{}{BackChannelLogoutEvent: map[string]interface{}{}},
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+
+	token, _, err = jwt.Generate(ctx, h.jwtStrategy(ctx), claims, jwt.NewTypedHeaders(fosite.JWTTypeLogoutToken))
+	return token, err
+}
+
+// LogoutTokenGenerator generates logout tokens, see DefaultStrategy.GenerateLogoutToken.
+type LogoutTokenGenerator interface {
+	GenerateLogoutToken(ctx context.Context, client fosite.Client, subject, sessionID string) (string, error)
+}
+
+// BackChannelLogoutDispatcher delivers a logout token to the back-channel logout URI of a client.
+type BackChannelLogoutDispatcher interface {
+	DispatchLogoutToken(ctx context.Context, client ClientWithBackChannelLogout, logoutToken string) error
+}
+
+// HTTPBackChannelLogoutDispatcher POSTs logout tokens to the back-channel logout URI of clients, as defined in
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#BCRequest
+type HTTPBackChannelLogoutDispatcher struct {
+	// Client sends the requests. Defaults to a client with a timeout of five seconds.
+	Client *http.Client
+}
+
+func (d *HTTPBackChannelLogoutDispatcher) DispatchLogoutToken(ctx context.Context, client ClientWithBackChannelLogout, logoutToken string) error {
+	hc := d.Client
+	if hc == nil {
+		hc = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	req, err := http.NewRequest("POST", client.GetBackChannelLogoutURI(), strings.NewReader(url.Values{"logout_token": {logoutToken}}.Encode()))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return errors.Errorf("The back-channel logout URI responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// BackChannelLogoutHandler notifies clients about logouts, for example when the end-user logs out or a session or
+// consent is revoked. Fosite never calls it on its own: RevokeSubjectTokens, RevokeSubjectClientTokens, RevokeConsent
+// and RevokeClientTokens only revoke tokens, so call Logout with the affected clients after them.
+type BackChannelLogoutHandler struct {
+	LogoutTokenGenerator LogoutTokenGenerator
+	Dispatcher           BackChannelLogoutDispatcher
+}
+
+// Logout sends a logout token to every client which registered a back-channel logout URI. All clients are notified
+// even if some of them fail; the returned error lists the failures.
+func (h *BackChannelLogoutHandler) Logout(ctx context.Context, clients []fosite.Client, subject, sessionID string) error {
+	var failures []string
+	for _, client := range clients {
+		bc, ok := client.(ClientWithBackChannelLogout)
+		if !ok || bc.GetBackChannelLogoutURI() == "" {
+			continue
+		}
+
+		token, err := h.LogoutTokenGenerator.GenerateLogoutToken(ctx, client, subject, sessionID)
+		if err == nil {
+			err = h.Dispatcher.DispatchLogoutToken(ctx, bc, token)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", client.GetID(), err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("Unable to notify %d client(s) about the logout: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
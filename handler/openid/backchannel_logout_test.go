@@ -0,0 +1,89 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateLogoutToken(t *testing.T) {
+	s := &DefaultStrategy{JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}, Issuer: "https://op.example.com"}
+	client := &DefaultClientWithLogout{DefaultClient: &fosite.DefaultClient{ID: "foo"}, BackChannelLogoutSessionRequired: true}
+
+	token, err := s.GenerateLogoutToken(context.Background(), client, "peter", "session")
+	require.NoError(t, err)
+
+	decoded, err := s.JWTStrategy.Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, fosite.JWTTypeLogoutToken, decoded.Header["typ"])
+	claims := decoded.Claims.(jwtgo.MapClaims)
+	assert.Equal(t, "https://op.example.com", claims["iss"])
+	assert.Equal(t, "peter", claims["sub"])
+	assert.Equal(t, "session", claims["sid"])
+	assert.Equal(t, []interface{}{"foo"}, claims["aud"])
+	assert.NotEmpty(t, claims["jti"])
+	assert.Contains(t, claims["events"], BackChannelLogoutEvent)
+	assert.NotContains(t, claims, "nonce")
+
+	_, err = s.GenerateLogoutToken(context.Background(), client, "peter", "")
+	assert.Error(t, err)
+	_, err = s.GenerateLogoutToken(context.Background(), &fosite.DefaultClient{ID: "bar"}, "", "")
+	assert.Error(t, err)
+}
+
+func TestBackChannelLogoutHandler(t *testing.T) {
+	var received []string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		received = append(received, r.PostForm.Get("logout_token"))
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	h := &BackChannelLogoutHandler{
+		LogoutTokenGenerator: &DefaultStrategy{JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}},
+		Dispatcher:           new(HTTPBackChannelLogoutDispatcher),
+	}
+
+	err := h.Logout(context.Background(), []fosite.Client{
+		&fosite.DefaultClient{ID: "plain"},
+		&DefaultClientWithLogout{DefaultClient: &fosite.DefaultClient{ID: "foo"}, BackChannelLogoutURI: ok.URL},
+		&DefaultClientWithLogout{DefaultClient: &fosite.DefaultClient{ID: "bar"}, BackChannelLogoutURI: failing.URL},
+	}, "peter", "session")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bar")
+	assert.NotContains(t, err.Error(), "foo")
+	assert.Len(t, received, 1)
+}
@@ -61,6 +61,12 @@ func (c *OpenIDConnectExplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 		return err
 	}
 
+	// The nonce is optional in the Authorization Code Flow, but it is stored with the session and echoed into the ID
+	// Token, so reject weak values before the code is handed out.
+	if err := validateNonce(ctx, ar, false); err != nil {
+		return err
+	}
+
 	if err := c.OpenIDConnectRequestStorage.CreateOpenIDConnectSession(ctx, resp.GetCode(), ar.Sanitize(oidcParameters)); err != nil {
 		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
 	}
@@ -69,3 +75,8 @@ func (c *OpenIDConnectExplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 
 	return nil
 }
+
+// SupportedResponseTypes implements fosite.ResponseTypesHandler.
+func (c *OpenIDConnectExplicitHandler) SupportedResponseTypes() []string {
+	return []string{"code"}
+}
@@ -61,3 +61,8 @@ func (c *OpenIDConnectExplicitHandler) PopulateTokenEndpointResponse(ctx context
 
 	return c.IssueExplicitIDToken(ctx, authorize, responder)
 }
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *OpenIDConnectExplicitHandler) SupportedGrantTypes() []string {
+	return []string{"authorization_code"}
+}
@@ -23,7 +23,6 @@ package openid
 
 import (
 	"context"
-	"encoding/base64"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
@@ -39,7 +38,12 @@ type OpenIDConnectHybridHandler struct {
 	OpenIDConnectRequestValidator     *OpenIDConnectRequestValidator
 	OpenIDConnectRequestStorage       OpenIDConnectRequestStorage
 
+	// Deprecated: Enigma is unused, the token hashes are computed with the algorithm the ID tokens of the client are
+	// signed with, see jwt.TokenHash.
 	Enigma *jwt.RS256JWTStrategy
+
+	// ConfigProvider, if set, overrides the scope strategy per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -60,10 +64,8 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 	//	return errors.WithStack(fosite.ErrInvalidGrant.WithDebug("The client is not allowed to use the id_token response type"))
 	//}
 
-	if nonce := ar.GetRequestForm().Get("nonce"); len(nonce) == 0 {
-		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter \"nonce\" must be set when using the OpenID Connect Hybrid Flow."))
-	} else if len(nonce) < fosite.MinParameterEntropy {
-		return errors.WithStack(fosite.ErrInsufficientEntropy.WithHintf("Parameter \"nonce\" is set but does not satisfy the minimum entropy of %d characters.", fosite.MinParameterEntropy))
+	if err := validateNonce(ctx, ar, true); err != nil {
+		return err
 	}
 
 	sess, ok := ar.GetSession().(Session)
@@ -77,7 +79,7 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 
 	client := ar.GetClient()
 	for _, scope := range ar.GetRequestedScopes() {
-		if !c.ScopeStrategy(client.GetScopes(), scope) {
+		if !fosite.EffectiveScopeStrategy(ctx, c.ConfigProvider, client, c.ScopeStrategy)(client.GetScopes(), scope) {
 			return errors.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope \"%s\".", scope))
 		}
 	}
@@ -98,11 +100,11 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		resp.AddFragment("code", code)
 		ar.SetResponseTypeHandled("code")
 
-		hash, err := c.Enigma.Hash([]byte(resp.GetFragment().Get("code")))
+		hash, err := tokenHash(ar.GetClient(), resp.GetFragment().Get("code"))
 		if err != nil {
 			return err
 		}
-		claims.CodeHash = base64.RawURLEncoding.EncodeToString([]byte(hash[:c.Enigma.GetSigningMethodLength()/2]))
+		claims.CodeHash = hash
 
 		if ar.GetGrantedScopes().Has("openid") {
 			if err := c.OpenIDConnectRequestStorage.CreateOpenIDConnectSession(ctx, resp.GetCode(), ar.Sanitize(oidcParameters)); err != nil {
@@ -119,17 +121,27 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		}
 		ar.SetResponseTypeHandled("token")
 
-		hash, err := c.Enigma.Hash([]byte(resp.GetFragment().Get("access_token")))
+		hash, err := tokenHash(ar.GetClient(), resp.GetFragment().Get("access_token"))
 		if err != nil {
 			return err
 		}
-		claims.AccessTokenHash = base64.RawURLEncoding.EncodeToString([]byte(hash[:c.Enigma.GetSigningMethodLength()/2]))
+		claims.AccessTokenHash = hash
 	}
 
 	if resp.GetFragment().Get("state") == "" {
 		resp.AddFragment("state", ar.GetState())
 	}
 
+	// The state hash protects the state against tampering, as required by
+	// https://openid.net/specs/openid-financial-api-part-2-1_0.html#id-token-as-detached-signature
+	if state := ar.GetState(); state != "" {
+		hash, err := tokenHash(ar.GetClient(), state)
+		if err != nil {
+			return err
+		}
+		claims.StateHash = hash
+	}
+
 	if !ar.GetGrantedScopes().Has("openid") || !ar.GetResponseTypes().Has("id_token") {
 		ar.SetResponseTypeHandled("id_token")
 		return nil
@@ -144,3 +156,8 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 	// there is no need to check for https, because implicit flow does not require https
 	// https://tools.ietf.org/html/rfc6819#section-4.4.2
 }
+
+// SupportedResponseTypes implements fosite.ResponseTypesHandler.
+func (c *OpenIDConnectHybridHandler) SupportedResponseTypes() []string {
+	return []string{"code id_token", "code token", "code id_token token"}
+}
@@ -29,6 +29,7 @@ import (
 
 	"net/url"
 
+	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/golang/mock/gomock"
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
@@ -195,6 +196,39 @@ func TestHybrid_HandleAuthorizeEndpointRequest(t *testing.T) {
 				assert.NotEmpty(t, aresp.GetFragment().Get("access_token"))
 			},
 		},
+		{
+			description: "should pass and include the state hash",
+			setup: func() {
+				aresp = fosite.NewAuthorizeResponse()
+				areq.State = "some-foobar-state"
+			},
+			check: func() {
+				token, err := idStrategy.JWTStrategy.Decode(aresp.GetFragment().Get("id_token"))
+				require.NoError(t, err)
+				assert.NotEmpty(t, token.Claims.(jwtgo.MapClaims)["s_hash"])
+			},
+		},
+		{
+			description: "should pass and include the code and access token hashes",
+			setup: func() {
+				aresp = fosite.NewAuthorizeResponse()
+			},
+			check: func() {
+				token, err := idStrategy.JWTStrategy.Decode(aresp.GetFragment().Get("id_token"))
+				require.NoError(t, err)
+				claims := token.Claims.(jwtgo.MapClaims)
+
+				for claim, value := range map[string]string{
+					"c_hash":  aresp.GetFragment().Get("code"),
+					"at_hash": aresp.GetFragment().Get("access_token"),
+					"s_hash":  areq.State,
+				} {
+					hash, err := jwt.TokenHash(jwt.RS256, value)
+					require.NoError(t, err)
+					assert.Equal(t, hash, claims[claim], claim)
+				}
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c.setup()
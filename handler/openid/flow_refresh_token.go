@@ -84,3 +84,8 @@ func (c *OpenIDConnectRefreshHandler) PopulateTokenEndpointResponse(ctx context.
 
 	return c.IssueExplicitIDToken(ctx, requester, responder)
 }
+
+// SupportedGrantTypes implements fosite.GrantTypesHandler.
+func (c *OpenIDConnectRefreshHandler) SupportedGrantTypes() []string {
+	return []string{"refresh_token"}
+}
@@ -25,6 +25,7 @@ import (
 	"context"
 
 	"github.com/ory/fosite"
+	"github.com/pkg/errors"
 )
 
 type IDTokenHandleHelper struct {
@@ -59,3 +60,19 @@ func (i *IDTokenHandleHelper) IssueExplicitIDToken(ctx context.Context, ar fosit
 	resp.SetExtra("id_token", token)
 	return nil
 }
+
+// validateNonce checks that the nonce parameter has at least the number of characters configured by
+// fosite.Fosite.MinParameterEntropy, see fosite.MinParameterEntropyFromContext. A missing nonce is only allowed if it
+// is not required.
+func validateNonce(ctx context.Context, requester fosite.Requester, required bool) error {
+	minEntropy := fosite.MinParameterEntropyFromContext(ctx)
+
+	nonce := requester.GetRequestForm().Get("nonce")
+	if len(nonce) == 0 && required {
+		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter \"nonce\" must be set when using the OpenID Connect Implicit or Hybrid Flow."))
+	} else if len(nonce) > 0 && len(nonce) < minEntropy {
+		// We're assuming that using less then 8 characters for the nonce can not be considered "unguessable"
+		return errors.WithStack(fosite.ErrInsufficientEntropy.WithHintf("Parameter \"nonce\" is set but does not satisfy the minimum entropy of %d characters.", minEntropy))
+	}
+	return nil
+}
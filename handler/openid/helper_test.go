@@ -22,6 +22,7 @@
 package openid
 
 import (
+	"context"
 	"net/url"
 	"testing"
 
@@ -120,3 +121,32 @@ func TestIssueImplicitToken(t *testing.T) {
 	err := h.IssueImplicitIDToken(nil, ar, resp)
 	assert.NoError(t, err)
 }
+
+func TestValidateNonce(t *testing.T) {
+	for k, c := range []struct {
+		nonce      string
+		required   bool
+		minEntropy int
+		expectErr  error
+	}{
+		{nonce: "", required: false},
+		{nonce: "", required: true, expectErr: fosite.ErrInvalidRequest},
+		{nonce: "short", expectErr: fosite.ErrInsufficientEntropy},
+		{nonce: "long-enough", required: true},
+		{nonce: "long-enough", minEntropy: 16, expectErr: fosite.ErrInsufficientEntropy},
+		{nonce: "short", minEntropy: 4},
+	} {
+		ar := fosite.NewAuthorizeRequest()
+		ar.Form = url.Values{"nonce": {c.nonce}}
+		ctx := context.Background()
+		if c.minEntropy != 0 {
+			ctx = fosite.ContextWithMinParameterEntropy(ctx, c.minEntropy)
+		}
+		err := validateNonce(ctx, ar, c.required)
+		if c.expectErr != nil {
+			assert.EqualError(t, errors.Cause(err), c.expectErr.Error(), "%d", k)
+		} else {
+			assert.NoError(t, err, "%d", k)
+		}
+	}
+}
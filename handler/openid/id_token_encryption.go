@@ -0,0 +1,123 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// DefaultIDTokenEncryptedResponseEncryption is the content encryption algorithm used when a client registered an
+// id_token_encrypted_response_alg but no id_token_encrypted_response_enc.
+// See https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+const DefaultIDTokenEncryptedResponseEncryption = string(jose.A128CBC_HS256)
+
+// ClientWithIDTokenEncryption is implemented by clients which receive encrypted ID tokens. The ID token is signed
+// first and then encrypted to a public key of the client, as a nested JWT.
+type ClientWithIDTokenEncryption interface {
+	fosite.OpenIDConnectClient
+
+	// GetIDTokenEncryptedResponseAlgorithm returns the key management algorithm (id_token_encrypted_response_alg),
+	// for example "RSA-OAEP-256". An empty string disables encryption.
+	GetIDTokenEncryptedResponseAlgorithm() string
+
+	// GetIDTokenEncryptedResponseEncryption returns the content encryption algorithm
+	// (id_token_encrypted_response_enc), for example "A256GCM". Defaults to DefaultIDTokenEncryptedResponseEncryption.
+	GetIDTokenEncryptedResponseEncryption() string
+}
+
+// DefaultClientWithIDTokenEncryption is a DefaultOpenIDConnectClient which receives encrypted ID tokens.
+type DefaultClientWithIDTokenEncryption struct {
+	*fosite.DefaultOpenIDConnectClient
+	IDTokenEncryptedResponseAlgorithm  string `json:"id_token_encrypted_response_alg"`
+	IDTokenEncryptedResponseEncryption string `json:"id_token_encrypted_response_enc"`
+}
+
+func (c *DefaultClientWithIDTokenEncryption) GetIDTokenEncryptedResponseAlgorithm() string {
+	return c.IDTokenEncryptedResponseAlgorithm
+}
+
+func (c *DefaultClientWithIDTokenEncryption) GetIDTokenEncryptedResponseEncryption() string {
+	return c.IDTokenEncryptedResponseEncryption
+}
+
+// encryptIDToken encrypts the signed token to the client, if the client requested encrypted ID tokens.
+func (h DefaultStrategy) encryptIDToken(client fosite.Client, token string) (string, error) {
+	c, ok := client.(ClientWithIDTokenEncryption)
+	if !ok || c.GetIDTokenEncryptedResponseAlgorithm() == "" {
+		return token, nil
+	}
+
+	alg := jose.KeyAlgorithm(c.GetIDTokenEncryptedResponseAlgorithm())
+	enc := jose.ContentEncryption(c.GetIDTokenEncryptedResponseEncryption())
+	if enc == "" {
+		enc = jose.ContentEncryption(DefaultIDTokenEncryptedResponseEncryption)
+	}
+
+	key, err := h.findEncryptionKey(c, alg)
+	if err != nil {
+		return "", err
+	}
+
+	encrypter, err := jose.NewEncrypter(enc, jose.Recipient{Algorithm: alg, Key: key.Key, KeyID: key.KeyID}, (&jose.EncrypterOptions{}).WithType("JWT").WithContentType("JWT"))
+	if err != nil {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebugf("Unable to encrypt the ID token using \"%s\" and \"%s\": %s", alg, enc, err))
+	}
+
+	encrypted, err := encrypter.Encrypt([]byte(token))
+	if err != nil {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebugf("Unable to encrypt the ID token: %s", err))
+	}
+	return encrypted.CompactSerialize()
+}
+
+// findEncryptionKey returns the first public key of the client which may be used for encryption with alg. The keys
+// registered with the client take precedence over the ones available at its jwks_uri.
+func (h DefaultStrategy) findEncryptionKey(c ClientWithIDTokenEncryption, alg jose.KeyAlgorithm) (*jose.JSONWebKey, error) {
+	keys := c.GetJSONWebKeys()
+	if keys == nil && c.GetJSONWebKeysURI() != "" {
+		if h.JWKSFetcher == nil {
+			return nil, errors.WithStack(fosite.ErrServerError.WithDebug("The client registered a jwks_uri for ID token encryption, but no JWKSFetcher is configured."))
+		}
+
+		var err error
+		if keys, err = h.JWKSFetcher.Resolve(c.GetJSONWebKeysURI(), false); err != nil {
+			return nil, errors.WithStack(fosite.ErrServerError.WithDebugf("Unable to fetch the JSON Web Keys of the client: %s", err))
+		}
+	}
+
+	if keys != nil {
+		for _, key := range keys.Keys {
+			if key.Use != "" && key.Use != "enc" {
+				continue
+			} else if key.Algorithm != "" && key.Algorithm != string(alg) {
+				continue
+			} else if !key.IsPublic() {
+				continue
+			}
+			return &key, nil
+		}
+	}
+
+	return nil, errors.WithStack(fosite.ErrServerError.WithDebugf("The client has no public JSON Web Key to encrypt the ID token using \"%s\".", alg))
+}
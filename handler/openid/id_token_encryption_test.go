@@ -0,0 +1,91 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestJWTStrategy_GenerateIDToken_Encrypted(t *testing.T) {
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	s := &DefaultStrategy{JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}}
+	newClient := func(keys ...jose.JSONWebKey) *DefaultClientWithIDTokenEncryption {
+		return &DefaultClientWithIDTokenEncryption{
+			DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+				DefaultClient: &fosite.DefaultClient{ID: "foo"},
+				JSONWebKeys:   &jose.JSONWebKeySet{Keys: keys},
+			},
+			IDTokenEncryptedResponseAlgorithm: string(jose.RSA_OAEP_256),
+		}
+	}
+	generate := func(client fosite.Client) (string, error) {
+		req := fosite.NewAccessRequest(&DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter"}, Headers: &jwt.Headers{}})
+		req.Client = client
+		return s.GenerateIDToken(nil, req)
+	}
+
+	t.Run("case=encrypts to the encryption key of the client", func(t *testing.T) {
+		token, err := generate(newClient(
+			jose.JSONWebKey{Key: &clientKey.PublicKey, KeyID: "sig", Use: "sig"},
+			jose.JSONWebKey{Key: &clientKey.PublicKey, KeyID: "enc", Use: "enc"},
+		))
+		require.NoError(t, err)
+
+		encrypted, err := jose.ParseEncrypted(token)
+		require.NoError(t, err)
+		assert.Equal(t, "enc", encrypted.Header.KeyID)
+		assert.Equal(t, string(jose.A128CBC_HS256), encrypted.Header.ExtraHeaders["enc"])
+		assert.Equal(t, "JWT", encrypted.Header.ExtraHeaders["cty"])
+
+		signed, err := encrypted.Decrypt(clientKey)
+		require.NoError(t, err)
+		decoded, err := s.JWTStrategy.Decode(string(signed))
+		require.NoError(t, err)
+		assert.Equal(t, "peter", decoded.Claims.(jwtgo.MapClaims)["sub"])
+	})
+
+	t.Run("case=fails without a suitable key", func(t *testing.T) {
+		_, err := generate(newClient(jose.JSONWebKey{Key: &clientKey.PublicKey, KeyID: "sig", Use: "sig"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=does not encrypt unless requested", func(t *testing.T) {
+		client := newClient()
+		client.IDTokenEncryptedResponseAlgorithm = ""
+		token, err := generate(client)
+		require.NoError(t, err)
+		_, err = s.JWTStrategy.Decode(token)
+		require.NoError(t, err)
+	})
+}
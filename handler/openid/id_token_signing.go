@@ -0,0 +1,88 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/pkg/errors"
+)
+
+// ClientWithIDTokenSigningAlgorithm is implemented by clients which registered the algorithm their ID tokens are
+// signed with.
+type ClientWithIDTokenSigningAlgorithm interface {
+	fosite.OpenIDConnectClient
+
+	// GetIDTokenSignedResponseAlgorithm returns the JSON Web Signature algorithm of ID tokens
+	// (id_token_signed_response_alg), for example "ES256". Defaults to "RS256" if empty.
+	GetIDTokenSignedResponseAlgorithm() string
+}
+
+// DefaultClientWithIDTokenSigningAlgorithm is a DefaultOpenIDConnectClient which registered the algorithm of its ID
+// tokens.
+type DefaultClientWithIDTokenSigningAlgorithm struct {
+	*fosite.DefaultOpenIDConnectClient
+	IDTokenSignedResponseAlgorithm string `json:"id_token_signed_response_alg"`
+}
+
+func (c *DefaultClientWithIDTokenSigningAlgorithm) GetIDTokenSignedResponseAlgorithm() string {
+	return c.IDTokenSignedResponseAlgorithm
+}
+
+// signingStrategy returns the strategy which signs the ID tokens of the client. Clients without a registered
+// algorithm, or which registered RS256 while no strategy is configured for it, use the strategy of the tenant of
+// ctx or JWTStrategy.
+func (h DefaultStrategy) signingStrategy(ctx context.Context, client fosite.Client) (jwt.JWTStrategy, error) {
+	c, ok := client.(ClientWithIDTokenSigningAlgorithm)
+	if !ok || c.GetIDTokenSignedResponseAlgorithm() == "" {
+		return h.jwtStrategy(ctx), nil
+	}
+
+	alg := c.GetIDTokenSignedResponseAlgorithm()
+	if strategy, ok := h.SigningStrategies[alg]; ok {
+		return strategy, nil
+	} else if alg == jwt.RS256 {
+		return h.jwtStrategy(ctx), nil
+	}
+	return nil, errors.WithStack(fosite.ErrServerError.WithDebugf("The client \"%s\" requested ID tokens signed with \"%s\" but no signing key is configured for that algorithm.", client.GetID(), alg))
+}
+
+// IDTokenSigningAlgorithm returns the JSON Web Signature algorithm the ID tokens of the client are signed with, which
+// is RS256 unless the client registered another one.
+func IDTokenSigningAlgorithm(client fosite.Client) string {
+	if c, ok := client.(ClientWithIDTokenSigningAlgorithm); ok && c.GetIDTokenSignedResponseAlgorithm() != "" {
+		return c.GetIDTokenSignedResponseAlgorithm()
+	}
+	return jwt.RS256
+}
+
+// tokenHash computes the "at_hash", "c_hash" or "s_hash" claim of token for the ID tokens of client, see
+// jwt.TokenHash.
+func tokenHash(client fosite.Client, token string) (string, error) {
+	hash, err := jwt.TokenHash(IDTokenSigningAlgorithm(client), token)
+	if err != nil {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+	return hash, nil
+}
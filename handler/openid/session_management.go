@@ -0,0 +1,286 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/pkg/errors"
+)
+
+// ClientWithLogout is implemented by clients which registered the logout related metadata of
+// https://openid.net/specs/openid-connect-rpinitiated-1_0.html and
+// https://openid.net/specs/openid-connect-frontchannel-1_0.html
+type ClientWithLogout interface {
+	// GetPostLogoutRedirectURIs returns the URIs the end-user may be redirected to after logging out.
+	GetPostLogoutRedirectURIs() []string
+
+	// GetFrontChannelLogoutURI returns the URI which is rendered in an iframe to log the end-user out of the client.
+	GetFrontChannelLogoutURI() string
+
+	// GetFrontChannelLogoutSessionRequired returns true if the client requires the "iss" and "sid" parameters to be
+	// added to the front-channel logout URI.
+	GetFrontChannelLogoutSessionRequired() bool
+}
+
+// DefaultClientWithLogout is a DefaultClient with logout metadata. It implements ClientWithLogout and
+// ClientWithBackChannelLogout.
+type DefaultClientWithLogout struct {
+	*fosite.DefaultClient
+	PostLogoutRedirectURIs            []string `json:"post_logout_redirect_uris"`
+	FrontChannelLogoutURI             string   `json:"frontchannel_logout_uri,omitempty"`
+	FrontChannelLogoutSessionRequired bool     `json:"frontchannel_logout_session_required,omitempty"`
+	BackChannelLogoutURI              string   `json:"backchannel_logout_uri,omitempty"`
+	BackChannelLogoutSessionRequired  bool     `json:"backchannel_logout_session_required,omitempty"`
+}
+
+func (c *DefaultClientWithLogout) GetPostLogoutRedirectURIs() []string {
+	return c.PostLogoutRedirectURIs
+}
+
+func (c *DefaultClientWithLogout) GetFrontChannelLogoutURI() string {
+	return c.FrontChannelLogoutURI
+}
+
+func (c *DefaultClientWithLogout) GetFrontChannelLogoutSessionRequired() bool {
+	return c.FrontChannelLogoutSessionRequired
+}
+
+func (c *DefaultClientWithLogout) GetBackChannelLogoutURI() string {
+	return c.BackChannelLogoutURI
+}
+
+func (c *DefaultClientWithLogout) GetBackChannelLogoutSessionRequired() bool {
+	return c.BackChannelLogoutSessionRequired
+}
+
+// SessionState computes the session_state of an authorize response, which the check_session_iframe of the OP and the
+// RP use to detect changes of the end-user's login state, see
+// https://openid.net/specs/openid-connect-session-1_0.html#CreatingUpdatingSessions
+//
+// browserState is the OP browser state, which is also available to the check_session_iframe, for example through a
+// cookie. It must change whenever the end-user logs in or out.
+func SessionState(clientID string, redirectURI *url.URL, browserState, salt string) string {
+	origin := redirectURI.Scheme + "://" + redirectURI.Host
+	hash := sha256.Sum256([]byte(clientID + " " + origin + " " + browserState + " " + salt))
+	return hex.EncodeToString(hash[:]) + "." + salt
+}
+
+// AddSessionState adds the session_state parameter to the authorize response, in the component of the redirect URI
+// which carries the other parameters of the response.
+func AddSessionState(ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder, browserState string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+
+	state := SessionState(ar.GetClient().GetID(), ar.GetRedirectURI(), browserState, base64.RawURLEncoding.EncodeToString(salt))
+	if ar.GetResponseTypes().Exact("code") {
+		resp.AddQuery("session_state", state)
+	} else {
+		resp.AddFragment("session_state", state)
+	}
+	return nil
+}
+
+// EndSessionRequest is a request of a relying party to log the end-user out, see
+// https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+type EndSessionRequest struct {
+	// Client is the client which requested the logout. It is nil if neither an ID token hint nor a client ID was
+	// given.
+	Client fosite.Client
+
+	// IDTokenHintClaims are the claims of the ID token hint, if one was given.
+	IDTokenHintClaims jwtgo.MapClaims
+
+	// Subject and SessionID are the "sub" and "sid" claims of the ID token hint, if any.
+	Subject   string
+	SessionID string
+
+	// PostLogoutRedirectURI is the validated post_logout_redirect_uri, if one was given.
+	PostLogoutRedirectURI *url.URL
+
+	State string
+}
+
+// GetRedirectURI returns the URI the end-user is redirected to once logged out, including the state, or nil if the
+// OP should show its own logged out page.
+func (r *EndSessionRequest) GetRedirectURI() *url.URL {
+	if r.PostLogoutRedirectURI == nil {
+		return nil
+	}
+
+	redirectURI := *r.PostLogoutRedirectURI
+	if r.State != "" {
+		query := redirectURI.Query()
+		query.Add("state", r.State)
+		redirectURI.RawQuery = query.Encode()
+	}
+	return &redirectURI
+}
+
+// EndSessionHandler validates end session requests.
+type EndSessionHandler struct {
+	// JWTStrategy verifies the signature of ID token hints. Expired ID token hints are accepted if the strategy is a
+	// *jwt.RS256JWTStrategy.
+	JWTStrategy jwt.JWTStrategy
+
+	// Issuers, if set, are the accepted issuers of ID token hints.
+	Issuers *fosite.IssuerSet
+
+	// Clients loads the client identified by the ID token hint or client_id parameter.
+	Clients fosite.ClientManager
+}
+
+// NewEndSessionRequest parses and validates an end session request. The ID token hint is required to identify the
+// client unless the client_id parameter is given, and a post_logout_redirect_uri must be registered by the client.
+func (h *EndSessionHandler) NewEndSessionRequest(ctx context.Context, r *http.Request) (*EndSessionRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to parse the end session request.").WithDebug(err.Error()))
+	}
+
+	req := &EndSessionRequest{State: r.Form.Get("state")}
+	clientID := r.Form.Get("client_id")
+	if hint := r.Form.Get("id_token_hint"); hint != "" {
+		claims, err := h.decodeIDTokenHint(hint)
+		if err != nil {
+			return nil, err
+		}
+
+		audience := audienceOf(claims)
+		if clientID == "" && len(audience) > 0 {
+			clientID = audience[0]
+		} else if clientID != "" && !fosite.StringInSlice(clientID, audience) {
+			return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("The client_id does not match the audience of the id_token_hint."))
+		}
+
+		req.IDTokenHintClaims = claims
+		req.Subject, _ = claims["sub"].(string)
+		req.SessionID, _ = claims["sid"].(string)
+	}
+
+	if clientID != "" {
+		client, err := h.Clients.GetClient(ctx, clientID)
+		if err != nil {
+			return nil, errors.WithStack(fosite.ErrInvalidClient.WithHint("The client of the end session request does not exist.").WithDebug(err.Error()))
+		}
+		req.Client = client
+	}
+
+	if location := r.Form.Get("post_logout_redirect_uri"); location != "" {
+		lc, ok := req.Client.(ClientWithLogout)
+		if !ok {
+			return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("The post_logout_redirect_uri requires the client to be identified by the id_token_hint or client_id parameter, and to register post logout redirect URIs."))
+		}
+
+		var registered bool
+		for _, uri := range lc.GetPostLogoutRedirectURIs() {
+			if uri == location {
+				registered = true
+				break
+			}
+		}
+		if !registered {
+			return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHintf("The post_logout_redirect_uri \"%s\" is not registered by the client.", location))
+		}
+
+		redirectURI, err := url.Parse(location)
+		if err != nil {
+			return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("The post_logout_redirect_uri is malformed.").WithDebug(err.Error()))
+		}
+		req.PostLogoutRedirectURI = redirectURI
+	}
+
+	return req, nil
+}
+
+func (h *EndSessionHandler) decodeIDTokenHint(hint string) (jwtgo.MapClaims, error) {
+	var token *jwtgo.Token
+	var err error
+	if rs, ok := h.JWTStrategy.(*jwt.RS256JWTStrategy); ok {
+		token, err = rs.DecodeIgnoringTimeClaims(hint)
+	} else {
+		token, err = h.JWTStrategy.Decode(hint)
+	}
+	if err != nil {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to verify the id_token_hint.").WithDebug(err.Error()))
+	}
+
+	claims, ok := token.Claims.(jwtgo.MapClaims)
+	if !ok {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to decode the claims of the id_token_hint."))
+	} else if iss, _ := claims["iss"].(string); h.Issuers != nil && !h.Issuers.Accepts(iss) {
+		return nil, errors.WithStack(fosite.ErrInvalidRequest.WithHint("The id_token_hint was not issued by this authorization server."))
+	}
+	return claims, nil
+}
+
+func audienceOf(claims jwtgo.MapClaims) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		var audience []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+		return audience
+	}
+	return nil
+}
+
+// FrontChannelLogoutURIs returns the front-channel logout URIs of the clients, which the OP renders in iframes on its
+// logged out page, see https://openid.net/specs/openid-connect-frontchannel-1_0.html#OPLogout
+func FrontChannelLogoutURIs(clients []fosite.Client, issuer, sessionID string) ([]string, error) {
+	var uris []string
+	for _, client := range clients {
+		lc, ok := client.(ClientWithLogout)
+		if !ok || lc.GetFrontChannelLogoutURI() == "" {
+			continue
+		}
+
+		u, err := url.Parse(lc.GetFrontChannelLogoutURI())
+		if err != nil {
+			return nil, errors.WithStack(fosite.ErrServerError.WithDebugf("The frontchannel_logout_uri of client \"%s\" is malformed: %s", client.GetID(), err))
+		}
+
+		if lc.GetFrontChannelLogoutSessionRequired() {
+			query := u.Query()
+			query.Set("iss", issuer)
+			query.Set("sid", sessionID)
+			u.RawQuery = query.Encode()
+		}
+		uris = append(uris, u.String())
+	}
+	return uris, nil
+}
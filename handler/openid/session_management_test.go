@@ -0,0 +1,160 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionState(t *testing.T) {
+	redirectURI, _ := url.Parse("https://rp.example.com/cb?foo=bar")
+	state := SessionState("client", redirectURI, "browser-state", "salt")
+	assert.True(t, strings.HasSuffix(state, ".salt"))
+	assert.Equal(t, state, SessionState("client", redirectURI, "browser-state", "salt"))
+	assert.NotEqual(t, state, SessionState("client", redirectURI, "other-browser-state", "salt"))
+
+	other, _ := url.Parse("https://rp.example.com/other")
+	assert.Equal(t, state, SessionState("client", other, "browser-state", "salt"))
+
+	ar := fosite.NewAuthorizeRequest()
+	ar.Client = &fosite.DefaultClient{ID: "client"}
+	ar.RedirectURI = redirectURI
+	ar.ResponseTypes = fosite.Arguments{"code"}
+	resp := fosite.NewAuthorizeResponse()
+	require.NoError(t, AddSessionState(ar, resp, "browser-state"))
+	assert.NotEmpty(t, resp.GetQuery().Get("session_state"))
+
+	ar.ResponseTypes = fosite.Arguments{"code", "id_token"}
+	resp = fosite.NewAuthorizeResponse()
+	require.NoError(t, AddSessionState(ar, resp, "browser-state"))
+	assert.NotEmpty(t, resp.GetFragment().Get("session_state"))
+}
+
+func TestEndSessionHandler(t *testing.T) {
+	strategy := &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClientWithLogout{
+		DefaultClient:          &fosite.DefaultClient{ID: "foo"},
+		PostLogoutRedirectURIs: []string{"https://rp.example.com/logged-out"},
+	}
+	store.Clients["bar"] = &fosite.DefaultClient{ID: "bar"}
+	h := &EndSessionHandler{JWTStrategy: strategy, Clients: store, Issuers: &fosite.IssuerSet{Issuer: "https://op.example.com"}}
+
+	hint := func(claims jwtgo.MapClaims) string {
+		token, _, err := strategy.Generate(claims, &jwt.Headers{})
+		require.NoError(t, err)
+		return token
+	}
+	validHint := hint(jwtgo.MapClaims{"iss": "https://op.example.com", "sub": "peter", "sid": "session", "aud": []string{"foo"}, "exp": time.Now().Add(-time.Hour).Unix()})
+
+	for k, c := range []struct {
+		form      url.Values
+		expectErr error
+		check     func(t *testing.T, req *EndSessionRequest)
+	}{
+		{
+			form: url.Values{},
+			check: func(t *testing.T, req *EndSessionRequest) {
+				assert.Nil(t, req.Client)
+				assert.Nil(t, req.GetRedirectURI())
+			},
+		},
+		{
+			form: url.Values{"id_token_hint": {validHint}, "post_logout_redirect_uri": {"https://rp.example.com/logged-out"}, "state": {"some-state"}},
+			check: func(t *testing.T, req *EndSessionRequest) {
+				assert.Equal(t, "foo", req.Client.GetID())
+				assert.Equal(t, "peter", req.Subject)
+				assert.Equal(t, "session", req.SessionID)
+				assert.Equal(t, "https://rp.example.com/logged-out?state=some-state", req.GetRedirectURI().String())
+			},
+		},
+		{
+			form: url.Values{"client_id": {"foo"}, "post_logout_redirect_uri": {"https://rp.example.com/logged-out"}},
+			check: func(t *testing.T, req *EndSessionRequest) {
+				assert.Equal(t, "https://rp.example.com/logged-out", req.GetRedirectURI().String())
+			},
+		},
+		{
+			form:      url.Values{"id_token_hint": {validHint}, "post_logout_redirect_uri": {"https://evil.example.com/"}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"post_logout_redirect_uri": {"https://rp.example.com/logged-out"}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"client_id": {"bar"}, "post_logout_redirect_uri": {"https://rp.example.com/logged-out"}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"id_token_hint": {validHint}, "client_id": {"bar"}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"id_token_hint": {hint(jwtgo.MapClaims{"iss": "https://evil.example.com", "aud": "foo"})}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"id_token_hint": {"not-a-token"}},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			form:      url.Values{"client_id": {"unknown"}},
+			expectErr: fosite.ErrInvalidClient,
+		},
+	} {
+		r := &http.Request{Method: "GET", URL: &url.URL{RawQuery: c.form.Encode()}}
+		req, err := h.NewEndSessionRequest(context.Background(), r)
+		if c.expectErr != nil {
+			require.EqualError(t, err, c.expectErr.Error(), "%d", k)
+			continue
+		}
+		require.NoError(t, err, "%d", k)
+		c.check(t, req)
+	}
+}
+
+func TestFrontChannelLogoutURIs(t *testing.T) {
+	uris, err := FrontChannelLogoutURIs([]fosite.Client{
+		&fosite.DefaultClient{ID: "plain"},
+		&DefaultClientWithLogout{DefaultClient: &fosite.DefaultClient{ID: "foo"}, FrontChannelLogoutURI: "https://foo.example.com/logout"},
+		&DefaultClientWithLogout{DefaultClient: &fosite.DefaultClient{ID: "bar"}, FrontChannelLogoutURI: "https://bar.example.com/logout?x=y", FrontChannelLogoutSessionRequired: true},
+	}, "https://op.example.com", "session")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://foo.example.com/logout",
+		"https://bar.example.com/logout?iss=https%3A%2F%2Fop.example.com&sid=session&x=y",
+	}, uris)
+}
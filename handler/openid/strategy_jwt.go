@@ -118,14 +118,65 @@ func (s *DefaultSession) IDTokenClaims() *jwt.IDTokenClaims {
 	return s.Claims
 }
 
+// SetExtraClaim adds a claim to the ID Token.
+func (s *DefaultSession) SetExtraClaim(key string, value interface{}) {
+	s.IDTokenClaims().Add(key, value)
+}
+
 type DefaultStrategy struct {
 	jwt.JWTStrategy
 
 	Expiry time.Duration
 	Issuer string
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// Issuers, if set, overrides Issuer for new ID tokens.
+	Issuers *fosite.IssuerSet
+
+	// SubjectIdentifierAlgorithms computes the sub claim of ID tokens by the subject type of the client, see
+	// ClientWithSubjectType. Clients with the public subject type receive the subject of the session unless an
+	// algorithm is registered for SubjectTypePublic.
+	SubjectIdentifierAlgorithms map[string]SubjectIdentifierAlgorithm
+
+	// JWKSFetcher resolves the jwks_uri of clients which receive encrypted ID tokens, see
+	// ClientWithIDTokenEncryption. Not required if the clients register their keys directly.
+	JWKSFetcher fosite.JWKSFetcherStrategy
+
+	// ClaimsEnricher, if set, adds custom claims to ID tokens.
+	ClaimsEnricher fosite.ClaimsEnricher
+
+	// TenantJWTStrategies signs the ID tokens of the tenants by tenant ID, see fosite.Tenant. Tenants without a
+	// strategy use JWTStrategy. ID tokens of tenants with an issuer are issued by it.
+	TenantJWTStrategies map[string]jwt.JWTStrategy
+
+	// GrantTypeExpiry overrides Expiry for ID tokens issued through a grant type, by grant type. ID tokens issued at
+	// the authorization endpoint use the "implicit" grant type.
+	GrantTypeExpiry map[string]time.Duration
+
+	// ConfigProvider, if set, overrides the lifespan of ID tokens per request, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
+
+	// SigningStrategies sign the ID tokens of clients which registered an id_token_signed_response_alg, by
+	// algorithm, for example a jwt.AsymmetricJWTStrategy for "ES256". See ClientWithIDTokenSigningAlgorithm.
+	SigningStrategies map[string]jwt.JWTStrategy
 }
 
-func (h DefaultStrategy) GenerateIDToken(_ context.Context, requester fosite.Requester) (token string, err error) {
+// jwtStrategy returns the strategy of the tenant of ctx, or JWTStrategy.
+func (h DefaultStrategy) jwtStrategy(ctx context.Context) jwt.JWTStrategy {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil {
+		if strategy, ok := h.TenantJWTStrategies[tenant.ID]; ok {
+			return strategy
+		}
+	}
+	return h.JWTStrategy
+}
+
+func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.Requester) (token string, err error) {
+	_, span := fosite.StartSpan(ctx, "fosite.openid.GenerateIDToken")
+	defer func() { span.End(err) }()
+
 	if h.Expiry == 0 {
 		h.Expiry = defaultExpiryTime
 	}
@@ -140,24 +191,36 @@ func (h DefaultStrategy) GenerateIDToken(_ context.Context, requester fosite.Req
 		return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because subject is an empty string."))
 	}
 
+	// The subject of the session is kept as it is, so that ID tokens issued later, for example when refreshing, are
+	// computed from the local subject again.
+	subject, err := subjectFor(h.SubjectIdentifierAlgorithms, claims.Subject, requester.GetClient())
+	if err != nil {
+		return "", err
+	}
+
+	strategy, err := h.signingStrategy(ctx, requester.GetClient())
+	if err != nil {
+		return "", err
+	}
+
 	if requester.GetRequestForm().Get("grant_type") != "refresh_token" {
+		// A max_age of zero requires the end-user to authenticate again, which is how clients step up the authentication
+		// after a resource server asked for it, see fosite.NewStepUpError.
 		maxAge, err := strconv.ParseInt(requester.GetRequestForm().Get("max_age"), 10, 64)
-		if err != nil {
-			maxAge = 0
-		}
+		hasMaxAge := err == nil && maxAge >= 0
 
 		// Adds a bit of wiggle room for timing issues
-		if claims.AuthTime.After(time.Now().UTC().Add(time.Second * 5)) {
+		if claims.AuthTime.After(fosite.Now(h.Clock).Add(time.Second * 5)) {
 			return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because authentication time is in the future."))
 		}
 
-		if maxAge > 0 {
+		if hasMaxAge {
 			if claims.AuthTime.IsZero() {
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because authentication time claim is required when max_age is set."))
 			} else if claims.RequestedAt.IsZero() {
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because requested at claim is required when max_age is set."))
-			} else if claims.AuthTime.Add(time.Second * time.Duration(maxAge)).Before(claims.RequestedAt) {
-				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because authentication time does not satisfy max_age time."))
+			} else if claims.AuthTime.Add(time.Second * time.Duration(maxAge)).Before(claims.RequestedAt.Truncate(time.Second)) {
+				return "", errors.WithStack(fosite.ErrLoginRequired.WithDebug("Failed to generate id token because authentication time does not satisfy max_age time."))
 			}
 		}
 
@@ -171,12 +234,12 @@ func (h DefaultStrategy) GenerateIDToken(_ context.Context, requester fosite.Req
 		switch prompt {
 		case "none":
 			if claims.AuthTime.After(claims.RequestedAt) {
-				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because prompt was set to \"none\" but auth_time happened after the authorization request was registered, indicating that the user was logged in during this request which is not allowed."))
+				return "", errors.WithStack(fosite.ErrLoginRequired.WithDebug("Failed to generate id token because prompt was set to \"none\" but auth_time happened after the authorization request was registered, indicating that the user was logged in during this request which is not allowed."))
 			}
 			break
 		case "login":
 			if claims.AuthTime.Before(claims.RequestedAt) {
-				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because prompt was set to \"login\" but auth_time happened before the authorization request was registered, indicating that the user was not re-authenticated which is forbidden."))
+				return "", errors.WithStack(fosite.ErrLoginRequired.WithDebug("Failed to generate id token because prompt was set to \"login\" but auth_time happened before the authorization request was registered, indicating that the user was not re-authenticated which is forbidden."))
 			}
 			break
 		}
@@ -188,7 +251,7 @@ func (h DefaultStrategy) GenerateIDToken(_ context.Context, requester fosite.Req
 		}
 
 		if tokenHintString := requester.GetRequestForm().Get("id_token_hint"); tokenHintString != "" {
-			tokenHint, err := h.JWTStrategy.Decode(tokenHintString)
+			tokenHint, err := strategy.Decode(tokenHintString)
 			if err != nil {
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug(fmt.Sprintf("Unable to decode id token from id_token_hint parameter because %s.", err.Error())))
 			}
@@ -197,40 +260,58 @@ func (h DefaultStrategy) GenerateIDToken(_ context.Context, requester fosite.Req
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Unable to decode id token from id_token_hint to *jwt.StandardClaims."))
 			} else if hintSub, _ := hintClaims["sub"].(string); hintSub == "" {
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug("Provided id token from id_token_hint does not have a subject."))
-			} else if hintSub != claims.Subject {
+			} else if hintSub != subject {
 				return "", errors.WithStack(fosite.ErrServerError.WithDebug(fmt.Sprintf("Subject from authorization mismatches id token subject from id_token_hint.")))
 			}
 		}
 	}
 
 	if claims.ExpiresAt.IsZero() {
-		claims.ExpiresAt = time.Now().UTC().Add(h.Expiry)
+		// ID Tokens issued at the authorization endpoint do not have a grant type, they are issued through the implicit grant.
+		grantType := requester.GetRequestForm().Get("grant_type")
+		if grantType == "" {
+			grantType = "implicit"
+		}
+		expiry := h.Expiry
+		if lifespan := h.GrantTypeExpiry[grantType]; lifespan != 0 {
+			expiry = lifespan
+		}
+		claims.ExpiresAt = fosite.Now(h.Clock).Add(fosite.EffectiveLifespan(ctx, h.ConfigProvider, requester.GetClient(), grantType, fosite.IDToken, expiry))
 	}
 
-	if claims.ExpiresAt.Before(time.Now().UTC()) {
+	if claims.ExpiresAt.Before(fosite.Now(h.Clock)) {
 		return "", errors.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because expiry claim can not be in the past."))
 	}
 
 	if claims.AuthTime.IsZero() {
-		claims.AuthTime = time.Now().UTC()
+		claims.AuthTime = fosite.Now(h.Clock)
 	}
 
-	if claims.Issuer == "" {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+		claims.Issuer = tenant.Issuer
+	} else if h.Issuers != nil {
+		claims.Issuer = h.Issuers.Migrate(claims.Issuer)
+	} else if claims.Issuer == "" {
 		claims.Issuer = h.Issuer
 	}
 
-	nonce := requester.GetRequestForm().Get("nonce")
 	// OPTIONAL. String value used to associate a Client session with an ID Token, and to mitigate replay attacks.
-	if len(nonce) == 0 {
-	} else if len(nonce) > 0 && len(nonce) < fosite.MinParameterEntropy {
-		// We're assuming that using less then 8 characters for the state can not be considered "unguessable"
-		return "", errors.WithStack(fosite.ErrInsufficientEntropy.WithHintf("Parameter \"nonce\" is set but does not satisfy the minimum entropy of %d characters.", fosite.MinParameterEntropy))
+	if err := validateNonce(ctx, requester, false); err != nil {
+		return "", err
 	}
 
-	claims.Nonce = nonce
+	claims.Nonce = requester.GetRequestForm().Get("nonce")
 	claims.Audience = stringsx.Unique(append(claims.Audience, requester.GetClient().GetID()))
-	claims.IssuedAt = time.Now().UTC()
+	claims.IssuedAt = fosite.Now(h.Clock)
 
-	token, _, err = h.JWTStrategy.Generate(claims.ToMapClaims(), sess.IDTokenHeaders())
-	return token, err
+	mapClaims := claims.ToMapClaims()
+	mapClaims["sub"] = subject
+	if err := fosite.EnrichClaims(ctx, h.ClaimsEnricher, fosite.IDToken, requester, mapClaims); err != nil {
+		return "", err
+	}
+	token, _, err = jwt.Generate(ctx, strategy, mapClaims, &jwt.TypedHeaders{Headers: sess.IDTokenHeaders(), Type: fosite.JWTTypeIDToken})
+	if err != nil {
+		return "", err
+	}
+	return h.encryptIDToken(requester.GetClient(), token)
 }
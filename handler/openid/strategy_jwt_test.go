@@ -22,14 +22,20 @@
 package openid
 
 import (
+	"context"
+	"crypto"
 	"testing"
 	"time"
 
 	"fmt"
 
+	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
 	"github.com/ory/fosite/token/jwt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 )
 
 func TestJWTStrategy_GenerateIDToken(t *testing.T) {
@@ -142,6 +148,38 @@ func TestJWTStrategy_GenerateIDToken(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			description: "should fail because max_age=0 was requested to step up but the end-user did not authenticate again",
+			setup: func() {
+				req = fosite.NewAccessRequest(&DefaultSession{
+					Claims: &jwt.IDTokenClaims{
+						Subject:     "peter",
+						AuthTime:    time.Now().Add(-time.Minute).UTC(),
+						RequestedAt: time.Now().UTC(),
+					},
+					Headers: &jwt.Headers{},
+				})
+				req.Form.Set("max_age", "0")
+				req.Form.Set("acr_values", "urn:example:mfa")
+			},
+			expectErr: true,
+		},
+		{
+			description: "should pass because max_age=0 was requested to step up and the end-user authenticated again",
+			setup: func() {
+				req = fosite.NewAccessRequest(&DefaultSession{
+					Claims: &jwt.IDTokenClaims{
+						Subject:     "peter",
+						AuthTime:    time.Now().UTC(),
+						RequestedAt: time.Now().Add(-time.Minute).UTC(),
+					},
+					Headers: &jwt.Headers{},
+				})
+				req.Form.Set("max_age", "0")
+				req.Form.Set("acr_values", "urn:example:mfa")
+			},
+			expectErr: false,
+		},
 		{
 			description: "should fail because prompt=none was requested and auth_time indicates fresh login",
 			setup: func() {
@@ -268,3 +306,78 @@ func TestJWTStrategy_GenerateIDToken(t *testing.T) {
 		})
 	}
 }
+
+func TestJWTStrategy_GenerateIDToken_ClaimsEnricher(t *testing.T) {
+	s := &DefaultStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()},
+		ClaimsEnricher: fosite.ClaimsEnricherFunc(func(_ context.Context, tokenType fosite.TokenType, _ fosite.Requester) (map[string]interface{}, error) {
+			assert.Equal(t, fosite.IDToken, tokenType)
+			return map[string]interface{}{"tenant": "acme"}, nil
+		}),
+	}
+
+	req := fosite.NewAccessRequest(&DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter"}, Headers: &jwt.Headers{}})
+	token, err := s.GenerateIDToken(nil, req)
+	require.NoError(t, err)
+	decoded, err := s.JWTStrategy.Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", decoded.Claims.(jwtgo.MapClaims)["tenant"])
+	assert.Equal(t, "peter", decoded.Claims.(jwtgo.MapClaims)["sub"])
+}
+
+func TestJWTStrategy_GenerateIDToken_GrantTypeExpiry(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	s := &DefaultStrategy{
+		JWTStrategy:     &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()},
+		Expiry:          time.Hour,
+		GrantTypeExpiry: map[string]time.Duration{"refresh_token": time.Minute},
+		Clock:           fosite.ClockFunc(func() time.Time { return now }),
+	}
+
+	for grantType, expiry := range map[string]time.Duration{"refresh_token": time.Minute, "authorization_code": time.Hour, "": time.Hour} {
+		req := fosite.NewAccessRequest(&DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter"}, Headers: &jwt.Headers{}})
+		req.Form.Set("grant_type", grantType)
+		token, err := s.GenerateIDToken(nil, req)
+		require.NoError(t, err)
+		decoded, err := s.JWTStrategy.Decode(token)
+		require.NoError(t, err)
+		assert.EqualValues(t, now.Add(expiry).Unix(), decoded.Claims.(jwtgo.MapClaims)["exp"], grantType)
+	}
+}
+
+func TestJWTStrategy_GenerateIDToken_SigningAlgorithm(t *testing.T) {
+	key, err := jwt.GenerateJSONWebKey(jose.ES256, "", "sig")
+	require.NoError(t, err)
+	es256, err := jwt.NewAsymmetricJWTStrategy(jwt.ES256, key.Key.(crypto.Signer))
+	require.NoError(t, err)
+	s := &DefaultStrategy{
+		JWTStrategy:       &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()},
+		SigningStrategies: map[string]jwt.JWTStrategy{jwt.ES256: es256},
+	}
+
+	generate := func(alg string) (string, error) {
+		req := fosite.NewAccessRequest(&DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter"}, Headers: &jwt.Headers{}})
+		req.Client = &DefaultClientWithIDTokenSigningAlgorithm{
+			DefaultOpenIDConnectClient:     &fosite.DefaultOpenIDConnectClient{DefaultClient: &fosite.DefaultClient{ID: "foo"}},
+			IDTokenSignedResponseAlgorithm: alg,
+		}
+		return s.GenerateIDToken(nil, req)
+	}
+
+	token, err := generate(jwt.ES256)
+	require.NoError(t, err)
+	decoded, err := es256.Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.ES256, decoded.Header["alg"])
+	assert.Equal(t, fosite.JWTTypeIDToken, decoded.Header["typ"])
+
+	for _, alg := range []string{"", jwt.RS256} {
+		token, err = generate(alg)
+		require.NoError(t, err)
+		_, err = s.JWTStrategy.Decode(token)
+		require.NoError(t, err)
+	}
+
+	_, err = generate(jwt.EdDSA)
+	assert.EqualError(t, err, fosite.ErrServerError.Error())
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SubjectTypePublic provides the same sub value to all clients. It is the default.
+	SubjectTypePublic = "public"
+
+	// SubjectTypePairwise provides a different sub value to each sector, so clients of different sectors can not
+	// correlate the end-user's activities. See https://openid.net/specs/openid-connect-core-1_0.html#PairwiseAlg
+	SubjectTypePairwise = "pairwise"
+)
+
+// ClientWithSubjectType is implemented by clients which choose the subject identifier type of their ID tokens.
+type ClientWithSubjectType interface {
+	// GetSubjectType returns SubjectTypePublic, SubjectTypePairwise or another subject type for which an algorithm
+	// is registered. An empty string means SubjectTypePublic.
+	GetSubjectType() string
+
+	// GetSectorIdentifierURI returns the sector_identifier_uri of the client, whose host identifies the sector of
+	// pairwise subject identifiers. It may be empty if all redirect URIs of the client share the same host.
+	GetSectorIdentifierURI() string
+}
+
+// DefaultClientWithSubjectType is a DefaultClient with a subject type and sector identifier.
+type DefaultClientWithSubjectType struct {
+	*fosite.DefaultClient
+	SubjectType         string `json:"subject_type"`
+	SectorIdentifierURI string `json:"sector_identifier_uri,omitempty"`
+}
+
+func (c *DefaultClientWithSubjectType) GetSubjectType() string {
+	return c.SubjectType
+}
+
+func (c *DefaultClientWithSubjectType) GetSectorIdentifierURI() string {
+	return c.SectorIdentifierURI
+}
+
+// SubjectIdentifierAlgorithm computes the sub value a client receives for the local subject of an end-user.
+type SubjectIdentifierAlgorithm interface {
+	Obfuscate(subject string, client fosite.Client) (string, error)
+}
+
+// PublicSubjectIdentifierAlgorithm returns the local subject unchanged.
+type PublicSubjectIdentifierAlgorithm struct{}
+
+func (PublicSubjectIdentifierAlgorithm) Obfuscate(subject string, _ fosite.Client) (string, error) {
+	return subject, nil
+}
+
+// PairwiseSubjectIdentifierAlgorithm computes the hex encoded SHA-256 hash of the sector identifier, the local
+// subject and a salt, as suggested by https://openid.net/specs/openid-connect-core-1_0.html#PairwiseAlg
+type PairwiseSubjectIdentifierAlgorithm struct {
+	// Salt is kept secret and must not change, otherwise all pairwise subject identifiers change.
+	Salt []byte
+}
+
+func (a *PairwiseSubjectIdentifierAlgorithm) Obfuscate(subject string, client fosite.Client) (string, error) {
+	if len(a.Salt) == 0 {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebug("The pairwise subject identifier algorithm requires a salt."))
+	}
+
+	sector, err := SectorIdentifier(client)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(sector))
+	h.Write([]byte(subject))
+	h.Write(a.Salt)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SectorIdentifier returns the host of the client's sector_identifier_uri or, if it has none, the host all of its
+// redirect URIs share. See https://openid.net/specs/openid-connect-core-1_0.html#PairwiseAlg
+func SectorIdentifier(client fosite.Client) (string, error) {
+	if c, ok := client.(ClientWithSubjectType); ok && c.GetSectorIdentifierURI() != "" {
+		u, err := url.Parse(c.GetSectorIdentifierURI())
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return "", errors.WithStack(fosite.ErrServerError.WithDebugf("The sector_identifier_uri \"%s\" of the client must be a valid https URL.", c.GetSectorIdentifierURI()))
+		}
+		return u.Host, nil
+	}
+
+	var sector string
+	for _, raw := range client.GetRedirectURIs() {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", errors.WithStack(fosite.ErrServerError.WithDebugf("The redirect URI \"%s\" of the client is malformed.", raw))
+		} else if sector != "" && sector != u.Host {
+			return "", errors.WithStack(fosite.ErrServerError.WithDebug("The redirect URIs of the client use more than one host, a sector_identifier_uri is required to use pairwise subject identifiers."))
+		}
+		sector = u.Host
+	}
+	if sector == "" {
+		return "", errors.WithStack(fosite.ErrServerError.WithDebug("The client has neither a sector_identifier_uri nor redirect URIs to derive the sector identifier of pairwise subject identifiers from."))
+	}
+	return sector, nil
+}
+
+// subjectFor returns the sub value the client receives for subject, using the one of algorithms which is registered
+// for the subject type of the client.
+func subjectFor(algorithms map[string]SubjectIdentifierAlgorithm, subject string, client fosite.Client) (string, error) {
+	subjectType := SubjectTypePublic
+	if c, ok := client.(ClientWithSubjectType); ok && c.GetSubjectType() != "" {
+		subjectType = c.GetSubjectType()
+	}
+
+	algorithm, ok := algorithms[subjectType]
+	if !ok {
+		if subjectType == SubjectTypePublic {
+			return subject, nil
+		}
+		return "", errors.WithStack(fosite.ErrServerError.WithDebugf("No subject identifier algorithm is registered for subject type \"%s\".", subjectType))
+	}
+	return algorithm.Obfuscate(subject, client)
+}
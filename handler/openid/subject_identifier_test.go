@@ -0,0 +1,130 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"testing"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSectorIdentifier(t *testing.T) {
+	for k, c := range []struct {
+		client    fosite.Client
+		expect    string
+		expectErr bool
+	}{
+		{client: &fosite.DefaultClient{RedirectURIs: []string{"https://a.example.com/cb", "https://a.example.com/other"}}, expect: "a.example.com"},
+		{client: &fosite.DefaultClient{RedirectURIs: []string{"https://a.example.com/cb", "https://b.example.com/cb"}}, expectErr: true},
+		{client: &fosite.DefaultClient{}, expectErr: true},
+		{
+			client: &DefaultClientWithSubjectType{
+				DefaultClient:       &fosite.DefaultClient{RedirectURIs: []string{"https://a.example.com/cb", "https://b.example.com/cb"}},
+				SectorIdentifierURI: "https://sector.example.com/redirect_uris.json",
+			},
+			expect: "sector.example.com",
+		},
+		{
+			client: &DefaultClientWithSubjectType{
+				DefaultClient:       &fosite.DefaultClient{},
+				SectorIdentifierURI: "http://sector.example.com/redirect_uris.json",
+			},
+			expectErr: true,
+		},
+	} {
+		sector, err := SectorIdentifier(c.client)
+		if c.expectErr {
+			assert.Error(t, err, "%d", k)
+			continue
+		}
+		require.NoError(t, err, "%d", k)
+		assert.Equal(t, c.expect, sector, "%d", k)
+	}
+}
+
+func TestPairwiseSubjectIdentifierAlgorithm(t *testing.T) {
+	a := &PairwiseSubjectIdentifierAlgorithm{Salt: []byte("some-salt")}
+	newClient := func(redirectURI string) fosite.Client {
+		return &DefaultClientWithSubjectType{
+			DefaultClient: &fosite.DefaultClient{RedirectURIs: []string{redirectURI}},
+			SubjectType:   SubjectTypePairwise,
+		}
+	}
+
+	a1, err := a.Obfuscate("peter", newClient("https://a.example.com/cb"))
+	require.NoError(t, err)
+	a2, err := a.Obfuscate("peter", newClient("https://a.example.com/other"))
+	require.NoError(t, err)
+	b, err := a.Obfuscate("peter", newClient("https://b.example.com/cb"))
+	require.NoError(t, err)
+
+	assert.Equal(t, a1, a2)
+	assert.NotEqual(t, a1, b)
+	assert.NotEqual(t, "peter", a1)
+
+	_, err = (&PairwiseSubjectIdentifierAlgorithm{}).Obfuscate("peter", newClient("https://a.example.com/cb"))
+	assert.Error(t, err)
+}
+
+func TestJWTStrategy_GenerateIDToken_PairwiseSubject(t *testing.T) {
+	s := &DefaultStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()},
+		SubjectIdentifierAlgorithms: map[string]SubjectIdentifierAlgorithm{
+			SubjectTypePairwise: &PairwiseSubjectIdentifierAlgorithm{Salt: []byte("some-salt")},
+		},
+	}
+
+	subjectOf := func(client fosite.Client) (string, error) {
+		req := fosite.NewAccessRequest(&DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter"}, Headers: &jwt.Headers{}})
+		req.Client = client
+		token, err := s.GenerateIDToken(nil, req)
+		if err != nil {
+			return "", err
+		}
+		decoded, err := s.JWTStrategy.Decode(token)
+		require.NoError(t, err)
+		assert.Equal(t, "peter", req.GetSession().(*DefaultSession).Claims.Subject)
+		return decoded.Claims.(jwtgo.MapClaims)["sub"].(string), nil
+	}
+
+	sub, err := subjectOf(&fosite.DefaultClient{ID: "public"})
+	require.NoError(t, err)
+	assert.Equal(t, "peter", sub)
+
+	sub, err = subjectOf(&DefaultClientWithSubjectType{
+		DefaultClient: &fosite.DefaultClient{ID: "pairwise", RedirectURIs: []string{"https://a.example.com/cb"}},
+		SubjectType:   SubjectTypePairwise,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, "peter", sub)
+
+	_, err = subjectOf(&DefaultClientWithSubjectType{
+		DefaultClient: &fosite.DefaultClient{ID: "unknown"},
+		SubjectType:   "unknown",
+	})
+	assert.Error(t, err)
+}
@@ -29,13 +29,22 @@ import (
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/jwt"
 	"github.com/ory/go-convenience/stringslice"
-	"github.com/ory/go-convenience/stringsx"
 	"github.com/pkg/errors"
 )
 
 type OpenIDConnectRequestValidator struct {
 	AllowedPrompt []string
 	Strategy      jwt.JWTStrategy
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// Issuers, if set, rejects ID token hints which were not issued by one of its issuers.
+	Issuers *fosite.IssuerSet
+
+	// SubjectIdentifierAlgorithms computes the sub claim the client received in its ID tokens, which ID token hints
+	// are compared with. It must be the same as DefaultStrategy.SubjectIdentifierAlgorithms.
+	SubjectIdentifierAlgorithms map[string]SubjectIdentifierAlgorithm
 }
 
 func NewOpenIDConnectRequestValidator(prompt []string, strategy jwt.JWTStrategy) *OpenIDConnectRequestValidator {
@@ -51,7 +60,7 @@ func NewOpenIDConnectRequestValidator(prompt []string, strategy jwt.JWTStrategy)
 
 func (v *OpenIDConnectRequestValidator) ValidatePrompt(req fosite.AuthorizeRequester) error {
 	// prompt is case sensitive!
-	prompt := stringsx.Splitx(req.GetRequestForm().Get("prompt"), " ")
+	prompt := fosite.SplitArguments(req.GetRequestForm().Get("prompt"))
 
 	if req.GetClient().IsPublic() {
 		// Threat: Malicious Client Obtains Existing Authorization by Fraud
@@ -90,10 +99,10 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(req fosite.AuthorizeReque
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter \"prompt\" was set to \"none\", but contains other values as well which is not allowed."))
 	}
 
+	// A max_age of zero requires the end-user to authenticate again, which is how clients step up the authentication
+	// after a resource server asked for it, see fosite.NewStepUpError.
 	maxAge, err := strconv.ParseInt(req.GetRequestForm().Get("max_age"), 10, 64)
-	if err != nil {
-		maxAge = 0
-	}
+	hasMaxAge := err == nil && maxAge >= 0
 
 	session, ok := req.GetSession().(Session)
 	if !ok {
@@ -106,16 +115,16 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(req fosite.AuthorizeReque
 	}
 
 	// Adds a bit of wiggle room for timing issues
-	if claims.AuthTime.After(time.Now().UTC().Add(time.Second * 5)) {
+	if claims.AuthTime.After(fosite.Now(v.Clock).Add(time.Second * 5)) {
 		return errors.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because authentication time is in the future."))
 	}
 
-	if maxAge > 0 {
+	if hasMaxAge {
 		if claims.AuthTime.IsZero() {
 			return errors.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because authentication time claim is required when max_age is set."))
 		} else if claims.RequestedAt.IsZero() {
 			return errors.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because requested at claim is required when max_age is set."))
-		} else if claims.AuthTime.Add(time.Second * time.Duration(maxAge)).Before(claims.RequestedAt) {
+		} else if claims.AuthTime.Add(time.Second * time.Duration(maxAge)).Before(claims.RequestedAt.Truncate(time.Second)) {
 			return errors.WithStack(fosite.ErrLoginRequired.WithDebug("Failed to validate OpenID Connect request because authentication time does not satisfy max_age time."))
 		}
 	}
@@ -146,12 +155,20 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(req fosite.AuthorizeReque
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHintf("Failed to validate OpenID Connect request as decoding id token from id_token_hint parameter failed because %s.", err.Error()))
 	}
 
+	// The sub claim of the hint is the one the client received, which is a pairwise identifier for pairwise clients.
+	expectedSub, err := subjectFor(v.SubjectIdentifierAlgorithms, claims.Subject, req.GetClient())
+	if err != nil {
+		return err
+	}
+
 	if hintClaims, ok := tokenHint.Claims.(jwtgo.MapClaims); !ok {
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request as decoding id token from id_token_hint to *jwt.StandardClaims failed."))
 	} else if hintSub, _ := hintClaims["sub"].(string); hintSub == "" {
 		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request because provided id token from id_token_hint does not have a subject."))
-	} else if hintSub != claims.Subject || hintSub != session.GetSubject() {
+	} else if hintSub != expectedSub || claims.Subject != session.GetSubject() {
 		return errors.WithStack(fosite.ErrLoginRequired.WithHintf("Failed to validate OpenID Connect request because subject from session does not subject from id_token_hint."))
+	} else if hintIss, _ := hintClaims["iss"].(string); v.Issuers != nil && !v.Issuers.Accepts(hintIss) {
+		return errors.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request because provided id token from id_token_hint was not issued by this authorization server."))
 	}
 
 	return nil
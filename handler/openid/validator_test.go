@@ -218,3 +218,53 @@ func TestValidatePrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePromptPairwiseIDTokenHint(t *testing.T) {
+	algorithm := &PairwiseSubjectIdentifierAlgorithm{Salt: []byte("salt")}
+	v := NewOpenIDConnectRequestValidator(nil, j)
+	v.SubjectIdentifierAlgorithms = map[string]SubjectIdentifierAlgorithm{SubjectTypePairwise: algorithm}
+
+	client := &DefaultClientWithSubjectType{
+		DefaultClient: &fosite.DefaultClient{RedirectURIs: []string{"https://client.example/cb"}},
+		SubjectType:   SubjectTypePairwise,
+	}
+	pairwise, err := algorithm.Obfuscate("foo", client)
+	require.NoError(t, err)
+
+	for k, tc := range []struct {
+		sub       string
+		expectErr bool
+	}{
+		{sub: pairwise},
+		{sub: "foo", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			hint, _, err := j.Generate(jwt.IDTokenClaims{
+				Subject:     tc.sub,
+				RequestedAt: time.Now(),
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}.ToMapClaims(), jwt.NewHeaders())
+			require.NoError(t, err)
+
+			err = v.ValidatePrompt(&fosite.AuthorizeRequest{
+				Request: fosite.Request{
+					Form:   url.Values{"id_token_hint": {hint}},
+					Client: client,
+					Session: &DefaultSession{
+						Subject: "foo",
+						Claims: &jwt.IDTokenClaims{
+							Subject:     "foo",
+							RequestedAt: time.Now().UTC(),
+							AuthTime:    time.Now().UTC().Add(-time.Second),
+						},
+					},
+				},
+			})
+			if tc.expectErr {
+				assert.EqualError(t, err, fosite.ErrLoginRequired.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
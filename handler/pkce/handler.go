@@ -23,8 +23,6 @@ package pkce
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/base64"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/oauth2"
@@ -40,6 +38,9 @@ type Handler struct {
 
 	AuthorizeCodeStrategy oauth2.AuthorizeCodeStrategy
 	Storage               PKCERequestStorage
+
+	// ConfigProvider, if set, decides per request whether PKCE is enforced, see fosite.ConfigProvider.
+	ConfigProvider fosite.ConfigProvider
 }
 
 func (c *Handler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -54,7 +55,7 @@ func (c *Handler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.
 
 	challenge := ar.GetRequestForm().Get("code_challenge")
 	method := ar.GetRequestForm().Get("code_challenge_method")
-	if err := c.validate(challenge, method); err != nil {
+	if err := c.validate(c.force(ctx, ar.GetClient()), challenge, method); err != nil {
 		return err
 	}
 
@@ -74,8 +75,13 @@ func (c *Handler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.
 	return nil
 }
 
-func (c *Handler) validate(challenge, method string) error {
-	if c.Force && challenge == "" {
+// force returns whether the client must use PKCE.
+func (c *Handler) force(ctx context.Context, client fosite.Client) bool {
+	return fosite.EffectiveEnforcePKCE(ctx, c.ConfigProvider, client, c.Force)
+}
+
+func (c *Handler) validate(force bool, challenge, method string) error {
+	if force && challenge == "" {
 		//If the server requires Proof Key for Code Exchange (PKCE) by OAuth
 		//public clients and the client does not send the "code_challenge" in
 		//the request, the authorization endpoint MUST return the authorization
@@ -88,7 +94,7 @@ func (c *Handler) validate(challenge, method string) error {
 			WithDebug("The server is configured in a way that enforces PKCE for public clients."))
 	}
 
-	if !c.Force && challenge == "" {
+	if !force && challenge == "" {
 		return nil
 	}
 
@@ -148,11 +154,12 @@ func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite
 	verifier := request.GetRequestForm().Get("code_verifier")
 	challenge := authorizeRequest.GetRequestForm().Get("code_challenge")
 	method := authorizeRequest.GetRequestForm().Get("code_challenge_method")
-	if err := c.validate(challenge, method); err != nil {
+	force := c.force(ctx, request.GetClient())
+	if err := c.validate(force, challenge, method); err != nil {
 		return err
 	}
 
-	if !c.Force && challenge == "" && verifier == "" {
+	if !force && challenge == "" && verifier == "" {
 		return nil
 	}
 
@@ -177,47 +184,19 @@ func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite
 	//as normal (as defined by OAuth 2.0 [RFC6749]).  If the values are not
 	//equal, an error response indicating "invalid_grant" as described in
 	//Section 5.2 of [RFC6749] MUST be returned.
-	switch method {
-	case "S256":
-		verifierLength := base64.RawURLEncoding.DecodedLen(len(verifier))
-
-		// NOTE: The code verifier SHOULD have enough entropy to make it
-		//	impractical to guess the value.  It is RECOMMENDED that the output of
-		//	a suitable random number generator be used to create a 32-octet
-		//	sequence.  The octet sequence is then base64url-encoded to produce a
-		//	43-octet URL safe string to use as the code verifier.
-		if verifierLength < 32 {
-			return errors.WithStack(fosite.ErrInsufficientEntropy.
-				WithHint("The PKCE code verifier must contain at least 32 octets."))
-		}
-
-		verifierBytes := make([]byte, verifierLength)
-		if _, err := base64.RawURLEncoding.Decode(verifierBytes, []byte(verifier)); err != nil {
-			return errors.WithStack(fosite.ErrInvalidGrant.WithHint("Unable to decode code_verifier using base64 url decoding without padding.").WithDebug(err.Error()))
-		}
-
-		hash := sha256.New()
-		if _, err := hash.Write([]byte(verifier)); err != nil {
-			return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
-		}
+	return oauth2.VerifyCodeChallenge(challenge, method, verifier)
+}
 
-		if base64.RawURLEncoding.EncodeToString(hash.Sum([]byte{})) != challenge {
-			return errors.WithStack(fosite.ErrInvalidGrant.
-				WithHint("The PKCE code challenge did not match the code verifier."))
-		}
-		break
-	case "plain":
-		fallthrough
-	default:
-		if verifier != challenge {
-			return errors.WithStack(fosite.ErrInvalidGrant.
-				WithHint("The PKCE code challenge did not match the code verifier."))
-		}
-	}
+func (c *Handler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	return nil
+}
 
+// SupportedResponseTypes implements fosite.ResponseTypesHandler. PKCE only adds to the authorize code flow.
+func (c *Handler) SupportedResponseTypes() []string {
 	return nil
 }
 
-func (c *Handler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+// SupportedGrantTypes implements fosite.GrantTypesHandler. PKCE only adds to the authorize code flow.
+func (c *Handler) SupportedGrantTypes() []string {
 	return nil
 }
@@ -300,9 +300,9 @@ func TestPKCEHandleTokenEndpointRequest(t *testing.T) {
 			}
 
 			if tc.expectErr {
-				assert.Error(t, h.validate(tc.challenge, tc.method))
+				assert.Error(t, h.validate(tc.force, tc.challenge, tc.method))
 			} else {
-				assert.NoError(t, h.validate(tc.challenge, tc.method))
+				assert.NoError(t, h.validate(tc.force, tc.challenge, tc.method))
 			}
 		})
 	}
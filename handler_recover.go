@@ -0,0 +1,77 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+)
+
+// HandlerPanic describes a panic which was recovered from an endpoint handler.
+type HandlerPanic struct {
+	// Endpoint is the endpoint the handler was invoked for, for example "authorize" or "token".
+	Endpoint string
+
+	// Handler is the type of the handler, for example "*oauth2.AuthorizeExplicitGrantHandler".
+	Handler string
+
+	// Value is the value the handler panicked with.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+// PanicHook receives panics recovered from endpoint handlers, for example to log their stack traces. The requester
+// is the request the handler was invoked for and may be nil.
+type PanicHook func(ctx context.Context, p *HandlerPanic, requester Requester)
+
+// runHandler invokes an endpoint handler within its span. A panicking handler does not take down the goroutine
+// serving the request, instead the panic is reported to the PanicHook and the AuditLogger, and ErrServerError is
+// returned, which clients receive without any details about the panic unless SendDebugMessagesToClients is enabled.
+func (f *Fosite) runHandler(ctx context.Context, endpoint string, handler interface{}, requester Requester, fn func(ctx context.Context) error) (err error) {
+	hctx, done := f.startHandler(ctx, endpoint, handler)
+	if f.MinParameterEntropy != 0 {
+		hctx = ContextWithMinParameterEntropy(hctx, f.MinParameterEntropy)
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			p := &HandlerPanic{
+				Endpoint: endpoint,
+				Handler:  reflect.TypeOf(handler).String(),
+				Value:    v,
+				Stack:    debug.Stack(),
+			}
+			err = errors.WithStack(ErrServerError.WithDebugf("The %s endpoint handler %s panicked: %v", p.Endpoint, p.Handler, p.Value))
+			if f.PanicHook != nil {
+				f.PanicHook(ctx, p, requester)
+			}
+			f.audit(ctx, AuditHandlerPanicked, requester, err)
+		}
+		done(err)
+	}()
+
+	return fn(hctx)
+}
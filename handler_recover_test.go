@@ -0,0 +1,81 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/ory/fosite"
+	. "github.com/ory/fosite/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewMockTokenEndpointHandler(ctrl)
+	h.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ AccessRequester, _ AccessResponder) {
+		panic("something went terribly wrong")
+	})
+
+	var panics []*HandlerPanic
+	var events recordingAuditLogger
+	f := &Fosite{
+		TokenEndpointHandlers: TokenEndpointHandlers{h},
+		AuditLogger:           &events,
+		PanicHook: func(_ context.Context, p *HandlerPanic, _ Requester) {
+			panics = append(panics, p)
+		},
+	}
+
+	ar := NewAccessRequest(new(DefaultSession))
+	ar.Client = &DefaultClient{ID: "foo"}
+	resp, err := f.NewAccessResponse(context.Background(), ar)
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrServerError.Name, ErrorToRFC6749Error(err).Name)
+
+	require.Len(t, panics, 1)
+	assert.Equal(t, "token", panics[0].Endpoint)
+	assert.Equal(t, "*internal.MockTokenEndpointHandler", panics[0].Handler)
+	assert.Equal(t, "something went terribly wrong", panics[0].Value)
+	assert.NotEmpty(t, panics[0].Stack)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, AuditHandlerPanicked, events[0].Type)
+	assert.Equal(t, "foo", events[0].ClientID)
+
+	// The client must not learn anything about the panic.
+	rw := httptest.NewRecorder()
+	f.WriteAccessError(rw, ar, err)
+	assert.Equal(t, 500, rw.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &body))
+	assert.Equal(t, "server_error", body["error"])
+	assert.NotContains(t, rw.Body.String(), "terribly")
+}
@@ -22,6 +22,8 @@
 package fosite
 
 import (
+	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -44,3 +46,54 @@ func removeEmpty(args []string) (ret []string) {
 	}
 	return
 }
+
+// duplicateParameter returns the name of the first parameter, in alphabetical order, which is included more than once
+// in form and which is not one of repeatable. See https://tools.ietf.org/html/rfc6749#section-3.1
+func duplicateParameter(form url.Values, repeatable []string) (string, bool) {
+	var duplicates []string
+	for name, values := range form {
+		if len(values) > 1 && !StringInSlice(name, repeatable) {
+			duplicates = append(duplicates, name)
+		}
+	}
+	if len(duplicates) == 0 {
+		return "", false
+	}
+	sort.Strings(duplicates)
+	return duplicates[0], true
+}
+
+// isScopeToken returns true if s is a valid scope token, which consists of one or more characters of
+// %x21 / %x23-5B / %x5D-7E. See https://tools.ietf.org/html/rfc6749#section-3.3
+func isScopeToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// isVisibleASCII returns true if s consists only of characters of %x20-7E, which is the character set of the state
+// parameter. See https://tools.ietf.org/html/rfc6749#appendix-A.5
+func isVisibleASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// malformedScope returns the first scope of scopes which is not a valid scope token.
+func malformedScope(scopes []string) (string, bool) {
+	for _, scope := range scopes {
+		if !isScopeToken(scope) {
+			return scope, true
+		}
+	}
+	return "", false
+}
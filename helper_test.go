@@ -22,6 +22,7 @@
 package fosite
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,3 +45,42 @@ func TestStringInSlice(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestDuplicateParameter(t *testing.T) {
+	for k, c := range []struct {
+		form       url.Values
+		repeatable []string
+		name       string
+		ok         bool
+	}{
+		{form: url.Values{}},
+		{form: url.Values{"client_id": {"foo"}, "scope": {"foo bar"}}},
+		{form: url.Values{"client_id": {"foo", "bar"}}, name: "client_id", ok: true},
+		{form: url.Values{"redirect_uri": {"a", "b"}, "client_id": {"foo", "bar"}}, name: "client_id", ok: true},
+		{form: url.Values{"resource": {"a", "b"}}, repeatable: []string{"resource"}},
+		{form: url.Values{"resource": {"a", "b"}, "state": {"a", "a"}}, repeatable: []string{"resource"}, name: "state", ok: true},
+	} {
+		name, ok := duplicateParameter(c.form, c.repeatable)
+		assert.Equal(t, c.ok, ok, "%d", k)
+		assert.Equal(t, c.name, name, "%d", k)
+	}
+}
+
+func TestScopeAndStateCharset(t *testing.T) {
+	for _, s := range []string{"openid", "offline_access", "https://api.example.com/read", "a!#[]~"} {
+		assert.True(t, isScopeToken(s), s)
+	}
+	for _, s := range []string{"", "foo\"", "foo\\bar", "foo\tbar", "föo", "foo\x7f"} {
+		assert.False(t, isScopeToken(s), s)
+	}
+
+	assert.True(t, isVisibleASCII("some state with spaces ~!"))
+	assert.False(t, isVisibleASCII("state\n"))
+	assert.False(t, isVisibleASCII("stäte"))
+
+	scope, ok := malformedScope([]string{"foo", "b\"ar", "baz\\"})
+	assert.True(t, ok)
+	assert.Equal(t, "b\"ar", scope)
+	_, ok = malformedScope([]string{"foo", "bar"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MessageCatalog localizes the messages fosite sends to end-users and clients, such as the error_description and
+// error_hint of error responses. Messages are identified by their English text, which is also the default.
+//
+// Messages are looked up as they are, so hints set using WithHintf, which embed request values, can only be
+// translated if the catalog knows the formatted text.
+type MessageCatalog interface {
+	// Translate returns message translated to the first of languages the catalog has a translation for, or message
+	// itself.
+	Translate(message string, languages []string) string
+}
+
+// DefaultMessageCatalog is a MessageCatalog backed by a map of language tags to translations keyed by the English
+// message, for example {"de": {"The requested scope is invalid, unknown, or malformed": "..."}}. A language tag with
+// a region, like "de-CH", falls back to its base language.
+type DefaultMessageCatalog map[string]map[string]string
+
+func (c DefaultMessageCatalog) Translate(message string, languages []string) string {
+	for _, language := range languages {
+		language = strings.ToLower(language)
+		if translated, ok := c[language][message]; ok {
+			return translated
+		}
+		if base := strings.SplitN(language, "-", 2)[0]; base != language {
+			if translated, ok := c[base][message]; ok {
+				return translated
+			}
+		}
+	}
+	return message
+}
+
+// LocalizedRequester is implemented by requests which know the preferred languages of the end-user.
+type LocalizedRequester interface {
+	// GetLanguages returns the preferred languages, most preferred first.
+	GetLanguages() []string
+}
+
+// RequestLanguages returns the preferred languages of the end-user: the "ui_locales" parameter of the form, if set,
+// followed by the languages of the Accept-Language header ordered by their quality.
+func RequestLanguages(r *http.Request, form url.Values) []string {
+	return requestLanguages(r.Header, form)
+}
+
+func requestLanguages(header http.Header, form url.Values) []string {
+	languages := SplitArguments(form.Get("ui_locales"))
+
+	type weighted struct {
+		language string
+		quality  float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(header.Get("Accept-Language"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		language, quality := strings.TrimSpace(fields[0]), 1.0
+		for _, param := range fields[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if parsed, err := strconv.ParseFloat(q[2:], 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if language != "" && language != "*" && quality > 0 {
+			accepted = append(accepted, weighted{language: language, quality: quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+
+	for _, a := range accepted {
+		languages = append(languages, a.language)
+	}
+	return languages
+}
+
+// localizeError translates the description and hint of rfcerr to the preferred languages of requester, if it is a
+// LocalizedRequester and a MessageCatalog is set.
+func (f *Fosite) localizeError(rfcerr *RFC6749Error, requester interface{}) {
+	if f.MessageCatalog == nil {
+		return
+	}
+	lr, ok := requester.(LocalizedRequester)
+	if !ok || len(lr.GetLanguages()) == 0 {
+		return
+	}
+
+	rfcerr.Description = f.MessageCatalog.Translate(rfcerr.Description, lr.GetLanguages())
+	if rfcerr.Hint != "" {
+		rfcerr.Hint = f.MessageCatalog.Translate(rfcerr.Hint, lr.GetLanguages())
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultMessageCatalog(t *testing.T) {
+	c := DefaultMessageCatalog{"de": {"foo": "bar"}, "de-at": {"foo": "baz"}}
+
+	assert.Equal(t, "foo", c.Translate("foo", nil))
+	assert.Equal(t, "foo", c.Translate("foo", []string{"fr"}))
+	assert.Equal(t, "bar", c.Translate("foo", []string{"fr", "de"}))
+	assert.Equal(t, "bar", c.Translate("foo", []string{"de-CH"}))
+	assert.Equal(t, "baz", c.Translate("foo", []string{"de-AT"}))
+	assert.Equal(t, "unknown", c.Translate("unknown", []string{"de"}))
+}
+
+func TestRequestLanguages(t *testing.T) {
+	for k, c := range []struct {
+		uiLocales      string
+		acceptLanguage string
+		expect         []string
+	}{
+		{},
+		{uiLocales: "fr-CA fr", expect: []string{"fr-CA", "fr"}},
+		{acceptLanguage: "de;q=0.5, en-US, *;q=0.1, fr;q=0", expect: []string{"en-US", "de"}},
+		{uiLocales: "fr", acceptLanguage: "de", expect: []string{"fr", "de"}},
+	} {
+		r := &http.Request{Header: http.Header{}}
+		r.Header.Set("Accept-Language", c.acceptLanguage)
+		assert.Equal(t, c.expect, RequestLanguages(r, url.Values{"ui_locales": {c.uiLocales}}), "%d", k)
+	}
+}
+
+func TestWriteAccessError_Localized(t *testing.T) {
+	f := &Fosite{MessageCatalog: DefaultMessageCatalog{"de": {
+		ErrInvalidRequest.Description: "Die Anfrage ist ungültig",
+		"Missing parameter":           "Fehlender Parameter",
+	}}}
+
+	for k, c := range []struct {
+		languages []string
+		expect    string
+		hint      string
+	}{
+		{languages: nil, expect: ErrInvalidRequest.Description, hint: "Missing parameter"},
+		{languages: []string{"de-DE"}, expect: "Die Anfrage ist ungültig", hint: "Fehlender Parameter"},
+	} {
+		rw := httptest.NewRecorder()
+		f.WriteAccessError(rw, &AccessRequest{Request: Request{Languages: c.languages}}, errors.WithStack(ErrInvalidRequest.WithHint("Missing parameter")))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &body))
+		assert.Equal(t, c.expect, body["error_description"], "%d", k)
+		assert.Equal(t, c.hint, body["error_hint"], "%d", k)
+	}
+}
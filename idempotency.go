@@ -0,0 +1,112 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IdempotencyKeyHeader is the HTTP header clients may use to mark token requests which are retries of one another.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// DefaultIdempotencyWindow is the default time during which a retried token request returns the original response.
+const DefaultIdempotencyWindow = time.Minute
+
+// IdempotencyStorage stores token endpoint responses, so that a token request which is retried, for example after a
+// network timeout, returns the originally issued tokens instead of failing with invalid_grant. The responses contain
+// the issued tokens in clear text and should be encrypted at rest and removed once they expire.
+type IdempotencyStorage interface {
+	// CreateIdempotentResponse stores the token response of the request with the given key until expiresAt.
+	CreateIdempotentResponse(ctx context.Context, key string, response map[string]interface{}, expiresAt time.Time) (err error)
+
+	// GetIdempotentResponse returns the token response stored for key and its expiry, or ErrNotFound.
+	GetIdempotentResponse(ctx context.Context, key string) (response map[string]interface{}, expiresAt time.Time, err error)
+}
+
+// idempotencyKey identifies token requests which are retries of one another. Only requests carrying the
+// Idempotency-Key header are idempotent. The key is bound to the authenticated client and to every parameter of the
+// request, including the code_verifier and redirect_uri, so that a stored response is only returned for an exact
+// retry. Presenting an intercepted authorization code or refresh token with other parameters therefore runs the
+// token endpoint handlers and their checks as usual.
+func idempotencyKey(r *http.Request, request *AccessRequest) string {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return ""
+	}
+
+	// Encode sorts the parameters by name, which makes the encoding independent of their order in the request.
+	h := sha256.Sum256([]byte(request.Client.GetID() + "\x00" + key + "\x00" + request.Form.Encode()))
+	return hex.EncodeToString(h[:])
+}
+
+// loadIdempotentResponse sets the stored response of a retried token request, if there is one.
+func (f *Fosite) loadIdempotentResponse(ctx context.Context, r *http.Request, request *AccessRequest) error {
+	if request.idempotencyKey = idempotencyKey(r, request); request.idempotencyKey == "" {
+		return nil
+	}
+
+	stored, expiresAt, err := f.IdempotencyStorage.GetIdempotentResponse(ctx, request.idempotencyKey)
+	if errors.Cause(err) == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	} else if !Now(f.Clock).Before(expiresAt) {
+		return nil
+	}
+
+	response := NewAccessResponse()
+	for k, v := range stored {
+		response.SetExtra(k, v)
+	}
+	response.AccessToken, _ = stored["access_token"].(string)
+	response.TokenType, _ = stored["token_type"].(string)
+	request.idempotentResponse = response
+	return nil
+}
+
+// storeIdempotentResponse stores the response of a token request, so that retries of the request return it.
+func (f *Fosite) storeIdempotentResponse(ctx context.Context, requester AccessRequester, response *AccessResponse) error {
+	request, ok := requester.(*AccessRequest)
+	if !ok || request.idempotencyKey == "" {
+		return nil
+	}
+
+	window := f.IdempotencyWindow
+	if window == 0 {
+		window = DefaultIdempotencyWindow
+	}
+
+	stored := map[string]interface{}{}
+	for k, v := range response.ToMap() {
+		stored[k] = v
+	}
+	if err := f.IdempotencyStorage.CreateIdempotentResponse(ctx, request.idempotencyKey, stored, Now(f.Clock).Add(window)); err != nil {
+		return errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redeemingTokenEndpointHandler issues a new token for every request and rejects codes which were already redeemed.
+type redeemingTokenEndpointHandler struct {
+	redeemed map[string]bool
+	issued   int
+}
+
+func (h *redeemingTokenEndpointHandler) HandleTokenEndpointRequest(_ context.Context, r AccessRequester) error {
+	if code := r.GetRequestForm().Get("code"); h.redeemed[code] {
+		return ErrInvalidGrant
+	}
+	return nil
+}
+
+func (h *redeemingTokenEndpointHandler) PopulateTokenEndpointResponse(_ context.Context, r AccessRequester, responder AccessResponder) error {
+	if code := r.GetRequestForm().Get("code"); code != "" {
+		h.redeemed[code] = true
+	}
+	h.issued++
+	responder.SetAccessToken(fmt.Sprintf("token-%d", h.issued))
+	responder.SetTokenType("bearer")
+	return nil
+}
+
+func TestIdempotentTokenRequests(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+	store.Clients["bar"] = &DefaultClient{ID: "bar", Public: true}
+	now := time.Now()
+	f := &Fosite{
+		Store:                 store,
+		Clock:                 ClockFunc(func() time.Time { return now }),
+		TokenEndpointHandlers: TokenEndpointHandlers{&redeemingTokenEndpointHandler{redeemed: map[string]bool{}}},
+		IdempotencyStorage:    store,
+	}
+
+	exchange := func(clientID string, form url.Values, key string) (string, error) {
+		form.Set("client_id", clientID)
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		ar, err := f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+		if err != nil {
+			return "", err
+		}
+		resp, err := f.NewAccessResponse(context.Background(), ar)
+		if err != nil {
+			return "", err
+		}
+		return resp.GetAccessToken(), nil
+	}
+	code := func(code string) url.Values {
+		return url.Values{"grant_type": {"authorization_code"}, "code": {code}}
+	}
+
+	first, err := exchange("foo", code("a"), "key")
+	require.NoError(t, err)
+
+	// A retry of the request returns the original tokens.
+	retry, err := exchange("foo", code("a"), "key")
+	require.NoError(t, err)
+	assert.Equal(t, first, retry)
+
+	// Without the Idempotency-Key header, the request is not a retry and the code was already redeemed.
+	_, err = exchange("foo", code("a"), "")
+	assert.EqualError(t, err, ErrInvalidGrant.Error())
+
+	// The response is bound to the client.
+	_, err = exchange("bar", code("a"), "key")
+	assert.EqualError(t, err, ErrInvalidGrant.Error())
+
+	// The response is bound to every parameter of the request, a replayed code with another code_verifier or
+	// redirect_uri is handled again.
+	replay := code("a")
+	replay.Set("code_verifier", "guessed")
+	_, err = exchange("foo", replay, "key")
+	assert.EqualError(t, err, ErrInvalidGrant.Error())
+	replay = code("a")
+	replay.Set("redirect_uri", "https://attacker.example/cb")
+	_, err = exchange("foo", replay, "key")
+	assert.EqualError(t, err, ErrInvalidGrant.Error())
+
+	// Requests of other grants are idempotent as well.
+	cc := url.Values{"grant_type": {"client_credentials"}}
+	first, err = exchange("foo", cc, "key")
+	require.NoError(t, err)
+	retry, err = exchange("foo", cc, "key")
+	require.NoError(t, err)
+	assert.Equal(t, first, retry)
+	other, err := exchange("foo", cc, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	// After the idempotency window, the request is handled again.
+	now = now.Add(DefaultIdempotencyWindow)
+	_, err = exchange("foo", code("a"), "key")
+	assert.EqualError(t, err, ErrInvalidGrant.Error())
+}
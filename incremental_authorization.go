@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// GrantStore persists the scopes end-users granted to clients, so that clients can ask for permissions incrementally:
+// an authorize request with include_granted_scopes=true is granted the scopes the end-user granted to the client
+// before, in addition to the scopes granted for the request itself. See Fosite.GrantStore.
+type GrantStore interface {
+	// GetGrantedScopes returns the scopes subject granted to the client before, if any.
+	GetGrantedScopes(ctx context.Context, clientID, subject string) (scopes Arguments, err error)
+
+	// AddGrantedScopes records that subject granted scopes to the client, in addition to the scopes granted before.
+	AddGrantedScopes(ctx context.Context, clientID, subject string, scopes Arguments) (err error)
+
+	// RevokeGrantedScopes removes the scopes subject granted to the client. Removing scopes which were never granted
+	// is not an error.
+	RevokeGrantedScopes(ctx context.Context, clientID, subject string) (err error)
+
+	// RevokeSubjectGrantedScopes removes the scopes subject granted to all clients.
+	RevokeSubjectGrantedScopes(ctx context.Context, subject string) (err error)
+}
+
+// includeGrantedScopes grants the scopes the subject of the session granted to the client before if the request
+// asks for it with include_granted_scopes=true. Scopes the client may no longer request are left out.
+func (f *Fosite) includeGrantedScopes(ctx context.Context, ar AuthorizeRequester) error {
+	if f.GrantStore == nil || ar.GetRequestForm().Get("include_granted_scopes") != "true" {
+		return nil
+	}
+
+	subject := grantSubject(ar)
+	if subject == "" {
+		return nil
+	}
+
+	scopes, err := f.GrantStore.GetGrantedScopes(ctx, ar.GetClient().GetID(), subject)
+	if err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+
+	client := ar.GetClient()
+	scopeStrategy := EffectiveScopeStrategy(ctx, f.ConfigProvider, client, f.ScopeStrategy)
+	for _, scope := range scopes {
+		if !scopeStrategy(client.GetScopes(), scope) {
+			continue
+		}
+		ar.AppendRequestedScope(scope)
+		ar.GrantScope(scope)
+	}
+	return nil
+}
+
+// recordGrantedScopes records the scopes granted for the request, so that later requests can include them.
+func (f *Fosite) recordGrantedScopes(ctx context.Context, ar AuthorizeRequester) error {
+	if f.GrantStore == nil || len(ar.GetGrantedScopes()) == 0 {
+		return nil
+	}
+
+	subject := grantSubject(ar)
+	if subject == "" {
+		return nil
+	}
+
+	if err := f.GrantStore.AddGrantedScopes(ctx, ar.GetClient().GetID(), subject, ar.GetGrantedScopes()); err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+	return nil
+}
+
+// grantSubject returns the end-user who granted the scopes of the request, or an empty string if the session does not
+// name one.
+func grantSubject(ar AuthorizeRequester) string {
+	if ar.GetSession() == nil {
+		return ""
+	}
+	return ar.GetSession().GetSubject()
+}
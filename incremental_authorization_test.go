@@ -0,0 +1,72 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestIncrementalAuthorization(t *testing.T) {
+	store := storage.NewMemoryStore()
+	f := &Fosite{GrantStore: store, ScopeStrategy: ExactScopeStrategy}
+	client := &DefaultClient{ID: "foo", Scopes: Arguments{"photos", "contacts", "calendar"}}
+
+	authorize := func(form url.Values, subject string, granted ...string) AuthorizeRequester {
+		ar := NewAuthorizeRequest()
+		ar.Client = client
+		ar.Form = form
+		ar.ResponseTypes = Arguments{"code"}
+		ar.SetResponseTypeHandled("code")
+		for _, scope := range granted {
+			ar.AppendRequestedScope(scope)
+			ar.GrantScope(scope)
+		}
+		_, err := f.NewAuthorizeResponse(context.Background(), ar, &DefaultSession{Subject: subject})
+		require.NoError(t, err)
+		return ar
+	}
+
+	ar := authorize(url.Values{}, "peter", "photos")
+	assert.Equal(t, Arguments{"photos"}, ar.GetGrantedScopes())
+
+	ar = authorize(url.Values{}, "peter", "contacts")
+	assert.Equal(t, Arguments{"contacts"}, ar.GetGrantedScopes(), "previous grants are only included on request")
+
+	ar = authorize(url.Values{"include_granted_scopes": {"true"}}, "peter", "calendar")
+	assert.Equal(t, Arguments{"calendar", "photos", "contacts"}, ar.GetGrantedScopes())
+	assert.Equal(t, Arguments{"calendar", "photos", "contacts"}, ar.GetRequestedScopes())
+
+	ar = authorize(url.Values{"include_granted_scopes": {"true"}}, "alice", "calendar")
+	assert.Equal(t, Arguments{"calendar"}, ar.GetGrantedScopes(), "grants of other end-users are not included")
+
+	client.Scopes = Arguments{"calendar", "contacts"}
+	ar = authorize(url.Values{"include_granted_scopes": {"true"}}, "peter", "calendar")
+	assert.Equal(t, Arguments{"calendar", "contacts"}, ar.GetGrantedScopes(), "scopes the client may no longer request are not included")
+}
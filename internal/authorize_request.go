@@ -50,6 +50,16 @@ func (_mr *_MockAuthorizeRequesterRecorder) DidHandleAllResponseTypes() *gomock.
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DidHandleAllResponseTypes")
 }
 
+func (_m *MockAuthorizeRequester) GetACRValues() fosite.Arguments {
+	ret := _m.ctrl.Call(_m, "GetACRValues")
+	ret0, _ := ret[0].(fosite.Arguments)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetACRValues() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetACRValues")
+}
+
 func (_m *MockAuthorizeRequester) GetClient() fosite.Client {
 	ret := _m.ctrl.Call(_m, "GetClient")
 	ret0, _ := ret[0].(fosite.Client)
@@ -60,6 +70,26 @@ func (_mr *_MockAuthorizeRequesterRecorder) GetClient() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetClient")
 }
 
+func (_m *MockAuthorizeRequester) GetDeviceHint() string {
+	ret := _m.ctrl.Call(_m, "GetDeviceHint")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetDeviceHint() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDeviceHint")
+}
+
+func (_m *MockAuthorizeRequester) GetDisplay() string {
+	ret := _m.ctrl.Call(_m, "GetDisplay")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetDisplay() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDisplay")
+}
+
 func (_m *MockAuthorizeRequester) GetGrantedScopes() fosite.Arguments {
 	ret := _m.ctrl.Call(_m, "GetGrantedScopes")
 	ret0, _ := ret[0].(fosite.Arguments)
@@ -80,6 +110,47 @@ func (_mr *_MockAuthorizeRequesterRecorder) GetID() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetID")
 }
 
+func (_m *MockAuthorizeRequester) GetIDTokenHint() string {
+	ret := _m.ctrl.Call(_m, "GetIDTokenHint")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetIDTokenHint() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetIDTokenHint")
+}
+
+func (_m *MockAuthorizeRequester) GetLoginHint() string {
+	ret := _m.ctrl.Call(_m, "GetLoginHint")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetLoginHint() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLoginHint")
+}
+
+func (_m *MockAuthorizeRequester) GetMaxAge() (time.Duration, bool) {
+	ret := _m.ctrl.Call(_m, "GetMaxAge")
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetMaxAge() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetMaxAge")
+}
+
+func (_m *MockAuthorizeRequester) GetPrompt() fosite.Arguments {
+	ret := _m.ctrl.Call(_m, "GetPrompt")
+	ret0, _ := ret[0].(fosite.Arguments)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetPrompt() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetPrompt")
+}
+
 func (_m *MockAuthorizeRequester) GetRedirectURI() *url.URL {
 	ret := _m.ctrl.Call(_m, "GetRedirectURI")
 	ret0, _ := ret[0].(*url.URL)
@@ -120,6 +191,16 @@ func (_mr *_MockAuthorizeRequesterRecorder) GetRequestedScopes() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetRequestedScopes")
 }
 
+func (_m *MockAuthorizeRequester) GetResponseMode() string {
+	ret := _m.ctrl.Call(_m, "GetResponseMode")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockAuthorizeRequesterRecorder) GetResponseMode() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetResponseMode")
+}
+
 func (_m *MockAuthorizeRequester) GetResponseTypes() fosite.Arguments {
 	ret := _m.ctrl.Call(_m, "GetResponseTypes")
 	ret0, _ := ret[0].(fosite.Arguments)
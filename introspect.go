@@ -53,13 +53,19 @@ func AccessTokenFromRequest(req *http.Request) string {
 	return split[1]
 }
 
-func (f *Fosite) IntrospectToken(ctx context.Context, token string, tokenType TokenType, session Session, scopes ...string) (TokenType, AccessRequester, error) {
+func (f *Fosite) IntrospectToken(ctx context.Context, token string, tokenType TokenType, session Session, scopes ...string) (_ TokenType, _ AccessRequester, err error) {
 	var found bool = false
 	var foundTokenType TokenType = ""
+	ctx, span := f.startSpan(ctx, "fosite.IntrospectToken")
+	defer func() { span.End(err) }()
 
 	ar := NewAccessRequest(session)
 	for _, validator := range f.TokenIntrospectionHandlers {
-		tt, err := validator.IntrospectToken(ctx, token, tokenType, ar, scopes)
+		var tt TokenType
+		err := f.runHandler(ctx, "introspection", validator, ar, func(ctx context.Context) (err error) {
+			tt, err = validator.IntrospectToken(ctx, token, tokenType, ar, scopes)
+			return err
+		})
 		if err := errors.Cause(err); err == nil {
 			found = true
 			foundTokenType = tt
@@ -75,5 +81,45 @@ func (f *Fosite) IntrospectToken(ctx context.Context, token string, tokenType To
 		return "", nil, errors.WithStack(ErrRequestUnauthorized.WithHint("Unable to find a suitable validation strategy for the token, thus it is invalid."))
 	}
 
+	// The client stored alongside the token reflects its state at issuance, so the current state is looked up. A
+	// client which can not be loaded, for example because it was deleted, is treated like a disabled one.
+	if client := ar.GetClient(); client != nil && client.GetID() != "" && f.Store != nil {
+		if current, err := f.getClient(ctx, client.GetID()); err != nil {
+			return "", nil, errors.WithStack(ErrInactiveToken.WithHint("The OAuth 2.0 Client the token was issued to could not be loaded.").WithWrap(err))
+		} else if !IsClientTokenValid(current, Now(f.Clock)) {
+			return "", nil, errors.WithStack(ErrInactiveToken.WithHint("The OAuth 2.0 Client the token was issued to has been deactivated."))
+		}
+	}
+
 	return foundTokenType, ar, nil
 }
+
+// ValidateToken is meant to be used by resource servers. It extracts the bearer token from the request's
+// Authorization header, form body or access_token query parameter, checks that it is a valid access token and that
+// all of the given scopes were granted. The returned AccessRequester carries the token's session, scopes and client.
+func (f *Fosite) ValidateToken(ctx context.Context, r *http.Request, session Session, scopes ...string) (AccessRequester, error) {
+	token := AccessTokenFromRequest(r)
+	if token == "" {
+		return nil, errors.WithStack(ErrRequestUnauthorized.WithHint("The request does not carry a bearer token.").WithWrap(ErrMissingBearerToken))
+	}
+
+	tokenType, ar, err := f.IntrospectToken(ctx, token, AccessToken, session, scopes...)
+	if err != nil {
+		return nil, err
+	} else if tokenType != AccessToken {
+		return nil, errors.WithStack(ErrRequestUnauthorized.WithHintf("Expected an access token but got a token of type \"%s\".", tokenType))
+	}
+
+	scopeStrategy := EffectiveScopeStrategy(ctx, f.ConfigProvider, ar.GetClient(), f.ScopeStrategy)
+	if scopeStrategy == nil {
+		scopeStrategy = HierarchicScopeStrategy
+	}
+
+	for _, scope := range scopes {
+		if scope != "" && !scopeStrategy(ar.GetGrantedScopes(), scope) {
+			return nil, errors.WithStack(ErrScopeNotGranted.WithHintf("The token was not granted the required scope \"%s\".", scope))
+		}
+	}
+
+	return ar, nil
+}
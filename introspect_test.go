@@ -24,6 +24,7 @@ package fosite_test
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"context"
 
@@ -130,3 +131,131 @@ func TestIntrospect(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	validator := internal.NewMockTokenIntrospector(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{TokenIntrospectionHandlers: TokenIntrospectionHandlers{validator}}
+
+	grant := func(scopes ...string) func(context.Context, string, TokenType, AccessRequester, []string) {
+		return func(_ context.Context, _ string, _ TokenType, accessRequest AccessRequester, _ []string) {
+			accessRequest.(*AccessRequest).GrantedScopes = scopes
+		}
+	}
+
+	for k, c := range []struct {
+		description string
+		header      string
+		scopes      []string
+		setup       func()
+		expectErr   error
+	}{
+		{
+			description: "should fail because no token was given",
+			setup:       func() {},
+			expectErr:   ErrRequestUnauthorized,
+		},
+		{
+			description: "should fail because the token is invalid",
+			header:      "bearer some-token",
+			setup: func() {
+				validator.EXPECT().IntrospectToken(nil, "some-token", AccessToken, gomock.Any(), gomock.Any()).Return(TokenType(""), ErrUnknownRequest)
+			},
+			expectErr: ErrRequestUnauthorized,
+		},
+		{
+			description: "should fail because a refresh token was given",
+			header:      "bearer some-token",
+			setup: func() {
+				validator.EXPECT().IntrospectToken(nil, "some-token", AccessToken, gomock.Any(), gomock.Any()).Return(RefreshToken, nil)
+			},
+			expectErr: ErrRequestUnauthorized,
+		},
+		{
+			description: "should fail because the scope was not granted",
+			header:      "bearer some-token",
+			scopes:      []string{"foo"},
+			setup: func() {
+				validator.EXPECT().IntrospectToken(nil, "some-token", AccessToken, gomock.Any(), gomock.Any()).Do(grant("bar")).Return(AccessToken, nil)
+			},
+			expectErr: ErrScopeNotGranted,
+		},
+		{
+			description: "should pass",
+			header:      "bearer some-token",
+			scopes:      []string{"foo.bar"},
+			setup: func() {
+				validator.EXPECT().IntrospectToken(nil, "some-token", AccessToken, gomock.Any(), gomock.Any()).Do(grant("foo")).Return(AccessToken, nil)
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://example.com/test", nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+
+			c.setup()
+			ar, err := f.ValidateToken(nil, req, nil, c.scopes...)
+			if c.expectErr != nil {
+				assert.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Arguments{"foo"}, ar.GetGrantedScopes())
+		})
+	}
+}
+
+func TestIntrospectDeactivatedClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	validator := internal.NewMockTokenIntrospector(ctrl)
+	defer ctrl.Finish()
+
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo"}
+	f := &Fosite{Store: store, TokenIntrospectionHandlers: TokenIntrospectionHandlers{validator}}
+
+	validator.EXPECT().IntrospectToken(nil, "some-token", gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ string, _ TokenType, accessRequest AccessRequester, _ []string) {
+		accessRequest.(*AccessRequest).Client = &DefaultClient{ID: "foo"}
+	}).Return(AccessToken, nil).Times(7)
+
+	require.NoError(t, store.DeactivateClient(nil, "foo", time.Hour))
+	_, _, err := f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	require.NoError(t, err, "tokens remain valid during the grace period")
+
+	require.NoError(t, store.RestoreClient(nil, "foo"))
+	require.NoError(t, store.DeactivateClient(nil, "foo", 0))
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	assert.EqualError(t, err, ErrInactiveToken.Error())
+
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Disabled: true}
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	assert.EqualError(t, err, ErrInactiveToken.Error())
+
+	store.Clients["foo"] = &DefaultClient{ID: "foo"}
+	require.NoError(t, store.DeactivateClient(nil, "foo", time.Hour))
+	require.NoError(t, store.DisableClient(nil, "foo"))
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	assert.EqualError(t, err, ErrInactiveToken.Error(), "disabling ignores the grace period")
+
+	require.NoError(t, store.DeactivateClient(nil, "foo", 0))
+	require.NoError(t, store.EnableClient(nil, "foo"))
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	require.NoError(t, err, "enabling the client keeps its deactivation")
+	assert.False(t, IsClientActive(store.Clients["foo"]))
+
+	require.NoError(t, store.RestoreClient(nil, "foo"))
+	require.NoError(t, store.DisableClient(nil, "foo"))
+	require.NoError(t, store.EnableClient(nil, "foo"))
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &DefaultClient{ID: "foo"}, store.Clients["foo"])
+
+	delete(store.Clients, "foo")
+	_, _, err = f.IntrospectToken(nil, "some-token", AccessToken, nil)
+	assert.EqualError(t, err, ErrInactiveToken.Error(), "tokens of deleted clients are inactive")
+}
@@ -223,3 +223,31 @@ func (f *Fosite) WriteIntrospectionResponse(rw http.ResponseWriter, r Introspect
 		// Session:   r.GetAccessRequester().GetSession(),
 	})
 }
+
+// IntrospectionResponseClaims returns the members of the introspection response of r, as written by
+// WriteIntrospectionResponse. Inactive tokens only have the "active" member.
+func IntrospectionResponseClaims(r IntrospectionResponder) map[string]interface{} {
+	if !r.IsActive() {
+		return map[string]interface{}{"active": false}
+	}
+
+	ar := r.GetAccessRequester()
+	claims := map[string]interface{}{
+		"active": true,
+		"iat":    ar.GetRequestedAt().Unix(),
+	}
+	for name, value := range map[string]string{
+		"client_id": ar.GetClient().GetID(),
+		"scope":     strings.Join(ar.GetGrantedScopes(), " "),
+		"sub":       ar.GetSession().GetSubject(),
+		"username":  ar.GetSession().GetUsername(),
+	} {
+		if value != "" {
+			claims[name] = value
+		}
+	}
+	if exp := ar.GetSession().GetExpiresAt(AccessToken); !exp.IsZero() {
+		claims["exp"] = exp.Unix()
+	}
+	return claims
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "sync"
+
+// IssuerSet is the issuer of the authorization server together with legacy issuers which are still accepted, for
+// example while the authorization server migrates to a new domain. New tokens are always issued by Issuer, while
+// tokens and ID token hints referencing a legacy issuer keep validating until the legacy issuer is removed. Usage of
+// legacy issuers is counted, so that it is known when they can be removed safely.
+type IssuerSet struct {
+	// Issuer is the issuer of new tokens.
+	Issuer string
+
+	// LegacyIssuers are accepted when validating tokens but never used to issue them.
+	LegacyIssuers []string
+
+	// OnLegacyIssuer, if set, is called whenever a token referencing a legacy issuer is accepted.
+	OnLegacyIssuer func(issuer string)
+
+	sync.Mutex
+	legacyUsage map[string]uint64
+}
+
+// Accepts returns true if iss is the issuer or one of the legacy issuers. Accepting a legacy issuer is recorded.
+func (s *IssuerSet) Accepts(iss string) bool {
+	if iss == s.Issuer {
+		return true
+	} else if !StringInSlice(iss, s.LegacyIssuers) {
+		return false
+	}
+
+	s.Lock()
+	if s.legacyUsage == nil {
+		s.legacyUsage = map[string]uint64{}
+	}
+	s.legacyUsage[iss]++
+	s.Unlock()
+
+	if s.OnLegacyIssuer != nil {
+		s.OnLegacyIssuer(iss)
+	}
+	return true
+}
+
+// LegacyUsage returns how many times tokens referencing each legacy issuer were accepted.
+func (s *IssuerSet) LegacyUsage() map[string]uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	usage := make(map[string]uint64, len(s.LegacyIssuers))
+	for _, iss := range s.LegacyIssuers {
+		usage[iss] = s.legacyUsage[iss]
+	}
+	return usage
+}
+
+// Migrate returns the issuer of a new token whose predecessor referenced iss, for example when a session is reused
+// during a refresh: Issuer if iss is empty or a legacy issuer, iss otherwise.
+func (s *IssuerSet) Migrate(iss string) string {
+	if iss == "" || StringInSlice(iss, s.LegacyIssuers) {
+		return s.Issuer
+	}
+	return iss
+}
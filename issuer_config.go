@@ -0,0 +1,159 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IssuerConfig is the issuer URL of the authorization server and the URLs of its endpoints. It is used to populate
+// the "iss" claim of tokens, discovery documents (see PopulateMetadata) and the error_uri of error responses, and
+// should be validated on start up using Validate.
+type IssuerConfig struct {
+	// Issuer is the issuer identifier of the authorization server, see https://tools.ietf.org/html/rfc8414#section-2.
+	Issuer string
+
+	// AuthorizationEndpoint is the URL of the authorization endpoint.
+	AuthorizationEndpoint string
+
+	// TokenEndpoint is the URL of the token endpoint. It is used as the token URL of Fosite unless Fosite.TokenURL
+	// is set.
+	TokenEndpoint string
+
+	// UserinfoEndpoint is the URL of the OpenID Connect userinfo endpoint.
+	UserinfoEndpoint string
+
+	// JSONWebKeysURI is the URL of the JSON Web Key Set of the authorization server.
+	JSONWebKeysURI string
+
+	// ErrorURI, if set, is the URL of a web page documenting errors. Error responses link to it with the name of the
+	// error appended as the "error" query parameter.
+	ErrorURI string
+}
+
+// Validate makes sure that the issuer is an https URL without query or fragment component as required by
+// https://tools.ietf.org/html/rfc8414#section-2, and that the endpoints are https URLs without fragment component.
+// Plain http is accepted for localhost. It returns ErrMisconfiguration listing every problem found.
+func (c *IssuerConfig) Validate() error {
+	var problems []string
+
+	if c.Issuer == "" {
+		problems = append(problems, "the issuer is not set")
+	} else if u := validateIssuerConfigURL("issuer", c.Issuer, &problems); u != nil && u.RawQuery != "" {
+		problems = append(problems, fmt.Sprintf("issuer \"%s\" must not contain a query component", c.Issuer))
+	}
+
+	for _, endpoint := range []struct{ name, url string }{
+		{"authorization_endpoint", c.AuthorizationEndpoint},
+		{"token_endpoint", c.TokenEndpoint},
+		{"userinfo_endpoint", c.UserinfoEndpoint},
+		{"jwks_uri", c.JSONWebKeysURI},
+	} {
+		if endpoint.url != "" {
+			validateIssuerConfigURL(endpoint.name, endpoint.url, &problems)
+		}
+	}
+
+	if c.ErrorURI != "" {
+		if u, err := url.Parse(c.ErrorURI); err != nil || !u.IsAbs() {
+			problems = append(problems, fmt.Sprintf("error_uri \"%s\" must be an absolute URL", c.ErrorURI))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.WithStack(ErrMisconfiguration.WithHint("The issuer configuration is invalid.").WithDebug(strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+// validateIssuerConfigURL appends the problems of the URL to problems. It returns the parsed URL, or nil if it is not
+// an absolute URL.
+func validateIssuerConfigURL(name, rawurl string, problems *[]string) *url.URL {
+	u, err := url.Parse(rawurl)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		*problems = append(*problems, fmt.Sprintf("%s \"%s\" must be an absolute URL", name, rawurl))
+		return nil
+	}
+
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLocalhost(u)) {
+		*problems = append(*problems, fmt.Sprintf("%s \"%s\" must use the https scheme", name, rawurl))
+	}
+	if strings.Contains(rawurl, "#") {
+		*problems = append(*problems, fmt.Sprintf("%s \"%s\" must not contain a fragment component", name, rawurl))
+	}
+	return u
+}
+
+// PopulateMetadata sets the issuer and the endpoints of m which are not set yet. The support of the "iss" parameter of
+// authorize responses is advertised separately, see Fosite.AuthorizationResponseIssParameter.
+func (c *IssuerConfig) PopulateMetadata(m *Metadata) {
+	for _, field := range []struct {
+		value *string
+		url   string
+	}{
+		{&m.Issuer, c.Issuer},
+		{&m.AuthorizationEndpoint, c.AuthorizationEndpoint},
+		{&m.TokenEndpoint, c.TokenEndpoint},
+		{&m.UserinfoEndpoint, c.UserinfoEndpoint},
+		{&m.JSONWebKeysURI, c.JSONWebKeysURI},
+	} {
+		if *field.value == "" {
+			*field.value = field.url
+		}
+	}
+}
+
+// ErrorURIFor returns the error_uri of errors with the given name, or an empty string if ErrorURI is not set.
+func (c *IssuerConfig) ErrorURIFor(name string) string {
+	if c.ErrorURI == "" {
+		return ""
+	}
+
+	u, err := url.Parse(c.ErrorURI)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	query.Set("error", name)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// checkMetadata appends the advertised issuer and endpoints of m which differ from the configuration to problems.
+func (c *IssuerConfig) checkMetadata(m *Metadata, problems []string) []string {
+	for _, field := range []struct{ name, advertised, configured string }{
+		{"issuer", m.Issuer, c.Issuer},
+		{"authorization_endpoint", m.AuthorizationEndpoint, c.AuthorizationEndpoint},
+		{"token_endpoint", m.TokenEndpoint, c.TokenEndpoint},
+		{"userinfo_endpoint", m.UserinfoEndpoint, c.UserinfoEndpoint},
+		{"jwks_uri", m.JSONWebKeysURI, c.JSONWebKeysURI},
+	} {
+		if field.advertised != "" && field.configured != "" && field.advertised != field.configured {
+			problems = append(problems, fmt.Sprintf("%s \"%s\" does not match the configured URL \"%s\"", field.name, field.advertised, field.configured))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+)
+
+func TestIssuerConfigValidate(t *testing.T) {
+	for k, c := range []struct {
+		config  IssuerConfig
+		problem string
+	}{
+		{config: IssuerConfig{Issuer: "https://op.example.com"}},
+		{config: IssuerConfig{Issuer: "https://op.example.com/tenant", TokenEndpoint: "https://op.example.com/token?foo=bar", ErrorURI: "https://docs.example.com/errors"}},
+		{config: IssuerConfig{Issuer: "http://localhost:4444", AuthorizationEndpoint: "http://127.0.0.1:4444/auth"}},
+		{config: IssuerConfig{}, problem: "the issuer is not set"},
+		{config: IssuerConfig{Issuer: "op.example.com"}, problem: "must be an absolute URL"},
+		{config: IssuerConfig{Issuer: "http://op.example.com"}, problem: "must use the https scheme"},
+		{config: IssuerConfig{Issuer: "https://op.example.com?tenant=foo"}, problem: "must not contain a query component"},
+		{config: IssuerConfig{Issuer: "https://op.example.com#"}, problem: "must not contain a fragment component"},
+		{config: IssuerConfig{Issuer: "https://op.example.com", TokenEndpoint: "/token"}, problem: "token_endpoint \"/token\" must be an absolute URL"},
+		{config: IssuerConfig{Issuer: "https://op.example.com", JSONWebKeysURI: "http://op.example.com/jwks"}, problem: "jwks_uri \"http://op.example.com/jwks\" must use the https scheme"},
+		{config: IssuerConfig{Issuer: "https://op.example.com", UserinfoEndpoint: "https://op.example.com/userinfo#me"}, problem: "userinfo_endpoint"},
+		{config: IssuerConfig{Issuer: "https://op.example.com", ErrorURI: "/errors"}, problem: "error_uri"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := c.config.Validate()
+			if c.problem == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, ErrMisconfiguration.Error())
+			assert.Contains(t, ErrorToRFC6749Error(err).Debug, c.problem)
+		})
+	}
+}
+
+func TestIssuerConfigPopulateMetadata(t *testing.T) {
+	config := &IssuerConfig{
+		Issuer:                "https://op.example.com",
+		AuthorizationEndpoint: "https://op.example.com/auth",
+		TokenEndpoint:         "https://op.example.com/token",
+		JSONWebKeysURI:        "https://op.example.com/jwks",
+	}
+
+	m := &Metadata{TokenEndpoint: "https://op.example.com/oauth2/token"}
+	config.PopulateMetadata(m)
+	assert.Equal(t, &Metadata{
+		Issuer:                "https://op.example.com",
+		AuthorizationEndpoint: "https://op.example.com/auth",
+		TokenEndpoint:         "https://op.example.com/oauth2/token",
+		JSONWebKeysURI:        "https://op.example.com/jwks",
+	}, m)
+
+	f := &Fosite{IssuerConfig: config}
+	err := f.CheckMetadata(m)
+	require.EqualError(t, err, ErrMisconfiguration.Error())
+	assert.Contains(t, ErrorToRFC6749Error(err).Debug, "token_endpoint \"https://op.example.com/oauth2/token\" does not match")
+
+	m = new(Metadata)
+	config.PopulateMetadata(m)
+	assert.NoError(t, f.CheckMetadata(m))
+}
+
+func TestIssuerConfigErrorURI(t *testing.T) {
+	config := &IssuerConfig{Issuer: "https://op.example.com"}
+	assert.Empty(t, config.ErrorURIFor("invalid_request"))
+
+	config.ErrorURI = "https://docs.example.com/errors?lang=en"
+	assert.Equal(t, "https://docs.example.com/errors?error=invalid_request&lang=en", config.ErrorURIFor("invalid_request"))
+
+	f := &Fosite{IssuerConfig: config}
+	write := func(err error) map[string]interface{} {
+		rec := httptest.NewRecorder()
+		f.WriteAccessError(rec, nil, err)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+	assert.Equal(t, "https://docs.example.com/errors?error=invalid_grant&lang=en", write(ErrInvalidGrant)["error_uri"])
+	assert.Equal(t, "https://other.example.com", write(ErrInvalidGrant.WithURI("https://other.example.com"))["error_uri"])
+}
+
+func TestComposeIssuerConfig(t *testing.T) {
+	secret := []byte("some-secret-thats-random-some-secret-thats-random-")
+	config := &IssuerConfig{Issuer: "https://op.example.com", TokenEndpoint: "https://op.example.com/token"}
+
+	f := compose.ComposeAllEnabled(&compose.Config{IssuerConfig: config}, storage.NewMemoryStore(), secret, nil).(*Fosite)
+	assert.Equal(t, "https://op.example.com/token", f.TokenURL)
+	assert.Equal(t, config, f.IssuerConfig)
+
+	f = compose.ComposeAllEnabled(&compose.Config{IssuerConfig: config, TokenURL: "https://op.example.com/oauth2/token"}, storage.NewMemoryStore(), secret, nil).(*Fosite)
+	assert.Equal(t, "https://op.example.com/oauth2/token", f.TokenURL)
+
+	assert.Panics(t, func() {
+		compose.ComposeAllEnabled(&compose.Config{IssuerConfig: &IssuerConfig{Issuer: "http://op.example.com"}}, storage.NewMemoryStore(), secret, nil)
+	})
+	assert.NoError(t, (&compose.Config{IssuerConfig: config}).Validate())
+	assert.EqualError(t, (&compose.Config{IssuerConfig: &IssuerConfig{Issuer: "http://op.example.com"}}).Validate(), ErrMisconfiguration.Error())
+	assert.EqualError(t, (&compose.Config{AuthorizationResponseIssParameter: true}).Validate(), ErrMisconfiguration.Error())
+
+	strategy := compose.NewOAuth2JWTStrategyWithConfig(&compose.Config{IssuerConfig: config, Clock: ClockFunc(time.Now), ClockSkew: time.Minute}, internal.MustRSAKey(), nil)
+	assert.Equal(t, "https://op.example.com", strategy.Issuer)
+	assert.NotNil(t, strategy.Clock)
+	assert.Equal(t, time.Minute, strategy.ClockSkew)
+
+	oidc := compose.NewOpenIDConnectStrategyWithConfig(&compose.Config{
+		IssuerConfig:            config,
+		IDTokenLifespan:         time.Hour,
+		GrantTypeTokenLifespans: map[string]map[TokenType]time.Duration{"refresh_token": {IDToken: time.Minute, AccessToken: time.Second}},
+	}, internal.MustRSAKey())
+	assert.Equal(t, "https://op.example.com", oidc.Issuer)
+	assert.Equal(t, time.Hour, oidc.Expiry)
+	assert.Equal(t, map[string]time.Duration{"refresh_token": time.Minute}, oidc.GrantTypeExpiry)
+
+	f = compose.ComposeAllEnabled(&compose.Config{IssuerConfig: config, AuthorizationResponseIssParameter: true}, storage.NewMemoryStore(), secret, nil).(*Fosite)
+	assert.True(t, f.AuthorizationResponseIssParameter)
+	assert.PanicsWithValue(t, "compose: AuthorizationResponseIssParameter requires IssuerConfig.", func() {
+		compose.ComposeAllEnabled(&compose.Config{AuthorizationResponseIssParameter: true}, storage.NewMemoryStore(), secret, nil)
+	}, "the iss parameter requires an issuer")
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuerSet(t *testing.T) {
+	var observed []string
+	s := &IssuerSet{
+		Issuer:         "https://new.example.com",
+		LegacyIssuers:  []string{"https://old.example.com"},
+		OnLegacyIssuer: func(iss string) { observed = append(observed, iss) },
+	}
+
+	assert.True(t, s.Accepts("https://new.example.com"))
+	assert.True(t, s.Accepts("https://old.example.com"))
+	assert.True(t, s.Accepts("https://old.example.com"))
+	assert.False(t, s.Accepts("https://evil.example.com"))
+	assert.False(t, s.Accepts(""))
+
+	assert.Equal(t, map[string]uint64{"https://old.example.com": 2}, s.LegacyUsage())
+	assert.Equal(t, []string{"https://old.example.com", "https://old.example.com"}, observed)
+
+	assert.Equal(t, "https://new.example.com", s.Migrate(""))
+	assert.Equal(t, "https://new.example.com", s.Migrate("https://old.example.com"))
+	assert.Equal(t, "https://other.example.com", s.Migrate("https://other.example.com"))
+}
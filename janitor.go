@@ -0,0 +1,85 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultJanitorInterval is the default time between two flushes of a Janitor.
+const DefaultJanitorInterval = time.Hour
+
+// TokenFlusher is implemented by storages which can delete token sessions that can no longer be used.
+type TokenFlusher interface {
+	// FlushInactiveTokens deletes the authorize code, PKCE, OpenID Connect, access token and refresh token sessions
+	// which expired before the given time, together with any data kept only for them, for example used refresh
+	// tokens. Sessions without an expiry time for their token type never expire and must be kept.
+	FlushInactiveTokens(ctx context.Context, before time.Time) (err error)
+}
+
+// Janitor periodically flushes inactive tokens from a TokenFlusher, so that long-lived deployments do not accumulate
+// expired authorize codes and token sessions.
+type Janitor struct {
+	// Storage is the storage whose inactive tokens are flushed.
+	Storage TokenFlusher
+
+	// Interval is the time between two flushes. Defaults to DefaultJanitorInterval.
+	Interval time.Duration
+
+	// Retention is how long tokens are kept after they expired, for example to answer introspection requests for
+	// recently expired tokens or to detect the reuse of rotated refresh tokens.
+	Retention time.Duration
+
+	// Clock provides the current time. Defaults to SystemClock.
+	Clock Clock
+
+	// OnError, if set, is called with the errors of failed flushes. Run keeps running after a failed flush.
+	OnError func(err error)
+}
+
+// Flush deletes the tokens which expired more than Retention ago.
+func (j *Janitor) Flush(ctx context.Context) error {
+	return j.Storage.FlushInactiveTokens(ctx, Now(j.Clock).Add(-j.Retention))
+}
+
+// Run flushes inactive tokens right away and then every Interval until ctx is done, which is the error it returns.
+func (j *Janitor) Run(ctx context.Context) error {
+	interval := j.Interval
+	if interval == 0 {
+		interval = DefaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := j.Flush(ctx); err != nil && j.OnError != nil {
+			j.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
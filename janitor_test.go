@@ -0,0 +1,106 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestJanitor(t *testing.T) {
+	now := time.Now().UTC()
+	newRequest := func(id string, tokenType TokenType, expiresAt time.Time) *Request {
+		r := NewRequest()
+		r.ID = id
+		r.Session = &DefaultSession{ExpiresAt: map[TokenType]time.Time{tokenType: expiresAt}}
+		return r
+	}
+
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	require.NoError(t, store.CreateAuthorizeCodeSession(ctx, "expired-code", newRequest("1", AuthorizeCode, now.Add(-time.Hour))))
+	require.NoError(t, store.CreateAuthorizeCodeSession(ctx, "active-code", newRequest("2", AuthorizeCode, now.Add(time.Hour))))
+	require.NoError(t, store.CreateAccessTokenSession(ctx, "expired-at", newRequest("3", AccessToken, now.Add(-time.Hour))))
+	require.NoError(t, store.CreateAccessTokenSession(ctx, "recent-at", newRequest("4", AccessToken, now.Add(-time.Second))))
+	require.NoError(t, store.CreateRefreshTokenSession(ctx, "expired-rt", newRequest("5", RefreshToken, now.Add(-time.Hour))))
+	require.NoError(t, store.CreateRefreshTokenSession(ctx, "eternal-rt", newRequest("6", RefreshToken, time.Time{})))
+
+	j := &Janitor{Storage: store, Retention: time.Minute, Clock: ClockFunc(func() time.Time { return now })}
+	require.NoError(t, j.Flush(ctx))
+
+	_, err := store.GetAuthorizeCodeSession(ctx, "expired-code", nil)
+	assert.EqualError(t, err, ErrNotFound.Error())
+	_, err = store.GetAuthorizeCodeSession(ctx, "active-code", nil)
+	assert.NoError(t, err)
+	_, err = store.GetAccessTokenSession(ctx, "expired-at", nil)
+	assert.EqualError(t, err, ErrNotFound.Error())
+	assert.NotContains(t, store.AccessTokenRequestIDs, "3")
+	_, err = store.GetAccessTokenSession(ctx, "recent-at", nil)
+	assert.NoError(t, err, "tokens are retained for the retention period")
+	_, err = store.GetRefreshTokenSession(ctx, "expired-rt", nil)
+	assert.EqualError(t, err, ErrNotFound.Error())
+	_, err = store.GetRefreshTokenSession(ctx, "eternal-rt", nil)
+	assert.NoError(t, err, "tokens without an expiry are never flushed")
+}
+
+type failingTokenFlusher struct {
+	flushes chan time.Time
+}
+
+func (f *failingTokenFlusher) FlushInactiveTokens(_ context.Context, before time.Time) error {
+	select {
+	case f.flushes <- before:
+	default:
+	}
+	return errors.New("storage unavailable")
+}
+
+func TestJanitor_Run(t *testing.T) {
+	flusher := &failingTokenFlusher{flushes: make(chan time.Time, 10)}
+	errs := make(chan error, 10)
+	j := &Janitor{Storage: flusher, Interval: time.Millisecond, OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- j.Run(ctx) }()
+
+	// The janitor keeps flushing after failed flushes.
+	<-flusher.flushes
+	<-flusher.flushes
+	assert.EqualError(t, <-errs, "storage unavailable")
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// DefaultClientAssertionMaxLifetime is the default of Fosite.ClientAssertionMaxLifetime.
+const DefaultClientAssertionMaxLifetime = time.Hour
+
+// JTIStore remembers the JWT IDs ("jti") of client assertions used for private_key_jwt client authentication, so
+// that each assertion can only be used once, see https://tools.ietf.org/html/rfc7523#section-3.
+type JTIStore interface {
+	// UseJTI marks the jti of a client assertion of the client as used until expiresAt. If the jti has already been
+	// used and has not yet expired, it must return ErrJTIKnown. Checking and marking the jti must be atomic.
+	UseJTI(ctx context.Context, clientID, jti string, expiresAt time.Time) (err error)
+}
+
+// useClientAssertionJTI rejects client assertions whose jti has already been used. Assertions must carry an "exp"
+// claim within ClientAssertionMaxLifetime, as the jti is only remembered until the assertion expires.
+func (f *Fosite) useClientAssertionJTI(ctx context.Context, clientID, jti string, claims jwt.MapClaims) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.WithStack(ErrInvalidClient.WithHint("Claim \"exp\" from \"client_assertion\" must be set but is not."))
+	}
+
+	expiresAt := time.Unix(int64(exp), 0).Add(f.ClockSkew)
+	if maxLifetime := f.clientAssertionMaxLifetime(); expiresAt.After(Now(f.Clock).Add(maxLifetime + f.ClockSkew)) {
+		return errors.WithStack(ErrInvalidClient.WithHintf("Claim \"exp\" from \"client_assertion\" must not be more than %s in the future.", maxLifetime))
+	}
+
+	if err := f.JTIStore.UseJTI(ctx, clientID, jti, expiresAt); errors.Cause(err) == ErrJTIKnown {
+		return errors.WithStack(ErrInvalidClient.WithHint("Claim \"jti\" from \"client_assertion\" has already been used."))
+	} else if err != nil {
+		return errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	}
+	return nil
+}
+
+func (f *Fosite) clientAssertionMaxLifetime() time.Duration {
+	if f.ClientAssertionMaxLifetime == 0 {
+		return DefaultClientAssertionMaxLifetime
+	}
+	return f.ClientAssertionMaxLifetime
+}
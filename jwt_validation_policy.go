@@ -0,0 +1,132 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ory/go-convenience/stringslice"
+	"github.com/pkg/errors"
+)
+
+// JWTValidationPolicy restricts the JSON Web Tokens accepted for one use case, such as request objects or client
+// assertions, so that a token issued for another purpose, for example an ID token or a JWT access token, can not be
+// passed off as one of them.
+type JWTValidationPolicy struct {
+	// Types are the accepted values of the "typ" header. They are compared case-insensitively and without the
+	// "application/" prefix, see https://tools.ietf.org/html/rfc7515#section-4.1.9. The empty string accepts tokens
+	// without "typ" header.
+	Types []string
+
+	// Algorithms, if set, are the accepted signing algorithms. Otherwise all algorithms supported by the use case are
+	// accepted.
+	Algorithms []string
+}
+
+const (
+	// JWTTypeAccessToken is the "typ" header of the JWT access tokens issued by this library, see
+	// https://tools.ietf.org/html/rfc9068#section-2.1
+	JWTTypeAccessToken = "at+jwt"
+
+	// JWTTypeIDToken is the "typ" header of the ID tokens issued by this library. OpenID Connect does not define one,
+	// but an explicit type tells ID tokens apart from request objects and assertions, which are often typed "JWT".
+	JWTTypeIDToken = "id_token+jwt"
+
+	// JWTTypeLogoutToken is the "typ" header of back-channel logout tokens, see
+	// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+	JWTTypeLogoutToken = "logout+jwt"
+)
+
+// DefaultRequestObjectPolicy returns the policy of request objects typed as such, see
+// https://tools.ietf.org/html/rfc9101#section-10.8, or untyped, signed with an asymmetric algorithm or unsigned.
+func DefaultRequestObjectPolicy() *JWTValidationPolicy {
+	return &JWTValidationPolicy{Types: []string{"", "JWT", "oauth-authz-req+jwt"}, Algorithms: append(asymmetricSigningAlgorithms(), "none")}
+}
+
+// DefaultClientAssertionPolicy returns the policy of client assertions typed as such or untyped, signed with an
+// asymmetric algorithm.
+func DefaultClientAssertionPolicy() *JWTValidationPolicy {
+	return &JWTValidationPolicy{Types: []string{"", "JWT", "client-authentication+jwt"}, Algorithms: asymmetricSigningAlgorithms()}
+}
+
+// DefaultJWTBearerGrantPolicy returns the policy of untyped authorization grant assertions, see
+// https://tools.ietf.org/html/rfc7523#section-3, signed with an asymmetric algorithm.
+func DefaultJWTBearerGrantPolicy() *JWTValidationPolicy {
+	return &JWTValidationPolicy{Types: []string{"", "JWT"}, Algorithms: asymmetricSigningAlgorithms()}
+}
+
+func asymmetricSigningAlgorithms() []string {
+	return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+}
+
+// CheckHeader returns an error if the "typ" or the "alg" header of the token is not accepted.
+func (p *JWTValidationPolicy) CheckHeader(header map[string]interface{}) error {
+	typ, _ := header["typ"].(string)
+	if !p.acceptsType(typ) {
+		return errors.Errorf("The token type \"%s\" is not accepted.", typ)
+	}
+
+	if alg, _ := header["alg"].(string); len(p.Algorithms) > 0 && !stringslice.Has(p.Algorithms, alg) {
+		return errors.Errorf("The signing algorithm \"%s\" is not accepted.", alg)
+	}
+	return nil
+}
+
+func (p *JWTValidationPolicy) acceptsType(typ string) bool {
+	typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+	for _, accepted := range p.Types {
+		if strings.ToLower(accepted) == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fosite) requestObjectPolicy() *JWTValidationPolicy {
+	if f.RequestObjectPolicy == nil {
+		return DefaultRequestObjectPolicy()
+	}
+	return f.RequestObjectPolicy
+}
+
+func (f *Fosite) clientAssertionPolicy() *JWTValidationPolicy {
+	if f.ClientAssertionPolicy == nil {
+		return DefaultClientAssertionPolicy()
+	}
+	return f.ClientAssertionPolicy
+}
+
+// jwtAudienceContains returns true if the "aud" claim is audience or a list containing it.
+func jwtAudienceContains(claims jwt.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
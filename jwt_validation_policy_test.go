@@ -0,0 +1,67 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+func TestJWTValidationPolicy(t *testing.T) {
+	for k, c := range []struct {
+		policy *JWTValidationPolicy
+		header map[string]interface{}
+		pass   bool
+	}{
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "RS256"}, pass: true},
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "JWT"}, pass: true},
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "application/oauth-authz-req+jwt"}, pass: true},
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "at+jwt"}},
+		{policy: DefaultClientAssertionPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "client-authentication+JWT"}, pass: true},
+		{policy: DefaultClientAssertionPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "oauth-authz-req+jwt"}},
+		{policy: DefaultJWTBearerGrantPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": "logout+jwt"}},
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": JWTTypeIDToken}},
+		{policy: DefaultClientAssertionPolicy(), header: map[string]interface{}{"alg": "RS256", "typ": JWTTypeAccessToken}},
+		{policy: DefaultRequestObjectPolicy(), header: map[string]interface{}{"alg": "none"}, pass: true},
+		{policy: DefaultClientAssertionPolicy(), header: map[string]interface{}{"alg": "none"}},
+		{policy: DefaultJWTBearerGrantPolicy(), header: map[string]interface{}{"alg": "HS256"}},
+		{policy: &JWTValidationPolicy{Types: []string{""}, Algorithms: []string{"PS256", "ES256"}}, header: map[string]interface{}{"alg": "ES256"}, pass: true},
+		{policy: &JWTValidationPolicy{Types: []string{""}, Algorithms: []string{"PS256", "ES256"}}, header: map[string]interface{}{"alg": "RS256"}},
+		{policy: &JWTValidationPolicy{Types: []string{"JWT"}}, header: map[string]interface{}{"alg": "RS256"}},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := c.policy.CheckHeader(c.header)
+			if c.pass {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	DefaultRequestObjectPolicy().Types[0] = "at+jwt"
+	assert.Equal(t, "", DefaultRequestObjectPolicy().Types[0], "the defaults can not be changed")
+}
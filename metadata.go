@@ -0,0 +1,167 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Metadata is the part of the authorization server metadata, see https://tools.ietf.org/html/rfc8414#section-2 and
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata, which depends on the handlers
+// registered with Fosite.
+type Metadata struct {
+	Issuer                 string   `json:"issuer,omitempty"`
+	AuthorizationEndpoint  string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint          string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint       string   `json:"userinfo_endpoint,omitempty"`
+	JSONWebKeysURI         string   `json:"jwks_uri,omitempty"`
+	IntrospectionEndpoint  string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint     string   `json:"revocation_endpoint,omitempty"`
+	ResponseTypesSupported []string `json:"response_types_supported"`
+	ResponseModesSupported []string `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported    []string `json:"grant_types_supported,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+
+	// AuthorizationResponseIssParameterSupported advertises the "iss" parameter of authorize responses, see
+	// https://tools.ietf.org/html/rfc9207#section-3
+	AuthorizationResponseIssParameterSupported bool `json:"authorization_response_iss_parameter_supported,omitempty"`
+}
+
+// CheckMetadata cross-checks the metadata a server advertises, for example at /.well-known/openid-configuration,
+// against the registered handlers. It is meant to be called on start up and returns ErrMisconfiguration listing every
+// inconsistency found. Callers that prefer to warn rather than to fail can log the error instead.
+//
+// Handlers which implement neither ResponseTypesHandler nor GrantTypesHandler are assumed to support anything, as
+// there is no way to tell what they handle. If Fosite.ScopeDescriptions is set, every advertised scope must be
+// described in all of its languages. If Fosite.IssuerConfig is set, the advertised issuer and endpoints must match it.
+func (f *Fosite) CheckMetadata(m *Metadata) error {
+	return f.CheckMetadataWithContext(context.Background(), m)
+}
+
+// CheckMetadataWithContext checks the metadata like CheckMetadata. If ctx carries a tenant with an issuer, see
+// ContextWithTenant, m is the metadata of the tenant and the advertised issuer must match the one of the tenant.
+func (f *Fosite) CheckMetadataWithContext(ctx context.Context, m *Metadata) error {
+	var problems []string
+
+	if m.TokenEndpoint != "" && f.TokenURL != "" && m.TokenEndpoint != f.TokenURL {
+		problems = append(problems, fmt.Sprintf("token_endpoint \"%s\" does not match the configured token URL \"%s\"", m.TokenEndpoint, f.TokenURL))
+	}
+	if tenant := TenantFromContext(ctx); tenant != nil && tenant.Issuer != "" {
+		if m.Issuer != "" && m.Issuer != tenant.Issuer {
+			problems = append(problems, fmt.Sprintf("issuer \"%s\" does not match the issuer \"%s\" of the tenant", m.Issuer, tenant.Issuer))
+		}
+	} else if f.IssuerConfig != nil {
+		problems = f.IssuerConfig.checkMetadata(m, problems)
+	}
+	if m.IntrospectionEndpoint != "" && len(f.TokenIntrospectionHandlers) == 0 {
+		problems = append(problems, "introspection_endpoint is advertised but no token introspection handler is registered")
+	}
+	if m.AuthorizationResponseIssParameterSupported && f.authorizationResponseIssuer(ctx) == "" {
+		problems = append(problems, "authorization_response_iss_parameter_supported is advertised but the iss parameter is not enabled")
+	}
+	if m.RevocationEndpoint != "" && len(f.RevocationHandlers) == 0 {
+		problems = append(problems, "revocation_endpoint is advertised but no revocation handler is registered")
+	}
+
+	responseTypes, responseTypesKnown := f.supportedResponseTypes()
+	if responseTypesKnown {
+		for _, advertised := range m.ResponseTypesSupported {
+			if !containsResponseType(responseTypes, advertised) {
+				problems = append(problems, fmt.Sprintf("response type \"%s\" is advertised but no authorize endpoint handler supports it", advertised))
+			}
+		}
+	}
+
+	for _, advertised := range m.ResponseModesSupported {
+		switch advertised {
+		case ResponseModeQuery, ResponseModeFragment, ResponseModeFormPost:
+		default:
+			problems = append(problems, fmt.Sprintf("response mode \"%s\" is advertised but can not be written", advertised))
+		}
+	}
+
+	grantTypes, grantTypesKnown := f.supportedGrantTypes()
+	for _, advertised := range m.GrantTypesSupported {
+		if advertised == "implicit" {
+			// The implicit grant is handled by the authorize endpoint, see https://tools.ietf.org/html/rfc6749#section-4.2
+			if responseTypesKnown && !containsResponseType(responseTypes, "token") && !containsResponseType(responseTypes, "id_token") {
+				problems = append(problems, "grant type \"implicit\" is advertised but no authorize endpoint handler supports it")
+			}
+		} else if grantTypesKnown && !StringInSlice(advertised, grantTypes) {
+			problems = append(problems, fmt.Sprintf("grant type \"%s\" is advertised but no token endpoint handler supports it", advertised))
+		}
+	}
+
+	if f.ScopeDescriptions != nil {
+		problems = append(problems, f.ScopeDescriptions.Missing(m.ScopesSupported)...)
+	}
+
+	if len(problems) > 0 {
+		return errors.WithStack(ErrMisconfiguration.WithHint("The advertised metadata does not match the server configuration.").WithDebug(strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+func (f *Fosite) supportedResponseTypes() ([]string, bool) {
+	var supported []string
+	for _, h := range f.AuthorizeEndpointHandlers {
+		rth, ok := h.(ResponseTypesHandler)
+		if !ok {
+			return nil, false
+		}
+		for _, responseType := range rth.SupportedResponseTypes() {
+			if !StringInSlice(responseType, supported) {
+				supported = append(supported, responseType)
+			}
+		}
+	}
+	return supported, true
+}
+
+func (f *Fosite) supportedGrantTypes() ([]string, bool) {
+	var supported []string
+	for _, h := range f.TokenEndpointHandlers {
+		gth, ok := h.(GrantTypesHandler)
+		if !ok {
+			return nil, false
+		}
+		for _, grantType := range gth.SupportedGrantTypes() {
+			if !StringInSlice(grantType, supported) {
+				supported = append(supported, grantType)
+			}
+		}
+	}
+	return supported, true
+}
+
+func containsResponseType(supported []string, responseType string) bool {
+	for _, s := range supported {
+		if SplitArguments(s).Matches(responseType) {
+			return true
+		}
+	}
+	return false
+}
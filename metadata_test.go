@@ -0,0 +1,78 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"testing"
+
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestCheckMetadata(t *testing.T) {
+	f := compose.ComposeAllEnabled(&compose.Config{TokenURL: "https://op/token"}, storage.NewMemoryStore(), []byte("some-secret-thats-random-some-secret-thats-random-"), nil).(*Fosite)
+
+	require.NoError(t, f.CheckMetadata(&Metadata{
+		TokenEndpoint:          "https://op/token",
+		IntrospectionEndpoint:  "https://op/introspect",
+		ResponseTypesSupported: []string{"code", "token", "id_token", "token id_token", "code id_token token"},
+		ResponseModesSupported: []string{"query", "fragment", "form_post"},
+		GrantTypesSupported:    []string{"authorization_code", "implicit", "refresh_token", "client_credentials", "password"},
+	}))
+
+	err := f.CheckMetadata(&Metadata{
+		TokenEndpoint:          "https://other/token",
+		ResponseTypesSupported: []string{"code", "none"},
+		ResponseModesSupported: []string{"query", "web_message"},
+		GrantTypesSupported:    []string{"authorization_code", "urn:ietf:params:oauth:grant-type:device_code"},
+	})
+	require.Error(t, err)
+	rfcerr := ErrorToRFC6749Error(err)
+	assert.Equal(t, ErrMisconfiguration.Name, rfcerr.Name)
+	assert.Contains(t, rfcerr.Debug, "token_endpoint")
+	assert.Contains(t, rfcerr.Debug, "\"none\"")
+	assert.Contains(t, rfcerr.Debug, "\"web_message\"")
+	assert.Contains(t, rfcerr.Debug, "device_code")
+	assert.NotContains(t, rfcerr.Debug, "\"code\"")
+
+	// ComposeAllEnabled does not register a revocation handler.
+	assert.Error(t, f.CheckMetadata(&Metadata{RevocationEndpoint: "https://op/revoke"}))
+
+	f.ScopeDescriptions = &ScopeDescriptions{
+		Languages: []string{"en", "de"},
+		Descriptions: map[string]map[string]string{
+			"openid": {"en": "Sign you in", "de": "Dich anmelden"},
+			"photos": {"en": "View your photos"},
+		},
+	}
+	assert.NoError(t, f.CheckMetadata(&Metadata{ScopesSupported: []string{"openid"}}))
+	err = f.CheckMetadata(&Metadata{ScopesSupported: []string{"openid", "photos", "offline"}})
+	require.Error(t, err)
+	rfcerr = ErrorToRFC6749Error(err)
+	assert.Contains(t, rfcerr.Debug, "scope \"photos\" has no description in language \"de\"")
+	assert.Contains(t, rfcerr.Debug, "scope \"offline\" has no description in language \"en\"")
+	assert.NotContains(t, rfcerr.Debug, "\"openid\"")
+}
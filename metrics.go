@@ -0,0 +1,143 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The metrics reported to the MetricsCollector. Durations are observed in seconds.
+const (
+	// MetricRequests counts authorize and access requests, labeled with "endpoint" ("authorize" or "token"), "type"
+	// (the response or grant types) and "error" (the error name, empty on success).
+	MetricRequests = "fosite_requests_total"
+
+	// MetricRequestDuration observes the duration of authorize and access requests, labeled like MetricRequests.
+	MetricRequestDuration = "fosite_request_duration_seconds"
+
+	// MetricHandlerDuration observes the duration of endpoint handler calls, labeled with "endpoint", "handler" (the
+	// handler type) and "error".
+	MetricHandlerDuration = "fosite_handler_duration_seconds"
+
+	// MetricStorageDuration observes the duration of client lookups, labeled with "operation" ("get_client") and
+	// "error". The token, code and session storage calls are made by the handlers and are only included in
+	// MetricHandlerDuration.
+	MetricStorageDuration = "fosite_storage_duration_seconds"
+)
+
+// MetricLabels are the labels of a metric.
+type MetricLabels map[string]string
+
+// MetricsCollector receives metrics from fosite, for example to export them to Prometheus, see
+// PrometheusMetricsCollector. Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	// IncCounter increments the counter identified by name and labels by one.
+	IncCounter(name string, labels MetricLabels)
+
+	// ObserveHistogram adds value to the histogram identified by name and labels.
+	ObserveHistogram(name string, labels MetricLabels, value float64)
+
+	// SetGauge sets the gauge identified by name and labels to value. Fosite does not report gauges itself, but
+	// extensions keeping state, such as the number of active device codes, may.
+	SetGauge(name string, labels MetricLabels, value float64)
+}
+
+// NoopMetricsCollector discards all metrics. It is used if Fosite.Metrics is not set.
+type NoopMetricsCollector struct{}
+
+func (NoopMetricsCollector) IncCounter(string, MetricLabels)                {}
+func (NoopMetricsCollector) ObserveHistogram(string, MetricLabels, float64) {}
+func (NoopMetricsCollector) SetGauge(string, MetricLabels, float64)         {}
+
+func (f *Fosite) metrics() MetricsCollector {
+	if f.Metrics == nil {
+		return NoopMetricsCollector{}
+	}
+	return f.Metrics
+}
+
+// metricTypes are the response and grant types reported as they are. Others are reported as "other" because the
+// label values must not be controlled by clients.
+var metricTypes = map[string]bool{
+	"code": true, "token": true, "id_token": true, "none": true,
+	"authorization_code": true, "refresh_token": true, "client_credentials": true, "password": true,
+	"implicit": true, "urn:ietf:params:oauth:grant-type:jwt-bearer": true,
+}
+
+func (f *Fosite) observeRequest(endpoint string, types Arguments, start time.Time, err error) {
+	typeLabel := strings.Join(types, " ")
+	for _, t := range types {
+		if !metricTypes[t] {
+			typeLabel = "other"
+			break
+		}
+	}
+
+	labels := MetricLabels{"endpoint": endpoint, "type": typeLabel, "error": metricErrorLabel(err)}
+	f.metrics().IncCounter(MetricRequests, labels)
+	f.metrics().ObserveHistogram(MetricRequestDuration, labels, time.Since(start).Seconds())
+}
+
+func (f *Fosite) observeHandler(endpoint, handlerType string, start time.Time, err error) {
+	f.metrics().ObserveHistogram(MetricHandlerDuration, MetricLabels{
+		"endpoint": endpoint,
+		"handler":  handlerType,
+		"error":    metricErrorLabel(err),
+	}, time.Since(start).Seconds())
+}
+
+// getClient loads a client from the store, or from the clients of the tenant of ctx if the store supports tenants,
+// tracing the call and reporting its duration.
+func (f *Fosite) getClient(ctx context.Context, id string) (Client, error) {
+	start := time.Now()
+	ctx, span := f.startSpan(ctx, "fosite.Store.GetClient")
+	var client Client
+	var err error
+	var manager ClientManager = f.Store
+	if f.ClientManager != nil {
+		manager = f.ClientManager
+	}
+	if tenants, ok := manager.(TenantClientManager); ok && TenantFromContext(ctx) != nil {
+		client, err = tenants.GetTenantClient(ctx, TenantFromContext(ctx).ID, id)
+	} else {
+		client, err = manager.GetClient(ctx, id)
+	}
+	span.End(err)
+	f.metrics().ObserveHistogram(MetricStorageDuration, MetricLabels{
+		"operation": "get_client",
+		"error":     metricErrorLabel(err),
+	}, time.Since(start).Seconds())
+	return client, err
+}
+
+// metricErrorLabel returns the name of err, or an empty string if err is nil. Handlers declining a request with
+// ErrUnknownRequest did not fail, so that error is not reported either.
+func metricErrorLabel(err error) string {
+	if err == nil || errors.Cause(err).Error() == ErrUnknownRequest.Error() {
+		return ""
+	}
+	return ErrorToRFC6749Error(err).Name
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPrometheusBuckets are the histogram buckets used by PrometheusMetricsCollector if none are set. They match
+// the default buckets of the Prometheus client libraries.
+var DefaultPrometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetricsCollector is a MetricsCollector which keeps the metrics in memory and serves them in the
+// Prometheus text exposition format, see https://prometheus.io/docs/instrumenting/exposition_formats/. Mount it as
+// the handler of your metrics endpoint, for example:
+//
+//	collector := new(fosite.PrometheusMetricsCollector)
+//	oauth2Provider.(*fosite.Fosite).Metrics = collector
+//	http.Handle("/metrics", collector)
+type PrometheusMetricsCollector struct {
+	// Buckets are the upper bounds of the histogram buckets. Defaults to DefaultPrometheusBuckets.
+	Buckets []float64
+
+	sync.Mutex
+	counters   map[string]map[string]float64
+	gauges     map[string]map[string]float64
+	histograms map[string]map[string]*prometheusHistogram
+}
+
+type prometheusHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (p *PrometheusMetricsCollector) IncCounter(name string, labels MetricLabels) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.counters == nil {
+		p.counters = map[string]map[string]float64{}
+	}
+	if p.counters[name] == nil {
+		p.counters[name] = map[string]float64{}
+	}
+	p.counters[name][formatPrometheusLabels(labels)]++
+}
+
+func (p *PrometheusMetricsCollector) SetGauge(name string, labels MetricLabels, value float64) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.gauges == nil {
+		p.gauges = map[string]map[string]float64{}
+	}
+	if p.gauges[name] == nil {
+		p.gauges[name] = map[string]float64{}
+	}
+	p.gauges[name][formatPrometheusLabels(labels)] = value
+}
+
+func (p *PrometheusMetricsCollector) ObserveHistogram(name string, labels MetricLabels, value float64) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.histograms == nil {
+		p.histograms = map[string]map[string]*prometheusHistogram{}
+	}
+	if p.histograms[name] == nil {
+		p.histograms[name] = map[string]*prometheusHistogram{}
+	}
+
+	key := formatPrometheusLabels(labels)
+	h, ok := p.histograms[name][key]
+	if !ok {
+		h = &prometheusHistogram{counts: make([]uint64, len(p.buckets()))}
+		p.histograms[name][key] = h
+	}
+
+	for i, bound := range p.buckets() {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format.
+func (p *PrometheusMetricsCollector) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	p.Lock()
+	p.write(&buf)
+	p.Unlock()
+
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buf.WriteTo(rw)
+}
+
+func (p *PrometheusMetricsCollector) write(buf *bytes.Buffer) {
+	writeSamples := func(kind string, metrics map[string]map[string]float64) {
+		for _, name := range sortedKeys(metrics) {
+			fmt.Fprintf(buf, "# TYPE %s %s\n", name, kind)
+			for _, labels := range sortedKeys(metrics[name]) {
+				fmt.Fprintf(buf, "%s%s %s\n", name, labels, formatPrometheusValue(metrics[name][labels]))
+			}
+		}
+	}
+	writeSamples("counter", p.counters)
+	writeSamples("gauge", p.gauges)
+
+	histogramNames := make([]string, 0, len(p.histograms))
+	for name := range p.histograms {
+		histogramNames = append(histogramNames, name)
+	}
+	sort.Strings(histogramNames)
+
+	for _, name := range histogramNames {
+		fmt.Fprintf(buf, "# TYPE %s histogram\n", name)
+
+		keys := make([]string, 0, len(p.histograms[name]))
+		for key := range p.histograms[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			h := p.histograms[name][key]
+			for i, bound := range p.buckets() {
+				fmt.Fprintf(buf, "%s_bucket%s %d\n", name, withPrometheusLabel(key, "le", formatPrometheusValue(bound)), h.counts[i])
+			}
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", name, withPrometheusLabel(key, "le", "+Inf"), h.count)
+			fmt.Fprintf(buf, "%s_sum%s %s\n", name, key, formatPrometheusValue(h.sum))
+			fmt.Fprintf(buf, "%s_count%s %d\n", name, key, h.count)
+		}
+	}
+}
+
+func (p *PrometheusMetricsCollector) buckets() []float64 {
+	if len(p.Buckets) == 0 {
+		return DefaultPrometheusBuckets
+	}
+	return p.Buckets
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]map[string]float64:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]float64:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatPrometheusLabels returns the labels as `{a="1",b="2"}`, sorted by name, or an empty string if there are none.
+func formatPrometheusLabels(labels MetricLabels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapePrometheusLabelValue(labels[name]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withPrometheusLabel appends a label to labels formatted by formatPrometheusLabels.
+func withPrometheusLabel(labels, name, value string) string {
+	pair := name + `="` + escapePrometheusLabelValue(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func escapePrometheusLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatPrometheusValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrapeMetrics(t *testing.T, collector *PrometheusMetricsCollector) string {
+	rw := httptest.NewRecorder()
+	collector.ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rw.Header().Get("Content-Type"))
+	body, err := ioutil.ReadAll(rw.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestPrometheusMetricsCollector(t *testing.T) {
+	collector := &PrometheusMetricsCollector{Buckets: []float64{0.1, 1}}
+	collector.IncCounter("requests_total", MetricLabels{"b": "2", "a": "\"1\""})
+	collector.IncCounter("requests_total", MetricLabels{"a": "\"1\"", "b": "2"})
+	collector.SetGauge("active", nil, 3)
+	collector.ObserveHistogram("duration_seconds", MetricLabels{"a": "1"}, 0.05)
+	collector.ObserveHistogram("duration_seconds", MetricLabels{"a": "1"}, 0.5)
+
+	assert.Equal(t, `# TYPE requests_total counter
+requests_total{a="\"1\"",b="2"} 2
+# TYPE active gauge
+active 3
+# TYPE duration_seconds histogram
+duration_seconds_bucket{a="1",le="0.1"} 1
+duration_seconds_bucket{a="1",le="1"} 2
+duration_seconds_bucket{a="1",le="+Inf"} 2
+duration_seconds_sum{a="1"} 0.55
+duration_seconds_count{a="1"} 2
+`, scrapeMetrics(t, collector))
+}
+
+func TestMetrics(t *testing.T) {
+	collector := new(PrometheusMetricsCollector)
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+	f := &Fosite{
+		Store:                 store,
+		TokenEndpointHandlers: TokenEndpointHandlers{acceptingTokenEndpointHandler{}},
+		Metrics:               collector,
+	}
+
+	for _, grantType := range []string{"client_credentials", "foo-bar"} {
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {grantType}, "client_id": {"foo"}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		_, err := f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+		require.NoError(t, err)
+	}
+
+	r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}, "client_id": {"unknown"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+	require.Error(t, err)
+
+	metrics := scrapeMetrics(t, collector)
+	assert.Contains(t, metrics, `fosite_requests_total{endpoint="token",error="",type="client_credentials"} 1`)
+	assert.Contains(t, metrics, `fosite_requests_total{endpoint="token",error="",type="other"} 1`)
+	assert.Contains(t, metrics, `fosite_requests_total{endpoint="token",error="invalid_client",type="client_credentials"} 1`)
+	assert.Contains(t, metrics, `fosite_handler_duration_seconds_count{endpoint="token",error="",handler="fosite_test.acceptingTokenEndpointHandler"} 2`)
+	assert.Contains(t, metrics, `fosite_storage_duration_seconds_count{error="",operation="get_client"} 2`)
+	assert.Contains(t, metrics, `fosite_storage_duration_seconds_count{error="not_found",operation="get_client"} 1`)
+}
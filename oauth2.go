@@ -40,6 +40,15 @@ const (
 	IDToken       TokenType = "id_token"
 )
 
+// TokenLineageEntry records a token issued for a request and the signature of the token it was exchanged for, for
+// example the authorize code of an access token or the previous refresh token of a rotated refresh token.
+type TokenLineageEntry struct {
+	TokenType       TokenType
+	Signature       string
+	ParentSignature string
+	CreatedAt       time.Time
+}
+
 // OAuth2Provider is an interface that enables you to write OAuth2 handlers with only a few lines of code.
 // Check fosite.Fosite for an implementation of this interface.
 type OAuth2Provider interface {
@@ -61,6 +70,10 @@ type OAuth2Provider interface {
 	// * https://tools.ietf.org/html/rfc6749#section-3.1.2.2 (everything MUST be implemented)
 	NewAuthorizeRequest(ctx context.Context, req *http.Request) (AuthorizeRequester, error)
 
+	// ResumeAuthorizeRequest restores an authorize request which was handed over to a login or consent application,
+	// for example using SignedConsentChallenge. The request is validated again as if NewAuthorizeRequest was called.
+	ResumeAuthorizeRequest(ctx context.Context, state *AuthorizeRequestState) (AuthorizeRequester, error)
+
 	// NewAuthorizeResponse iterates through all response type handlers and returns their result or
 	// ErrUnsupportedResponseType if none of the handler's were able to handle it.
 	//
@@ -138,6 +151,22 @@ type OAuth2Provider interface {
 	// https://tools.ietf.org/html/rfc7009#section-2.1
 	NewRevocationRequest(ctx context.Context, r *http.Request) error
 
+	// RevokeToken revokes a token programmatically, for example when the end-user logs out or an administrator
+	// revokes access. The reason is passed to the revocation storage through the context.
+	RevokeToken(ctx context.Context, token string, tokenTypeHint TokenType, client Client, reason RevocationReason) error
+
+	// RevokeClientTokens revokes all access and refresh tokens issued to the client, for example when it was
+	// compromised. The storage must implement ClientRevocationStorage.
+	RevokeClientTokens(ctx context.Context, clientID string) error
+
+	// RevokeSubjectTokens revokes all authorize codes, access and refresh tokens issued on behalf of the subject, for
+	// example to log the end-user out everywhere. The storage must implement SubjectRevocationStorage.
+	RevokeSubjectTokens(ctx context.Context, subject string) error
+
+	// RevokeSubjectClientTokens revokes all authorize codes, access and refresh tokens issued to the client on behalf
+	// of the subject. The storage must implement SubjectRevocationStorage.
+	RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) error
+
 	// WriteRevocationResponse writes the revoke response.
 	//
 	// The following specs must be considered in any implementation of this method:
@@ -148,6 +177,14 @@ type OAuth2Provider interface {
 	// such as the authorization code, can not be introspected.
 	IntrospectToken(ctx context.Context, token string, tokenType TokenType, session Session, scope ...string) (TokenType, AccessRequester, error)
 
+	// ValidateToken validates the bearer token of a request made to a resource server and makes sure that all of the
+	// given scopes were granted. The token is read as defined in https://tools.ietf.org/html/rfc6750#section-2
+	ValidateToken(ctx context.Context, r *http.Request, session Session, scopes ...string) (AccessRequester, error)
+
+	// WriteBearerError responds with an error if ValidateToken failed, including the WWW-Authenticate challenge
+	// defined in https://tools.ietf.org/html/rfc6750#section-3
+	WriteBearerError(rw http.ResponseWriter, err error, scopes ...string)
+
 	// NewIntrospectionRequest initiates token introspection as defined in
 	// https://tools.ietf.org/search/rfc7662#section-2.1
 	NewIntrospectionRequest(ctx context.Context, r *http.Request, session Session) (IntrospectionResponder, error)
@@ -217,7 +254,8 @@ type Requester interface {
 	// Merge merges the argument into the method receiver.
 	Merge(requester Requester)
 
-	// Sanitize returns a sanitized clone of the request which can be used for storage.
+	// Sanitize returns a sanitized clone of the request which can be used for storage. Only the form parameters
+	// listed in allowedParameters are retained, including all of their values.
 	Sanitize(allowedParameters []string) Requester
 }
 
@@ -251,6 +289,35 @@ type AuthorizeRequester interface {
 	// GetState returns the request's state.
 	GetState() (state string)
 
+	// GetResponseMode returns the requested response mode, or ResponseModeDefault if the response mode is implied
+	// by the response types.
+	GetResponseMode() (responseMode string)
+
+	// GetPrompt returns the values of the OpenID Connect prompt parameter.
+	GetPrompt() (prompt Arguments)
+
+	// GetMaxAge returns the maximum authentication age requested with the OpenID Connect max_age parameter. isSet is
+	// false if the parameter was not given.
+	GetMaxAge() (maxAge time.Duration, isSet bool)
+
+	// GetLoginHint returns the value of the OpenID Connect login_hint parameter.
+	GetLoginHint() (loginHint string)
+
+	// GetIDTokenHint returns the value of the OpenID Connect id_token_hint parameter.
+	GetIDTokenHint() (idTokenHint string)
+
+	// GetACRValues returns the authentication context class references requested with the OpenID Connect acr_values
+	// parameter, in order of preference.
+	GetACRValues() (acrValues Arguments)
+
+	// GetDisplay returns the value of the OpenID Connect display parameter, which specifies how the authorization
+	// server displays the authentication and consent user interface pages.
+	GetDisplay() (display string)
+
+	// GetDeviceHint returns the value of the non-standard device_hint parameter, which describes the device the
+	// end-user interacts with, for example "tv".
+	GetDeviceHint() (deviceHint string)
+
 	Requester
 }
 
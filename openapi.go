@@ -0,0 +1,228 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+)
+
+// OpenAPISpec returns an OpenAPI 3 document, see https://swagger.io/specification/, which describes the endpoints
+// advertised in the metadata. Endpoints without a registered handler are left out, and the values of response_type
+// and grant_type are restricted to the ones the registered handlers declare, see ResponseTypesHandler and
+// GrantTypesHandler. The document can be encoded as JSON and served to API gateways or client generators.
+func (f *Fosite) OpenAPISpec(m *Metadata, title, version string) map[string]interface{} {
+	paths := map[string]interface{}{}
+
+	if m.AuthorizationEndpoint != "" && len(f.AuthorizeEndpointHandlers) > 0 {
+		responseType := map[string]interface{}{"type": "string"}
+		if responseTypes, ok := f.supportedResponseTypes(); ok {
+			responseType["enum"] = responseTypes
+		}
+		responseMode := map[string]interface{}{"type": "string", "enum": []string{ResponseModeQuery, ResponseModeFragment, ResponseModeFormPost}}
+
+		paths[openAPIPath(m.AuthorizationEndpoint)] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":     "OAuth 2.0 authorization endpoint, see https://tools.ietf.org/html/rfc6749#section-3.1",
+				"operationId": "authorize",
+				"parameters": []interface{}{
+					openAPIQueryParameter("response_type", true, responseType),
+					openAPIQueryParameter("client_id", true, nil),
+					openAPIQueryParameter("redirect_uri", false, nil),
+					openAPIQueryParameter("scope", false, nil),
+					openAPIQueryParameter("state", false, nil),
+					openAPIQueryParameter("response_mode", false, responseMode),
+					openAPIQueryParameter("nonce", false, nil),
+					openAPIQueryParameter("prompt", false, nil),
+					openAPIQueryParameter("max_age", false, map[string]interface{}{"type": "integer", "minimum": 0}),
+					openAPIQueryParameter("code_challenge", false, nil),
+					openAPIQueryParameter("code_challenge_method", false, nil),
+				},
+				"responses": map[string]interface{}{
+					"302":     map[string]interface{}{"description": "Redirects the user agent to the client's redirect URI."},
+					"200":     map[string]interface{}{"description": "Posts the response to the client's redirect URI if response_mode is form_post."},
+					"default": openAPIErrorResponse(),
+				},
+			},
+		}
+	}
+
+	if m.TokenEndpoint != "" && len(f.TokenEndpointHandlers) > 0 {
+		grantType := map[string]interface{}{"type": "string"}
+		if grantTypes, ok := f.supportedGrantTypes(); ok {
+			grantType["enum"] = grantTypes
+		}
+
+		paths[openAPIPath(m.TokenEndpoint)] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "OAuth 2.0 token endpoint, see https://tools.ietf.org/html/rfc6749#section-3.2",
+				"operationId": "token",
+				"security":    openAPIClientSecurity(),
+				"requestBody": openAPIFormBody([]string{"grant_type"}, map[string]interface{}{
+					"grant_type":    grantType,
+					"code":          map[string]interface{}{"type": "string"},
+					"redirect_uri":  map[string]interface{}{"type": "string"},
+					"refresh_token": map[string]interface{}{"type": "string"},
+					"username":      map[string]interface{}{"type": "string"},
+					"password":      map[string]interface{}{"type": "string"},
+					"scope":         map[string]interface{}{"type": "string"},
+					"code_verifier": map[string]interface{}{"type": "string"},
+					"client_id":     map[string]interface{}{"type": "string"},
+					"client_secret": map[string]interface{}{"type": "string"},
+				}),
+				"responses": map[string]interface{}{
+					"200":     openAPIJSONResponse("The access token response.", "#/components/schemas/TokenResponse"),
+					"default": openAPIErrorResponse(),
+				},
+			},
+		}
+	}
+
+	if m.IntrospectionEndpoint != "" && len(f.TokenIntrospectionHandlers) > 0 {
+		paths[openAPIPath(m.IntrospectionEndpoint)] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "OAuth 2.0 token introspection endpoint, see https://tools.ietf.org/html/rfc7662",
+				"operationId": "introspect",
+				"security":    []interface{}{map[string]interface{}{"basic": []string{}}, map[string]interface{}{"bearer": []string{}}},
+				"requestBody": openAPIFormBody([]string{"token"}, map[string]interface{}{
+					"token":           map[string]interface{}{"type": "string"},
+					"token_type_hint": map[string]interface{}{"type": "string"},
+					"scope":           map[string]interface{}{"type": "string"},
+				}),
+				"responses": map[string]interface{}{
+					"200":     openAPIJSONResponse("The introspection response.", "#/components/schemas/IntrospectionResponse"),
+					"default": openAPIErrorResponse(),
+				},
+			},
+		}
+	}
+
+	if m.RevocationEndpoint != "" && len(f.RevocationHandlers) > 0 {
+		paths[openAPIPath(m.RevocationEndpoint)] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     "OAuth 2.0 token revocation endpoint, see https://tools.ietf.org/html/rfc7009",
+				"operationId": "revoke",
+				"security":    openAPIClientSecurity(),
+				"requestBody": openAPIFormBody([]string{"token"}, map[string]interface{}{
+					"token":           map[string]interface{}{"type": "string"},
+					"token_type_hint": map[string]interface{}{"type": "string", "enum": []string{string(AccessToken), string(RefreshToken)}},
+					"reason":          map[string]interface{}{"type": "string"},
+				}),
+				"responses": map[string]interface{}{
+					"200":     map[string]interface{}{"description": "The token was revoked or was invalid."},
+					"default": openAPIErrorResponse(),
+				},
+			},
+		}
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"basic":  map[string]interface{}{"type": "http", "scheme": "basic"},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+			"schemas": map[string]interface{}{
+				"Error": openAPIObjectSchema([]string{"error"}, map[string]interface{}{
+					"error":             map[string]interface{}{"type": "string"},
+					"error_description": map[string]interface{}{"type": "string"},
+					"error_uri":         map[string]interface{}{"type": "string"},
+					"error_hint":        map[string]interface{}{"type": "string"},
+					"error_debug":       map[string]interface{}{"type": "string"},
+					"status_code":       map[string]interface{}{"type": "integer"},
+				}),
+				"TokenResponse": openAPIObjectSchema([]string{"access_token", "token_type"}, map[string]interface{}{
+					"access_token":  map[string]interface{}{"type": "string"},
+					"token_type":    map[string]interface{}{"type": "string"},
+					"expires_in":    map[string]interface{}{"type": "integer"},
+					"refresh_token": map[string]interface{}{"type": "string"},
+					"id_token":      map[string]interface{}{"type": "string"},
+					"scope":         map[string]interface{}{"type": "string"},
+				}),
+				"IntrospectionResponse": openAPIObjectSchema([]string{"active"}, map[string]interface{}{
+					"active":    map[string]interface{}{"type": "boolean"},
+					"client_id": map[string]interface{}{"type": "string"},
+					"scope":     map[string]interface{}{"type": "string"},
+					"exp":       map[string]interface{}{"type": "integer"},
+					"iat":       map[string]interface{}{"type": "integer"},
+					"sub":       map[string]interface{}{"type": "string"},
+					"username":  map[string]interface{}{"type": "string"},
+				}),
+			},
+		},
+	}
+
+	if m.Issuer != "" {
+		spec["servers"] = []interface{}{map[string]interface{}{"url": m.Issuer}}
+	}
+
+	return spec
+}
+
+// openAPIPath returns the path of an endpoint URL, so that the document's paths are relative to the server.
+func openAPIPath(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return endpoint
+}
+
+func openAPIQueryParameter(name string, required bool, schema map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		schema = map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{"name": name, "in": "query", "required": required, "schema": schema}
+}
+
+func openAPIObjectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "required": required, "properties": properties}
+}
+
+func openAPIFormBody(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/x-www-form-urlencoded": map[string]interface{}{"schema": openAPIObjectSchema(required, properties)},
+		},
+	}
+}
+
+func openAPIJSONResponse(description, ref string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": ref}},
+		},
+	}
+}
+
+func openAPIErrorResponse() map[string]interface{} {
+	return openAPIJSONResponse("An OAuth 2.0 error response.", "#/components/schemas/Error")
+}
+
+// openAPIClientSecurity allows client authentication using HTTP basic authentication or, as the client_id and
+// client_secret parameters in the request body are part of the form, no HTTP authentication at all.
+func openAPIClientSecurity() []interface{} {
+	return []interface{}{map[string]interface{}{"basic": []string{}}, map[string]interface{}{}}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	f := compose.ComposeAllEnabled(new(compose.Config), storage.NewMemoryStore(), []byte("some-secret-thats-random-some-secret-thats-random-"), nil).(*Fosite)
+
+	spec := f.OpenAPISpec(&Metadata{
+		Issuer:                "https://op.example.org",
+		AuthorizationEndpoint: "https://op.example.org/oauth2/auth",
+		TokenEndpoint:         "https://op.example.org/oauth2/token",
+		IntrospectionEndpoint: "https://op.example.org/oauth2/introspect",
+		RevocationEndpoint:    "https://op.example.org/oauth2/revoke",
+	}, "My Authorization Server", "1.0.0")
+
+	out, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string                            `json:"openapi"`
+		Servers []map[string]string               `json:"servers"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(out, &doc))
+
+	assert.Equal(t, "3.0.0", doc.OpenAPI)
+	assert.Equal(t, "https://op.example.org", doc.Servers[0]["url"])
+	assert.Contains(t, doc.Paths["/oauth2/auth"], "get")
+	assert.Contains(t, doc.Paths["/oauth2/token"], "post")
+	assert.Contains(t, doc.Paths["/oauth2/introspect"], "post")
+	// ComposeAllEnabled does not register a revocation handler.
+	assert.NotContains(t, doc.Paths, "/oauth2/revoke")
+
+	assert.Contains(t, string(out), `"enum":["authorization_code","client_credentials","refresh_token","password"]`)
+	assert.Contains(t, string(out), `"enum":["code","token","id_token","id_token token","code id_token","code token","code id_token token"]`)
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "context"
+
+type minParameterEntropyContextKey struct{}
+
+// ContextWithMinParameterEntropy returns a copy of ctx which carries the minimum number of characters of the state
+// and nonce parameters. Fosite passes Fosite.MinParameterEntropy on to its endpoint handlers this way, so that it is
+// configured in one place.
+func ContextWithMinParameterEntropy(ctx context.Context, minEntropy int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, minParameterEntropyContextKey{}, minEntropy)
+}
+
+// MinParameterEntropyFromContext returns the minimum number of characters of the state and nonce parameters carried
+// by ctx, or MinParameterEntropy.
+func MinParameterEntropyFromContext(ctx context.Context) int {
+	if ctx != nil {
+		if minEntropy, ok := ctx.Value(minParameterEntropyContextKey{}).(int); ok && minEntropy > 0 {
+			return minEntropy
+		}
+	}
+	return MinParameterEntropy
+}
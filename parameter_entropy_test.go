@@ -0,0 +1,59 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+type minParameterEntropyHandler struct {
+	minEntropy int
+}
+
+func (h *minParameterEntropyHandler) HandleAuthorizeEndpointRequest(ctx context.Context, _ AuthorizeRequester, _ AuthorizeResponder) error {
+	h.minEntropy = MinParameterEntropyFromContext(ctx)
+	return nil
+}
+
+func TestMinParameterEntropyFromContext(t *testing.T) {
+	assert.Equal(t, MinParameterEntropy, MinParameterEntropyFromContext(nil))
+	assert.Equal(t, MinParameterEntropy, MinParameterEntropyFromContext(context.Background()))
+	assert.Equal(t, 4, MinParameterEntropyFromContext(ContextWithMinParameterEntropy(nil, 4)))
+
+	for _, c := range []struct {
+		configured int
+		expected   int
+	}{
+		{configured: 0, expected: MinParameterEntropy},
+		{configured: 16, expected: 16},
+	} {
+		h := new(minParameterEntropyHandler)
+		f := &Fosite{AuthorizeEndpointHandlers: AuthorizeEndpointHandlers{h}, MinParameterEntropy: c.configured}
+		_, _ = f.NewAuthorizeResponse(context.Background(), NewAuthorizeRequest(), new(DefaultSession))
+		assert.Equal(t, c.expected, h.minEntropy)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PendingAuthorizationStatus is the status of a pending authorization.
+type PendingAuthorizationStatus string
+
+const (
+	PendingAuthorizationPending  PendingAuthorizationStatus = "pending"
+	PendingAuthorizationApproved PendingAuthorizationStatus = "approved"
+	PendingAuthorizationDenied   PendingAuthorizationStatus = "denied"
+
+	// PendingAuthorizationUsed is the status of an approved authorization which has been resumed. It can not be
+	// resumed again.
+	PendingAuthorizationUsed PendingAuthorizationStatus = "used"
+)
+
+// ErrPendingAuthorizationChanged is returned by PendingAuthorizationStorage.UpdatePendingAuthorization if the status
+// of the stored authorization is not the expected one, for example because it was decided concurrently.
+var ErrPendingAuthorizationChanged = errors.New("The status of the pending authorization has changed")
+
+// PendingAuthorization is an authorize request which was parked until another party, for example an administrator
+// who has to approve an elevated scope, approves or denies it out of band.
+type PendingAuthorization struct {
+	ID        string                     `json:"id"`
+	State     *AuthorizeRequestState     `json:"state"`
+	Status    PendingAuthorizationStatus `json:"status"`
+	ExpiresAt time.Time                  `json:"expires_at"`
+
+	// DecidedBy identifies the party which approved or denied the authorization.
+	DecidedBy string `json:"decided_by,omitempty"`
+
+	// ApprovedScopes are the scopes granted by the approving party. They are granted when the request is resumed.
+	ApprovedScopes Arguments `json:"approved_scopes,omitempty"`
+}
+
+// PendingAuthorizationStorage persists pending authorizations. It is implemented by the Storage passed to Fosite.
+type PendingAuthorizationStorage interface {
+	CreatePendingAuthorization(ctx context.Context, p *PendingAuthorization) error
+	GetPendingAuthorization(ctx context.Context, id string) (*PendingAuthorization, error)
+
+	// UpdatePendingAuthorization stores p if the stored authorization still has the status from, and returns
+	// ErrPendingAuthorizationChanged otherwise. The check and the update must be atomic, so that an authorization
+	// is decided and resumed only once.
+	UpdatePendingAuthorization(ctx context.Context, p *PendingAuthorization, from PendingAuthorizationStatus) error
+}
+
+// PendingAuthorizationNotifier is notified when an authorize request is parked, so that the party expected to decide
+// on it can be informed, for example by e-mail.
+type PendingAuthorizationNotifier interface {
+	NotifyPendingAuthorization(ctx context.Context, p *PendingAuthorization) error
+}
+
+func (f *Fosite) pendingAuthorizationStorage() (PendingAuthorizationStorage, error) {
+	store, ok := f.Store.(PendingAuthorizationStorage)
+	if !ok {
+		return nil, errors.WithStack(ErrServerError.WithDebug("The storage does not implement PendingAuthorizationStorage."))
+	}
+	return store, nil
+}
+
+// ParkAuthorizeRequest parks the authorize request until it is approved or denied out of band, or until lifespan has
+// passed. The PendingAuthorizationNotifier, if set, is notified. The end-user should be told that the request awaits
+// approval and the client can later be redirected to resume it using ResumePendingAuthorization.
+func (f *Fosite) ParkAuthorizeRequest(ctx context.Context, ar AuthorizeRequester, lifespan time.Duration) (*PendingAuthorization, error) {
+	store, err := f.pendingAuthorizationStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PendingAuthorization{
+		ID: ar.GetID(),
+		State: &AuthorizeRequestState{
+			ID:          ar.GetID(),
+			RequestedAt: ar.GetRequestedAt(),
+			Form:        ar.GetRequestForm(),
+		},
+		Status:    PendingAuthorizationPending,
+		ExpiresAt: Now(f.Clock).Add(lifespan),
+	}
+
+	if err := store.CreatePendingAuthorization(ctx, p); err != nil {
+		return nil, errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	}
+
+	if f.PendingAuthorizationNotifier != nil {
+		if err := f.PendingAuthorizationNotifier.NotifyPendingAuthorization(ctx, p); err != nil {
+			return nil, errors.WithStack(ErrServerError.WithDebug(err.Error()))
+		}
+	}
+
+	return p, nil
+}
+
+// ApprovePendingAuthorization approves a pending authorization on behalf of approver and grants the given scopes,
+// which must have been requested by the authorize request.
+func (f *Fosite) ApprovePendingAuthorization(ctx context.Context, id string, approver string, scopes ...string) error {
+	return f.decidePendingAuthorization(ctx, id, approver, PendingAuthorizationApproved, scopes)
+}
+
+// DenyPendingAuthorization denies a pending authorization on behalf of approver.
+func (f *Fosite) DenyPendingAuthorization(ctx context.Context, id string, approver string) error {
+	return f.decidePendingAuthorization(ctx, id, approver, PendingAuthorizationDenied, nil)
+}
+
+func (f *Fosite) decidePendingAuthorization(ctx context.Context, id, approver string, status PendingAuthorizationStatus, scopes []string) error {
+	store, p, err := f.getPendingAuthorization(ctx, id)
+	if err != nil {
+		return err
+	} else if p.Status != PendingAuthorizationPending {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The authorization has already been %s.", p.Status))
+	}
+
+	requested := SplitArguments(p.State.Form.Get("scope"))
+	for _, scope := range scopes {
+		if !requested.Has(scope) {
+			return errors.WithStack(ErrInvalidScope.WithHintf("The scope \"%s\" was not requested by the authorization.", scope))
+		}
+	}
+
+	p.Status = status
+	p.DecidedBy = approver
+	p.ApprovedScopes = scopes
+	if err := store.UpdatePendingAuthorization(ctx, p, PendingAuthorizationPending); errors.Cause(err) == ErrPendingAuthorizationChanged {
+		return errors.WithStack(ErrInvalidRequest.WithHint("The authorization has already been decided."))
+	} else if err != nil {
+		return errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	}
+	return nil
+}
+
+// ResumePendingAuthorization resumes an approved authorize request using ResumeAuthorizeRequest and grants the
+// approved scopes. An approved authorization can be resumed only once. It returns ErrAccessDenied if the
+// authorization was denied, has expired or was already resumed, and ErrInteractionRequired if no decision has been
+// made yet.
+func (f *Fosite) ResumePendingAuthorization(ctx context.Context, id string) (AuthorizeRequester, error) {
+	store, p, err := f.getPendingAuthorization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Status {
+	case PendingAuthorizationApproved:
+	case PendingAuthorizationDenied:
+		return nil, errors.WithStack(ErrAccessDenied.WithHint("The authorization has been denied."))
+	case PendingAuthorizationUsed:
+		return nil, errors.WithStack(ErrAccessDenied.WithHint("The authorization has already been resumed."))
+	default:
+		return nil, errors.WithStack(ErrInteractionRequired.WithHint("The authorization is still awaiting approval."))
+	}
+
+	p.Status = PendingAuthorizationUsed
+	if err := store.UpdatePendingAuthorization(ctx, p, PendingAuthorizationApproved); errors.Cause(err) == ErrPendingAuthorizationChanged {
+		return nil, errors.WithStack(ErrAccessDenied.WithHint("The authorization has already been resumed."))
+	} else if err != nil {
+		return nil, errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	}
+
+	ar, err := f.ResumeAuthorizeRequest(ctx, p.State)
+	if err != nil {
+		return ar, err
+	}
+
+	for _, scope := range p.ApprovedScopes {
+		ar.GrantScope(scope)
+	}
+	return ar, nil
+}
+
+func (f *Fosite) getPendingAuthorization(ctx context.Context, id string) (PendingAuthorizationStorage, *PendingAuthorization, error) {
+	store, err := f.pendingAuthorizationStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := store.GetPendingAuthorization(ctx, id)
+	if errors.Cause(err) == ErrNotFound {
+		return nil, nil, errors.WithStack(ErrInvalidRequest.WithHint("The authorization does not exist."))
+	} else if err != nil {
+		return nil, nil, errors.WithStack(ErrServerError.WithDebug(err.Error()))
+	} else if p.ExpiresAt.Before(Now(f.Clock)) {
+		return nil, nil, errors.WithStack(ErrAccessDenied.WithHint("The authorization has expired."))
+	}
+	return store, p, nil
+}
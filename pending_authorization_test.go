@@ -0,0 +1,122 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pendingAuthorizationNotifier []*PendingAuthorization
+
+func (n *pendingAuthorizationNotifier) NotifyPendingAuthorization(_ context.Context, p *PendingAuthorization) error {
+	*n = append(*n, p)
+	return nil
+}
+
+func TestPendingAuthorization(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["1234"] = &DefaultClient{ID: "1234", RedirectURIs: []string{"https://foo.bar/cb"}, ResponseTypes: []string{"code"}, Scopes: []string{"foo", "admin"}}
+
+	notifier := new(pendingAuthorizationNotifier)
+	f := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, PendingAuthorizationNotifier: notifier}
+
+	park := func(lifespan time.Duration) *PendingAuthorization {
+		ar := NewAuthorizeRequest()
+		ar.Form = url.Values{
+			"redirect_uri":  {"https://foo.bar/cb"},
+			"client_id":     {"1234"},
+			"response_type": {"code"},
+			"state":         {"strong-state"},
+			"scope":         {"foo admin"},
+		}
+
+		p, err := f.ParkAuthorizeRequest(nil, ar, lifespan)
+		require.NoError(t, err)
+		assert.Equal(t, PendingAuthorizationPending, p.Status)
+		return p
+	}
+
+	t.Run("case=approved", func(t *testing.T) {
+		p := park(time.Hour)
+		assert.Equal(t, p, (*notifier)[len(*notifier)-1])
+
+		_, err := f.ResumePendingAuthorization(nil, p.ID)
+		assert.EqualError(t, errors.Cause(err), ErrInteractionRequired.Error())
+
+		require.NoError(t, f.ApprovePendingAuthorization(nil, p.ID, "admin@example.org", "admin"))
+		assert.EqualError(t, errors.Cause(f.DenyPendingAuthorization(nil, p.ID, "admin@example.org")), ErrInvalidRequest.Error())
+
+		ar, err := f.ResumePendingAuthorization(nil, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.ID, ar.GetID())
+		assert.Equal(t, Arguments{"admin"}, ar.GetGrantedScopes())
+		assert.Equal(t, "admin@example.org", store.PendingAuthorizations[p.ID].DecidedBy)
+
+		// An approved authorization is consumed when it is resumed.
+		_, err = f.ResumePendingAuthorization(nil, p.ID)
+		assert.EqualError(t, errors.Cause(err), ErrAccessDenied.Error())
+	})
+
+	t.Run("case=scope not requested", func(t *testing.T) {
+		p := park(time.Hour)
+		assert.EqualError(t, errors.Cause(f.ApprovePendingAuthorization(nil, p.ID, "admin@example.org", "superuser")), ErrInvalidScope.Error())
+		assert.Equal(t, PendingAuthorizationPending, store.PendingAuthorizations[p.ID].Status)
+	})
+
+	t.Run("case=decided concurrently", func(t *testing.T) {
+		p := park(time.Hour)
+		stale := *store.PendingAuthorizations[p.ID].State
+		require.NoError(t, f.DenyPendingAuthorization(nil, p.ID, "admin@example.org"))
+
+		// An update based on the pending authorization as it was read before the decision is rejected.
+		err := store.UpdatePendingAuthorization(nil, &PendingAuthorization{ID: p.ID, State: &stale, Status: PendingAuthorizationApproved}, PendingAuthorizationPending)
+		assert.EqualError(t, err, ErrPendingAuthorizationChanged.Error())
+		assert.Equal(t, PendingAuthorizationDenied, store.PendingAuthorizations[p.ID].Status)
+	})
+
+	t.Run("case=denied", func(t *testing.T) {
+		p := park(time.Hour)
+		require.NoError(t, f.DenyPendingAuthorization(nil, p.ID, "admin@example.org"))
+
+		_, err := f.ResumePendingAuthorization(nil, p.ID)
+		assert.EqualError(t, errors.Cause(err), ErrAccessDenied.Error())
+	})
+
+	t.Run("case=expired", func(t *testing.T) {
+		p := park(-time.Minute)
+		assert.EqualError(t, errors.Cause(f.ApprovePendingAuthorization(nil, p.ID, "admin@example.org")), ErrAccessDenied.Error())
+	})
+
+	t.Run("case=unknown", func(t *testing.T) {
+		_, err := f.ResumePendingAuthorization(nil, "unknown")
+		assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+	})
+}
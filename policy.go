@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// PolicyStage identifies the endpoint at which a PolicyEngine is consulted.
+type PolicyStage string
+
+const (
+	PolicyStageAuthorize PolicyStage = "authorize"
+	PolicyStageToken     PolicyStage = "token"
+)
+
+// PolicyEngine is an external authorization policy, for example Open Policy Agent or Cedar, which centralizes scope
+// and grant decisions. At the authorize endpoint it is consulted by NewAuthorizeRequest once fosite validated the
+// request, which is before the end-user consents and the response handlers run, so no scopes are granted yet. At the
+// token endpoint it is consulted by NewAccessRequest after the registered handlers validated the grant.
+type PolicyEngine interface {
+	// Evaluate returns an error if the request must be rejected. Errors which are not of type *RFC6749Error are
+	// treated as server errors.
+	Evaluate(ctx context.Context, input *PolicyInput) error
+}
+
+// PolicyInput is the request context passed to the PolicyEngine.
+type PolicyInput struct {
+	Stage           PolicyStage `json:"stage"`
+	ClientID        string      `json:"client_id"`
+	GrantTypes      Arguments   `json:"grant_types,omitempty"`
+	ResponseTypes   Arguments   `json:"response_types,omitempty"`
+	RequestedScopes Arguments   `json:"requested_scopes"`
+	GrantedScopes   Arguments   `json:"granted_scopes"`
+	Display         string      `json:"display,omitempty"`
+	DeviceHint      string      `json:"device_hint,omitempty"`
+
+	// Form contains the request parameters with credentials redacted, see RedactForm.
+	Form url.Values `json:"form"`
+
+	// Requester is the request being evaluated. It is not passed on to external policy services.
+	Requester Requester `json:"-"`
+}
+
+// NewPolicyInput assembles the PolicyInput for the given request.
+func NewPolicyInput(stage PolicyStage, requester Requester) *PolicyInput {
+	input := &PolicyInput{
+		Stage:           stage,
+		RequestedScopes: requester.GetRequestedScopes(),
+		GrantedScopes:   requester.GetGrantedScopes(),
+		Form:            RedactForm(requester.GetRequestForm()),
+		Requester:       requester,
+	}
+
+	if c := requester.GetClient(); c != nil {
+		input.ClientID = c.GetID()
+	}
+	if ar, ok := requester.(AccessRequester); ok {
+		input.GrantTypes = ar.GetGrantTypes()
+	}
+	if ar, ok := requester.(AuthorizeRequester); ok {
+		input.ResponseTypes = ar.GetResponseTypes()
+		input.Display = ar.GetDisplay()
+		input.DeviceHint = ar.GetDeviceHint()
+	}
+
+	return input
+}
+
+func (f *Fosite) evaluatePolicy(ctx context.Context, stage PolicyStage, requester Requester) error {
+	if f.PolicyEngine == nil {
+		return nil
+	}
+
+	if err := f.PolicyEngine.Evaluate(ctx, NewPolicyInput(stage, requester)); err != nil {
+		if _, ok := errors.Cause(err).(*RFC6749Error); ok {
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(ErrServerError.WithHint("Unable to evaluate the authorization policy.").WithDebug(err.Error()))
+	}
+	return nil
+}
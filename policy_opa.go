@@ -0,0 +1,94 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// OPAPolicyEngine is a PolicyEngine which queries the Data API of an Open Policy Agent server, see
+// https://www.openpolicyagent.org/docs/latest/rest-api/#get-a-document-with-input. The PolicyInput is sent as the
+// "input" document. The request is allowed if the decision is either true or an object whose "allow" field is true,
+// every other decision (including an undefined one) denies it.
+type OPAPolicyEngine struct {
+	// URL is the URL of the decision document, for example "http://localhost:8181/v1/data/fosite/allow".
+	URL string
+
+	// HTTPClient is used to query Open Policy Agent. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func (o *OPAPolicyEngine) Evaluate(ctx context.Context, input *PolicyInput) error {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest("POST", o.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	hc := o.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("expected status code 200 from policy engine but got %d", res.StatusCode)
+	}
+
+	var decision struct {
+		Result interface{} `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&decision); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var allowed bool
+	switch result := decision.Result.(type) {
+	case bool:
+		allowed = result
+	case map[string]interface{}:
+		allowed, _ = result["allow"].(bool)
+	}
+
+	if !allowed {
+		return errors.WithStack(ErrAccessDenied.WithHint("The request was denied by the authorization policy."))
+	}
+	return nil
+}
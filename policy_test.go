@@ -0,0 +1,126 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policyEngineFunc func(ctx context.Context, input *PolicyInput) error
+
+func (p policyEngineFunc) Evaluate(ctx context.Context, input *PolicyInput) error {
+	return p(ctx, input)
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	ar := NewAccessRequest(new(DefaultSession))
+	ar.Client = &DefaultClient{ID: "foo"}
+	ar.GrantTypes = Arguments{"client_credentials"}
+	ar.Scopes = Arguments{"foo", "bar"}
+	ar.GrantScope("foo")
+	ar.Form = url.Values{"client_secret": {"bar"}, "scope": {"foo bar"}}
+
+	var input *PolicyInput
+	f := &Fosite{PolicyEngine: policyEngineFunc(func(_ context.Context, i *PolicyInput) error {
+		input = i
+		return nil
+	})}
+
+	require.NoError(t, f.evaluatePolicy(nil, PolicyStageToken, ar))
+	assert.Equal(t, PolicyStageToken, input.Stage)
+	assert.Equal(t, "foo", input.ClientID)
+	assert.Equal(t, Arguments{"client_credentials"}, input.GrantTypes)
+	assert.Equal(t, Arguments{"foo", "bar"}, input.RequestedScopes)
+	assert.Equal(t, Arguments{"foo"}, input.GrantedScopes)
+	assert.Equal(t, "[redacted]", input.Form.Get("client_secret"))
+
+	f.PolicyEngine = policyEngineFunc(func(_ context.Context, _ *PolicyInput) error {
+		return errors.WithStack(ErrInvalidScope)
+	})
+	assert.EqualError(t, errors.Cause(f.evaluatePolicy(nil, PolicyStageToken, ar)), ErrInvalidScope.Error())
+
+	f.PolicyEngine = policyEngineFunc(func(_ context.Context, _ *PolicyInput) error {
+		return errors.New("connection refused")
+	})
+	assert.EqualError(t, errors.Cause(f.evaluatePolicy(nil, PolicyStageToken, ar)), ErrServerError.Error())
+
+	f.PolicyEngine = nil
+	assert.NoError(t, f.evaluatePolicy(nil, PolicyStageToken, ar))
+}
+
+func TestOPAPolicyEngine(t *testing.T) {
+	var decision string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input PolicyInput `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "foo", body.Input.ClientID)
+		assert.Equal(t, PolicyStageAuthorize, body.Input.Stage)
+
+		if decision == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(decision))
+	}))
+	defer ts.Close()
+
+	ar := NewAuthorizeRequest()
+	ar.Client = &DefaultClient{ID: "foo"}
+	input := NewPolicyInput(PolicyStageAuthorize, ar)
+	engine := &OPAPolicyEngine{URL: ts.URL}
+
+	for k, c := range []struct {
+		decision  string
+		expectErr error
+	}{
+		{decision: `{"result": true}`},
+		{decision: `{"result": {"allow": true}}`},
+		{decision: `{"result": false}`, expectErr: ErrAccessDenied},
+		{decision: `{"result": {"allow": false}}`, expectErr: ErrAccessDenied},
+		{decision: `{}`, expectErr: ErrAccessDenied},
+	} {
+		decision = c.decision
+		err := engine.Evaluate(nil, input)
+		if c.expectErr != nil {
+			assert.EqualError(t, errors.Cause(err), c.expectErr.Error(), "%d", k)
+		} else {
+			assert.NoError(t, err, "%d", k)
+		}
+	}
+
+	decision = ""
+	err := engine.Evaluate(nil, input)
+	require.Error(t, err)
+	_, ok := errors.Cause(err).(*RFC6749Error)
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultForbiddenRedirectURISchemes are the redirect URI schemes a RedirectURIPolicy rejects unless its
+// ForbiddenSchemes are set. User agents execute or open them locally instead of navigating to the client.
+var DefaultForbiddenRedirectURISchemes = []string{"javascript", "data", "file", "vbscript"}
+
+// RedirectURIPolicy restricts the redirect URIs of authorize requests beyond IsValidRedirectURI and the redirect URIs
+// registered by the client.
+type RedirectURIPolicy struct {
+	// AllowedSchemes, if set, are the only schemes redirect URIs may use, for example "https" and the private-use
+	// schemes of native apps.
+	AllowedSchemes []string
+
+	// ForbiddenSchemes are the schemes redirect URIs must not use. Defaults to DefaultForbiddenRedirectURISchemes.
+	ForbiddenSchemes []string
+
+	// RequireHTTPSForConfidentialClients rejects redirect URIs of confidential clients which do not use https.
+	RequireHTTPSForConfidentialClients bool
+}
+
+// redirectURIPolicy returns RedirectURIPolicy or, if it is not set, a policy which only rejects the
+// DefaultForbiddenRedirectURISchemes.
+func (f *Fosite) redirectURIPolicy() *RedirectURIPolicy {
+	if f.RedirectURIPolicy == nil {
+		return &RedirectURIPolicy{}
+	}
+	return f.RedirectURIPolicy
+}
+
+func (p *RedirectURIPolicy) forbiddenSchemes() []string {
+	if p.ForbiddenSchemes == nil {
+		return DefaultForbiddenRedirectURISchemes
+	}
+	return p.ForbiddenSchemes
+}
+
+// Validate returns ErrInvalidRequest if the policy does not allow the client to use redirectURI.
+func (p *RedirectURIPolicy) Validate(redirectURI *url.URL, client Client) error {
+	if containsScheme(p.forbiddenSchemes(), redirectURI.Scheme) {
+		return errors.WithStack(ErrInvalidRequest.WithHintf(`Redirect URIs with the scheme "%s" are not allowed.`, redirectURI.Scheme))
+	} else if len(p.AllowedSchemes) > 0 && !containsScheme(p.AllowedSchemes, redirectURI.Scheme) {
+		return errors.WithStack(ErrInvalidRequest.WithHintf(`Redirect URIs with the scheme "%s" are not allowed.`, redirectURI.Scheme))
+	} else if p.RequireHTTPSForConfidentialClients && !client.IsPublic() && !strings.EqualFold(redirectURI.Scheme, "https") {
+		return errors.WithStack(ErrInvalidRequest.WithHint(`The redirect URIs of confidential OAuth 2.0 Clients must use the scheme "https".`))
+	}
+	return nil
+}
+
+func containsScheme(schemes []string, scheme string) bool {
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
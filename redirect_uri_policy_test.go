@@ -0,0 +1,99 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+	. "github.com/ory/fosite/internal"
+)
+
+func TestRedirectURIPolicy(t *testing.T) {
+	public := &DefaultClient{Public: true}
+	confidential := &DefaultClient{}
+
+	for k, c := range []struct {
+		policy *RedirectURIPolicy
+		uri    string
+		client Client
+		pass   bool
+	}{
+		{policy: &RedirectURIPolicy{}, uri: "https://foo.bar/cb", client: confidential, pass: true},
+		{policy: &RedirectURIPolicy{}, uri: "com.example.app:/cb", client: public, pass: true},
+		{policy: &RedirectURIPolicy{}, uri: "javascript:alert(1)", client: public},
+		{policy: &RedirectURIPolicy{}, uri: "JavaScript:alert(1)", client: public},
+		{policy: &RedirectURIPolicy{}, uri: "data:text/html,foo", client: public},
+		{policy: &RedirectURIPolicy{}, uri: "file:///etc/passwd", client: public},
+		{policy: &RedirectURIPolicy{ForbiddenSchemes: []string{}}, uri: "file:///tmp/cb", client: public, pass: true},
+		{policy: &RedirectURIPolicy{AllowedSchemes: []string{"https"}}, uri: "com.example.app:/cb", client: public},
+		{policy: &RedirectURIPolicy{AllowedSchemes: []string{"https", "com.example.app"}}, uri: "com.example.app:/cb", client: public, pass: true},
+		{policy: &RedirectURIPolicy{RequireHTTPSForConfidentialClients: true}, uri: "http://foo.bar/cb", client: confidential},
+		{policy: &RedirectURIPolicy{RequireHTTPSForConfidentialClients: true}, uri: "http://localhost/cb", client: public, pass: true},
+		{policy: &RedirectURIPolicy{RequireHTTPSForConfidentialClients: true}, uri: "https://foo.bar/cb", client: confidential, pass: true},
+	} {
+		u, err := url.Parse(c.uri)
+		assert.NoError(t, err)
+
+		err = c.policy.Validate(u, c.client)
+		if c.pass {
+			assert.NoError(t, err, "%d", k)
+		} else {
+			assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error(), "%d", k)
+		}
+	}
+}
+
+func TestNewAuthorizeRequest_DefaultRedirectURIPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{Store: store}
+	for k, c := range []struct {
+		uri  string
+		pass bool
+	}{
+		{uri: "https://foo.bar/cb", pass: true},
+		{uri: "javascript:alert(1)"},
+		{uri: "data:text/html,foo"},
+	} {
+		store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{ResponseTypes: []string{"code"}, RedirectURIs: []string{c.uri}}, nil)
+		_, err := f.NewAuthorizeRequestFromValues(context.Background(), url.Values{
+			"redirect_uri":  {c.uri},
+			"client_id":     {"1234"},
+			"response_type": {"code"},
+			"state":         {"strong-state"},
+		}, nil)
+		if c.pass {
+			assert.NoError(t, err, "%d", k)
+		} else {
+			assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error(), "%d", k)
+		}
+	}
+}
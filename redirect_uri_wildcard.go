@@ -0,0 +1,123 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ClientWithWildcardRedirectURIs is implemented by clients which opt in to wildcard redirect URIs, for example
+// "https://*.example.com/cb" for per-tenant subdomain callbacks.
+//
+// WARNING: Wildcard redirect URIs weaken one of the most important protections of OAuth 2.0. Everyone who controls
+// any subdomain matching the pattern, for example through a subdomain takeover or a user-content domain, can
+// receive authorization codes and tokens issued to the client. Only use them if every matching subdomain is under
+// your control, and prefer exact redirect URIs wherever possible.
+//
+// Patterns are validated by ValidateWildcardRedirectURI and matched by MatchWildcardRedirectURI.
+type ClientWithWildcardRedirectURIs interface {
+	// GetWildcardRedirectURIs returns the wildcard redirect URI patterns of the client.
+	GetWildcardRedirectURIs() []string
+}
+
+// DefaultClientWithWildcardRedirectURIs is a DefaultClient which opts in to wildcard redirect URIs. Read the warning
+// of ClientWithWildcardRedirectURIs before using it.
+type DefaultClientWithWildcardRedirectURIs struct {
+	*DefaultClient
+	WildcardRedirectURIs []string `json:"wildcard_redirect_uris"`
+}
+
+func (c *DefaultClientWithWildcardRedirectURIs) GetWildcardRedirectURIs() []string {
+	return c.WildcardRedirectURIs
+}
+
+var hostLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// ValidateWildcardRedirectURI checks that pattern is an acceptable wildcard redirect URI:
+//
+// * The scheme must be https.
+// * The wildcard "*" must be the leftmost label of the host and appear nowhere else.
+// * At least two labels must follow the wildcard, so "https://*.com/cb" is rejected.
+// * User information and fragments are not allowed.
+func ValidateWildcardRedirectURI(pattern string) error {
+	u, err := url.Parse(pattern)
+	if err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" is malformed.", pattern).WithDebug(err.Error()))
+	}
+
+	if u.Scheme != "https" {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" must use the https scheme.", pattern))
+	} else if u.User != nil || u.Fragment != "" {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" must not contain user information or a fragment.", pattern))
+	}
+
+	labels := strings.Split(u.Hostname(), ".")
+	if labels[0] != "*" || strings.Count(pattern, "*") != 1 {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" may only use a wildcard as the leftmost label of the host.", pattern))
+	} else if len(labels) < 3 {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" must have at least two host labels after the wildcard.", pattern))
+	}
+
+	for _, label := range labels[1:] {
+		if !hostLabel.MatchString(label) {
+			return errors.WithStack(ErrInvalidRequest.WithHintf("Wildcard redirect URI \"%s\" has an invalid host.", pattern))
+		}
+	}
+
+	return nil
+}
+
+// MatchWildcardRedirectURI returns true if rawurl matches the wildcard redirect URI pattern. The wildcard matches
+// exactly one host label, so "https://*.example.com/cb" matches "https://tenant.example.com/cb" but neither
+// "https://example.com/cb" nor "https://a.tenant.example.com/cb". Everything but the wildcard label, including the
+// port, path and query, must match exactly. Patterns rejected by ValidateWildcardRedirectURI never match.
+func MatchWildcardRedirectURI(pattern, rawurl string) bool {
+	if ValidateWildcardRedirectURI(pattern) != nil {
+		return false
+	}
+
+	expected, err := url.Parse(pattern)
+	if err != nil {
+		return false
+	}
+	actual, err := url.Parse(rawurl)
+	if err != nil {
+		return false
+	}
+
+	if actual.Scheme != "https" || actual.User != nil || actual.Fragment != "" || actual.Opaque != "" {
+		return false
+	} else if actual.Port() != expected.Port() || actual.EscapedPath() != expected.EscapedPath() || actual.RawQuery != expected.RawQuery {
+		return false
+	}
+
+	label := strings.SplitN(actual.Hostname(), ".", 2)
+	if len(label) != 2 || !hostLabel.MatchString(label[0]) {
+		return false
+	}
+
+	return strings.EqualFold(label[1], strings.TrimPrefix(expected.Hostname(), "*."))
+}
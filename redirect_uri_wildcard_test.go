@@ -0,0 +1,102 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWildcardRedirectURI(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		valid   bool
+	}{
+		{pattern: "https://*.example.com/cb", valid: true},
+		{pattern: "https://*.example.com:8443/cb?foo=bar", valid: true},
+		{pattern: "http://*.example.com/cb"},
+		{pattern: "https://*.com/cb"},
+		{pattern: "https://tenant.*.example.com/cb"},
+		{pattern: "https://foo*.example.com/cb"},
+		{pattern: "https://*.example.com/*"},
+		{pattern: "https://*.*.example.com/cb"},
+		{pattern: "https://user@*.example.com/cb"},
+		{pattern: "https://*.example.com/cb#foo"},
+		{pattern: "https://example.com/cb"},
+	} {
+		if c.valid {
+			assert.NoError(t, ValidateWildcardRedirectURI(c.pattern), "%s", c.pattern)
+		} else {
+			assert.Error(t, ValidateWildcardRedirectURI(c.pattern), "%s", c.pattern)
+		}
+	}
+}
+
+func TestMatchWildcardRedirectURI(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		rawurl  string
+		match   bool
+	}{
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenant.example.com/cb", match: true},
+		{pattern: "https://*.example.com/cb", rawurl: "https://Tenant-1.EXAMPLE.com/cb", match: true},
+		{pattern: "https://*.example.com:8443/cb?foo=bar", rawurl: "https://tenant.example.com:8443/cb?foo=bar", match: true},
+		{pattern: "https://*.example.com/cb", rawurl: "https://example.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://a.tenant.example.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenant.example.com.evil.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenantexample.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "http://tenant.example.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenant.example.com/cb/other"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenant.example.com/cb?foo=bar"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://tenant.example.com:8443/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://evil@tenant.example.com/cb"},
+		{pattern: "https://*.example.com/cb", rawurl: "https://-.example.com/cb"},
+		{pattern: "https://*.com/cb", rawurl: "https://example.com/cb"},
+	} {
+		assert.Equal(t, c.match, MatchWildcardRedirectURI(c.pattern, c.rawurl), "%s %s", c.pattern, c.rawurl)
+	}
+}
+
+func TestMatchRedirectURIWithWildcardClient(t *testing.T) {
+	client := &DefaultClientWithWildcardRedirectURIs{
+		DefaultClient:        &DefaultClient{RedirectURIs: []string{"https://example.com/cb"}},
+		WildcardRedirectURIs: []string{"https://*.example.com/cb"},
+	}
+
+	u, err := MatchRedirectURIWithClientRedirectURIs("https://tenant.example.com/cb", client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://tenant.example.com/cb", u.String())
+
+	u, err = MatchRedirectURIWithClientRedirectURIs("https://example.com/cb", client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/cb", u.String())
+
+	_, err = MatchRedirectURIWithClientRedirectURIs("https://tenant.example.org/cb", client)
+	assert.Error(t, err)
+
+	// Clients which did not opt in only accept exact matches.
+	_, err = MatchRedirectURIWithClientRedirectURIs("https://tenant.example.com/cb", client.DefaultClient)
+	assert.Error(t, err)
+}
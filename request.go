@@ -37,6 +37,9 @@ type Request struct {
 	GrantedScopes Arguments  `json:"grantedScopes" gorethink:"grantedScopes"`
 	Form          url.Values `json:"form" gorethink:"form"`
 	Session       Session    `json:"session" gorethink:"session"`
+
+	// Languages are the preferred languages of the end-user, see RequestLanguages.
+	Languages []string `json:"languages,omitempty" gorethink:"languages"`
 }
 
 func NewRequest() *Request {
@@ -56,6 +59,11 @@ func (a *Request) GetID() string {
 	return a.ID
 }
 
+// GetLanguages implements LocalizedRequester.
+func (a *Request) GetLanguages() []string {
+	return a.Languages
+}
+
 func (a *Request) SetID(id string) {
 	a.ID = id
 }
@@ -113,6 +121,9 @@ func (a *Request) GetSession() Session {
 	return a.Session
 }
 
+// Merge carries the requested and granted scopes, the client, the session, the time and the form values of request
+// over to this request, for example from the authorize request of a code which is exchanged for tokens. Scopes are
+// added to the ones of this request and form values of request replace the ones of the same key.
 func (a *Request) Merge(request Requester) {
 	for _, scope := range request.GetRequestedScopes() {
 		a.AppendRequestedScope(scope)
@@ -124,12 +135,25 @@ func (a *Request) Merge(request Requester) {
 	a.Client = request.GetClient()
 	a.Session = request.GetSession()
 
+	if a.Form == nil {
+		a.Form = url.Values{}
+	}
 	for k, v := range request.GetRequestForm() {
-		a.Form[k] = v
+		a.Form[k] = append([]string{}, v...)
+	}
+
+	if r, ok := request.(LocalizedRequester); ok && len(a.Languages) == 0 {
+		a.Languages = append([]string(nil), r.GetLanguages()...)
 	}
 }
 
+// Sanitize returns a copy of the request which only keeps the form values of allowedParameters. The copy does not
+// share scopes or form values with the request, so either can be changed without affecting the other.
 func (a *Request) Sanitize(allowedParameters []string) Requester {
+	return a.sanitize(allowedParameters)
+}
+
+func (a *Request) sanitize(allowedParameters []string) *Request {
 	b := new(Request)
 	allowed := map[string]bool{}
 	for _, v := range allowedParameters {
@@ -138,12 +162,22 @@ func (a *Request) Sanitize(allowedParameters []string) Requester {
 
 	*b = *a
 	b.ID = a.GetID()
+	b.Scopes = copyArguments(a.Scopes)
+	b.GrantedScopes = copyArguments(a.GrantedScopes)
+	b.Languages = append([]string(nil), a.Languages...)
 	b.Form = url.Values{}
-	for k := range a.Form {
+	for k, vs := range a.Form {
 		if _, ok := allowed[k]; ok {
-			b.Form.Add(k, a.Form.Get(k))
+			b.Form[k] = append([]string{}, vs...)
 		}
 	}
 
 	return b
 }
+
+func copyArguments(args Arguments) Arguments {
+	if args == nil {
+		return nil
+	}
+	return append(Arguments{}, args...)
+}
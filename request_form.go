@@ -0,0 +1,80 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RedactedFormParameters lists the request parameters which carry credentials, assertions or verifiers and must
+// therefore never show up in logs.
+var RedactedFormParameters = []string{
+	"client_secret",
+	"client_assertion",
+	"assertion",
+	"password",
+	"code",
+	"code_verifier",
+	"refresh_token",
+	"access_token",
+	"id_token_hint",
+	"token",
+	"request",
+}
+
+const redactedFormValue = "[redacted]"
+
+// RedactForm returns a canonical copy of form that is safe to be logged. Parameter names are trimmed, empty values
+// are dropped and the values of the parameters listed in RedactedFormParameters, as well as the values of parameters
+// passed as additional, are replaced with a placeholder.
+func RedactForm(form url.Values, additional ...string) url.Values {
+	redacted := map[string]bool{}
+	for _, p := range RedactedFormParameters {
+		redacted[p] = true
+	}
+	for _, p := range additional {
+		redacted[p] = true
+	}
+
+	res := url.Values{}
+	for k, vs := range form {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+
+		for _, v := range removeEmpty(vs) {
+			if redacted[k] {
+				v = redactedFormValue
+			}
+			res.Add(k, v)
+		}
+	}
+
+	return res
+}
+
+// DumpForm returns the URL-encoded representation of the redacted form, sorted by parameter name. See RedactForm.
+func DumpForm(form url.Values, additional ...string) string {
+	return RedactForm(form, additional...).Encode()
+}
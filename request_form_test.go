@@ -0,0 +1,57 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactForm(t *testing.T) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"some-code"},
+		"client_secret": {"foobar"},
+		"redirect_uri":  {" https://foo.bar/cb "},
+		"state":         {""},
+		" scope":        {"foo bar"},
+		"foo":           {"bar"},
+	}
+
+	assert.Equal(t, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"[redacted]"},
+		"client_secret": {"[redacted]"},
+		"redirect_uri":  {"https://foo.bar/cb"},
+		"scope":         {"foo bar"},
+		"foo":           {"bar"},
+	}, RedactForm(form))
+	assert.Equal(t, "[redacted]", RedactForm(form, "foo").Get("foo"))
+	assert.Equal(t, "foobar", form.Get("client_secret"))
+
+	assert.Equal(t,
+		"client_secret=%5Bredacted%5D&code=%5Bredacted%5D&foo=bar&grant_type=authorization_code&redirect_uri=https%3A%2F%2Ffoo.bar%2Fcb&scope=foo+bar",
+		DumpForm(form),
+	)
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultMaxRequestBodySize is the default maximum size in bytes of request bodies.
+	DefaultMaxRequestBodySize = 1 << 20
+
+	// DefaultMaxRequestParameters is the default maximum number of parameter values in a request.
+	DefaultMaxRequestParameters = 100
+
+	// DefaultMaxScopes is the default maximum number of scopes in a request.
+	DefaultMaxScopes = 100
+
+	// DefaultMaxScopeLength is the default maximum length in bytes of a single requested scope.
+	DefaultMaxScopeLength = 256
+)
+
+func (f *Fosite) maxRequestBodySize() int64 {
+	if f.MaxRequestBodySize == 0 {
+		return DefaultMaxRequestBodySize
+	}
+	return f.MaxRequestBodySize
+}
+
+func (f *Fosite) maxRequestParameters() int {
+	if f.MaxRequestParameters == 0 {
+		return DefaultMaxRequestParameters
+	}
+	return f.MaxRequestParameters
+}
+
+func (f *Fosite) maxScopes() int {
+	if f.MaxScopes == 0 {
+		return DefaultMaxScopes
+	}
+	return f.MaxScopes
+}
+
+func (f *Fosite) maxScopeLength() int {
+	if f.MaxScopeLength == 0 {
+		return DefaultMaxScopeLength
+	}
+	return f.MaxScopeLength
+}
+
+// parsePostForm parses the form serialized body of r, which must not be larger than MaxRequestBodySize.
+func (f *Fosite) parsePostForm(r *http.Request) error {
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, r.Body, f.maxRequestBodySize())
+	}
+	if err := r.ParseMultipartForm(f.maxRequestBodySize()); err != nil && err != http.ErrNotMultipart {
+		return errors.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithDebug(err.Error()))
+	}
+	return nil
+}
+
+// validateRequestLimits rejects requests with more parameter values than MaxRequestParameters, more scopes than
+// MaxScopes or scopes longer than MaxScopeLength.
+func (f *Fosite) validateRequestLimits(form url.Values) error {
+	var parameters int
+	for _, values := range form {
+		parameters += len(values)
+	}
+	if parameters > f.maxRequestParameters() {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The request contains %d parameters but at most %d are allowed.", parameters, f.maxRequestParameters()))
+	}
+
+	scopes := SplitArguments(form.Get("scope"))
+	if len(scopes) > f.maxScopes() {
+		return errors.WithStack(ErrInvalidScope.WithHintf("The request contains %d scopes but at most %d are allowed.", len(scopes), f.maxScopes()))
+	}
+	for _, scope := range scopes {
+		if len(scope) > f.maxScopeLength() {
+			return errors.WithStack(ErrInvalidScope.WithHintf("The requested scopes must not be longer than %d characters.", f.maxScopeLength()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+func TestRequestLimits(t *testing.T) {
+	f := &Fosite{MaxRequestParameters: 3, MaxScopes: 2, MaxScopeLength: 5}
+
+	for k, c := range []struct {
+		form   url.Values
+		expect error
+	}{
+		{
+			form:   url.Values{"a": {"1"}, "b": {"1", "2"}, "c": {"1"}},
+			expect: ErrInvalidRequest,
+		},
+		{
+			form:   url.Values{"scope": {"foo bar baz"}},
+			expect: ErrInvalidScope,
+		},
+		{
+			form:   url.Values{"scope": {"foo barbaz"}},
+			expect: ErrInvalidScope,
+		},
+	} {
+		_, err := f.NewAuthorizeRequestFromValues(context.Background(), c.form, nil)
+		assert.EqualError(t, errors.Cause(err), c.expect.Error(), "authorize %d", k)
+
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(c.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		_, err = f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+		assert.EqualError(t, errors.Cause(err), c.expect.Error(), "token %d", k)
+	}
+
+	r, _ := http.NewRequest("POST", "/token", strings.NewReader("grant_type="+strings.Repeat("a", DefaultMaxRequestBodySize)))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+	assert.EqualError(t, errors.Cause(err), ErrInvalidRequest.Error())
+}
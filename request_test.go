@@ -75,6 +75,22 @@ func TestMergeRequest(t *testing.T) {
 	assert.EqualValues(t, a.Session, b.Session)
 }
 
+func TestMergeRequestCopiesForm(t *testing.T) {
+	a := &Request{
+		Client:  &DefaultClient{ID: "123"},
+		Form:    url.Values{"foo": []string{"bar"}},
+		Session: new(DefaultSession),
+	}
+	b := &Request{Languages: []string{"en"}}
+
+	b.Merge(a)
+	assert.Equal(t, "bar", b.GetRequestForm().Get("foo"))
+	assert.Equal(t, []string{"en"}, b.GetLanguages())
+
+	b.GetRequestForm()["foo"][0] = "changed"
+	assert.Equal(t, "bar", a.GetRequestForm().Get("foo"))
+}
+
 func TestSanitizeRequest(t *testing.T) {
 	a := &Request{
 		RequestedAt:   time.Now().UTC(),
@@ -94,7 +110,9 @@ func TestSanitizeRequest(t *testing.T) {
 	assert.Empty(t, b.GetRequestForm().Get("foo"))
 	assert.Equal(t, "fasdf", b.GetRequestForm().Get("bar"))
 	assert.Equal(t, "fasdf", b.GetRequestForm().Get("baz"))
+	assert.Equal(t, []string{"fasdf", "fasdf"}, b.GetRequestForm()["bar"])
 
+	b.GetRequestForm()["bar"][0] = "changed"
 	assert.Equal(t, "fasdf", a.GetRequestForm().Get("bar"))
 	assert.Equal(t, "fasdf", a.GetRequestForm().Get("baz"))
 	assert.Equal(t, "fasdf", a.GetRequestForm().Get("foo"))
@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "context"
+
+// RevocationReason describes why a token was revoked. Reasons are passed to the revocation storage through the
+// context, see RevocationReasonFromContext, so that they can be persisted for later forensics.
+type RevocationReason string
+
+const (
+	RevocationReasonUnspecified RevocationReason = ""
+	RevocationReasonUserLogout  RevocationReason = "user_logout"
+	RevocationReasonAdminAction RevocationReason = "admin_action"
+	RevocationReasonCompromise  RevocationReason = "compromise"
+	RevocationReasonRotation    RevocationReason = "rotation"
+)
+
+// IsValid returns true if the reason is one of the known revocation reasons.
+func (r RevocationReason) IsValid() bool {
+	switch r {
+	case RevocationReasonUnspecified, RevocationReasonUserLogout, RevocationReasonAdminAction, RevocationReasonCompromise, RevocationReasonRotation:
+		return true
+	}
+	return false
+}
+
+type revocationReasonContextKey struct{}
+
+// ContextWithRevocationReason returns a copy of ctx which carries the revocation reason.
+func ContextWithRevocationReason(ctx context.Context, reason RevocationReason) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, revocationReasonContextKey{}, reason)
+}
+
+// RevocationReasonFromContext returns the revocation reason carried by ctx, or RevocationReasonUnspecified.
+func RevocationReasonFromContext(ctx context.Context) RevocationReason {
+	if ctx == nil {
+		return RevocationReasonUnspecified
+	}
+	reason, _ := ctx.Value(revocationReasonContextKey{}).(RevocationReason)
+	return reason
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationReason(t *testing.T) {
+	assert.True(t, RevocationReasonCompromise.IsValid())
+	assert.True(t, RevocationReasonUnspecified.IsValid())
+	assert.False(t, RevocationReason("foo").IsValid())
+
+	assert.Equal(t, RevocationReasonUnspecified, RevocationReasonFromContext(nil))
+	assert.Equal(t, RevocationReasonUnspecified, RevocationReasonFromContext(context.Background()))
+	assert.Equal(t, RevocationReasonRotation, RevocationReasonFromContext(ContextWithRevocationReason(nil, RevocationReasonRotation)))
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ClientRevocationStorage finds and revokes the tokens issued to a client, so that all of them can be revoked at once.
+// It is implemented by the Storage passed to Fosite.
+type ClientRevocationStorage interface {
+	// GetClientRequestIDs returns the IDs of the requests whose access or refresh tokens were issued to the client
+	// and have not been revoked.
+	GetClientRequestIDs(ctx context.Context, clientID string) (requestIDs []string, err error)
+
+	// RevokeRefreshToken revokes the refresh tokens of the request and the access tokens based on them.
+	RevokeRefreshToken(ctx context.Context, requestID string) error
+
+	// RevokeAccessToken revokes the access tokens of the request.
+	RevokeAccessToken(ctx context.Context, requestID string) error
+}
+
+// RevokeClientTokens revokes all access and refresh tokens issued to the client, for example when it was
+// compromised. A disabled client (see DisableableClient and ClientDisabler) already fails client authentication, so
+// that its authorize codes and refresh tokens can no longer be redeemed; revoking its tokens removes them from storage
+// as well. The reason passed to the storage can be set with ContextWithRevocationReason.
+func (f *Fosite) RevokeClientTokens(ctx context.Context, clientID string) (err error) {
+	ctx, span := f.startSpan(ctx, "fosite.RevokeClientTokens")
+	defer func() { span.End(err) }()
+
+	if clientID == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint("The client ID must not be empty."))
+	}
+
+	store, ok := f.Store.(ClientRevocationStorage)
+	if !ok {
+		return errors.WithStack(ErrServerError.WithDebug("The storage does not implement ClientRevocationStorage."))
+	}
+
+	requestIDs, err := store.GetClientRequestIDs(ctx, clientID)
+	if err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+
+	for _, requestID := range requestIDs {
+		if err := f.revokeRequestTokens(ctx, store, requestID, AuditEvent{ClientID: clientID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
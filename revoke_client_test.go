@@ -0,0 +1,61 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestRevokeClientTokens(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	events := new(auditEvents)
+	f := &Fosite{Store: store, AuditLogger: events}
+
+	issue, active := tokenIssuer(t, store)
+
+	issue("1", "foo", "peter")
+	issue("2", "foo", "alice")
+	issue("3", "bar", "peter")
+
+	require.NoError(t, f.RevokeClientTokens(ContextWithRevocationReason(ctx, RevocationReasonAdminAction), "foo"))
+	assert.False(t, active("1"))
+	assert.False(t, active("2"))
+	assert.True(t, active("3"))
+	assert.Equal(t, RevocationReasonAdminAction, store.RevocationReasons["1"])
+	assert.Equal(t, RevocationReasonAdminAction, store.RevocationReasons["2"])
+	require.Len(t, *events, 2)
+	for _, event := range *events {
+		assert.Equal(t, AuditTokenRevoked, event.Type)
+		assert.Equal(t, "foo", event.ClientID)
+	}
+
+	assert.EqualError(t, f.RevokeClientTokens(ctx, ""), ErrInvalidRequest.Error())
+	assert.EqualError(t, (&Fosite{Store: struct{ Storage }{store}}).RevokeClientTokens(ctx, "bar"), ErrServerError.Error())
+}
@@ -48,6 +48,10 @@ import (
 // * https://tools.ietf.org/html/rfc7009#section-2.2
 // An invalid token type hint value is ignored by the authorization
 // server and does not influence the revocation response.
+//
+// The optional, non-standard "reason" parameter is handled like the token
+// type hint: unknown values are ignored. If the token type hint is absent,
+// it is derived from the token, see IntrospectTokenType.
 func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) error {
 	if r.Method != "POST" {
 		return errors.WithStack(ErrInvalidRequest.WithHintf("HTTP method is \"%s\", expected \"POST\".", r.Method))
@@ -62,12 +66,33 @@ func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) erro
 		return err
 	}
 
+	reason := RevocationReason(r.PostForm.Get("reason"))
+	if !reason.IsValid() {
+		reason = RevocationReasonUnspecified
+	}
+
 	token := r.PostForm.Get("token")
 	tokenTypeHint := TokenType(r.PostForm.Get("token_type_hint"))
+	if tokenTypeHint == "" {
+		tokenTypeHint = f.IntrospectTokenType(token)
+	}
+
+	return f.RevokeToken(ctx, token, tokenTypeHint, client, reason)
+}
+
+// RevokeToken revokes the token on behalf of the client, as if the client had called the revocation endpoint. The
+// reason is made available to the revocation storage through the context, see RevocationReasonFromContext.
+func (f *Fosite) RevokeToken(ctx context.Context, token string, tokenTypeHint TokenType, client Client, reason RevocationReason) (err error) {
+	ctx, span := f.startSpan(ctx, "fosite.RevokeToken")
+	defer func() { span.End(err) }()
+	ctx = ContextWithRevocationReason(ctx, reason)
 
 	var found bool
 	for _, loader := range f.RevocationHandlers {
-		if err := loader.RevokeToken(ctx, token, tokenTypeHint, client); err == nil {
+		err := f.runHandler(ctx, "revocation", loader, nil, func(ctx context.Context) error {
+			return loader.RevokeToken(ctx, token, tokenTypeHint, client)
+		})
+		if err == nil {
 			found = true
 		} else if errors.Cause(err).Error() == ErrUnknownRequest.Error() {
 			// do nothing
@@ -80,6 +105,13 @@ func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) erro
 		return errors.WithStack(ErrInvalidRequest)
 	}
 
+	if f.AuditLogger != nil {
+		event := &AuditEvent{Type: AuditTokenRevoked, Time: Now(f.Clock), Reason: reason}
+		if client != nil {
+			event.ClientID = client.GetID()
+		}
+		f.AuditLogger.LogAuditEvent(ctx, event)
+	}
 	return nil
 }
 
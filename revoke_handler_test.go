@@ -22,6 +22,7 @@
 package fosite_test
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"testing"
@@ -43,7 +44,7 @@ func TestNewRevocationRequest(t *testing.T) {
 	defer ctrl.Finish()
 
 	client := &DefaultClient{}
-	fosite := &Fosite{Store: store, Hasher: hasher}
+	fosite := &Fosite{Store: store, Hasher: hasher, TokenPrefixes: map[TokenType]string{RefreshToken: "ory_rt_"}}
 	for k, c := range []struct {
 		header    http.Header
 		form      url.Values
@@ -157,6 +158,22 @@ func TestNewRevocationRequest(t *testing.T) {
 			},
 			handlers: RevocationHandlers{handler},
 		},
+		{
+			header: http.Header{
+				"Authorization": {basicAuth("foo", "")},
+			},
+			method: "POST",
+			form: url.Values{
+				"token": {"ory_rt_foo"},
+			},
+			expectErr: nil,
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+				client.Public = true
+				handler.EXPECT().RevokeToken(gomock.Any(), "ory_rt_foo", RefreshToken, gomock.Any()).Return(nil)
+			},
+			handlers: RevocationHandlers{handler},
+		},
 		{
 			header: http.Header{
 				"Authorization": {basicAuth("foo", "bar")},
@@ -195,6 +212,48 @@ func TestNewRevocationRequest(t *testing.T) {
 			},
 			handlers: RevocationHandlers{handler},
 		},
+		{
+			header: http.Header{
+				"Authorization": {basicAuth("foo", "bar")},
+			},
+			method: "POST",
+			form: url.Values{
+				"token":  {"foo"},
+				"reason": {"user_logout"},
+			},
+			expectErr: nil,
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+				client.Secret = []byte("foo")
+				client.Public = false
+				hasher.EXPECT().Compare(gomock.Eq([]byte("foo")), gomock.Eq([]byte("bar"))).Return(nil)
+				handler.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(ctx context.Context, _ string, _ TokenType, _ Client) {
+					assert.Equal(t, RevocationReasonUserLogout, RevocationReasonFromContext(ctx))
+				}).Return(nil)
+			},
+			handlers: RevocationHandlers{handler},
+		},
+		{
+			header: http.Header{
+				"Authorization": {basicAuth("foo", "bar")},
+			},
+			method: "POST",
+			form: url.Values{
+				"token":  {"foo"},
+				"reason": {"boredom"},
+			},
+			expectErr: nil,
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+				client.Secret = []byte("foo")
+				client.Public = false
+				hasher.EXPECT().Compare(gomock.Eq([]byte("foo")), gomock.Eq([]byte("bar"))).Return(nil)
+				handler.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(ctx context.Context, _ string, _ TokenType, _ Client) {
+					assert.Equal(t, RevocationReasonUnspecified, RevocationReasonFromContext(ctx))
+				}).Return(nil)
+			},
+			handlers: RevocationHandlers{handler},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			r := &http.Request{
@@ -0,0 +1,123 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// SubjectRevocationStorage finds and revokes the authorize codes and tokens issued on behalf of an end-user, so that
+// all of them can be revoked at once. It is implemented by the Storage passed to Fosite.
+type SubjectRevocationStorage interface {
+	// GetSubjectRequests returns the requests whose authorize codes, access or refresh tokens were issued on behalf
+	// of subject and have not been redeemed or revoked. If clientID is not empty, only the requests of that client are
+	// returned.
+	GetSubjectRequests(ctx context.Context, subject, clientID string) (requests []Requester, err error)
+
+	// RevokeAuthorizeCodes invalidates the authorize codes of the request which have not been redeemed yet, as
+	// InvalidateAuthorizeCodeSession does.
+	RevokeAuthorizeCodes(ctx context.Context, requestID string) error
+
+	// RevokeRefreshToken revokes the refresh tokens of the request and the access tokens based on them.
+	RevokeRefreshToken(ctx context.Context, requestID string) error
+
+	// RevokeAccessToken revokes the access tokens of the request.
+	RevokeAccessToken(ctx context.Context, requestID string) error
+}
+
+// RevokeSubjectTokens revokes all authorize codes, access and refresh tokens issued on behalf of subject, for example
+// to log the end-user out everywhere. The reason passed to the storage can be set with ContextWithRevocationReason,
+// for example RevocationReasonUserLogout. Clients are not notified; use openid.BackChannelLogoutHandler for that.
+func (f *Fosite) RevokeSubjectTokens(ctx context.Context, subject string) error {
+	return f.revokeSubjectTokens(ctx, subject, "")
+}
+
+// RevokeSubjectClientTokens revokes all authorize codes, access and refresh tokens issued to the client on behalf of
+// subject, for example when the end-user withdraws the consent given to the client, in which case RevokeConsent
+// forgets the consent itself. See RevokeSubjectTokens.
+func (f *Fosite) RevokeSubjectClientTokens(ctx context.Context, subject, clientID string) error {
+	if clientID == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint("The client ID must not be empty."))
+	}
+	return f.revokeSubjectTokens(ctx, subject, clientID)
+}
+
+func (f *Fosite) revokeSubjectTokens(ctx context.Context, subject, clientID string) (err error) {
+	ctx, span := f.startSpan(ctx, "fosite.RevokeSubjectTokens")
+	defer func() { span.End(err) }()
+
+	if subject == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHint("The subject must not be empty."))
+	}
+
+	store, ok := f.Store.(SubjectRevocationStorage)
+	if !ok {
+		return errors.WithStack(ErrServerError.WithDebug("The storage does not implement SubjectRevocationStorage."))
+	}
+
+	requests, err := store.GetSubjectRequests(ctx, subject, clientID)
+	if err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+
+	for _, request := range requests {
+		if err := store.RevokeAuthorizeCodes(ctx, request.GetID()); err != nil {
+			return errors.WithStack(ErrServerError.WithWrap(err))
+		}
+
+		event := AuditEvent{Subject: subject}
+		if client := request.GetClient(); client != nil {
+			event.ClientID = client.GetID()
+		}
+		if err := f.revokeRequestTokens(ctx, store, request.GetID(), event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestTokenRevocationStorage revokes the access and refresh tokens of a request.
+type requestTokenRevocationStorage interface {
+	RevokeRefreshToken(ctx context.Context, requestID string) error
+	RevokeAccessToken(ctx context.Context, requestID string) error
+}
+
+// revokeRequestTokens revokes the access and refresh tokens of the request and logs an AuditTokenRevoked event based
+// on event.
+func (f *Fosite) revokeRequestTokens(ctx context.Context, store requestTokenRevocationStorage, requestID string, event AuditEvent) error {
+	if err := store.RevokeRefreshToken(ctx, requestID); err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	} else if err := store.RevokeAccessToken(ctx, requestID); err != nil {
+		return errors.WithStack(ErrServerError.WithWrap(err))
+	}
+
+	if f.AuditLogger != nil {
+		event.Type = AuditTokenRevoked
+		event.Time = Now(f.Clock)
+		event.RequestID = requestID
+		event.Reason = RevocationReasonFromContext(ctx)
+		f.AuditLogger.LogAuditEvent(ctx, &event)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+type auditEvents []*AuditEvent
+
+func (e *auditEvents) LogAuditEvent(_ context.Context, event *AuditEvent) {
+	*e = append(*e, event)
+}
+
+// tokenIssuer returns functions which issue an access and a refresh token for a request to the store, and which
+// report whether either of them is still active.
+func tokenIssuer(t *testing.T, store *storage.MemoryStore) (issue func(requestID, clientID, subject string), active func(requestID string) bool) {
+	issue = func(requestID, clientID, subject string) {
+		r := NewAccessRequest(&DefaultSession{Subject: subject})
+		r.ID = requestID
+		r.Client = &DefaultClient{ID: clientID}
+		require.NoError(t, store.CreateAccessTokenSession(context.Background(), requestID+"-at", r))
+		require.NoError(t, store.CreateRefreshTokenSession(context.Background(), requestID+"-rt", r))
+	}
+	active = func(requestID string) bool {
+		_, at := store.AccessTokens[requestID+"-at"]
+		_, rt := store.RefreshTokens[requestID+"-rt"]
+		return at || rt
+	}
+	return issue, active
+}
+
+func TestRevokeSubjectTokens(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	events := new(auditEvents)
+	f := &Fosite{Store: store, AuditLogger: events}
+
+	issue, active := tokenIssuer(t, store)
+
+	issue("1", "foo", "peter")
+	issue("2", "bar", "peter")
+	issue("3", "foo", "alice")
+
+	code := func(requestID, clientID, subject string) string {
+		r := NewAuthorizeRequest()
+		r.ID = requestID
+		r.Client = &DefaultClient{ID: clientID}
+		r.Session = &DefaultSession{Subject: subject}
+		require.NoError(t, store.CreateAuthorizeCodeSession(ctx, requestID+"-code", r))
+		return requestID + "-code"
+	}
+	peterCode, aliceCode := code("4", "baz", "peter"), code("5", "baz", "alice")
+
+	require.NoError(t, f.RevokeSubjectClientTokens(ContextWithRevocationReason(ctx, RevocationReasonAdminAction), "peter", "foo"))
+	assert.False(t, active("1"))
+	assert.True(t, active("2"))
+	assert.True(t, active("3"))
+	assert.Equal(t, RevocationReasonAdminAction, store.RevocationReasons["1"])
+	require.Len(t, *events, 1)
+	assert.Equal(t, AuditEvent{Type: AuditTokenRevoked, Time: (*events)[0].Time, ClientID: "foo", Subject: "peter", RequestID: "1", Reason: RevocationReasonAdminAction}, *(*events)[0])
+
+	require.NoError(t, f.RevokeSubjectTokens(ContextWithRevocationReason(ctx, RevocationReasonUserLogout), "peter"))
+	assert.False(t, active("2"))
+	assert.True(t, active("3"))
+	assert.Equal(t, RevocationReasonUserLogout, store.RevocationReasons["2"])
+	_, err := store.GetAuthorizeCodeSession(ctx, peterCode, nil)
+	assert.EqualError(t, err, ErrInvalidatedAuthorizeCode.Error())
+	_, err = store.GetAuthorizeCodeSession(ctx, aliceCode, nil)
+	assert.NoError(t, err)
+
+	// Each event reports the client of the revoked request.
+	clients := map[string]string{}
+	for _, event := range (*events)[1:] {
+		clients[event.RequestID] = event.ClientID
+	}
+	assert.Equal(t, map[string]string{"2": "bar", "4": "baz"}, clients)
+
+	assert.EqualError(t, f.RevokeSubjectTokens(ctx, ""), ErrInvalidRequest.Error())
+	assert.EqualError(t, f.RevokeSubjectClientTokens(ctx, "peter", ""), ErrInvalidRequest.Error())
+	assert.EqualError(t, (&Fosite{Store: struct{ Storage }{store}}).RevokeSubjectTokens(ctx, "alice"), ErrServerError.Error())
+}
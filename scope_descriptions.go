@@ -0,0 +1,79 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "fmt"
+
+// ScopeDescriptions holds the human readable descriptions of scopes shown to end users, for example on consent
+// screens, so that raw scope identifiers are never rendered.
+type ScopeDescriptions struct {
+	// Languages are the language tags every scope must have a description in, for example []string{"en", "de"}.
+	Languages []string
+
+	// Descriptions maps scopes to their descriptions keyed by language tag, for example
+	// {"photos": {"en": "View your photos", "de": "Deine Fotos ansehen"}}.
+	Descriptions map[string]map[string]string
+}
+
+// Describe returns the description of scope in language, falling back to the first of Languages the scope is
+// described in. The second return value is false if the scope has no description at all.
+func (d *ScopeDescriptions) Describe(scope, language string) (string, bool) {
+	if description, ok := d.Descriptions[scope][language]; ok && description != "" {
+		return description, true
+	}
+	for _, fallback := range d.Languages {
+		if description, ok := d.Descriptions[scope][fallback]; ok && description != "" {
+			return description, true
+		}
+	}
+	return "", false
+}
+
+// Missing returns a problem for every scope which lacks a description in one of Languages, or any description at all
+// if no languages are required.
+func (d *ScopeDescriptions) Missing(scopes []string) []string {
+	var problems []string
+	for _, scope := range scopes {
+		if len(d.Languages) == 0 {
+			if !d.described(scope) {
+				problems = append(problems, fmt.Sprintf("scope \"%s\" has no description", scope))
+			}
+			continue
+		}
+
+		for _, language := range d.Languages {
+			if d.Descriptions[scope][language] == "" {
+				problems = append(problems, fmt.Sprintf("scope \"%s\" has no description in language \"%s\"", scope, language))
+			}
+		}
+	}
+	return problems
+}
+
+func (d *ScopeDescriptions) described(scope string) bool {
+	for _, description := range d.Descriptions[scope] {
+		if description != "" {
+			return true
+		}
+	}
+	return false
+}
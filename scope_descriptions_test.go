@@ -0,0 +1,56 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeDescriptions(t *testing.T) {
+	d := &ScopeDescriptions{
+		Languages: []string{"en"},
+		Descriptions: map[string]map[string]string{
+			"photos": {"en": "View your photos", "de": "Deine Fotos ansehen"},
+			"email":  {"de": "Deine E-Mail-Adresse sehen"},
+		},
+	}
+
+	description, ok := d.Describe("photos", "de")
+	assert.True(t, ok)
+	assert.Equal(t, "Deine Fotos ansehen", description)
+
+	description, ok = d.Describe("photos", "fr")
+	assert.True(t, ok)
+	assert.Equal(t, "View your photos", description)
+
+	_, ok = d.Describe("email", "fr")
+	assert.False(t, ok)
+	_, ok = d.Describe("offline", "en")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"scope \"email\" has no description in language \"en\""}, d.Missing([]string{"photos", "email"}))
+
+	d.Languages = nil
+	assert.Equal(t, []string{"scope \"offline\" has no description"}, d.Missing([]string{"photos", "offline"}))
+}
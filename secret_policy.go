@@ -0,0 +1,115 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// CommonSecrets are client secrets which are rejected by every SecretPolicy because they are easily guessed.
+var CommonSecrets = []string{
+	"secret", "client_secret", "clientsecret", "password", "passw0rd", "changeme", "changeit", "default", "admin",
+	"test", "demo", "foobar", "letmein", "qwerty", "123456", "12345678", "1234567890",
+}
+
+// SecretPolicy is the minimum strength of client secrets chosen by humans, for example when clients are provisioned
+// manually or through a client registration endpoint. Secrets generated by the authorization server from a
+// cryptographically secure source do not need to be checked.
+type SecretPolicy struct {
+	// MinLength is the minimum number of characters of a secret.
+	MinLength int
+
+	// MinCharacterClasses is the minimum number of character classes (lower case letters, upper case letters,
+	// digits and other characters) a secret must contain.
+	MinCharacterClasses int
+
+	// DeniedSecrets are rejected in addition to CommonSecrets. Both are compared case-insensitively.
+	DeniedSecrets []string
+}
+
+// DefaultSecretPolicy requires secrets of at least 32 characters, which are not one of CommonSecrets.
+var DefaultSecretPolicy = &SecretPolicy{MinLength: 32}
+
+// Validate returns ErrInvalidClientMetadata if the secret does not satisfy the policy.
+func (p *SecretPolicy) Validate(secret string) error {
+	if length := len([]rune(secret)); length < p.MinLength {
+		return errors.WithStack(ErrInvalidClientMetadata.WithHintf("The client secret must be at least %d characters long but is %d characters long.", p.MinLength, length))
+	} else if classes := characterClasses(secret); classes < p.MinCharacterClasses {
+		return errors.WithStack(ErrInvalidClientMetadata.WithHintf("The client secret must contain at least %d of lower case letters, upper case letters, digits and other characters but contains %d.", p.MinCharacterClasses, classes))
+	}
+
+	for _, denied := range [][]string{CommonSecrets, p.DeniedSecrets} {
+		for _, d := range denied {
+			if strings.EqualFold(d, secret) {
+				return errors.WithStack(ErrInvalidClientMetadata.WithHint("The client secret is too common."))
+			}
+		}
+	}
+	return nil
+}
+
+// characterClasses returns the number of character classes the secret contains.
+func characterClasses(secret string) int {
+	var lower, upper, digit, other int
+	for _, r := range secret {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+func (f *Fosite) secretPolicy() *SecretPolicy {
+	if f.SecretPolicy == nil {
+		return DefaultSecretPolicy
+	}
+	return f.SecretPolicy
+}
+
+// HashClientSecret validates the client secret against SecretPolicy and returns its hash, which is to be stored as
+// the hashed secret of the client. It is meant to be used when clients are provisioned, for example
+//
+//	hash, err := f.HashClientSecret(secret)
+//	client := &DefaultClient{ID: "my-client", Secret: hash}
+func (f *Fosite) HashClientSecret(secret string) ([]byte, error) {
+	if err := f.secretPolicy().Validate(secret); err != nil {
+		return nil, err
+	} else if f.Hasher == nil {
+		return nil, errors.WithStack(ErrMisconfiguration.WithHint("The hasher has not been set."))
+	}
+
+	hash, err := f.Hasher.Hash([]byte(secret))
+	if err != nil {
+		return nil, errors.WithStack(ErrServerError.WithWrap(err))
+	}
+	return hash, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestSecretPolicy(t *testing.T) {
+	for k, c := range []struct {
+		policy *SecretPolicy
+		secret string
+		pass   bool
+	}{
+		{policy: DefaultSecretPolicy, secret: strings.Repeat("a", 32), pass: true},
+		{policy: DefaultSecretPolicy, secret: strings.Repeat("a", 31)},
+		{policy: DefaultSecretPolicy, secret: strings.Repeat("ü", 32), pass: true},
+		{policy: &SecretPolicy{MinLength: 6}, secret: "Secret"},
+		{policy: &SecretPolicy{MinLength: 6}, secret: "Secret1", pass: true},
+		{policy: &SecretPolicy{MinLength: 6, DeniedSecrets: []string{"secret1"}}, secret: "Secret1"},
+		{policy: &SecretPolicy{MinCharacterClasses: 3}, secret: "abcDEF", pass: false},
+		{policy: &SecretPolicy{MinCharacterClasses: 3}, secret: "abcDEF1", pass: true},
+		{policy: &SecretPolicy{MinCharacterClasses: 4}, secret: "abcDEF1-", pass: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := c.policy.Validate(c.secret)
+			if c.pass {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, ErrInvalidClientMetadata.Error())
+			}
+		})
+	}
+}
+
+func TestHashClientSecret(t *testing.T) {
+	f := &Fosite{Hasher: &BCrypt{WorkFactor: 4}}
+
+	_, err := f.HashClientSecret("foobar")
+	assert.EqualError(t, err, ErrInvalidClientMetadata.Error())
+
+	secret := "some-secret-thats-random-some-secret"
+	hash, err := f.HashClientSecret(secret)
+	require.NoError(t, err)
+	assert.NoError(t, f.Hasher.Compare(hash, []byte(secret)))
+
+	f.SecretPolicy = &SecretPolicy{MinLength: 6}
+	_, err = f.HashClientSecret("foobar")
+	assert.EqualError(t, err, ErrInvalidClientMetadata.Error(), "common secrets are always rejected")
+	_, err = f.HashClientSecret("foobaz")
+	assert.NoError(t, err)
+}
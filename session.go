@@ -50,6 +50,12 @@ type Session interface {
 	Clone() Session
 }
 
+// ExtraClaimsSession is a Session which accepts additional claims, for example claims contributed by a Webhook.
+type ExtraClaimsSession interface {
+	// SetExtraClaim adds a claim to the tokens issued for this session.
+	SetExtraClaim(key string, value interface{})
+}
+
 // DefaultSession is a default implementation of the session interface.
 type DefaultSession struct {
 	ExpiresAt map[TokenType]time.Time
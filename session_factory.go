@@ -0,0 +1,46 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+)
+
+// SessionFactory creates the session of an access request for which no session was passed to NewAccessRequest.
+// The request's grant types, form and authenticated client are already set when the factory is called.
+type SessionFactory func(ctx context.Context, request AccessRequester) Session
+
+// ClientCredentialsSessionFactory is a SessionFactory for the client credentials grant which uses the client ID as
+// the subject, because the client acts on its own behalf, see https://tools.ietf.org/html/rfc6749#section-4.4
+func ClientCredentialsSessionFactory(_ context.Context, request AccessRequester) Session {
+	return &DefaultSession{Subject: request.GetClient().GetID()}
+}
+
+// newSession returns the session created by the factory registered for the request's grant type, or nil.
+func (f *Fosite) newSession(ctx context.Context, request AccessRequester) Session {
+	for _, grantType := range request.GetGrantTypes() {
+		if factory, ok := f.SessionFactories[grantType]; ok {
+			return factory(ctx, request)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccessRequestWithSessionFactory(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+	f := &Fosite{
+		Store:                 store,
+		TokenEndpointHandlers: TokenEndpointHandlers{acceptingTokenEndpointHandler{}},
+	}
+
+	newRequest := func(grantType string) *http.Request {
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {grantType}, "client_id": {"foo"}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	_, err := f.NewAccessRequest(context.Background(), newRequest("client_credentials"), nil)
+	assert.Error(t, err)
+
+	f.SessionFactories = map[string]SessionFactory{"client_credentials": ClientCredentialsSessionFactory}
+
+	ar, err := f.NewAccessRequest(context.Background(), newRequest("client_credentials"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", ar.GetSession().GetSubject())
+
+	ar, err = f.NewAccessRequest(context.Background(), newRequest("client_credentials"), &DefaultSession{Subject: "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", ar.GetSession().GetSubject())
+
+	_, err = f.NewAccessRequest(context.Background(), newRequest("password"), nil)
+	assert.Error(t, err)
+}
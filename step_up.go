@@ -0,0 +1,69 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StepUpChallenge describes the authentication a protected resource requires from the end-user, see
+// https://www.rfc-editor.org/rfc/rfc9470
+type StepUpChallenge struct {
+	// ACRValues are the authentication context class references of which one must be satisfied, most preferred first.
+	ACRValues Arguments
+
+	// MaxAge is the maximum time in seconds since the end-user authenticated. A negative value means that the time of
+	// the authentication does not matter, zero means that the end-user must authenticate again.
+	MaxAge int64
+}
+
+// Error implements error, so that the challenge can be wrapped by ErrInsufficientUserAuthentication.
+func (c *StepUpChallenge) Error() string {
+	return "insufficient user authentication"
+}
+
+// AuthorizeParameters returns the acr_values and max_age parameters with which the client repeats the authorize
+// request to satisfy the challenge.
+func (c *StepUpChallenge) AuthorizeParameters() url.Values {
+	values := url.Values{}
+	if len(c.ACRValues) > 0 {
+		values.Set("acr_values", strings.Join(c.ACRValues, " "))
+	}
+	if c.MaxAge >= 0 {
+		values.Set("max_age", strconv.FormatInt(c.MaxAge, 10))
+	}
+	return values
+}
+
+// NewStepUpError returns an insufficient_user_authentication error which asks the client to obtain a new access token
+// with one of acrValues and, unless maxAge is negative, an authentication no older than maxAge seconds. Resource
+// servers respond with it using WriteBearerError, which includes the requirements in the WWW-Authenticate challenge.
+func NewStepUpError(acrValues []string, maxAge int64) error {
+	return errors.WithStack(ErrInsufficientUserAuthentication.WithWrap(&StepUpChallenge{
+		ACRValues: acrValues,
+		MaxAge:    maxAge,
+	}))
+}
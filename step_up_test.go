@@ -0,0 +1,37 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/ory/fosite"
+)
+
+func TestStepUpChallengeAuthorizeParameters(t *testing.T) {
+	assert.Equal(t, url.Values{"acr_values": {"urn:example:mfa phr"}, "max_age": {"0"}}, (&StepUpChallenge{ACRValues: Arguments{"urn:example:mfa", "phr"}}).AuthorizeParameters())
+	assert.Equal(t, url.Values{"acr_values": {"phr"}}, (&StepUpChallenge{ACRValues: Arguments{"phr"}, MaxAge: -1}).AuthorizeParameters())
+	assert.Equal(t, url.Values{}, (&StepUpChallenge{MaxAge: -1}).AuthorizeParameters())
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// ClientCache is a read-through cache of the clients loaded from a fosite.ClientManager. It keeps up to Size clients
+// for TTL and evicts the least recently used client once it is full. Errors are not cached.
+//
+// Clients which are changed or deleted must be invalidated, or they are served from the cache until their TTL is
+// over. DeactivateClient, RestoreClient, DisableClient and EnableClient do so automatically, for the client itself and
+// for every tenant it is cached for.
+type ClientCache struct {
+	Manager fosite.ClientManager
+	Size    int
+	TTL     time.Duration
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cachedClient struct {
+	id        string
+	clientID  string
+	client    fosite.Client
+	expiresAt time.Time
+}
+
+// NewClientCache returns a cache of up to size clients of manager, which are kept for ttl.
+func NewClientCache(manager fosite.ClientManager, size int, ttl time.Duration) *ClientCache {
+	return &ClientCache{Manager: manager, Size: size, TTL: ttl}
+}
+
+// GetClient implements fosite.ClientManager.
+func (c *ClientCache) GetClient(ctx context.Context, id string) (fosite.Client, error) {
+	if client, ok := c.get(id); ok {
+		return client, nil
+	}
+
+	client, err := c.Manager.GetClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.add(id, id, client)
+	return client, nil
+}
+
+// GetTenantClient implements fosite.TenantClientManager. Clients are loaded through the cached manager's
+// GetTenantClient if it implements fosite.TenantClientManager, and through GetClient otherwise.
+func (c *ClientCache) GetTenantClient(ctx context.Context, tenantID string, id string) (fosite.Client, error) {
+	key := tenantClientKey(tenantID, id)
+	if client, ok := c.get(key); ok {
+		return client, nil
+	}
+
+	var client fosite.Client
+	var err error
+	if tenants, ok := c.Manager.(fosite.TenantClientManager); ok {
+		client, err = tenants.GetTenantClient(ctx, tenantID, id)
+	} else {
+		client, err = c.Manager.GetClient(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.add(key, id, client)
+	return client, nil
+}
+
+// InvalidateTenant removes the client of the tenant from the cache.
+func (c *ClientCache) InvalidateTenant(tenantID string, id string) {
+	c.Invalidate(tenantClientKey(tenantID, id))
+}
+
+// Invalidate removes the client from the cache.
+func (c *ClientCache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[id]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, id)
+	}
+}
+
+// InvalidateClient removes the client from the cache, together with the copies cached for any tenant.
+func (c *ClientCache) InvalidateClient(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if e.Value.(*cachedClient).clientID == id {
+			c.lru.Remove(e)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Purge removes all clients from the cache.
+func (c *ClientCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.lru = nil, nil
+}
+
+// DeactivateClient implements fosite.ClientDeactivator if the cached manager does.
+func (c *ClientCache) DeactivateClient(ctx context.Context, id string, gracePeriod time.Duration) error {
+	d, ok := c.Manager.(fosite.ClientDeactivator)
+	if !ok {
+		return errors.WithStack(fosite.ErrServerError.WithDebug("The cached client manager does not support deactivating clients."))
+	}
+	defer c.InvalidateClient(id)
+	return d.DeactivateClient(ctx, id, gracePeriod)
+}
+
+// RestoreClient implements fosite.ClientDeactivator if the cached manager does.
+func (c *ClientCache) RestoreClient(ctx context.Context, id string) error {
+	d, ok := c.Manager.(fosite.ClientDeactivator)
+	if !ok {
+		return errors.WithStack(fosite.ErrServerError.WithDebug("The cached client manager does not support restoring clients."))
+	}
+	defer c.InvalidateClient(id)
+	return d.RestoreClient(ctx, id)
+}
+
+// DisableClient implements fosite.ClientDisabler if the cached manager does.
+func (c *ClientCache) DisableClient(ctx context.Context, id string) error {
+	d, ok := c.Manager.(fosite.ClientDisabler)
+	if !ok {
+		return errors.WithStack(fosite.ErrServerError.WithDebug("The cached client manager does not support disabling clients."))
+	}
+	defer c.InvalidateClient(id)
+	return d.DisableClient(ctx, id)
+}
+
+// EnableClient implements fosite.ClientDisabler if the cached manager does.
+func (c *ClientCache) EnableClient(ctx context.Context, id string) error {
+	d, ok := c.Manager.(fosite.ClientDisabler)
+	if !ok {
+		return errors.WithStack(fosite.ErrServerError.WithDebug("The cached client manager does not support enabling clients."))
+	}
+	defer c.InvalidateClient(id)
+	return d.EnableClient(ctx, id)
+}
+
+func (c *ClientCache) get(id string) (fosite.Client, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	cached := e.Value.(*cachedClient)
+	if !fosite.Now(c.Clock).Before(cached.expiresAt) {
+		c.lru.Remove(e)
+		delete(c.entries, id)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return cached.client, true
+}
+
+func (c *ClientCache) add(id string, clientID string, client fosite.Client) {
+	if c.Size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries, c.lru = map[string]*list.Element{}, list.New()
+	}
+
+	cached := &cachedClient{id: id, clientID: clientID, client: client, expiresAt: fosite.Now(c.Clock).Add(c.TTL)}
+	if e, ok := c.entries[id]; ok {
+		e.Value = cached
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[id] = c.lru.PushFront(cached)
+	for c.lru.Len() > c.Size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedClient).id)
+	}
+}
+
+func tenantClientKey(tenantID string, id string) string {
+	return tenantID + "\x00" + id
+}
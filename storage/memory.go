@@ -23,17 +23,51 @@ package storage
 
 import (
 	"context"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ory/fosite"
 	"github.com/pkg/errors"
 )
 
+// tenantKeySeparator separates the tenant ID from the signature or request ID in the keys of the tokens of tenants.
+const tenantKeySeparator = "\x00"
+
+// tenantKey returns the key under which the token, code or request with the given signature or ID is stored for the
+// tenant of ctx, which keeps the tokens of tenants apart. Keys of requests without a tenant are left unchanged.
+func tenantKey(ctx context.Context, key string) string {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.ID != "" {
+		return tenant.ID + tenantKeySeparator + key
+	}
+	return key
+}
+
+// inTenant returns true if key was stored for the tenant of ctx, see tenantKey.
+func inTenant(ctx context.Context, key string) bool {
+	if tenant := fosite.TenantFromContext(ctx); tenant != nil && tenant.ID != "" {
+		return strings.HasPrefix(key, tenant.ID+tenantKeySeparator)
+	}
+	return !strings.Contains(key, tenantKeySeparator)
+}
+
+// requestIDKey returns the key of requestID for the tenant the token stored under signatureKey belongs to.
+func requestIDKey(signatureKey, requestID string) string {
+	if i := strings.Index(signatureKey, tenantKeySeparator); i >= 0 {
+		return signatureKey[:i+len(tenantKeySeparator)] + requestID
+	}
+	return requestID
+}
+
 type MemoryUserRelation struct {
 	Username string
 	Password string
 }
 
+// MemoryStore is an in-memory storage which is safe for concurrent use.
 type MemoryStore struct {
+	mutex sync.RWMutex
+
 	Clients        map[string]fosite.Client
 	AuthorizeCodes map[string]StoreAuthorizeCode
 	IDSessions     map[string]fosite.Requester
@@ -45,6 +79,29 @@ type MemoryStore struct {
 	// In-memory request ID to token signatures
 	AccessTokenRequestIDs  map[string]string
 	RefreshTokenRequestIDs map[string]string
+	// In-memory request ID to the reason the request's tokens were revoked for
+	RevocationReasons map[string]fosite.RevocationReason
+	// In-memory pending authorizations, keyed by ID
+	PendingAuthorizations map[string]fosite.PendingAuthorization
+	// In-memory refresh token signatures to rotated refresh tokens
+	UsedRefreshTokens map[string]UsedRefreshToken
+	// In-memory request ID to the tokens issued for the request
+	TokenLineages map[string][]fosite.TokenLineageEntry
+	// In-memory idempotency keys to token responses
+	IdempotentResponses map[string]IdempotentResponse
+	// In-memory client assertion JWT IDs to their expiry
+	UsedJTIs map[string]time.Time
+	// In-memory tenant IDs to the clients of the tenant
+	TenantClients map[string]map[string]fosite.Client
+	// In-memory client IDs and subjects to the scopes the subject granted to the client
+	GrantedScopes map[string]fosite.Arguments
+	// In-memory client IDs and subjects to the remembered consent of the subject
+	Consents map[string]fosite.Consent
+
+	// Clock provides the current time to UseJTI. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	jtiPruneAt int
 }
 
 func NewMemoryStore() *MemoryStore {
@@ -59,9 +116,28 @@ func NewMemoryStore() *MemoryStore {
 		Users:          make(map[string]MemoryUserRelation),
 		AccessTokenRequestIDs:  make(map[string]string),
 		RefreshTokenRequestIDs: make(map[string]string),
+		RevocationReasons:      make(map[string]fosite.RevocationReason),
+		PendingAuthorizations:  make(map[string]fosite.PendingAuthorization),
+		UsedRefreshTokens:      make(map[string]UsedRefreshToken),
+		TokenLineages:          make(map[string][]fosite.TokenLineageEntry),
+		IdempotentResponses:    make(map[string]IdempotentResponse),
+		UsedJTIs:               make(map[string]time.Time),
+		TenantClients:          make(map[string]map[string]fosite.Client),
+		GrantedScopes:          make(map[string]fosite.Arguments),
+		Consents:               make(map[string]fosite.Consent),
 	}
 }
 
+type IdempotentResponse struct {
+	Response  map[string]interface{}
+	ExpiresAt time.Time
+}
+
+type UsedRefreshToken struct {
+	UsedAt time.Time
+	fosite.Requester
+}
+
 type StoreAuthorizeCode struct {
 	active bool
 	fosite.Requester
@@ -106,25 +182,37 @@ func NewExampleStore() *MemoryStore {
 	}
 }
 
-func (s *MemoryStore) CreateOpenIDConnectSession(_ context.Context, authorizeCode string, requester fosite.Requester) error {
-	s.IDSessions[authorizeCode] = requester
+func (s *MemoryStore) CreateOpenIDConnectSession(ctx context.Context, authorizeCode string, requester fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.IDSessions[tenantKey(ctx, authorizeCode)] = requester
 	return nil
 }
 
-func (s *MemoryStore) GetOpenIDConnectSession(_ context.Context, authorizeCode string, requester fosite.Requester) (fosite.Requester, error) {
-	cl, ok := s.IDSessions[authorizeCode]
+func (s *MemoryStore) GetOpenIDConnectSession(ctx context.Context, authorizeCode string, requester fosite.Requester) (fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cl, ok := s.IDSessions[tenantKey(ctx, authorizeCode)]
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
 	return cl, nil
 }
 
-func (s *MemoryStore) DeleteOpenIDConnectSession(_ context.Context, authorizeCode string) error {
-	delete(s.IDSessions, authorizeCode)
+func (s *MemoryStore) DeleteOpenIDConnectSession(ctx context.Context, authorizeCode string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.IDSessions, tenantKey(ctx, authorizeCode))
 	return nil
 }
 
 func (s *MemoryStore) GetClient(_ context.Context, id string) (fosite.Client, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	cl, ok := s.Clients[id]
 	if !ok {
 		return nil, fosite.ErrNotFound
@@ -132,13 +220,108 @@ func (s *MemoryStore) GetClient(_ context.Context, id string) (fosite.Client, er
 	return cl, nil
 }
 
-func (s *MemoryStore) CreateAuthorizeCodeSession(_ context.Context, code string, req fosite.Requester) error {
-	s.AuthorizeCodes[code] = StoreAuthorizeCode{active: true, Requester: req}
+// GetTenantClient implements fosite.TenantClientManager. Tenants without any clients in TenantClients share Clients.
+func (s *MemoryStore) GetTenantClient(_ context.Context, tenantID string, id string) (fosite.Client, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	clients, ok := s.TenantClients[tenantID]
+	if !ok {
+		clients = s.Clients
+	}
+	cl, ok := clients[id]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	return cl, nil
+}
+
+// DeactivateClient implements fosite.ClientDeactivator by wrapping the client in a fosite.DeactivatedClient.
+func (s *MemoryStore) DeactivateClient(_ context.Context, id string, gracePeriod time.Duration) error {
+	return s.updateClient(id, func(cl fosite.Client) fosite.Client {
+		if dc, ok := cl.(fosite.DeactivatableClient); ok && !dc.GetDeactivatedAt().IsZero() {
+			return cl
+		}
+		return &fosite.DeactivatedClient{Client: cl, DeactivatedAt: time.Now().UTC(), GracePeriod: gracePeriod}
+	})
+}
+
+// RestoreClient implements fosite.ClientDeactivator.
+func (s *MemoryStore) RestoreClient(_ context.Context, id string) error {
+	return s.updateClient(id, func(cl fosite.Client) fosite.Client {
+		switch c := cl.(type) {
+		case *fosite.DeactivatedClient:
+			return c.Client
+		case *fosite.DisabledClient:
+			if dc, ok := c.Client.(*fosite.DeactivatedClient); ok {
+				return &fosite.DisabledClient{Client: dc.Client}
+			}
+		}
+		return cl
+	})
+}
+
+// DisableClient implements fosite.ClientDisabler by wrapping the client in a fosite.DisabledClient.
+func (s *MemoryStore) DisableClient(_ context.Context, id string) error {
+	return s.updateClient(id, func(cl fosite.Client) fosite.Client {
+		if fosite.IsClientDisabled(cl) {
+			return cl
+		}
+		return &fosite.DisabledClient{Client: cl}
+	})
+}
+
+// EnableClient implements fosite.ClientDisabler.
+func (s *MemoryStore) EnableClient(_ context.Context, id string) error {
+	return s.updateClient(id, func(cl fosite.Client) fosite.Client {
+		switch c := cl.(type) {
+		case *fosite.DisabledClient:
+			return c.Client
+		case *fosite.DeactivatedClient:
+			if dc, ok := c.Client.(*fosite.DisabledClient); ok {
+				return &fosite.DeactivatedClient{Client: dc.Client, DeactivatedAt: c.DeactivatedAt, GracePeriod: c.GracePeriod}
+			}
+		}
+		return cl
+	})
+}
+
+// updateClient replaces the client with the given ID in Clients and in TenantClients by the result of update. It
+// returns fosite.ErrNotFound if no such client exists.
+func (s *MemoryStore) updateClient(id string, update func(fosite.Client) fosite.Client) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var found bool
+	clients := []map[string]fosite.Client{s.Clients}
+	for _, tenantClients := range s.TenantClients {
+		clients = append(clients, tenantClients)
+	}
+	for _, c := range clients {
+		if cl, ok := c[id]; ok {
+			c[id] = update(cl)
+			found = true
+		}
+	}
+	if !found {
+		return fosite.ErrNotFound
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateAuthorizeCodeSession(ctx context.Context, code string, req fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.AuthorizeCodes[tenantKey(ctx, code)] = StoreAuthorizeCode{active: true, Requester: req}
 	return nil
 }
 
-func (s *MemoryStore) GetAuthorizeCodeSession(_ context.Context, code string, _ fosite.Session) (fosite.Requester, error) {
-	rel, ok := s.AuthorizeCodes[code]
+func (s *MemoryStore) GetAuthorizeCodeSession(ctx context.Context, code string, _ fosite.Session) (fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rel, ok := s.AuthorizeCodes[tenantKey(ctx, code)]
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
@@ -150,82 +333,126 @@ func (s *MemoryStore) GetAuthorizeCodeSession(_ context.Context, code string, _
 }
 
 func (s *MemoryStore) InvalidateAuthorizeCodeSession(ctx context.Context, code string) error {
-	rel, ok := s.AuthorizeCodes[code]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := tenantKey(ctx, code)
+	rel, ok := s.AuthorizeCodes[key]
 	if !ok {
 		return fosite.ErrNotFound
+	} else if !rel.active {
+		return fosite.ErrInvalidatedAuthorizeCode
 	}
 	rel.active = false
-	s.AuthorizeCodes[code] = rel
+	s.AuthorizeCodes[key] = rel
 	return nil
 }
 
-func (s *MemoryStore) DeleteAuthorizeCodeSession(_ context.Context, code string) error {
-	delete(s.AuthorizeCodes, code)
+func (s *MemoryStore) DeleteAuthorizeCodeSession(ctx context.Context, code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.AuthorizeCodes, tenantKey(ctx, code))
 	return nil
 }
 
-func (s *MemoryStore) CreatePKCERequestSession(_ context.Context, code string, req fosite.Requester) error {
-	s.PKCES[code] = req
+func (s *MemoryStore) CreatePKCERequestSession(ctx context.Context, code string, req fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.PKCES[tenantKey(ctx, code)] = req
 	return nil
 }
 
-func (s *MemoryStore) GetPKCERequestSession(_ context.Context, code string, _ fosite.Session) (fosite.Requester, error) {
-	rel, ok := s.PKCES[code]
+func (s *MemoryStore) GetPKCERequestSession(ctx context.Context, code string, _ fosite.Session) (fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rel, ok := s.PKCES[tenantKey(ctx, code)]
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
 	return rel, nil
 }
 
-func (s *MemoryStore) DeletePKCERequestSession(_ context.Context, code string) error {
-	delete(s.PKCES, code)
+func (s *MemoryStore) DeletePKCERequestSession(ctx context.Context, code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.PKCES, tenantKey(ctx, code))
 	return nil
 }
 
-func (s *MemoryStore) CreateAccessTokenSession(_ context.Context, signature string, req fosite.Requester) error {
-	s.AccessTokens[signature] = req
-	s.AccessTokenRequestIDs[req.GetID()] = signature
+func (s *MemoryStore) CreateAccessTokenSession(ctx context.Context, signature string, req fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := tenantKey(ctx, signature)
+	s.AccessTokens[key] = req
+	s.AccessTokenRequestIDs[tenantKey(ctx, req.GetID())] = key
 	return nil
 }
 
-func (s *MemoryStore) GetAccessTokenSession(_ context.Context, signature string, _ fosite.Session) (fosite.Requester, error) {
-	rel, ok := s.AccessTokens[signature]
+func (s *MemoryStore) GetAccessTokenSession(ctx context.Context, signature string, _ fosite.Session) (fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rel, ok := s.AccessTokens[tenantKey(ctx, signature)]
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
 	return rel, nil
 }
 
-func (s *MemoryStore) DeleteAccessTokenSession(_ context.Context, signature string) error {
-	delete(s.AccessTokens, signature)
+func (s *MemoryStore) DeleteAccessTokenSession(ctx context.Context, signature string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.AccessTokens, tenantKey(ctx, signature))
 	return nil
 }
 
-func (s *MemoryStore) CreateRefreshTokenSession(_ context.Context, signature string, req fosite.Requester) error {
-	s.RefreshTokens[signature] = req
-	s.RefreshTokenRequestIDs[req.GetID()] = signature
+func (s *MemoryStore) CreateRefreshTokenSession(ctx context.Context, signature string, req fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := tenantKey(ctx, signature)
+	s.RefreshTokens[key] = req
+	s.RefreshTokenRequestIDs[tenantKey(ctx, req.GetID())] = key
 	return nil
 }
 
-func (s *MemoryStore) GetRefreshTokenSession(_ context.Context, signature string, _ fosite.Session) (fosite.Requester, error) {
-	rel, ok := s.RefreshTokens[signature]
+func (s *MemoryStore) GetRefreshTokenSession(ctx context.Context, signature string, _ fosite.Session) (fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rel, ok := s.RefreshTokens[tenantKey(ctx, signature)]
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
 	return rel, nil
 }
 
-func (s *MemoryStore) DeleteRefreshTokenSession(_ context.Context, signature string) error {
-	delete(s.RefreshTokens, signature)
+func (s *MemoryStore) DeleteRefreshTokenSession(ctx context.Context, signature string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.RefreshTokens, tenantKey(ctx, signature))
 	return nil
 }
 
-func (s *MemoryStore) CreateImplicitAccessTokenSession(_ context.Context, code string, req fosite.Requester) error {
-	s.Implicit[code] = req
+func (s *MemoryStore) CreateImplicitAccessTokenSession(ctx context.Context, code string, req fosite.Requester) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Implicit[tenantKey(ctx, code)] = req
 	return nil
 }
 
 func (s *MemoryStore) Authenticate(_ context.Context, name string, secret string) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	rel, ok := s.Users[name]
 	if !ok {
 		return fosite.ErrNotFound
@@ -237,16 +464,390 @@ func (s *MemoryStore) Authenticate(_ context.Context, name string, secret string
 }
 
 func (s *MemoryStore) RevokeRefreshToken(ctx context.Context, requestID string) error {
-	if signature, exists := s.RefreshTokenRequestIDs[requestID]; exists {
-		s.DeleteRefreshTokenSession(ctx, signature)
-		s.DeleteAccessTokenSession(ctx, signature)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if key, exists := s.RefreshTokenRequestIDs[tenantKey(ctx, requestID)]; exists {
+		delete(s.RefreshTokens, key)
+		delete(s.AccessTokens, key)
+		s.recordRevocationReason(ctx, requestID)
 	}
 	return nil
 }
 
 func (s *MemoryStore) RevokeAccessToken(ctx context.Context, requestID string) error {
-	if signature, exists := s.AccessTokenRequestIDs[requestID]; exists {
-		s.DeleteAccessTokenSession(ctx, signature)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if key, exists := s.AccessTokenRequestIDs[tenantKey(ctx, requestID)]; exists {
+		delete(s.AccessTokens, key)
+		s.recordRevocationReason(ctx, requestID)
+	}
+	return nil
+}
+
+func (s *MemoryStore) MarkRefreshTokenUsed(ctx context.Context, signature string, req fosite.Requester, usedAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.UsedRefreshTokens == nil {
+		s.UsedRefreshTokens = make(map[string]UsedRefreshToken)
+	}
+	key := tenantKey(ctx, signature)
+	if _, ok := s.UsedRefreshTokens[key]; !ok {
+		s.UsedRefreshTokens[key] = UsedRefreshToken{UsedAt: usedAt, Requester: req}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetUsedRefreshToken(ctx context.Context, signature string, _ fosite.Session) (fosite.Requester, time.Time, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	used, ok := s.UsedRefreshTokens[tenantKey(ctx, signature)]
+	if !ok {
+		return nil, time.Time{}, fosite.ErrNotFound
+	}
+	return used.Requester, used.UsedAt, nil
+}
+
+func (s *MemoryStore) RecordTokenLineage(_ context.Context, requestID string, entry fosite.TokenLineageEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.TokenLineages == nil {
+		s.TokenLineages = make(map[string][]fosite.TokenLineageEntry)
+	}
+	s.TokenLineages[requestID] = append(s.TokenLineages[requestID], entry)
+	return nil
+}
+
+func (s *MemoryStore) GetTokenLineage(_ context.Context, requestID string) ([]fosite.TokenLineageEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.TokenLineages[requestID], nil
+}
+
+func (s *MemoryStore) recordRevocationReason(ctx context.Context, requestID string) {
+	if s.RevocationReasons == nil {
+		s.RevocationReasons = make(map[string]fosite.RevocationReason)
+	}
+	s.RevocationReasons[requestID] = fosite.RevocationReasonFromContext(ctx)
+}
+
+func (s *MemoryStore) CreatePendingAuthorization(_ context.Context, p *fosite.PendingAuthorization) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.PendingAuthorizations == nil {
+		s.PendingAuthorizations = make(map[string]fosite.PendingAuthorization)
+	}
+	s.PendingAuthorizations[p.ID] = *p
+	return nil
+}
+
+func (s *MemoryStore) GetPendingAuthorization(_ context.Context, id string) (*fosite.PendingAuthorization, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	p, ok := s.PendingAuthorizations[id]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (s *MemoryStore) UpdatePendingAuthorization(_ context.Context, p *fosite.PendingAuthorization, from fosite.PendingAuthorizationStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if stored, ok := s.PendingAuthorizations[p.ID]; !ok {
+		return fosite.ErrNotFound
+	} else if stored.Status != from {
+		return fosite.ErrPendingAuthorizationChanged
+	}
+	s.PendingAuthorizations[p.ID] = *p
+	return nil
+}
+
+func (s *MemoryStore) CreateIdempotentResponse(_ context.Context, key string, response map[string]interface{}, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.IdempotentResponses == nil {
+		s.IdempotentResponses = make(map[string]IdempotentResponse)
+	}
+	s.IdempotentResponses[key] = IdempotentResponse{Response: response, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) GetIdempotentResponse(_ context.Context, key string) (map[string]interface{}, time.Time, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	r, ok := s.IdempotentResponses[key]
+	if !ok {
+		return nil, time.Time{}, fosite.ErrNotFound
+	}
+	return r.Response, r.ExpiresAt, nil
+}
+
+// UseJTI implements fosite.JTIStore. Expired JWT IDs are removed once the number of stored ones has doubled, so that
+// they are not retained beyond the expiry of their assertion for long.
+func (s *MemoryStore) UseJTI(_ context.Context, clientID, jti string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.UsedJTIs == nil {
+		s.UsedJTIs = make(map[string]time.Time)
+	}
+
+	now := fosite.Now(s.Clock)
+	key := clientID + "\x00" + jti
+	if exp, ok := s.UsedJTIs[key]; ok && now.Before(exp) {
+		return fosite.ErrJTIKnown
+	}
+	s.UsedJTIs[key] = expiresAt
+
+	if len(s.UsedJTIs) >= s.jtiPruneAt {
+		for key, exp := range s.UsedJTIs {
+			if !now.Before(exp) {
+				delete(s.UsedJTIs, key)
+			}
+		}
+		s.jtiPruneAt = 2 * len(s.UsedJTIs)
+		if s.jtiPruneAt < 1024 {
+			s.jtiPruneAt = 1024
+		}
+	}
+	return nil
+}
+
+// FlushInactiveTokens implements fosite.TokenFlusher.
+func (s *MemoryStore) FlushInactiveTokens(_ context.Context, before time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for code, rel := range s.AuthorizeCodes {
+		if expiredBefore(rel.Requester, fosite.AuthorizeCode, before) {
+			delete(s.AuthorizeCodes, code)
+		}
+	}
+	for code, req := range s.PKCES {
+		if expiredBefore(req, fosite.AuthorizeCode, before) {
+			delete(s.PKCES, code)
+		}
+	}
+	for code, req := range s.IDSessions {
+		if expiredBefore(req, fosite.AuthorizeCode, before) {
+			delete(s.IDSessions, code)
+		}
+	}
+	for signature, req := range s.AccessTokens {
+		if expiredBefore(req, fosite.AccessToken, before) {
+			delete(s.AccessTokens, signature)
+			if id := requestIDKey(signature, req.GetID()); s.AccessTokenRequestIDs[id] == signature {
+				delete(s.AccessTokenRequestIDs, id)
+			}
+		}
+	}
+	for signature, req := range s.Implicit {
+		if expiredBefore(req, fosite.AccessToken, before) {
+			delete(s.Implicit, signature)
+		}
+	}
+	for signature, req := range s.RefreshTokens {
+		if expiredBefore(req, fosite.RefreshToken, before) {
+			delete(s.RefreshTokens, signature)
+			if id := requestIDKey(signature, req.GetID()); s.RefreshTokenRequestIDs[id] == signature {
+				delete(s.RefreshTokenRequestIDs, id)
+			}
+		}
+	}
+	for signature, used := range s.UsedRefreshTokens {
+		if expiredBefore(used.Requester, fosite.RefreshToken, before) {
+			delete(s.UsedRefreshTokens, signature)
+		}
+	}
+	for key, r := range s.IdempotentResponses {
+		if r.ExpiresAt.Before(before) {
+			delete(s.IdempotentResponses, key)
+		}
+	}
+	for key, expiresAt := range s.UsedJTIs {
+		if expiresAt.Before(before) {
+			delete(s.UsedJTIs, key)
+		}
+	}
+	return nil
+}
+
+// expiredBefore returns true if the token of tokenType of r has an expiry time which is before the given time.
+func expiredBefore(r fosite.Requester, tokenType fosite.TokenType, before time.Time) bool {
+	if r == nil || r.GetSession() == nil {
+		return false
+	}
+	expiresAt := r.GetSession().GetExpiresAt(tokenType)
+	return !expiresAt.IsZero() && expiresAt.Before(before)
+}
+
+// GetGrantedScopes implements fosite.GrantStore.
+func (s *MemoryStore) GetGrantedScopes(_ context.Context, clientID, subject string) (fosite.Arguments, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.GrantedScopes[clientID+"\x00"+subject], nil
+}
+
+// AddGrantedScopes implements fosite.GrantStore.
+func (s *MemoryStore) AddGrantedScopes(_ context.Context, clientID, subject string, scopes fosite.Arguments) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.GrantedScopes == nil {
+		s.GrantedScopes = make(map[string]fosite.Arguments)
+	}
+
+	key := clientID + "\x00" + subject
+	granted := append(fosite.Arguments{}, s.GrantedScopes[key]...)
+	for _, scope := range scopes {
+		if !granted.Has(scope) {
+			granted = append(granted, scope)
+		}
+	}
+	s.GrantedScopes[key] = granted
+	return nil
+}
+
+// RevokeGrantedScopes implements fosite.GrantStore.
+func (s *MemoryStore) RevokeGrantedScopes(_ context.Context, clientID, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.GrantedScopes, clientID+"\x00"+subject)
+	return nil
+}
+
+// RevokeSubjectGrantedScopes implements fosite.GrantStore.
+func (s *MemoryStore) RevokeSubjectGrantedScopes(_ context.Context, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key := range s.GrantedScopes {
+		if strings.HasSuffix(key, "\x00"+subject) {
+			delete(s.GrantedScopes, key)
+		}
+	}
+	return nil
+}
+
+// SaveConsent implements fosite.ConsentStore.
+func (s *MemoryStore) SaveConsent(_ context.Context, consent *fosite.Consent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Consents == nil {
+		s.Consents = make(map[string]fosite.Consent)
+	}
+	s.Consents[consent.ClientID+"\x00"+consent.Subject] = *consent
+	return nil
+}
+
+// GetConsent implements fosite.ConsentStore.
+func (s *MemoryStore) GetConsent(_ context.Context, clientID, subject string) (*fosite.Consent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	consent, ok := s.Consents[clientID+"\x00"+subject]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	return &consent, nil
+}
+
+// RevokeConsent implements fosite.ConsentStore.
+func (s *MemoryStore) RevokeConsent(_ context.Context, clientID, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.Consents, clientID+"\x00"+subject)
+	return nil
+}
+
+// RevokeSubjectConsents implements fosite.ConsentStore.
+func (s *MemoryStore) RevokeSubjectConsents(_ context.Context, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, consent := range s.Consents {
+		if consent.Subject == subject {
+			delete(s.Consents, key)
+		}
+	}
+	return nil
+}
+
+// GetClientRequestIDs implements fosite.ClientRevocationStorage.
+func (s *MemoryStore) GetClientRequestIDs(ctx context.Context, clientID string) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var requestIDs []string
+	seen := map[string]bool{}
+	for _, tokens := range []map[string]fosite.Requester{s.AccessTokens, s.RefreshTokens} {
+		for key, r := range tokens {
+			if !inTenant(ctx, key) || r.GetClient() == nil || r.GetClient().GetID() != clientID {
+				continue
+			} else if !seen[r.GetID()] {
+				seen[r.GetID()] = true
+				requestIDs = append(requestIDs, r.GetID())
+			}
+		}
+	}
+	return requestIDs, nil
+}
+
+// GetSubjectRequests implements fosite.SubjectRevocationStorage.
+func (s *MemoryStore) GetSubjectRequests(ctx context.Context, subject, clientID string) ([]fosite.Requester, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var requests []fosite.Requester
+	seen := map[string]bool{}
+	add := func(key string, r fosite.Requester) {
+		if !inTenant(ctx, key) || r.GetSession() == nil || r.GetSession().GetSubject() != subject {
+			return
+		} else if clientID != "" && r.GetClient().GetID() != clientID {
+			return
+		} else if !seen[r.GetID()] {
+			seen[r.GetID()] = true
+			requests = append(requests, r)
+		}
+	}
+	for _, tokens := range []map[string]fosite.Requester{s.AccessTokens, s.RefreshTokens} {
+		for key, r := range tokens {
+			add(key, r)
+		}
+	}
+	for key, rel := range s.AuthorizeCodes {
+		if rel.active {
+			add(key, rel.Requester)
+		}
+	}
+	return requests, nil
+}
+
+// RevokeAuthorizeCodes implements fosite.SubjectRevocationStorage.
+func (s *MemoryStore) RevokeAuthorizeCodes(ctx context.Context, requestID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, rel := range s.AuthorizeCodes {
+		if inTenant(ctx, key) && rel.active && rel.GetID() == requestID {
+			rel.active = false
+			s.AuthorizeCodes[key] = rel
+		}
 	}
 	return nil
 }
@@ -0,0 +1,77 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package storage
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+)
+
+// Transactional is an optional interface of storage implementations which can group several writes into one
+// transaction. The transaction is carried by the context returned from BeginTX, which is then passed to all storage
+// calls belonging to it.
+type Transactional interface {
+	// BeginTX starts a transaction and returns a context carrying it.
+	BeginTX(ctx context.Context) (context.Context, error)
+
+	// Commit commits the transaction carried by ctx.
+	Commit(ctx context.Context) error
+
+	// Rollback aborts the transaction carried by ctx.
+	Rollback(ctx context.Context) error
+}
+
+// RunInTransaction calls fn in a transaction if store implements Transactional, and without one otherwise. The
+// transaction is committed if fn succeeds and rolled back if it fails or panics, so that no partial writes remain and
+// no transaction is left open.
+func RunInTransaction(ctx context.Context, store interface{}, fn func(ctx context.Context) error) error {
+	tx, ok := store.(Transactional)
+	if !ok {
+		return fn(ctx)
+	}
+
+	ctx, err := tx.BeginTX(ctx)
+	if err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			tx.Rollback(ctx)
+			panic(v)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if rerr := tx.Rollback(ctx); rerr != nil {
+			return errors.WithStack(fosite.ErrServerError.WithDebugf("%s: rolling back the transaction failed: %s", err, rerr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.WithStack(fosite.ErrServerError.WithDebug(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Tenant is one of several authorization servers served by the same Fosite instance. The tenant of a request is
+// carried by its context, see ContextWithTenant, so that handlers, strategies and storages can select the signing
+// keys, clients and configuration of the tenant. Storages should keep the data of tenants apart by the tenant of the
+// context they are called with.
+type Tenant struct {
+	// ID identifies the tenant, for example in storage keys.
+	ID string
+
+	// Issuer, if set, is the issuer of the tokens of the tenant.
+	Issuer string
+
+	// TokenURL, if set, is the URL of the token endpoint of the tenant and overrides Fosite.TokenURL.
+	TokenURL string
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a context carrying tenant, see TenantFromContext.
+func ContextWithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of the context, or nil if it carries none.
+func TenantFromContext(ctx context.Context) *Tenant {
+	if ctx == nil {
+		return nil
+	}
+	tenant, _ := ctx.Value(tenantContextKey{}).(*Tenant)
+	return tenant
+}
+
+type unknownTenantContextKey struct{}
+
+// unknownTenantContext returns the context of methods which are not passed the context of the request. If
+// TenantResolver is set, the tenant of the request is unknown, see isUnknownTenant.
+func (f *Fosite) unknownTenantContext() context.Context {
+	ctx := context.Background()
+	if f.TenantResolver != nil {
+		ctx = context.WithValue(ctx, unknownTenantContextKey{}, true)
+	}
+	return ctx
+}
+
+// isUnknownTenant returns true if ctx belongs to a request whose tenant is unknown.
+func isUnknownTenant(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	unknown, _ := ctx.Value(unknownTenantContextKey{}).(bool)
+	return unknown
+}
+
+// TenantResolver determines the tenant of an HTTP request.
+type TenantResolver interface {
+	// ResolveTenant returns the tenant of r, or nil if r does not belong to a tenant.
+	ResolveTenant(r *http.Request) (*Tenant, error)
+}
+
+// TenantResolverFunc adapts a function to the TenantResolver interface.
+type TenantResolverFunc func(r *http.Request) (*Tenant, error)
+
+func (f TenantResolverFunc) ResolveTenant(r *http.Request) (*Tenant, error) {
+	return f(r)
+}
+
+// HostTenantResolver resolves the tenant of a request by its Host header, ignoring the port. Requests to unknown
+// hosts are rejected with ErrNotFound.
+type HostTenantResolver map[string]*Tenant
+
+func (h HostTenantResolver) ResolveTenant(r *http.Request) (*Tenant, error) {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if tenant, ok := h[strings.ToLower(host)]; ok {
+		return tenant, nil
+	}
+	return nil, errors.WithStack(ErrNotFound)
+}
+
+// TenantClientManager is implemented by client managers which keep the clients of each tenant apart. Clients of
+// requests carrying a tenant are loaded through it if the client manager implements it.
+type TenantClientManager interface {
+	// GetTenantClient loads the client of the tenant by its ID.
+	GetTenantClient(ctx context.Context, tenantID string, id string) (Client, error)
+}
+
+// TenantHandler resolves the tenant of every request using TenantResolver and passes it to next through the context
+// of the request. Requests whose tenant can not be resolved are answered with HTTP 404 Not Found, all requests are
+// answered with ErrMisconfiguration if TenantResolver is not set.
+func (f *Fosite) TenantHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if f.TenantResolver == nil {
+			f.writeJsonError(rw, ErrMisconfiguration.WithDebug("TenantHandler requires a TenantResolver."))
+			return
+		}
+
+		tenant, err := f.TenantResolver.ResolveTenant(r)
+		if err != nil {
+			f.writeJsonError(rw, ErrNotFound.WithHint("The requested tenant does not exist.").WithDebug(err.Error()))
+			return
+		} else if tenant != nil {
+			r = r.WithContext(ContextWithTenant(r.Context(), tenant))
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// tokenURL returns the URL of the token endpoint of the tenant of ctx, TokenURL or the token endpoint of
+// IssuerConfig.
+func (f *Fosite) tokenURL(ctx context.Context) string {
+	if tenant := TenantFromContext(ctx); tenant != nil && tenant.TokenURL != "" {
+		return tenant.TokenURL
+	} else if f.TokenURL == "" && f.IssuerConfig != nil {
+		return f.IssuerConfig.TokenEndpoint
+	}
+	return f.TokenURL
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+)
+
+func TestTenantHandler(t *testing.T) {
+	acme := &Tenant{ID: "acme", Issuer: "https://acme.example.com"}
+	f := &Fosite{TenantResolver: HostTenantResolver{"acme.example.com": acme}}
+
+	var resolved *Tenant
+	h := f.TenantHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		resolved = TenantFromContext(r.Context())
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "https://ACME.example.com:8443/oauth2/auth", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, acme, resolved)
+
+	resolved = nil
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "https://evil.example.com/oauth2/auth", nil))
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Nil(t, resolved)
+
+	assert.Nil(t, TenantFromContext(nil))
+	assert.Nil(t, TenantFromContext(context.Background()))
+
+	// Without a resolver, requests are answered with an error instead of panicking.
+	rw = httptest.NewRecorder()
+	(&Fosite{}).TenantHandler(h).ServeHTTP(rw, httptest.NewRequest("GET", "https://acme.example.com/oauth2/auth", nil))
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+}
+
+func TestTenantTokenIsolation(t *testing.T) {
+	store := storage.NewMemoryStore()
+	acme := ContextWithTenant(context.Background(), &Tenant{ID: "acme"})
+	other := ContextWithTenant(context.Background(), &Tenant{ID: "other"})
+
+	r := &Request{ID: "request", Client: &DefaultClient{ID: "foo"}, Session: &DefaultSession{Subject: "peter"}}
+	require.NoError(t, store.CreateAccessTokenSession(acme, "at", r))
+	require.NoError(t, store.CreateRefreshTokenSession(acme, "rt", r))
+	require.NoError(t, store.CreateAuthorizeCodeSession(acme, "code", r))
+
+	// The tokens and codes of a tenant are not visible to other tenants, nor to requests without a tenant.
+	for _, ctx := range []context.Context{other, context.Background()} {
+		_, err := store.GetAccessTokenSession(ctx, "at", nil)
+		assert.EqualError(t, err, ErrNotFound.Error())
+		_, err = store.GetRefreshTokenSession(ctx, "rt", nil)
+		assert.EqualError(t, err, ErrNotFound.Error())
+		_, err = store.GetAuthorizeCodeSession(ctx, "code", nil)
+		assert.EqualError(t, err, ErrNotFound.Error())
+
+		ids, err := store.GetClientRequestIDs(ctx, "foo")
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		require.NoError(t, store.RevokeAccessToken(ctx, "request"))
+		require.NoError(t, store.RevokeRefreshToken(ctx, "request"))
+	}
+
+	_, err := store.GetAccessTokenSession(acme, "at", nil)
+	require.NoError(t, err)
+	_, err = store.GetRefreshTokenSession(acme, "rt", nil)
+	require.NoError(t, err)
+	requests, err := store.GetSubjectRequests(acme, "peter", "")
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "request", requests[0].GetID())
+
+	require.NoError(t, store.RevokeAccessToken(acme, "request"))
+	_, err = store.GetAccessTokenSession(acme, "at", nil)
+	assert.EqualError(t, err, ErrNotFound.Error())
+
+	// Flushing expired tokens of a tenant also removes their request IDs.
+	expired := &Request{ID: "expired", Session: &DefaultSession{ExpiresAt: map[TokenType]time.Time{AccessToken: time.Now().Add(-time.Hour)}}}
+	require.NoError(t, store.CreateAccessTokenSession(acme, "expired", expired))
+	before := len(store.AccessTokenRequestIDs)
+	require.NoError(t, store.FlushInactiveTokens(acme, time.Now()))
+	assert.Len(t, store.AccessTokenRequestIDs, before-1)
+}
+
+func TestTenantClients(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+	store.TenantClients["acme"] = map[string]Client{"bar": &DefaultClient{ID: "bar", Public: true}}
+	f := &Fosite{Store: store}
+
+	acme := ContextWithTenant(context.Background(), &Tenant{ID: "acme"})
+	other := ContextWithTenant(context.Background(), &Tenant{ID: "other"})
+	form := func(id string) url.Values { return url.Values{"client_id": {id}} }
+
+	// Clients of a tenant are not visible to others.
+	_, err := f.AuthenticateClient(acme, new(http.Request), form("bar"))
+	require.NoError(t, err)
+	_, err = f.AuthenticateClient(acme, new(http.Request), form("foo"))
+	assert.Error(t, err)
+	_, err = f.AuthenticateClient(context.Background(), new(http.Request), form("bar"))
+	assert.Error(t, err)
+
+	// Tenants without clients of their own share the default clients.
+	_, err = f.AuthenticateClient(other, new(http.Request), form("foo"))
+	require.NoError(t, err)
+
+	// The client cache keeps the clients of tenants apart.
+	f.ClientManager = storage.NewClientCache(store, 10, time.Minute)
+	_, err = f.AuthenticateClient(acme, new(http.Request), form("bar"))
+	require.NoError(t, err)
+	_, err = f.AuthenticateClient(other, new(http.Request), form("bar"))
+	assert.Error(t, err)
+}
+
+func TestTenantTokenURL(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	store := storage.NewMemoryStore()
+	store.Clients["bar"] = &DefaultOpenIDConnectClient{
+		DefaultClient:           &DefaultClient{ID: "bar"},
+		JSONWebKeys:             &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+	f := &Fosite{Store: store, TokenURL: "token-url"}
+	acme := ContextWithTenant(context.Background(), &Tenant{ID: "acme", TokenURL: "acme-token-url"})
+
+	assertion := func(aud string) url.Values {
+		claims := jwt.MapClaims{"sub": "bar", "iss": "bar", "jti": "12345", "aud": aud, "exp": time.Now().Add(time.Hour).Unix()}
+		return url.Values{"client_assertion": {mustGenerateAssertion(t, claims, key, "kid-foo")}, "client_assertion_type": {at}}
+	}
+
+	_, err := f.AuthenticateClient(acme, new(http.Request), assertion("acme-token-url"))
+	require.NoError(t, err)
+	_, err = f.AuthenticateClient(acme, new(http.Request), assertion("token-url"))
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+	_, err = f.AuthenticateClient(context.Background(), new(http.Request), assertion("token-url"))
+	require.NoError(t, err)
+
+	// Without a token URL, the token endpoint of the issuer configuration is used.
+	f = &Fosite{Store: store, IssuerConfig: &IssuerConfig{TokenEndpoint: "token-url"}}
+	_, err = f.AuthenticateClient(context.Background(), new(http.Request), assertion("token-url"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ThrottlingKind identifies the kind of credentials being checked.
+type ThrottlingKind string
+
+const (
+	// ThrottleClientAuthentication is used when the credentials of a client are checked.
+	ThrottleClientAuthentication ThrottlingKind = "client_authentication"
+
+	// ThrottleResourceOwnerPassword is used when the username and password of a resource owner are checked.
+	ThrottleResourceOwnerPassword ThrottlingKind = "resource_owner_password"
+)
+
+// ThrottlingKey identifies the attempts a ThrottlingStrategy decides on.
+type ThrottlingKey struct {
+	Kind ThrottlingKind
+
+	// ClientID is the ID of the client, as presented by the client.
+	ClientID string
+
+	// RemoteIP is the IP address of the caller, taken from http.Request.RemoteAddr. If the authorization server is
+	// behind a proxy, RemoteAddr has to be rewritten by a middleware.
+	RemoteIP string
+
+	// Username is the username of the resource owner, it is only set for ThrottleResourceOwnerPassword.
+	Username string
+}
+
+// ThrottlingStrategy slows down or blocks brute-force attempts on client and resource owner credentials.
+type ThrottlingStrategy interface {
+	// Allow is consulted before the credentials are checked. It may delay the attempt, or return an error, usually
+	// ErrTooManyRequests, to reject it.
+	Allow(ctx context.Context, key ThrottlingKey) error
+
+	// Record is called after the credentials were checked. success is false if they were invalid.
+	Record(ctx context.Context, key ThrottlingKey, success bool)
+}
+
+type remoteIPContextKey struct{}
+
+// ContextWithRemoteIP returns a context carrying the IP address of the caller, see RemoteIPFromContext.
+func ContextWithRemoteIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, remoteIPContextKey{}, ip)
+}
+
+// RemoteIPFromContext returns the IP address of the caller of the token endpoint, which NewAccessRequest passes to
+// the token endpoint handlers, or an empty string.
+func RemoteIPFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ip, _ := ctx.Value(remoteIPContextKey{}).(string)
+	return ip
+}
+
+// RemoteIP returns the IP address of the caller of r.
+func RemoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// presentedClientID returns the client ID as presented in the HTTP Authorization header or the form, without
+// authenticating it.
+func presentedClientID(r *http.Request, form url.Values) string {
+	if id, _, ok := r.BasicAuth(); ok {
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			return unescaped
+		}
+		return id
+	}
+	return form.Get("client_id")
+}
+
+// MemoryThrottlingStrategy is a ThrottlingStrategy which blocks a key with ErrTooManyRequests once MaxFailures
+// failed attempts were made within Window of each other, until Window has passed since the last failed attempt.
+// A successful attempt resets the key. Failed attempts are also counted per kind and IP address across all clients
+// and usernames, so that trying many of them from one IP address is blocked as well. The state is kept in memory, so
+// it is not shared between instances.
+type MemoryThrottlingStrategy struct {
+	// MaxFailures is the number of failed attempts after which a key is blocked. Defaults to five.
+	MaxFailures int
+
+	// MaxFailuresPerIP is the number of failed attempts from one IP address, across all clients and usernames, after
+	// which the IP address is blocked. Defaults to 20.
+	MaxFailuresPerIP int
+
+	// MaxKeys is the number of keys kept in memory. Once it is reached, expired keys are removed and, if that does
+	// not suffice, the key whose last failed attempt is the oldest. Defaults to 10000.
+	MaxKeys int
+
+	// Window is the time after which failed attempts are forgotten. Defaults to one minute.
+	Window time.Duration
+
+	// Clock provides the current time. Defaults to SystemClock.
+	Clock Clock
+
+	sync.Mutex
+	failures map[ThrottlingKey]*throttlingFailures
+}
+
+type throttlingFailures struct {
+	count int
+	last  time.Time
+}
+
+func (m *MemoryThrottlingStrategy) Allow(_ context.Context, key ThrottlingKey) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := Now(m.Clock)
+	if err := m.allow(key, m.maxFailures(), now); err != nil {
+		return err
+	} else if ipKey, ok := throttlingIPKey(key); ok {
+		return m.allow(ipKey, m.maxFailuresPerIP(), now)
+	}
+	return nil
+}
+
+func (m *MemoryThrottlingStrategy) Record(_ context.Context, key ThrottlingKey, success bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	// A successful attempt does not reset the IP address, or valid credentials could be used to keep guessing others.
+	if success {
+		delete(m.failures, key)
+		return
+	}
+
+	now := Now(m.Clock)
+	m.fail(key, now)
+	if ipKey, ok := throttlingIPKey(key); ok {
+		m.fail(ipKey, now)
+	}
+}
+
+func (m *MemoryThrottlingStrategy) allow(key ThrottlingKey, maxFailures int, now time.Time) error {
+	f, ok := m.failures[key]
+	if !ok {
+		return nil
+	} else if now.Sub(f.last) >= m.window() {
+		delete(m.failures, key)
+		return nil
+	} else if f.count >= maxFailures {
+		return errors.WithStack(ErrTooManyRequests.WithHintf("Try again after \"%s\".", f.last.Add(m.window())))
+	}
+	return nil
+}
+
+func (m *MemoryThrottlingStrategy) fail(key ThrottlingKey, now time.Time) {
+	if m.failures == nil {
+		m.failures = map[ThrottlingKey]*throttlingFailures{}
+	}
+
+	f, ok := m.failures[key]
+	if !ok || now.Sub(f.last) >= m.window() {
+		if !ok && len(m.failures) >= m.maxKeys() {
+			m.prune(now)
+		}
+		f = &throttlingFailures{}
+		m.failures[key] = f
+	}
+	f.count++
+	f.last = now
+}
+
+// prune removes the expired keys and, if there are still too many, the key whose last failed attempt is the oldest.
+func (m *MemoryThrottlingStrategy) prune(now time.Time) {
+	var oldest *ThrottlingKey
+	var oldestAt time.Time
+	for key, f := range m.failures {
+		if now.Sub(f.last) >= m.window() {
+			delete(m.failures, key)
+		} else if oldest == nil || f.last.Before(oldestAt) {
+			key := key
+			oldest, oldestAt = &key, f.last
+		}
+	}
+	if oldest != nil && len(m.failures) >= m.maxKeys() {
+		delete(m.failures, *oldest)
+	}
+}
+
+// throttlingIPKey returns the key counting the failed attempts of the kind of key from its IP address, if key names
+// an IP address and a client or username.
+func throttlingIPKey(key ThrottlingKey) (ThrottlingKey, bool) {
+	if key.RemoteIP == "" || (key.ClientID == "" && key.Username == "") {
+		return ThrottlingKey{}, false
+	}
+	return ThrottlingKey{Kind: key.Kind, RemoteIP: key.RemoteIP}, true
+}
+
+func (m *MemoryThrottlingStrategy) maxFailures() int {
+	if m.MaxFailures == 0 {
+		return 5
+	}
+	return m.MaxFailures
+}
+
+func (m *MemoryThrottlingStrategy) maxFailuresPerIP() int {
+	if m.MaxFailuresPerIP == 0 {
+		return 20
+	}
+	return m.MaxFailuresPerIP
+}
+
+func (m *MemoryThrottlingStrategy) maxKeys() int {
+	if m.MaxKeys == 0 {
+		return 10000
+	}
+	return m.MaxKeys
+}
+
+func (m *MemoryThrottlingStrategy) window() time.Duration {
+	if m.Window == 0 {
+		return time.Minute
+	}
+	return m.Window
+}
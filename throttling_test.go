@@ -0,0 +1,149 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryThrottlingStrategy(t *testing.T) {
+	now := time.Now().UTC()
+	s := &MemoryThrottlingStrategy{
+		MaxFailures: 2,
+		Window:      time.Minute,
+		Clock:       ClockFunc(func() time.Time { return now }),
+	}
+	ctx := context.Background()
+	key := ThrottlingKey{Kind: ThrottleClientAuthentication, ClientID: "foo", RemoteIP: "10.0.0.1"}
+	other := ThrottlingKey{Kind: ThrottleClientAuthentication, ClientID: "foo", RemoteIP: "10.0.0.2"}
+
+	s.Record(ctx, key, false)
+	assert.NoError(t, s.Allow(ctx, key))
+	s.Record(ctx, key, false)
+	err := s.Allow(ctx, key)
+	require.Error(t, err)
+	assert.Equal(t, ErrTooManyRequests.Name, ErrorToRFC6749Error(err).Name)
+	assert.NoError(t, s.Allow(ctx, other))
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, s.Allow(ctx, key))
+
+	s.Record(ctx, key, false)
+	s.Record(ctx, key, true)
+	s.Record(ctx, key, false)
+	assert.NoError(t, s.Allow(ctx, key))
+}
+
+func TestMemoryThrottlingStrategyPerIP(t *testing.T) {
+	s := &MemoryThrottlingStrategy{MaxFailures: 2, MaxFailuresPerIP: 3}
+	ctx := context.Background()
+	key := func(clientID, ip string) ThrottlingKey {
+		return ThrottlingKey{Kind: ThrottleClientAuthentication, ClientID: clientID, RemoteIP: ip}
+	}
+
+	for _, clientID := range []string{"foo", "bar", "baz"} {
+		assert.NoError(t, s.Allow(ctx, key(clientID, "10.0.0.1")))
+		s.Record(ctx, key(clientID, "10.0.0.1"), false)
+	}
+	s.Record(ctx, key("foo", "10.0.0.1"), true)
+
+	err := s.Allow(ctx, key("qux", "10.0.0.1"))
+	require.Error(t, err, "guessing the secrets of many clients from one IP address is blocked")
+	assert.Equal(t, ErrTooManyRequests.Name, ErrorToRFC6749Error(err).Name)
+	assert.NoError(t, s.Allow(ctx, key("qux", "10.0.0.2")))
+}
+
+func TestMemoryThrottlingStrategyMaxKeys(t *testing.T) {
+	now := time.Now().UTC()
+	s := &MemoryThrottlingStrategy{
+		MaxFailures: 1,
+		MaxKeys:     2,
+		Window:      time.Minute,
+		Clock:       ClockFunc(func() time.Time { return now }),
+	}
+	ctx := context.Background()
+	key := func(clientID string) ThrottlingKey {
+		return ThrottlingKey{Kind: ThrottleClientAuthentication, ClientID: clientID}
+	}
+
+	s.Record(ctx, key("foo"), false)
+	now = now.Add(time.Second)
+	s.Record(ctx, key("bar"), false)
+	now = now.Add(time.Second)
+	s.Record(ctx, key("baz"), false)
+
+	assert.NoError(t, s.Allow(ctx, key("foo")), "the oldest key is evicted once MaxKeys is reached")
+	assert.Error(t, s.Allow(ctx, key("bar")))
+	assert.Error(t, s.Allow(ctx, key("baz")))
+
+	now = now.Add(time.Minute)
+	s.Record(ctx, key("qux"), false)
+	s.Record(ctx, key("quux"), false)
+	assert.Error(t, s.Allow(ctx, key("qux")), "expired keys are removed before others are evicted")
+	assert.Error(t, s.Allow(ctx, key("quux")))
+}
+
+func TestAuthenticateClientWithThrottling(t *testing.T) {
+	hasher := &BCrypt{WorkFactor: 4}
+	secret, err := hasher.Hash([]byte("secret"))
+	require.NoError(t, err)
+
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Secret: secret}
+	f := &Fosite{
+		Store:              store,
+		Hasher:             hasher,
+		ThrottlingStrategy: &MemoryThrottlingStrategy{MaxFailures: 2},
+	}
+
+	authenticate := func(secret, remoteAddr string) error {
+		r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = remoteAddr
+		r.SetBasicAuth("foo", secret)
+		require.NoError(t, r.ParseForm())
+		_, err := f.AuthenticateClient(context.Background(), r, r.PostForm)
+		return err
+	}
+
+	assert.NoError(t, authenticate("secret", "10.0.0.1:1234"))
+	for i := 0; i < 2; i++ {
+		err := authenticate("wrong", "10.0.0.1:1234")
+		require.Error(t, err)
+		assert.Equal(t, ErrInvalidClient.Name, ErrorToRFC6749Error(err).Name)
+	}
+
+	err = authenticate("secret", "10.0.0.1:4321")
+	require.Error(t, err)
+	assert.Equal(t, ErrTooManyRequests.Name, ErrorToRFC6749Error(err).Name)
+	assert.NoError(t, authenticate("secret", "10.0.0.2:1234"))
+}
@@ -25,8 +25,10 @@
 package hmac
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 
@@ -37,11 +39,27 @@ import (
 
 // HMACStrategy is responsible for generating and validating challenges.
 type HMACStrategy struct {
+	// AuthCodeEntropy is the number of random bytes of a token, which is eight times its entropy in bits. Values
+	// below 32 (256 bits) are raised to 32.
 	AuthCodeEntropy int
 	GlobalSecret    []byte
+
+	// RandomSource provides the random bytes of tokens. Defaults to crypto/rand.Reader.
+	RandomSource io.Reader
+
+	// Encoding encodes the random bytes and the signature of tokens, for example base64.NewEncoding with a custom
+	// alphabet. The alphabet must not contain ".", which separates both parts. Defaults to unpadded base64url.
+	Encoding Encoding
+
 	sync.Mutex
 }
 
+// Encoding encodes tokens to strings and back. It is implemented by *base64.Encoding and *base32.Encoding.
+type Encoding interface {
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
 const (
 	// key should be at least 256 bit long, making it
 	minimumEntropy = 32
@@ -76,15 +94,15 @@ func (c *HMACStrategy) Generate() (string, string, error) {
 	// constructed from a cryptographically strong random or pseudo-random
 	// number sequence (see [RFC4086] for best current practice) generated
 	// by the authorization server.
-	tokenKey, err := RandomBytes(c.AuthCodeEntropy)
-	if err != nil {
+	tokenKey := make([]byte, c.AuthCodeEntropy)
+	if _, err := io.ReadFull(c.randomSource(), tokenKey); err != nil {
 		return "", "", errors.WithStack(err)
 	}
 
 	signature := cryptopasta.GenerateHMAC(tokenKey, &signingKey)
 
-	encodedSignature := b64.EncodeToString(signature)
-	encodedToken := fmt.Sprintf("%s.%s", b64.EncodeToString(tokenKey), encodedSignature)
+	encodedSignature := c.encoding().EncodeToString(signature)
+	encodedToken := fmt.Sprintf("%s.%s", c.encoding().EncodeToString(tokenKey), encodedSignature)
 	return encodedToken, encodedSignature, nil
 }
 
@@ -108,12 +126,12 @@ func (c *HMACStrategy) Validate(token string) error {
 		return errors.WithStack(fosite.ErrInvalidTokenFormat)
 	}
 
-	decodedTokenSignature, err := b64.DecodeString(tokenSignature)
+	decodedTokenSignature, err := c.encoding().DecodeString(tokenSignature)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	decodedTokenKey, err := b64.DecodeString(tokenKey)
+	decodedTokenKey, err := c.encoding().DecodeString(tokenKey)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -135,3 +153,17 @@ func (c *HMACStrategy) Signature(token string) string {
 
 	return split[1]
 }
+
+func (c *HMACStrategy) randomSource() io.Reader {
+	if c.RandomSource == nil {
+		return rand.Reader
+	}
+	return c.RandomSource
+}
+
+func (c *HMACStrategy) encoding() Encoding {
+	if c.Encoding == nil {
+		return b64
+	}
+	return c.Encoding
+}
@@ -22,6 +22,9 @@
 package hmac
 
 import (
+	"bytes"
+	"encoding/base32"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -75,3 +78,25 @@ func TestValidateSignatureRejects(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestGenerateWithRandomSourceAndEncoding(t *testing.T) {
+	cg := HMACStrategy{
+		GlobalSecret:    []byte("1234567890123456789012345678901234567890"),
+		AuthCodeEntropy: 40,
+		RandomSource:    bytes.NewReader(bytes.Repeat([]byte{0xff}, 40)),
+		Encoding:        base32.StdEncoding.WithPadding(base32.NoPadding),
+	}
+
+	token, signature, err := cg.Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, strings.Repeat("7", 64)+"."), "%s", token)
+	assert.Equal(t, signature, cg.Signature(token))
+	assert.NoError(t, cg.Validate(token))
+
+	// The random source is exhausted.
+	_, _, err = cg.Generate()
+	assert.Error(t, err)
+
+	cg.Encoding = nil
+	assert.Error(t, cg.Validate(token))
+}
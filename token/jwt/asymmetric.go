@@ -0,0 +1,338 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+)
+
+// The JSON Web Signature algorithms supported by AsymmetricJWTStrategy.
+const (
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	EdDSA = "EdDSA"
+)
+
+type signingAlgorithm struct {
+	hash crypto.Hash
+	pss  bool
+
+	// ecKeySize is the size of the R and S values of ECDSA signatures.
+	ecKeySize int
+}
+
+var signingAlgorithms = map[string]signingAlgorithm{
+	RS256: {hash: crypto.SHA256},
+	RS384: {hash: crypto.SHA384},
+	RS512: {hash: crypto.SHA512},
+	PS256: {hash: crypto.SHA256, pss: true},
+	PS384: {hash: crypto.SHA384, pss: true},
+	PS512: {hash: crypto.SHA512, pss: true},
+	ES256: {hash: crypto.SHA256, ecKeySize: 32},
+	ES384: {hash: crypto.SHA384, ecKeySize: 48},
+	ES512: {hash: crypto.SHA512, ecKeySize: 66},
+	// Ed25519 signs the message itself, the hash is used for token hashes such as at_hash.
+	EdDSA: {hash: crypto.SHA512},
+}
+
+// IsSupportedSigningAlgorithm returns true if AsymmetricJWTStrategy supports the JSON Web Signature algorithm.
+func IsSupportedSigningAlgorithm(alg string) bool {
+	_, ok := signingAlgorithms[alg]
+	return ok
+}
+
+// AsymmetricJWTStrategy generates and validates tokens signed with one of the asymmetric JSON Web Signature
+// algorithms RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512 or EdDSA (Ed25519). Tokens signed with
+// any other algorithm are rejected.
+type AsymmetricJWTStrategy struct {
+	// Algorithm is the JSON Web Signature algorithm, for example ES256.
+	Algorithm string
+
+	// Signer signs the tokens. It is an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey matching the
+	// algorithm, or a signer of such a key which is kept elsewhere, see RemoteSigner.
+	Signer crypto.Signer
+
+	// KeyProvider, if set, resolves the keys instead of Signer whenever a token is signed or verified, see
+	// KeyProvider.
+	KeyProvider KeyProvider
+
+	// Clock provides the current time when validating tokens. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ClockSkew is the leeway applied when validating the "exp", "nbf" and "iat" claims.
+	ClockSkew time.Duration
+}
+
+// NewAsymmetricJWTStrategy returns a strategy which signs tokens with alg using signer. It fails if the algorithm is
+// not supported or does not match the key of signer.
+func NewAsymmetricJWTStrategy(alg string, signer crypto.Signer) (*AsymmetricJWTStrategy, error) {
+	if err := checkSigningKey(alg, signer.Public()); err != nil {
+		return nil, err
+	}
+	return &AsymmetricJWTStrategy{Algorithm: alg, Signer: signer}, nil
+}
+
+func checkSigningKey(alg string, key crypto.PublicKey) error {
+	var ok bool
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		ok = strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		ok = signingAlgorithms[alg].ecKeySize == (k.Curve.Params().BitSize+7)/8
+	case ed25519.PublicKey:
+		ok = alg == EdDSA
+	}
+	if !IsSupportedSigningAlgorithm(alg) {
+		return errors.Errorf("Signing algorithm \"%s\" is not supported.", alg)
+	} else if !ok {
+		return errors.Errorf("A key of type %T can not be used with signing algorithm \"%s\".", key, alg)
+	}
+	return nil
+}
+
+// Generate generates a new token.
+func (j *AsymmetricJWTStrategy) Generate(claims jwt.Claims, header Mapper) (string, string, error) {
+	return j.GenerateWithContext(context.Background(), claims, header)
+}
+
+// GenerateWithContext implements ContextGenerator.
+func (j *AsymmetricJWTStrategy) GenerateWithContext(ctx context.Context, claims jwt.Claims, header Mapper) (string, string, error) {
+	if header == nil || claims == nil {
+		return "", "", errors.New("Either claims or header is nil.")
+	}
+
+	method := jwt.GetSigningMethod(j.Algorithm)
+	if method == nil || !IsSupportedSigningAlgorithm(j.Algorithm) {
+		return "", "", errors.Errorf("Signing algorithm \"%s\" is not supported.", j.Algorithm)
+	}
+
+	kid, signer, err := j.signingKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header = assign(token.Header, header.ToMap())
+	if _, ok := token.Header["kid"]; !ok && kid != "" {
+		token.Header["kid"] = kid
+	}
+	if typ := headerType(header); typ != "" {
+		token.Header["typ"] = typ
+	}
+
+	sstr, err := token.SigningString()
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	sig, err := signJWS(ctx, j.Algorithm, signer, sstr)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	return fmt.Sprintf("%s.%s", sstr, sig), sig, nil
+}
+
+func (j *AsymmetricJWTStrategy) signingKey() (string, crypto.Signer, error) {
+	if j.KeyProvider != nil {
+		return j.KeyProvider.SigningKey()
+	}
+	return signerKeyID(j.Signer), j.Signer, nil
+}
+
+// Validate validates a token and returns its signature or an error if the token is not valid.
+func (j *AsymmetricJWTStrategy) Validate(token string) (string, error) {
+	if _, err := j.Decode(token); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return j.GetSignature(token)
+}
+
+// Decode decodes a token and verifies its signature and its "exp", "nbf" and "iat" claims.
+func (j *AsymmetricJWTStrategy) Decode(token string) (*jwt.Token, error) {
+	parsedToken, err := j.DecodeIgnoringTimeClaims(token)
+	if err != nil {
+		return nil, err
+	} else if err := ValidateTimeClaims(parsedToken.Claims, fosite.Now(j.Clock), j.ClockSkew); err != nil {
+		return nil, errors.WithStack(err)
+	} else if !parsedToken.Valid {
+		return nil, errors.WithStack(fosite.ErrInactiveToken)
+	}
+	return parsedToken, nil
+}
+
+// DecodeIgnoringTimeClaims decodes a token and verifies its signature, but not its "exp", "nbf" and "iat" claims.
+func (j *AsymmetricJWTStrategy) DecodeIgnoringTimeClaims(token string) (*jwt.Token, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true, ValidMethods: []string{j.Algorithm}}
+	parsedToken, err := parser.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if j.KeyProvider != nil {
+			kid, _ := t.Header["kid"].(string)
+			return j.KeyProvider.VerificationKey(kid)
+		}
+		return j.Signer.Public(), nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parsedToken, nil
+}
+
+// GetSignature returns the signature of a token.
+func (j *AsymmetricJWTStrategy) GetSignature(token string) (string, error) {
+	split := strings.Split(token, ".")
+	if len(split) != 3 {
+		return "", errors.New("Header, body and signature must all be set")
+	}
+	return split[2], nil
+}
+
+// Hash hashes in with the hash of the algorithm, which is SHA-512 for EdDSA.
+func (j *AsymmetricJWTStrategy) Hash(in []byte) ([]byte, error) {
+	hash := signingAlgorithms[j.Algorithm].hash.New()
+	if _, err := hash.Write(in); err != nil {
+		return []byte{}, errors.WithStack(err)
+	}
+	return hash.Sum([]byte{}), nil
+}
+
+// GetSigningMethodLength returns the size of the hash of the algorithm.
+func (j *AsymmetricJWTStrategy) GetSigningMethodLength() int {
+	return signingAlgorithms[j.Algorithm].hash.Size()
+}
+
+// signJWS returns the signature of the signing string with the algorithm, encoded for use in a JWT.
+func signJWS(ctx context.Context, alg string, signer crypto.Signer, signingString string) (string, error) {
+	a, ok := signingAlgorithms[alg]
+	if !ok {
+		return "", errors.Errorf("Signing algorithm \"%s\" is not supported.", alg)
+	}
+
+	// Ed25519 signs the message itself rather than a digest.
+	var digest []byte
+	var opts crypto.SignerOpts = crypto.Hash(0)
+	if alg == EdDSA {
+		digest = []byte(signingString)
+	} else {
+		hash := a.hash.New()
+		hash.Write([]byte(signingString))
+		digest, opts = hash.Sum(nil), a.hash
+		if a.pss {
+			opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.hash}
+		}
+	}
+
+	var signature []byte
+	var err error
+	if cs, ok := signer.(ContextSigner); ok {
+		signature, err = cs.SignWithContext(ctx, digest, opts)
+	} else {
+		signature, err = signer.Sign(rand.Reader, digest, opts)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if a.ecKeySize > 0 {
+		if signature, err = ecdsaSignatureToJWS(signature, a.ecKeySize); err != nil {
+			return "", err
+		}
+	}
+	return jwt.EncodeSegment(signature), nil
+}
+
+// ecdsaSignatureToJWS converts an ASN.1 encoded ECDSA signature, as returned by crypto.Signer, to the concatenated R
+// and S values of JSON Web Signatures. Signatures which already have that form are returned as they are.
+func ecdsaSignatureToJWS(signature []byte, keySize int) ([]byte, error) {
+	if len(signature) == 2*keySize {
+		return signature, nil
+	}
+
+	var rs struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(signature, &rs); err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("The ECDSA signature contains trailing data.")
+	}
+
+	out := make([]byte, 2*keySize)
+	rs.R.FillBytes(out[:keySize])
+	rs.S.FillBytes(out[keySize:])
+	return out, nil
+}
+
+// SigningMethodEdDSA verifies Ed25519 signatures of JSON Web Tokens, see RFC 8037. It is registered as "EdDSA".
+var SigningMethodEdDSA = new(signingMethodEdDSA)
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(EdDSA, func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return EdDSA
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	} else if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ory/fosite/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestAsymmetricJWTStrategy(t *testing.T) {
+	claims := (&JWTClaims{Subject: "peter", ExpiresAt: time.Now().UTC().Add(time.Hour)}).ToMapClaims()
+
+	strategies := map[string]*AsymmetricJWTStrategy{}
+	for _, alg := range []string{RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, EdDSA} {
+		t.Run("alg="+alg, func(t *testing.T) {
+			key, err := GenerateJSONWebKey(jose.SignatureAlgorithm(alg), "", "sig")
+			require.NoError(t, err)
+			j, err := NewAsymmetricJWTStrategy(alg, key.Key.(crypto.Signer))
+			require.NoError(t, err)
+			strategies[alg] = j
+
+			token, sig, err := j.Generate(claims, header)
+			require.NoError(t, err)
+			parsed, err := j.Decode(token)
+			require.NoError(t, err)
+			assert.Equal(t, alg, parsed.Header["alg"])
+			assert.Equal(t, "peter", parsed.Claims.(jwt.MapClaims)["sub"])
+
+			validated, err := j.Validate(token)
+			require.NoError(t, err)
+			assert.Equal(t, sig, validated)
+		})
+	}
+
+	// Tokens of other algorithms are rejected, even if the key would fit.
+	token, _, err := strategies[RS256].Generate(claims, header)
+	require.NoError(t, err)
+	_, err = strategies[PS256].Validate(token)
+	assert.Error(t, err)
+	_, err = strategies[ES256].Validate(token)
+	assert.Error(t, err)
+
+	_, err = NewAsymmetricJWTStrategy(ES384, internal.MustRSAKey())
+	assert.Error(t, err)
+	_, err = NewAsymmetricJWTStrategy("HS256", internal.MustRSAKey())
+	assert.Error(t, err)
+}
+
+func TestAsymmetricJWTStrategyRemoteECDSA(t *testing.T) {
+	key, err := GenerateJSONWebKey(jose.ES256, "kms-key", "sig")
+	require.NoError(t, err)
+	private := key.Key.(*ecdsa.PrivateKey)
+
+	// Key management services return ASN.1 encoded ECDSA signatures, which must be converted.
+	signer := NewRemoteSigner(func(_ context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+		return private.Sign(rand.Reader, digest, opts)
+	}, &jose.JSONWebKey{Key: &private.PublicKey, KeyID: "kms-key"})
+	j, err := NewAsymmetricJWTStrategy(ES256, signer)
+	require.NoError(t, err)
+
+	token, _, err := j.Generate((&JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}).ToMapClaims(), header)
+	require.NoError(t, err)
+	parsed, err := (&AsymmetricJWTStrategy{Algorithm: ES256, Signer: private}).Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, "kms-key", parsed.Header["kid"])
+}
+
+func TestAsymmetricJWTStrategyRemoteRSAPSS(t *testing.T) {
+	key := internal.MustRSAKey()
+
+	// The key management service is told to sign using RSASSA-PSS by the options.
+	signer := NewRemoteSigner(func(_ context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+		pss, ok := opts.(*rsa.PSSOptions)
+		require.True(t, ok)
+		return rsa.SignPSS(rand.Reader, key, pss.Hash, digest, pss)
+	}, &jose.JSONWebKey{Key: &key.PublicKey, KeyID: "kms-key"})
+	j, err := NewAsymmetricJWTStrategy(PS256, signer)
+	require.NoError(t, err)
+
+	token, _, err := j.Generate((&JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}).ToMapClaims(), header)
+	require.NoError(t, err)
+	_, err = (&AsymmetricJWTStrategy{Algorithm: PS256, Signer: key}).Decode(token)
+	require.NoError(t, err)
+}
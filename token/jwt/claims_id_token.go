@@ -41,7 +41,9 @@ type IDTokenClaims struct {
 	AuthTime                            time.Time
 	AccessTokenHash                     string
 	AuthenticationContextClassReference string
+	AuthenticationMethodsReference      []string
 	CodeHash                            string
+	StateHash                           string
 	Extra                               map[string]interface{}
 }
 
@@ -74,6 +76,10 @@ func (c *IDTokenClaims) ToMap() map[string]interface{} {
 		ret["c_hash"] = c.CodeHash
 	}
 
+	if len(c.StateHash) > 0 {
+		ret["s_hash"] = c.StateHash
+	}
+
 	if !c.AuthTime.IsZero() {
 		ret["auth_time"] = c.AuthTime.Unix()
 	}
@@ -82,6 +88,10 @@ func (c *IDTokenClaims) ToMap() map[string]interface{} {
 		ret["acr"] = c.AuthenticationContextClassReference
 	}
 
+	if len(c.AuthenticationMethodsReference) > 0 {
+		ret["amr"] = c.AuthenticationMethodsReference
+	}
+
 	ret["iat"] = float64(c.IssuedAt.Unix())
 	ret["exp"] = float64(c.ExpiresAt.Unix())
 	ret["rat"] = float64(c.RequestedAt.Unix())
@@ -40,7 +40,9 @@ var idTokenClaims = &IDTokenClaims{
 	RequestedAt:     time.Now().UTC(),
 	AccessTokenHash: "foobar",
 	CodeHash:        "barfoo",
+	StateHash:       "bazfoo",
 	AuthenticationContextClassReference: "acr",
+	AuthenticationMethodsReference:      []string{"pwd", "otp"},
 	Extra: map[string]interface{}{
 		"foo": "bar",
 		"baz": "bar",
@@ -70,7 +72,9 @@ func TestIDTokenClaimsToMap(t *testing.T) {
 		"baz":       idTokenClaims.Extra["baz"],
 		"at_hash":   idTokenClaims.AccessTokenHash,
 		"c_hash":    idTokenClaims.CodeHash,
+		"s_hash":    idTokenClaims.StateHash,
 		"auth_time": idTokenClaims.AuthTime.Unix(),
 		"acr":       idTokenClaims.AuthenticationContextClassReference,
+		"amr":       idTokenClaims.AuthenticationMethodsReference,
 	}, idTokenClaims.ToMap())
 }
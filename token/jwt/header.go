@@ -32,6 +32,26 @@ func NewHeaders() *Headers {
 	return &Headers{Extra: map[string]interface{}{}}
 }
 
+// TypedHeaders are Headers of tokens with an explicit media type, for example "token-introspection+jwt", which is
+// written to the "typ" header instead of "JWT".
+type TypedHeaders struct {
+	*Headers
+	Type string
+}
+
+// NewTypedHeaders returns empty headers of tokens of the media type.
+func NewTypedHeaders(typ string) *TypedHeaders {
+	return &TypedHeaders{Headers: NewHeaders(), Type: typ}
+}
+
+// headerType returns the media type of the token, if the headers set one.
+func headerType(header Mapper) string {
+	if h, ok := header.(*TypedHeaders); ok {
+		return h.Type
+	}
+	return ""
+}
+
 // ToMap will transform the headers to a map structure
 func (h *Headers) ToMap() map[string]interface{} {
 	var filter = map[string]bool{"alg": true, "typ": true}
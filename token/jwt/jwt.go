@@ -25,10 +25,13 @@
 package jwt
 
 import (
+	"context"
+	"crypto"
 	"crypto/rsa"
 	"crypto/sha256"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/ory/fosite"
@@ -47,30 +50,79 @@ type JWTStrategy interface {
 // RS256JWTStrategy is responsible for generating and validating JWT challenges
 type RS256JWTStrategy struct {
 	PrivateKey *rsa.PrivateKey
+
+	// Signer, if set, signs tokens instead of PrivateKey, for example a RemoteSigner of a key in a key management
+	// service. Tokens are verified with its public key, which must be an *rsa.PublicKey.
+	Signer crypto.Signer
+
+	// KeyProvider, if set, resolves the keys instead of PrivateKey and Signer whenever a token is signed or verified,
+	// see KeyProvider.
+	KeyProvider KeyProvider
+
+	// Clock provides the current time when validating tokens. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	// ClockSkew is the leeway applied when validating the "exp", "nbf" and "iat" claims.
+	ClockSkew time.Duration
 }
 
 // Generate generates a new authorize code or returns an error. set secret
 func (j *RS256JWTStrategy) Generate(claims jwt.Claims, header Mapper) (string, string, error) {
+	return j.GenerateWithContext(context.Background(), claims, header)
+}
+
+// GenerateWithContext implements ContextGenerator.
+func (j *RS256JWTStrategy) GenerateWithContext(ctx context.Context, claims jwt.Claims, header Mapper) (string, string, error) {
 	if header == nil || claims == nil {
 		return "", "", errors.New("Either claims or header is nil.")
 	}
 
+	kid, key, err := j.signingKey()
+	if err != nil {
+		return "", "", err
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 	token.Header = assign(token.Header, header.ToMap())
+	if _, ok := token.Header["kid"]; !ok && kid != "" {
+		token.Header["kid"] = kid
+	}
+	if typ := headerType(header); typ != "" {
+		token.Header["typ"] = typ
+	}
 
 	var sig, sstr string
-	var err error
 	if sstr, err = token.SigningString(); err != nil {
 		return "", "", errors.WithStack(err)
 	}
 
-	if sig, err = token.Method.Sign(sstr, j.PrivateKey); err != nil {
+	if sig, err = signJWS(ctx, RS256, key, sstr); err != nil {
 		return "", "", errors.WithStack(err)
 	}
 
 	return fmt.Sprintf("%s.%s", sstr, sig), sig, nil
 }
 
+// signingKey returns the key new tokens are signed with and its key ID, if known.
+func (j *RS256JWTStrategy) signingKey() (string, crypto.Signer, error) {
+	if j.KeyProvider != nil {
+		return j.KeyProvider.SigningKey()
+	} else if j.Signer != nil {
+		return signerKeyID(j.Signer), j.Signer, nil
+	}
+	return "", j.PrivateKey, nil
+}
+
+// verificationKey returns the public key which verifies tokens with the key ID.
+func (j *RS256JWTStrategy) verificationKey(kid string) (crypto.PublicKey, error) {
+	if j.KeyProvider != nil {
+		return j.KeyProvider.VerificationKey(kid)
+	} else if j.Signer != nil {
+		return j.Signer.Public(), nil
+	}
+	return &j.PrivateKey.PublicKey, nil
+}
+
 // Validate validates a token and returns its signature or an error if the token is not valid.
 func (j *RS256JWTStrategy) Validate(token string) (string, error) {
 	if _, err := j.Decode(token); err != nil {
@@ -82,21 +134,52 @@ func (j *RS256JWTStrategy) Validate(token string) (string, error) {
 
 // Decode will decode a JWT token
 func (j *RS256JWTStrategy) Decode(token string) (*jwt.Token, error) {
-	// Parse the token.
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+	// Parse the token, the time based claims are validated below taking the clock skew into account.
+	parsedToken, err := j.DecodeIgnoringTimeClaims(token)
+	if err != nil {
+		return nil, err
+	} else if err := ValidateTimeClaims(parsedToken.Claims, fosite.Now(j.Clock), j.ClockSkew); err != nil {
+		return nil, errors.WithStack(err)
+	} else if !parsedToken.Valid {
+		return nil, errors.WithStack(fosite.ErrInactiveToken)
+	}
+
+	return parsedToken, err
+}
+
+// DecodeIgnoringTimeClaims decodes a JWT token and verifies its signature, but not its "exp", "nbf" and "iat" claims.
+// This is useful for tokens which remain meaningful after they expired, like ID token hints.
+func (j *RS256JWTStrategy) DecodeIgnoringTimeClaims(token string) (*jwt.Token, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	parsedToken, err := parser.Parse(token, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, errors.Errorf("Unexpected signing method: %v", t.Header["alg"])
 		}
-		return &j.PrivateKey.PublicKey, nil
+		kid, _ := t.Header["kid"].(string)
+		return j.verificationKey(kid)
 	})
-
 	if err != nil {
 		return nil, errors.WithStack(err)
-	} else if !parsedToken.Valid {
-		return nil, errors.WithStack(fosite.ErrInactiveToken)
+	}
+	return parsedToken, nil
+}
+
+// ValidateTimeClaims validates the "exp", "nbf" and "iat" claims against now, tolerating a clock skew of leeway.
+// Claims other than jwt.MapClaims are validated using their Valid method. The error is a *jwt.ValidationError.
+func ValidateTimeClaims(claims jwt.Claims, now time.Time, leeway time.Duration) error {
+	c, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return claims.Valid()
 	}
 
-	return parsedToken, err
+	if !c.VerifyExpiresAt(now.Add(-leeway).Unix(), false) {
+		return jwt.NewValidationError("Token is expired", jwt.ValidationErrorExpired)
+	} else if !c.VerifyNotBefore(now.Add(leeway).Unix(), false) {
+		return jwt.NewValidationError("Token is not valid yet", jwt.ValidationErrorNotValidYet)
+	} else if !c.VerifyIssuedAt(now.Add(leeway).Unix(), false) {
+		return jwt.NewValidationError("Token used before issued", jwt.ValidationErrorIssuedAt)
+	}
+	return nil
 }
 
 // GetSignature will return the signature of a token
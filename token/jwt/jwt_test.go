@@ -27,6 +27,7 @@ import (
 
 	"time"
 
+	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -145,3 +146,31 @@ func TestValidateSignatureRejectsJWT(t *testing.T) {
 		t.Logf("Passed test case %d", k)
 	}
 }
+
+func TestValidateWithClockSkew(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	j := RS256JWTStrategy{
+		PrivateKey: internal.MustRSAKey(),
+		Clock:      fosite.ClockFunc(func() time.Time { return now }),
+	}
+
+	expired, _, err := j.Generate((&JWTClaims{ExpiresAt: now.Add(-time.Second * 30)}).ToMapClaims(), header)
+	require.NoError(t, err)
+	notYetValid, _, err := j.Generate((&JWTClaims{ExpiresAt: now.Add(time.Hour), NotBefore: now.Add(time.Second * 30)}).ToMapClaims(), header)
+	require.NoError(t, err)
+
+	_, err = j.Validate(expired)
+	assert.Error(t, err)
+	_, err = j.Validate(notYetValid)
+	assert.Error(t, err)
+
+	j.ClockSkew = time.Minute
+	_, err = j.Validate(expired)
+	assert.NoError(t, err)
+	_, err = j.Validate(notYetValid)
+	assert.NoError(t, err)
+
+	j.Clock = fosite.ClockFunc(func() time.Time { return now.Add(time.Hour * 2) })
+	_, err = j.Validate(notYetValid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// KeyProvider resolves the keys of RS256JWTStrategy and AsymmetricJWTStrategy whenever a token is signed or verified, which allows rotating
+// keys without restarting the process. Implementations may be backed by files, a key management service or a
+// database and must be safe for concurrent use.
+type KeyProvider interface {
+	// SigningKey returns the key which signs new tokens, and its key ID which is written to the "kid" header. The key
+	// is a private key or a signer of a key which is kept elsewhere, see RemoteSigner.
+	SigningKey() (kid string, key crypto.Signer, err error)
+
+	// VerificationKey returns the public key with the key ID. Keys which were rotated out should still be returned
+	// until the tokens they signed have expired. The key ID is empty for tokens without a "kid" header.
+	VerificationKey(kid string) (crypto.PublicKey, error)
+}
+
+// RotatingKeyProvider is a KeyProvider which signs tokens with the most recently added key and keeps previous keys
+// for verification.
+type RotatingKeyProvider struct {
+	// Retain is the number of previous keys which still verify tokens. Defaults to one.
+	Retain int
+
+	mu   sync.RWMutex
+	keys []rotatedKey
+}
+
+type rotatedKey struct {
+	kid string
+	key crypto.Signer
+}
+
+// Rotate makes key, identified by kid, the signing key. Adding the current signing key again has no effect.
+func (p *RotatingKeyProvider) Rotate(kid string, key crypto.Signer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) > 0 && p.keys[0].kid == kid {
+		return
+	}
+
+	retain := p.Retain
+	if retain <= 0 {
+		retain = 1
+	}
+	p.keys = append([]rotatedKey{{kid: kid, key: key}}, p.keys...)
+	if len(p.keys) > retain+1 {
+		p.keys = p.keys[:retain+1]
+	}
+}
+
+func (p *RotatingKeyProvider) SigningKey() (string, crypto.Signer, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.keys) == 0 {
+		return "", nil, errors.WithStack(fosite.ErrServerError.WithDebug("No signing key has been configured."))
+	}
+	return p.keys[0].kid, p.keys[0].key, nil
+}
+
+func (p *RotatingKeyProvider) VerificationKey(kid string) (crypto.PublicKey, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for k, key := range p.keys {
+		// Tokens without a key ID can only be verified by the signing key.
+		if key.kid == kid || (kid == "" && k == 0) {
+			return key.key.Public(), nil
+		}
+	}
+	return nil, errors.Errorf("Unable to find the verification key with key ID \"%s\".", kid)
+}
+
+// PEMFileKeyProvider is a KeyProvider which loads a PEM encoded RSA private key from a file and reloads it once the
+// file has been changed, for example by a secret manager rotating the key. The key ID is the RFC 7638 thumbprint of
+// the key.
+type PEMFileKeyProvider struct {
+	Path string
+
+	// CheckInterval is the time between checks whether the file has changed. Defaults to one minute.
+	CheckInterval time.Duration
+
+	// Clock provides the current time. Defaults to fosite.SystemClock.
+	Clock fosite.Clock
+
+	RotatingKeyProvider
+
+	reload    sync.Mutex
+	checkedAt time.Time
+	modTime   time.Time
+}
+
+func (p *PEMFileKeyProvider) SigningKey() (string, crypto.Signer, error) {
+	if err := p.refresh(); err != nil {
+		return "", nil, err
+	}
+	return p.RotatingKeyProvider.SigningKey()
+}
+
+func (p *PEMFileKeyProvider) VerificationKey(kid string) (crypto.PublicKey, error) {
+	if err := p.refresh(); err != nil {
+		return nil, err
+	}
+	return p.RotatingKeyProvider.VerificationKey(kid)
+}
+
+// refresh reloads the key if the file changed since it was last loaded. Once a key has been loaded, errors reading
+// the file are ignored so that the previous key stays in use.
+func (p *PEMFileKeyProvider) refresh() error {
+	p.reload.Lock()
+	defer p.reload.Unlock()
+
+	interval := p.CheckInterval
+	if interval == 0 {
+		interval = time.Minute
+	}
+	now := fosite.Now(p.Clock)
+	loaded := !p.modTime.IsZero()
+	if loaded && now.Before(p.checkedAt.Add(interval)) {
+		return nil
+	}
+	p.checkedAt = now
+
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		if loaded {
+			return nil
+		}
+		return errors.WithStack(err)
+	} else if loaded && info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	kid, key, err := loadPEMKey(p.Path)
+	if err != nil {
+		if loaded {
+			return nil
+		}
+		return err
+	}
+	p.Rotate(kid, key)
+	p.modTime = info.ModTime()
+	return nil
+}
+
+func loadPEMKey(path string) (string, *rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", nil, errors.Errorf("File \"%s\" does not contain a PEM encoded key.", path)
+	}
+
+	var key *rsa.PrivateKey
+	if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return "", nil, errors.Errorf("File \"%s\" contains a %T but expected an RSA private key.", path, parsed)
+		}
+	}
+
+	thumbprint, err := (&jose.JSONWebKey{Key: &key.PublicKey}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), key, nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingKeyProvider(t *testing.T) {
+	provider := new(RotatingKeyProvider)
+	j := RS256JWTStrategy{KeyProvider: provider}
+	claims := (&JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}).ToMapClaims()
+
+	_, _, err := j.Generate(claims, header)
+	require.Error(t, err)
+
+	provider.Rotate("first", internal.MustRSAKey())
+	first, _, err := j.Generate(claims, header)
+	require.NoError(t, err)
+	parsed, err := j.Decode(first)
+	require.NoError(t, err)
+	assert.Equal(t, "first", parsed.Header["kid"])
+
+	// Tokens signed before the rotation stay valid while their key is retained.
+	provider.Rotate("second", internal.MustRSAKey())
+	second, _, err := j.Generate(claims, header)
+	require.NoError(t, err)
+	parsed, err = j.Decode(second)
+	require.NoError(t, err)
+	assert.Equal(t, "second", parsed.Header["kid"])
+	_, err = j.Validate(first)
+	require.NoError(t, err)
+
+	provider.Rotate("third", internal.MustRSAKey())
+	_, err = j.Validate(first)
+	require.Error(t, err)
+	_, err = j.Validate(second)
+	require.NoError(t, err)
+}
+
+func TestPEMFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fosite-keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key.pem")
+	writeKey := func(modTime time.Time) {
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(internal.MustRSAKey())})
+		require.NoError(t, ioutil.WriteFile(path, data, 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	now := time.Now()
+	provider := &PEMFileKeyProvider{Path: path, Clock: fosite.ClockFunc(func() time.Time { return now })}
+	_, _, err = provider.SigningKey()
+	require.Error(t, err)
+
+	writeKey(now.Add(-time.Hour))
+	first, _, err := provider.SigningKey()
+	require.NoError(t, err)
+	assert.NotEmpty(t, first)
+
+	// The file is only checked again after the check interval.
+	writeKey(now)
+	kid, _, err := provider.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, first, kid)
+
+	now = now.Add(time.Minute)
+	second, _, err := provider.SigningKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	_, err = provider.VerificationKey(first)
+	assert.NoError(t, err)
+
+	// A broken file does not replace the key in use.
+	require.NoError(t, ioutil.WriteFile(path, []byte("broken"), 0600))
+	now = now.Add(time.Minute)
+	kid, _, err = provider.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, second, kid)
+}
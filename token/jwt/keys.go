@@ -0,0 +1,94 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// RSAKeyBits is the size of RSA keys generated by GenerateJSONWebKey.
+const RSAKeyBits = 2048
+
+// KeyManager persists JSON Web Keys, grouped in sets such as "id-token" or "access-token".
+type KeyManager interface {
+	AddKey(ctx context.Context, set string, key *jose.JSONWebKey) error
+}
+
+// GenerateJSONWebKey generates a private key suitable for signature algorithm alg and wraps it as a JSON Web Key
+// with the key ID, use and algorithm set. RSA (RS*, PS*), EC (ES256, ES384, ES512) and OKP (EdDSA) keys are
+// supported. If kid is empty, a random key ID is generated. The public key can be obtained with the key's Public method.
+func GenerateJSONWebKey(alg jose.SignatureAlgorithm, kid, use string) (*jose.JSONWebKey, error) {
+	var key crypto.PrivateKey
+	var err error
+
+	switch alg {
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+		key, err = rsa.GenerateKey(rand.Reader, RSAKeyBits)
+	case jose.ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jose.ES384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case jose.ES512:
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case jose.EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, errors.Errorf("Unable to generate a key for unsupported signature algorithm \"%s\".", alg)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if kid == "" {
+		kid = uuid.New()
+	}
+
+	return &jose.JSONWebKey{
+		Key:       key,
+		KeyID:     kid,
+		Use:       use,
+		Algorithm: string(alg),
+	}, nil
+}
+
+// GenerateAndPersistJSONWebKey generates a key using GenerateJSONWebKey and adds it to the given set of the key manager.
+func GenerateAndPersistJSONWebKey(ctx context.Context, m KeyManager, set string, alg jose.SignatureAlgorithm, kid, use string) (*jose.JSONWebKey, error) {
+	key, err := GenerateJSONWebKey(alg, kid, use)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.AddKey(ctx, set, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
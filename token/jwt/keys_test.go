@@ -0,0 +1,76 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type memoryKeyManager map[string][]jose.JSONWebKey
+
+func (m memoryKeyManager) AddKey(_ context.Context, set string, key *jose.JSONWebKey) error {
+	m[set] = append(m[set], *key)
+	return nil
+}
+
+func TestGenerateJSONWebKey(t *testing.T) {
+	for _, alg := range []jose.SignatureAlgorithm{jose.RS256, jose.ES256, jose.ES384, jose.ES512, jose.EdDSA} {
+		t.Run("alg="+string(alg), func(t *testing.T) {
+			key, err := GenerateJSONWebKey(alg, "", "sig")
+			require.NoError(t, err)
+			assert.NotEmpty(t, key.KeyID)
+			assert.Equal(t, "sig", key.Use)
+			assert.Equal(t, string(alg), key.Algorithm)
+			assert.True(t, key.Valid())
+			assert.False(t, key.IsPublic())
+
+			public := key.Public()
+			assert.True(t, public.IsPublic())
+			assert.Equal(t, key.KeyID, public.KeyID)
+
+			out, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+			require.NoError(t, err)
+			jws, err := out.Sign([]byte("foo"))
+			require.NoError(t, err)
+			payload, err := jws.Verify(&public)
+			require.NoError(t, err)
+			assert.Equal(t, "foo", string(payload))
+		})
+	}
+
+	_, err := GenerateJSONWebKey(jose.HS256, "", "sig")
+	assert.Error(t, err)
+}
+
+func TestGenerateAndPersistJSONWebKey(t *testing.T) {
+	m := memoryKeyManager{}
+	key, err := GenerateAndPersistJSONWebKey(context.Background(), m, "id-token", jose.ES256, "my-kid", "sig")
+	require.NoError(t, err)
+	require.Len(t, m["id-token"], 1)
+	assert.Equal(t, "my-kid", m["id-token"][0].KeyID)
+	assert.Equal(t, key.KeyID, m["id-token"][0].KeyID)
+}
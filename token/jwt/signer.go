@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"io"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// ContextSigner is a crypto.Signer which also accepts the context of the request, for example to cancel calls to a
+// key management service.
+type ContextSigner interface {
+	crypto.Signer
+
+	SignWithContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// SignFunc signs the digest of a token, for example by calling a key management service or an HSM. The digest has
+// been hashed with opts.HashFunc(). RSA keys must sign using RSASSA-PSS if opts is an *rsa.PSSOptions, as it is for
+// PS256, PS384 and PS512, and using RSASSA-PKCS1-v1_5 otherwise.
+type SignFunc func(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+
+// RemoteSigner is a ContextSigner for keys which never leave a key management service or an HSM. It only needs a
+// SignFunc and the public JSON Web Key, whose key ID is written to the "kid" header of the tokens.
+type RemoteSigner struct {
+	SignFunc SignFunc
+
+	// JSONWebKey is the public key of the remote key.
+	JSONWebKey *jose.JSONWebKey
+}
+
+// NewRemoteSigner returns a signer of the remote key with the public key jwk.
+func NewRemoteSigner(sign SignFunc, jwk *jose.JSONWebKey) *RemoteSigner {
+	return &RemoteSigner{SignFunc: sign, JSONWebKey: jwk}
+}
+
+// Public implements crypto.Signer.
+func (s *RemoteSigner) Public() crypto.PublicKey {
+	return s.JSONWebKey.Key
+}
+
+// Sign implements crypto.Signer.
+func (s *RemoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignWithContext(context.Background(), digest, opts)
+}
+
+// SignWithContext implements ContextSigner.
+func (s *RemoteSigner) SignWithContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signature, err := s.SignFunc(ctx, digest, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return signature, nil
+}
+
+// KeyID returns the key ID of the public key.
+func (s *RemoteSigner) KeyID() string {
+	return s.JSONWebKey.KeyID
+}
+
+// ContextGenerator is implemented by JWT strategies which pass the context of the request to their signer.
+type ContextGenerator interface {
+	GenerateWithContext(ctx context.Context, claims jwt.Claims, header Mapper) (string, string, error)
+}
+
+// Generate generates a token using the strategy, passing ctx to it if it implements ContextGenerator.
+func Generate(ctx context.Context, s JWTStrategy, claims jwt.Claims, header Mapper) (string, string, error) {
+	if g, ok := s.(ContextGenerator); ok {
+		return g.GenerateWithContext(ctx, claims, header)
+	}
+	return s.Generate(claims, header)
+}
+
+// signerKeyID returns the key ID of signers which know it, such as RemoteSigner.
+func signerKeyID(signer crypto.Signer) string {
+	if k, ok := signer.(interface{ KeyID() string }); ok {
+		return k.KeyID()
+	}
+	return ""
+}
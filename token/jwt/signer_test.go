@@ -0,0 +1,73 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite/internal"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+type signerContextKey struct{}
+
+func TestRemoteSigner(t *testing.T) {
+	key := internal.MustRSAKey()
+	ctx := context.WithValue(context.Background(), signerContextKey{}, "request")
+
+	var calls int
+	signer := NewRemoteSigner(func(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+		calls++
+		assert.Equal(t, "request", ctx.Value(signerContextKey{}))
+		assert.Equal(t, crypto.SHA256, opts.HashFunc())
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+	}, &jose.JSONWebKey{Key: &key.PublicKey, KeyID: "kms-key", Algorithm: "RS256", Use: "sig"})
+	j := &RS256JWTStrategy{Signer: signer}
+	claims := (&JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}).ToMapClaims()
+
+	token, _, err := Generate(ctx, j, claims, header)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	parsed, err := j.Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, "kms-key", parsed.Header["kid"])
+
+	// The signature is the same as one of the private key.
+	_, err = (&RS256JWTStrategy{PrivateKey: key}).Validate(token)
+	require.NoError(t, err)
+
+	// Errors of the remote signer are passed on.
+	signer.SignFunc = func(context.Context, []byte, crypto.SignerOpts) ([]byte, error) {
+		return nil, errors.New("kms unavailable")
+	}
+	_, _, err = j.Generate(claims, header)
+	assert.EqualError(t, errors.Cause(err), "kms unavailable")
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// TokenHash computes the value of the "at_hash", "c_hash" and "s_hash" ID token claims for token: the base64url
+// encoded left-most half of the hash of token, where the hash is the one used by alg, the JSON Web Signature algorithm
+// of the ID token. For example, ID tokens signed with RS256 or ES256 use SHA-256 and ID tokens signed with EdDSA use
+// SHA-512.
+//
+// See https://openid.net/specs/openid-connect-core-1_0.html#HybridIDToken
+func TokenHash(alg, token string) (string, error) {
+	a, ok := signingAlgorithms[alg]
+	if !ok {
+		return "", errors.Errorf("Signing algorithm \"%s\" is not supported.", alg)
+	}
+
+	h := a.hash.New()
+	_, _ = h.Write([]byte(token))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenHash(t *testing.T) {
+	// Examples from https://openid.net/specs/openid-connect-core-1_0.html#code-id_tokenExample
+	for _, alg := range []string{RS256, PS256, ES256} {
+		hash, err := TokenHash(alg, "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y")
+		require.NoError(t, err)
+		assert.Equal(t, "77QmUPtjPfzWtF2AnpK9RQ", hash, alg)
+
+		hash, err = TokenHash(alg, "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk")
+		require.NoError(t, err)
+		assert.Equal(t, "LDktKdoQak3Pk0cnXxCltA", hash, alg)
+	}
+
+	sum := sha512.Sum512([]byte("foo"))
+	for _, alg := range []string{RS512, ES512, EdDSA} {
+		hash, err := TokenHash(alg, "foo")
+		require.NoError(t, err)
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:32]), hash, alg)
+	}
+
+	_, err := TokenHash("HS256", "foo")
+	assert.Error(t, err)
+}
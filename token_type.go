@@ -0,0 +1,68 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// IntrospectTokenType tells the type of a token by its prefix, see TokenPrefixes, or by its structure. JSON Web Tokens
+// are told apart by their claims: access tokens carry the granted scopes, ID tokens claims about the authentication.
+// An empty token type is returned if the type can not be told.
+//
+// The revocation and introspection endpoints use it if the client sent no token_type_hint.
+func (f *Fosite) IntrospectTokenType(token string) TokenType {
+	for tokenType, prefix := range f.TokenPrefixes {
+		if prefix != "" && strings.HasPrefix(token, prefix) {
+			return tokenType
+		}
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	for _, claim := range []string{"scp", "scope"} {
+		if _, ok := claims[claim]; ok {
+			return AccessToken
+		}
+	}
+	for _, claim := range []string{"nonce", "at_hash", "c_hash", "s_hash", "auth_time", "acr", "amr"} {
+		if _, ok := claims[claim]; ok {
+			return IDToken
+		}
+	}
+	return ""
+}
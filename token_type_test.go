@@ -0,0 +1,58 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospectTokenType(t *testing.T) {
+	f := &Fosite{TokenPrefixes: map[TokenType]string{
+		AccessToken:   "ory_at_",
+		RefreshToken:  "ory_rt_",
+		AuthorizeCode: "ory_ac_",
+	}}
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+
+	for _, c := range []struct {
+		token    string
+		expected TokenType
+	}{
+		{token: "ory_at_foo.bar", expected: AccessToken},
+		{token: "ory_rt_foo.bar", expected: RefreshToken},
+		{token: "ory_ac_foo.bar", expected: AuthorizeCode},
+		{token: "foo.bar", expected: ""},
+		{token: jwt(`{"sub":"peter","scp":["photos"]}`), expected: AccessToken},
+		{token: jwt(`{"sub":"peter","nonce":"1234567890"}`), expected: IDToken},
+		{token: jwt(`{"sub":"peter"}`), expected: ""},
+		{token: "foo.bar.baz", expected: ""},
+		{token: "", expected: ""},
+	} {
+		assert.Equal(t, c.expected, f.IntrospectTokenType(c.token), "%s", c.token)
+	}
+}
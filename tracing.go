@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Tracer creates spans for distributed tracing, for example by adapting an OpenTelemetry trace.Tracer. Fosite creates
+// spans for its public API, every endpoint handler invocation, client lookups, client secret comparisons and token
+// generation. The token, code and session storage calls are made by the handlers and are only covered by their spans.
+// The tracer is passed on through the context, so storage implementations and custom handlers can add their own
+// spans using StartSpan.
+type Tracer interface {
+	// Start starts a span named name as a child of the span in ctx, if any, and returns a context carrying it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span created by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key, value string)
+
+	// End ends the span. If err is not nil, the span is marked as failed.
+	End(err error)
+}
+
+type tracerContextKey struct{}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, string) {}
+func (noopSpan) End(error)                   {}
+
+// ContextWithTracer returns a context carrying the tracer, see StartSpan.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerContextKey{}, tracer)
+}
+
+// StartSpan starts a span using the tracer carried by ctx. If there is none, the span does nothing.
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if ctx != nil {
+		if tracer, ok := ctx.Value(tracerContextKey{}).(Tracer); ok {
+			return tracer.Start(ctx, name)
+		}
+	}
+	return ctx, noopSpan{}
+}
+
+// startSpan starts a span using Fosite.Tracer, which is added to the context for everything called with it.
+func (f *Fosite) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if f.Tracer != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, ok := ctx.Value(tracerContextKey{}).(Tracer); !ok {
+			ctx = ContextWithTracer(ctx, f.Tracer)
+		}
+	}
+	return StartSpan(ctx, name)
+}
+
+// startHandler starts the span of an endpoint handler invocation. The returned function must be called with the
+// result of the invocation, it ends the span and reports the handler metrics.
+func (f *Fosite) startHandler(ctx context.Context, endpoint string, handler interface{}) (context.Context, func(error)) {
+	start := time.Now()
+	handlerType := reflect.TypeOf(handler).String()
+	ctx, span := f.startSpan(ctx, "fosite.handler")
+	span.SetAttribute("fosite.endpoint", endpoint)
+	span.SetAttribute("fosite.handler", handlerType)
+
+	return ctx, func(err error) {
+		if metricErrorLabel(err) == "" {
+			span.End(nil)
+		} else {
+			span.End(err)
+		}
+		f.observeHandler(endpoint, handlerType, start, err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTracer struct {
+	sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]string
+	ended      bool
+	err        error
+}
+
+type recordedSpanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.Lock()
+	defer t.Unlock()
+
+	span := &recordedSpan{name: name, attributes: map[string]string{}}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func (s *recordedSpan) SetAttribute(key, value string) {
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+type tracingStore struct {
+	*storage.MemoryStore
+}
+
+func (s tracingStore) GetClient(ctx context.Context, id string) (Client, error) {
+	_, span := StartSpan(ctx, "store.GetClient")
+	defer span.End(nil)
+	return s.MemoryStore.GetClient(ctx, id)
+}
+
+func TestTracer(t *testing.T) {
+	hasher := &BCrypt{WorkFactor: 4}
+	secret, err := hasher.Hash([]byte("secret"))
+	require.NoError(t, err)
+
+	tracer := new(recordingTracer)
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{ID: "foo", Secret: secret}
+	f := &Fosite{
+		Store:                 tracingStore{store},
+		Hasher:                hasher,
+		TokenEndpointHandlers: TokenEndpointHandlers{issuingTokenEndpointHandler{}},
+		Tracer:                tracer,
+	}
+
+	r, _ := http.NewRequest("POST", "/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth("foo", "secret")
+	_, err = f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+	require.NoError(t, err)
+
+	r.SetBasicAuth("foo", "wrong")
+	_, err = f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+	require.Error(t, err)
+
+	var spans []string
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended, "%s", span.name)
+		spans = append(spans, span.name+" < "+span.parent)
+	}
+	assert.Equal(t, []string{
+		"fosite.NewAccessRequest < ",
+		"fosite.Store.GetClient < fosite.NewAccessRequest",
+		"store.GetClient < fosite.Store.GetClient",
+		"fosite.Hasher.Compare < fosite.NewAccessRequest",
+		"fosite.handler < fosite.NewAccessRequest",
+		"fosite.NewAccessRequest < ",
+		"fosite.Store.GetClient < fosite.NewAccessRequest",
+		"store.GetClient < fosite.Store.GetClient",
+		"fosite.Hasher.Compare < fosite.NewAccessRequest",
+	}, spans)
+
+	assert.Equal(t, "token", tracer.spans[4].attributes["fosite.endpoint"])
+	assert.Equal(t, "fosite_test.issuingTokenEndpointHandler", tracer.spans[4].attributes["fosite.handler"])
+	assert.NoError(t, tracer.spans[4].err)
+	assert.Error(t, tracer.spans[8].err)
+	assert.Error(t, tracer.spans[5].err)
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// URIKind is the kind of a URI registered by a client.
+type URIKind string
+
+const (
+	RedirectURIKind         URIKind = "redirect_uri"
+	JSONWebKeysURIKind      URIKind = "jwks_uri"
+	SectorIdentifierURIKind URIKind = "sector_identifier_uri"
+)
+
+// URIVerifier verifies URIs when clients are registered, for example to reject URIs pointing to internal services.
+// It is meant to be used by dynamic client registration implementations.
+type URIVerifier interface {
+	// VerifyURI returns an error if the client must not register the uri.
+	VerifyURI(ctx context.Context, client Client, kind URIKind, uri string) error
+}
+
+// sectorIdentifierClient is implemented by clients which may register a sector_identifier_uri, such as
+// openid.DefaultClientWithSubjectType.
+type sectorIdentifierClient interface {
+	GetSectorIdentifierURI() string
+}
+
+// VerifyClientURIs verifies the client's redirect URIs and, for OpenID Connect clients, the JSON Web Key Set URI and
+// the sector_identifier_uri, see
+// https://openid.net/specs/openid-connect-registration-1_0.html#SectorIdentifierValidation
+func VerifyClientURIs(ctx context.Context, v URIVerifier, client Client) error {
+	for _, uri := range client.GetRedirectURIs() {
+		if err := v.VerifyURI(ctx, client, RedirectURIKind, uri); err != nil {
+			return err
+		}
+	}
+
+	if oidc, ok := client.(OpenIDConnectClient); ok && oidc.GetJSONWebKeysURI() != "" {
+		if err := v.VerifyURI(ctx, client, JSONWebKeysURIKind, oidc.GetJSONWebKeysURI()); err != nil {
+			return err
+		}
+	}
+
+	if sector, ok := client.(sectorIdentifierClient); ok && sector.GetSectorIdentifierURI() != "" {
+		if err := v.VerifyURI(ctx, client, SectorIdentifierURIKind, sector.GetSectorIdentifierURI()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyClientURIsAsync runs VerifyClientURIs in the background, so that registration does not have to wait for DNS
+// lookups and HTTP requests, and calls done with the result. Registration implementations can, for example, keep the
+// client deactivated until the verification succeeded.
+func VerifyClientURIsAsync(ctx context.Context, v URIVerifier, client Client, done func(error)) {
+	go func() {
+		done(VerifyClientURIs(ctx, v, client))
+	}()
+}
+
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.168.0.0/16", "::1/128", "fc00::/7", "fe80::/10",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+func isPrivateIP(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return ip.IsUnspecified() || ip.IsMulticast()
+}
+
+// DefaultURIVerifier is the default URIVerifier. It rejects URIs which resolve to private, loopback or link-local
+// addresses, except for loopback redirect URIs used by native apps, see https://tools.ietf.org/html/rfc8252#section-7.3
+// Redirect URIs using private-use URI schemes are not resolved. JSON Web Key Set and sector identifier URIs must use
+// HTTPS and are fetched to make sure they return a JSON document no larger than MaxResponseSize. The redirect URIs of
+// the client must be listed in the document of the sector identifier URI.
+type DefaultURIVerifier struct {
+	// AllowPrivateNetworks disables the checks for private addresses, for example in development environments.
+	AllowPrivateNetworks bool
+
+	// MaxResponseSize limits the size of fetched documents. Defaults to 512 KiB.
+	MaxResponseSize int64
+
+	// Resolver is used to look up host names. Defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+
+	// HTTPClient is used to fetch documents. Defaults to a client with a timeout of 10 seconds which refuses to
+	// connect to private addresses, even if a host name resolves to one after it was verified.
+	HTTPClient *http.Client
+}
+
+func (v *DefaultURIVerifier) VerifyURI(ctx context.Context, client Client, kind URIKind, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The %s \"%s\" is not a valid URI.", kind, uri).WithDebug(err.Error()))
+	}
+
+	if kind == RedirectURIKind {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil
+		} else if isLoopbackHost(u.Hostname()) {
+			return nil
+		}
+	} else if u.Scheme != "https" {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The %s \"%s\" must use HTTPS.", kind, uri))
+	}
+
+	if err := v.verifyHost(ctx, kind, u.Hostname()); err != nil {
+		return err
+	}
+
+	switch kind {
+	case JSONWebKeysURIKind:
+		var keys struct {
+			Keys []json.RawMessage `json:"keys"`
+		}
+		return v.fetch(ctx, kind, uri, &keys)
+	case SectorIdentifierURIKind:
+		var redirectURIs []string
+		if err := v.fetch(ctx, kind, uri, &redirectURIs); err != nil {
+			return err
+		}
+		for _, redirectURI := range client.GetRedirectURIs() {
+			if !StringInSlice(redirectURI, redirectURIs) {
+				return errors.WithStack(ErrInvalidRequest.WithHintf("The redirect_uri \"%s\" is not listed at the sector_identifier_uri \"%s\".", redirectURI, uri))
+			}
+		}
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (v *DefaultURIVerifier) verifyHost(ctx context.Context, kind URIKind, host string) error {
+	if host == "" {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The %s must contain a host.", kind))
+	} else if v.AllowPrivateNetworks {
+		return nil
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		resolver := v.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return errors.WithStack(ErrInvalidRequest.WithHintf("Unable to resolve the host \"%s\" of the %s.", host, kind).WithDebug(err.Error()))
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			return errors.WithStack(ErrInvalidRequest.WithHintf("The host \"%s\" of the %s resolves to a private address.", host, kind))
+		}
+	}
+	return nil
+}
+
+func (v *DefaultURIVerifier) httpClient() *http.Client {
+	if v.HTTPClient != nil {
+		return v.HTTPClient
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); !v.AllowPrivateNetworks && (ip == nil || isPrivateIP(ip)) {
+				return errors.Errorf("Refusing to connect to private address %s", address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+func (v *DefaultURIVerifier) fetch(ctx context.Context, kind URIKind, uri string, out interface{}) error {
+	maxSize := v.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = 512 * 1024
+	}
+
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The %s \"%s\" is not a valid URI.", kind, uri).WithDebug(err.Error()))
+	}
+
+	res, err := v.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Unable to fetch the %s \"%s\".", kind, uri).WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Fetching the %s \"%s\" returned status code %d.", kind, uri, res.StatusCode))
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("Unable to fetch the %s \"%s\".", kind, uri).WithDebug(err.Error()))
+	} else if int64(len(body)) > maxSize {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The document at the %s \"%s\" exceeds %d bytes.", kind, uri, maxSize))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return errors.WithStack(ErrInvalidRequest.WithHintf("The document at the %s \"%s\" is not valid.", kind, uri).WithDebug(strings.TrimSpace(err.Error())))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultURIVerifier(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(rw, `{"keys":[]}`)
+	})
+	mux.HandleFunc("/sector", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(rw, `["https://client.example.com/cb"]`)
+	})
+	mux.HandleFunc("/large", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(rw, `{"keys":["%s"]}`, strings.Repeat("a", 128))
+	})
+	mux.HandleFunc("/invalid", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(rw, `not json`)
+	})
+	ts := httptest.NewTLSServer(mux)
+	defer ts.Close()
+
+	client := &DefaultClient{RedirectURIs: []string{"https://client.example.com/cb"}}
+	strict := &DefaultURIVerifier{}
+	local := &DefaultURIVerifier{AllowPrivateNetworks: true, MaxResponseSize: 100, HTTPClient: ts.Client()}
+
+	for k, c := range []struct {
+		v         URIVerifier
+		kind      URIKind
+		uri       string
+		expectErr bool
+	}{
+		{v: strict, kind: RedirectURIKind, uri: "com.example.app:/cb"},
+		{v: strict, kind: RedirectURIKind, uri: "http://127.0.0.1:8080/cb"},
+		{v: strict, kind: RedirectURIKind, uri: "http://localhost/cb"},
+		{v: strict, kind: RedirectURIKind, uri: "https://93.184.216.34/cb"},
+		{v: strict, kind: RedirectURIKind, uri: "https://10.0.0.1/cb", expectErr: true},
+		{v: strict, kind: RedirectURIKind, uri: "https://169.254.169.254/latest", expectErr: true},
+		{v: strict, kind: RedirectURIKind, uri: "https://[fd00::1]/cb", expectErr: true},
+		{v: strict, kind: RedirectURIKind, uri: "https:///cb", expectErr: true},
+		{v: strict, kind: JSONWebKeysURIKind, uri: "http://93.184.216.34/jwks", expectErr: true},
+		{v: strict, kind: JSONWebKeysURIKind, uri: ts.URL + "/jwks", expectErr: true},
+		{v: local, kind: JSONWebKeysURIKind, uri: ts.URL + "/jwks"},
+		{v: local, kind: JSONWebKeysURIKind, uri: ts.URL + "/large", expectErr: true},
+		{v: local, kind: JSONWebKeysURIKind, uri: ts.URL + "/invalid", expectErr: true},
+		{v: local, kind: JSONWebKeysURIKind, uri: ts.URL + "/missing", expectErr: true},
+		{v: local, kind: SectorIdentifierURIKind, uri: ts.URL + "/sector"},
+		{v: local, kind: SectorIdentifierURIKind, uri: ts.URL + "/jwks", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := c.v.VerifyURI(context.Background(), client, c.kind, c.uri)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("case=sector identifier must list all redirect uris", func(t *testing.T) {
+		c := &DefaultClient{RedirectURIs: []string{"https://client.example.com/cb", "https://client.example.com/other"}}
+		assert.Error(t, local.VerifyURI(context.Background(), c, SectorIdentifierURIKind, ts.URL+"/sector"))
+	})
+}
+
+type uriVerifierFunc func(ctx context.Context, client Client, kind URIKind, uri string) error
+
+func (f uriVerifierFunc) VerifyURI(ctx context.Context, client Client, kind URIKind, uri string) error {
+	return f(ctx, client, kind, uri)
+}
+
+type sectorClient struct {
+	*DefaultClient
+	SectorIdentifierURI string
+}
+
+func (c *sectorClient) GetSectorIdentifierURI() string {
+	return c.SectorIdentifierURI
+}
+
+func TestVerifyClientURIs(t *testing.T) {
+	c := &DefaultOpenIDConnectClient{
+		DefaultClient:  &DefaultClient{RedirectURIs: []string{"https://93.184.216.34/cb"}},
+		JSONWebKeysURI: "https://10.0.0.1/jwks",
+	}
+	assert.Error(t, VerifyClientURIs(context.Background(), &DefaultURIVerifier{}, c))
+
+	done := make(chan error)
+	VerifyClientURIsAsync(context.Background(), &DefaultURIVerifier{}, c.DefaultClient, func(err error) {
+		done <- err
+	})
+	assert.NoError(t, <-done)
+
+	var verified []URIKind
+	recorder := uriVerifierFunc(func(_ context.Context, _ Client, kind URIKind, _ string) error {
+		verified = append(verified, kind)
+		return nil
+	})
+	sector := &sectorClient{DefaultClient: c.DefaultClient, SectorIdentifierURI: "https://93.184.216.34/sector"}
+	assert.NoError(t, VerifyClientURIs(context.Background(), recorder, sector))
+	assert.Equal(t, []URIKind{RedirectURIKind, SectorIdentifierURIKind}, verified)
+}
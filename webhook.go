@@ -0,0 +1,186 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Webhook is a PolicyEngine which delegates decisions to a remote HTTP service. The PolicyInput is POSTed as JSON
+// to URL and the service answers with a WebhookResponse, which may veto the request and, at the token endpoint,
+// contribute claims to sessions implementing ExtraClaimsSession.
+//
+// If Secret is set, every request carries the headers X-Fosite-Timestamp (unix time) and X-Fosite-Signature, the
+// hex encoded HMAC-SHA256 of the timestamp, a dot and the request body.
+type Webhook struct {
+	URL    string
+	Secret []byte
+
+	// Timeout limits the duration of a single attempt. Defaults to five seconds.
+	Timeout time.Duration
+
+	// MaxRetries sets how often a request is retried on network errors and 5xx responses. Defaults to zero.
+	MaxRetries int
+
+	// RetryBackoff is the wait time before the first retry, it doubles with every further retry. Defaults to
+	// 100 milliseconds.
+	RetryBackoff time.Duration
+
+	// HTTPClient is used to call the webhook. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// WebhookResponse is the response expected from a Webhook.
+type WebhookResponse struct {
+	// Allow must be true for the request to proceed.
+	Allow bool `json:"allow"`
+
+	// Hint is passed on to the client if the request is not allowed.
+	Hint string `json:"hint,omitempty"`
+
+	// Claims are added to the session of the request.
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+func (w *Webhook) Evaluate(ctx context.Context, input *PolicyInput) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	res, err := w.call(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	if !res.Allow {
+		hint := res.Hint
+		if hint == "" {
+			hint = "The request was denied by the authorization policy."
+		}
+		return errors.WithStack(ErrAccessDenied.WithHint(hint))
+	}
+
+	if len(res.Claims) > 0 && input.Requester != nil {
+		if sess, ok := input.Requester.GetSession().(ExtraClaimsSession); ok {
+			for k, v := range res.Claims {
+				sess.SetExtraClaim(k, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *Webhook) call(ctx context.Context, body []byte) (*WebhookResponse, error) {
+	backoff := w.RetryBackoff
+	if backoff == 0 {
+		backoff = time.Millisecond * 100
+	}
+
+	var err error
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, errors.WithStack(ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		var res *WebhookResponse
+		var retry bool
+		if res, retry, err = w.do(ctx, body); err == nil {
+			return res, nil
+		} else if !retry {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (w *Webhook) do(ctx context.Context, body []byte) (res *WebhookResponse, retry bool, err error) {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = time.Second * 5
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(w.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().UTC().Unix(), 10)
+		req.Header.Set("X-Fosite-Timestamp", timestamp)
+		req.Header.Set("X-Fosite-Signature", SignWebhookPayload(w.Secret, timestamp, body))
+	}
+
+	hc := w.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	response, err := hc.Do(req)
+	if err != nil {
+		return nil, true, errors.WithStack(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 500 {
+		return nil, true, errors.Errorf("expected status code 200 from webhook but got %d", response.StatusCode)
+	} else if response.StatusCode != http.StatusOK {
+		return nil, false, errors.Errorf("expected status code 200 from webhook but got %d", response.StatusCode)
+	}
+
+	var result WebhookResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return &result, false, nil
+}
+
+// SignWebhookPayload returns the signature sent in the X-Fosite-Signature header. Webhook receivers can use it to
+// verify requests.
+func SignWebhookPayload(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
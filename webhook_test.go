@@ -0,0 +1,122 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type extraClaimsSession struct {
+	DefaultSession
+	claims map[string]interface{}
+}
+
+func (s *extraClaimsSession) SetExtraClaim(key string, value interface{}) {
+	if s.claims == nil {
+		s.claims = map[string]interface{}{}
+	}
+	s.claims[key] = value
+}
+
+func TestWebhook(t *testing.T) {
+	secret := []byte("some-secret")
+	var calls int
+	var responses []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, SignWebhookPayload(secret, r.Header.Get("X-Fosite-Timestamp"), body), r.Header.Get("X-Fosite-Signature"))
+
+		res := responses[calls]
+		calls++
+		if res == "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(res))
+	}))
+	defer ts.Close()
+
+	ar := NewAccessRequest(new(extraClaimsSession))
+	ar.Client = &DefaultClient{ID: "foo"}
+
+	for k, c := range []struct {
+		d          string
+		hook       *Webhook
+		responses  []string
+		expectErr  error
+		expectHint string
+		calls      int
+	}{
+		{
+			d:         "should pass and contribute claims",
+			hook:      &Webhook{URL: ts.URL, Secret: secret},
+			responses: []string{`{"allow": true, "claims": {"foo": "bar"}}`},
+			calls:     1,
+		},
+		{
+			d:          "should be vetoed",
+			hook:       &Webhook{URL: ts.URL, Secret: secret},
+			responses:  []string{`{"allow": false, "hint": "Nope."}`},
+			expectErr:  ErrAccessDenied,
+			expectHint: "Nope.",
+			calls:      1,
+		},
+		{
+			d:         "should retry and pass",
+			hook:      &Webhook{URL: ts.URL, Secret: secret, MaxRetries: 2, RetryBackoff: time.Millisecond},
+			responses: []string{"", "", `{"allow": true}`},
+			calls:     3,
+		},
+		{
+			d:         "should give up after retries",
+			hook:      &Webhook{URL: ts.URL, Secret: secret, MaxRetries: 1, RetryBackoff: time.Millisecond},
+			responses: []string{"", ""},
+			calls:     2,
+		},
+	} {
+		calls = 0
+		responses = c.responses
+		err := c.hook.Evaluate(context.Background(), NewPolicyInput(PolicyStageToken, ar))
+		assert.Equal(t, c.calls, calls, "%d: %s", k, c.d)
+
+		if c.expectErr != nil {
+			require.EqualError(t, errors.Cause(err), c.expectErr.Error(), "%d: %s", k, c.d)
+			assert.Equal(t, c.expectHint, ErrorToRFC6749Error(err).Hint)
+		} else if c.calls == len(c.responses) && c.responses[len(c.responses)-1] == "" {
+			require.Error(t, err, "%d: %s", k, c.d)
+		} else {
+			require.NoError(t, err, "%d: %s", k, c.d)
+		}
+	}
+
+	assert.Equal(t, "bar", ar.GetSession().(*extraClaimsSession).claims["foo"])
+}